@@ -31,10 +31,13 @@ import (
 )
 
 type (
-	// AnalyzedCostProductMeta can be the additional metadata in AnalyzedCostEssentialMeta.
-	// It's used to detect product anomalies and store them in ElasticSearch with more info.
-	AnalyzedCostProductMeta struct {
-		Product string
+	// AnalyzedCostDimensionMeta can be the additional metadata in AnalyzedCostEssentialMeta.
+	// It's used to detect anomalies along a dimension and store them in ElasticSearch with more info.
+	// Currency is the currency the costs are billed in.
+	AnalyzedCostDimensionMeta struct {
+		Dimension string
+		Key       string
+		Currency  string
 	}
 
 	// AnalyzedCostEssentialMeta is the mandatory metadata ignored by the algorithm
@@ -56,12 +59,15 @@ type (
 
 	AnalyzedCosts []AnalyzedCost
 
-	// AnomalyEsQueryParams will store the parsed query params
+	// AnomalyEsQueryParams will store the parsed query params.
+	// When BillRepositoryIds is not empty, the line items of these bill
+	// repositories are queried instead of the ones of Account.
 	AnomalyEsQueryParams struct {
-		DateBegin time.Time
-		DateEnd   time.Time
-		Account   string
-		Index     string
+		DateBegin         time.Time
+		DateEnd           time.Time
+		Account           string
+		Index             string
+		BillRepositoryIds []int
 	}
 
 	// ElasticSearchFunction is a function passed to makeElasticSearchRequest,
@@ -76,8 +82,17 @@ type (
 	) *elastic.SearchService
 )
 
-// RunAnomaliesDetection run every anomaly detection algorithms and store results in ElasticSearch.
+// RunAnomaliesDetection run the anomaly detection algorithm chosen for the account
+// along every dimension and store results in ElasticSearch.
 func RunAnomaliesDetection(account aws.AwsAccount, ctx context.Context, tx *sql.Tx) error {
+	settings, err := GetDetectorSettings(account, tx)
+	if err != nil {
+		return err
+	}
+	detector, err := GetDetector(settings.Detector)
+	if err != nil {
+		return err
+	}
 	now := time.Now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	parsedParams := AnomalyEsQueryParams{
@@ -86,7 +101,35 @@ func RunAnomaliesDetection(account aws.AwsAccount, ctx context.Context, tx *sql.
 		Account:   account.AwsIdentity,
 		Index:     es.IndexNameForUserId(account.UserId, s3.IndexPrefixLineItem),
 	}
-	return runAnomaliesDetectionForProducts(parsedParams, account, ctx)
+	for _, dimension := range Dimensions {
+		dimensionParams := parsedParams
+		if dimension.PayerOnly {
+			if !account.Payer {
+				continue
+			} else if dimensionParams.BillRepositoryIds, err = getBillRepositoryIds(account, tx); err != nil {
+				return err
+			} else if len(dimensionParams.BillRepositoryIds) == 0 {
+				continue
+			}
+		}
+		if err = runAnomaliesDetectionForDimension(dimensionParams, account, dimension, detector, settings.Params, ctx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBillRepositoryIds returns the IDs of the bill repositories of an AwsAccount.
+func getBillRepositoryIds(account aws.AwsAccount, tx *sql.Tx) ([]int, error) {
+	billRepositories, err := s3.GetBillRepositoriesForAwsAccount(account, tx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(billRepositories))
+	for i, br := range billRepositories {
+		ids[i] = br.Id
+	}
+	return ids, nil
 }
 
 // deleteOffset deletes the offset set in createQueryTimeRange.
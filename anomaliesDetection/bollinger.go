@@ -22,6 +22,25 @@ import (
 	"github.com/trackit/trackit-server/config"
 )
 
+// register the Bollinger Bands detector, its default parameters being the
+// AnomalyDetectionBollingerBand* flags.
+func init() {
+	Detector{
+		Name:        "bollinger",
+		Description: "Bollinger Bands: a day is abnormal when its cost exceeds the average of the previous days plus a multiple of their standard deviation.",
+		DefaultParams: DetectorParams{
+			"period":                       float64(config.AnomalyDetectionBollingerBandPeriod),
+			"standardDeviationCoefficient": config.AnomalyDetectionBollingerBandStandardDeviationCoefficient,
+			"upperBandCoefficient":         config.AnomalyDetectionBollingerBandUpperBandCoefficient,
+		},
+		ParamRanges: map[string]ParamRange{
+			"period": periodRange,
+		},
+		History: func(params DetectorParams) int { return int(params["period"]) },
+		Func:    analyseAnomalies,
+	}.Register()
+}
+
 // min returns the minimum between a and b.
 func min(a, b int) int {
 	if a < b {
@@ -61,18 +80,22 @@ func deviation(sigma float64, period int) float64 {
 }
 
 // analyseAnomalies calculates anomalies with Bollinger Bands algorithm and
-// const values above. It consists in generating an upper band, which, if
-// exceeded, make an alert.
-func analyseAnomalies(aCosts AnalyzedCosts) AnalyzedCosts {
+// the detector's parameters. It consists in generating an upper band, which,
+// if exceeded, make an alert.
+func analyseAnomalies(aCosts AnalyzedCosts, params DetectorParams) AnalyzedCosts {
+	period := int(params["period"])
+	if period < 1 {
+		period = 1
+	}
 	for index := range aCosts {
 		if index > 0 {
 			a := &aCosts[index]
-			tempSliceSize := min(index, config.AnomalyDetectionBollingerBandPeriod)
+			tempSliceSize := min(index, period)
 			tempSlice := aCosts[index-tempSliceSize : index]
 			avg := average(tempSlice)
 			sigma := sigma(tempSlice, avg)
 			deviation := deviation(sigma, tempSliceSize)
-			a.UpperBand = avg*config.AnomalyDetectionBollingerBandUpperBandCoefficient + (deviation * config.AnomalyDetectionBollingerBandStandardDeviationCoefficient)
+			a.UpperBand = avg*params["upperBandCoefficient"] + (deviation * params["standardDeviationCoefficient"])
 			if a.Cost > a.UpperBand {
 				a.Anomaly = true
 			}
@@ -85,23 +108,21 @@ func analyseAnomalies(aCosts AnalyzedCosts) AnalyzedCosts {
 // but ES has only from 12 to 15. So 10 11 will be padded.
 func addPadding(aCosts AnalyzedCosts, dateBegin time.Time) AnalyzedCosts {
 	if cd, err := time.Parse("2006-01-02T15:04:05.000Z", aCosts[0].Meta.Date); err == nil && dateBegin.Before(cd) {
-		for i := int(cd.Sub(dateBegin).Hours() / 24); i > 0; i-- {
+		n := int(cd.Sub(dateBegin).Hours() / 24)
+		padded := make(AnalyzedCosts, n, n+len(aCosts))
+		for i := n - 1; i >= 0; i-- {
 			cd = cd.AddDate(0, 0, -1)
-			pad := AnalyzedCost{
-				Meta: AnalyzedCostEssentialMeta{
-					Date: cd.Format("2006-01-02T15:04:05.000Z"),
-				},
-			}
-			aCosts = append(AnalyzedCosts{pad}, aCosts...)
+			padded[i].Meta.Date = cd.Format("2006-01-02T15:04:05.000Z")
 		}
+		return append(padded, aCosts...)
 	}
 	return aCosts
 }
 
 // computeAnomalies calls every functions to well format
-// AnalyzedCosts and do BollingerBand.
-func computeAnomalies(ctx context.Context, aCosts AnalyzedCosts, dateBegin time.Time) AnalyzedCosts {
+// AnalyzedCosts and run the detector on them.
+func computeAnomalies(ctx context.Context, aCosts AnalyzedCosts, dateBegin time.Time, detector Detector, params DetectorParams) AnalyzedCosts {
 	aCosts = addPadding(aCosts, dateBegin)
-	aCosts = analyseAnomalies(aCosts)
+	aCosts = detector.Func(aCosts, params)
 	return aCosts
 }
@@ -0,0 +1,122 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// DetectorParams are the parameters of a Detector, by name.
+	DetectorParams map[string]float64
+
+	// DetectorFunc is the type that should be implemented by the detector's
+	// function. It sets UpperBand and Anomaly on every AnalyzedCost from
+	// the costs preceding it.
+	DetectorFunc func(aCosts AnalyzedCosts, params DetectorParams) AnalyzedCosts
+
+	// HistoryFunc is the type of the function returning how many days of
+	// history a detector needs before the first analyzed day.
+	HistoryFunc func(params DetectorParams) int
+
+	// ParamRange is the range of the valid values of a detector's
+	// parameter. Min is excluded from it if MinExcluded is set.
+	ParamRange struct {
+		Min         float64
+		Max         float64
+		MinExcluded bool
+	}
+
+	// Detector is the struct that defines an anomaly detection algorithm.
+	// Its parameters which have no range in ParamRanges only need to be
+	// positive.
+	Detector struct {
+		Name          string
+		Description   string
+		DefaultParams DetectorParams
+		ParamRanges   map[string]ParamRange
+		History       HistoryFunc
+		Func          DetectorFunc
+	}
+)
+
+// contains returns whether value is in the range.
+func (r ParamRange) contains(value float64) bool {
+	if r.MinExcluded && value <= r.Min || value < r.Min {
+		return false
+	}
+	return value <= r.Max
+}
+
+// String formats the range in interval notation.
+func (r ParamRange) String() string {
+	if r.MinExcluded {
+		return fmt.Sprintf("(%v, %v]", r.Min, r.Max)
+	}
+	return fmt.Sprintf("[%v, %v]", r.Min, r.Max)
+}
+
+// DefaultDetector is the name of the detector used for the accounts which
+// did not choose one.
+const DefaultDetector = "bollinger"
+
+// periodRange is the range of the number of previous days a detector can
+// compute its upper band from.
+var periodRange = ParamRange{Min: 1, Max: 90}
+
+// RegisteredDetectors is the list of registered detectors, by name.
+var RegisteredDetectors = make(map[string]Detector)
+
+// Register allows detectors to register themselves on server startup.
+func (d Detector) Register() Detector {
+	RegisteredDetectors[d.Name] = d
+	return d
+}
+
+// GetDetector returns the registered detector named name.
+func GetDetector(name string) (Detector, error) {
+	if d, ok := RegisteredDetectors[name]; ok {
+		return d, nil
+	}
+	names := make([]string, 0, len(RegisteredDetectors))
+	for n := range RegisteredDetectors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Detector{}, fmt.Errorf("unknown detector %s, possible values are %s", name, strings.Join(names, ", "))
+}
+
+// Params returns the default parameters of the detector overridden by the
+// given ones. An error is returned if a parameter is unknown to the
+// detector or is out of its range.
+func (d Detector) Params(params DetectorParams) (DetectorParams, error) {
+	res := make(DetectorParams, len(d.DefaultParams))
+	for name, value := range d.DefaultParams {
+		res[name] = value
+	}
+	for name, value := range params {
+		if _, ok := d.DefaultParams[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %s for detector %s", name, d.Name)
+		} else if r, ok := d.ParamRanges[name]; ok && !r.contains(value) {
+			return nil, fmt.Errorf("parameter %s must be in %s", name, r)
+		} else if !ok && value < 0 {
+			return nil, fmt.Errorf("parameter %s must not be negative", name)
+		}
+		res[name] = value
+	}
+	return res, nil
+}
@@ -0,0 +1,138 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// costsFromValues creates AnalyzedCosts from a list of daily costs.
+func costsFromValues(values []float64) AnalyzedCosts {
+	aCosts := make(AnalyzedCosts, len(values))
+	for i, v := range values {
+		aCosts[i].Cost = v
+	}
+	return aCosts
+}
+
+// anomalousIndexes returns the indexes of the costs detected as anomalies.
+func anomalousIndexes(aCosts AnalyzedCosts) []int {
+	res := []int{}
+	for i, a := range aCosts {
+		if a.Anomaly {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func TestDetectorsAreRegistered(t *testing.T) {
+	for _, name := range []string{"bollinger", "weekday-zscore", "ewma-mad"} {
+		if _, err := GetDetector(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := GetDetector("unknown"); err == nil {
+		t.Fatalf("Expected an error for an unknown detector")
+	}
+}
+
+func TestDetectorParams(t *testing.T) {
+	d := Detector{Name: "test", DefaultParams: DetectorParams{"a": 1, "b": 2}}
+	params, err := d.Params(DetectorParams{"b": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DetectorParams{"a": 1, "b": 3}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("Expected %v but got %v", expected, params)
+	}
+	if _, err := d.Params(DetectorParams{"c": 1}); err == nil {
+		t.Fatalf("Expected an error for an unknown parameter")
+	}
+	if _, err := d.Params(DetectorParams{"a": -1}); err == nil {
+		t.Fatalf("Expected an error for a negative parameter")
+	}
+}
+
+func TestDetectorParamRanges(t *testing.T) {
+	for _, tc := range []struct {
+		detector string
+		params   DetectorParams
+		valid    bool
+	}{
+		{"bollinger", DetectorParams{"period": 90}, true},
+		{"bollinger", DetectorParams{"period": 91}, false},
+		{"bollinger", DetectorParams{"period": 0}, false},
+		{"weekday-zscore", DetectorParams{"weeks": 12}, true},
+		{"weekday-zscore", DetectorParams{"weeks": 13}, false},
+		{"ewma-mad", DetectorParams{"alpha": 1}, true},
+		{"ewma-mad", DetectorParams{"alpha": 0}, false},
+		{"ewma-mad", DetectorParams{"alpha": 1.5}, false},
+		{"ewma-mad", DetectorParams{"coefficient": -1}, false},
+	} {
+		d, err := GetDetector(tc.detector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.Params(tc.params); (err == nil) != tc.valid {
+			t.Errorf("Params %v of %s should be valid: %v, got error %v instead.", tc.params, tc.detector, tc.valid, err)
+		}
+	}
+}
+
+func TestAddPadding(t *testing.T) {
+	aCosts := AnalyzedCosts{{Cost: 1, Meta: AnalyzedCostEssentialMeta{Date: "2018-01-12T00:00:00.000Z"}}}
+	aCosts = addPadding(aCosts, time.Date(2018, 1, 10, 0, 0, 0, 0, time.UTC))
+	expected := []string{"2018-01-10T00:00:00.000Z", "2018-01-11T00:00:00.000Z", "2018-01-12T00:00:00.000Z"}
+	if len(aCosts) != len(expected) {
+		t.Fatalf("Padded costs should have %d days, has %d instead.", len(expected), len(aCosts))
+	}
+	for i, date := range expected {
+		if aCosts[i].Meta.Date != date {
+			t.Errorf("Day %d should be %s, is %s instead.", i, date, aCosts[i].Meta.Date)
+		}
+	}
+	if aCosts[2].Cost != 1 {
+		t.Errorf("Last day's cost should be 1, is %f instead.", aCosts[2].Cost)
+	}
+}
+
+func TestWeekdayDetectorIgnoresWeeklyPattern(t *testing.T) {
+	week := []float64{100, 100, 100, 100, 100, 10, 10}
+	values := []float64{}
+	for i := 0; i < 4; i++ {
+		values = append(values, week...)
+	}
+	values = append(values, 100, 100, 300, 100, 100, 10, 10)
+	params := DetectorParams{"weeks": 4, "zScore": 3, "upperBandCoefficient": 1.05}
+	res := anomalousIndexes(analyseWeekdayAnomalies(costsFromValues(values), params))
+	expected := []int{30}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Expected %v but got %v", expected, res)
+	}
+}
+
+func TestEwmaMadDetectorIsRobustToPastSpikes(t *testing.T) {
+	values := []float64{100, 102, 98, 500, 101, 99, 100, 103, 250}
+	params := DetectorParams{"period": 14, "alpha": 0.3, "coefficient": 3, "upperBandCoefficient": 1.05}
+	res := anomalousIndexes(analyseEwmaMadAnomalies(costsFromValues(values), params))
+	expected := []int{3, 8}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Expected %v but got %v", expected, res)
+	}
+}
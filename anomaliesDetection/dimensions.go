@@ -0,0 +1,309 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/aws/usageReports"
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/es"
+)
+
+type (
+	// Dimension is a field of the line items along which the costs are
+	// split to detect anomalies.
+	Dimension struct {
+		// Name is the name of the dimension in the API and the name of
+		// the field holding its value in the ElasticSearch documents.
+		Name string
+		// Field is the line item field the costs are split on.
+		Field string
+		// Type is the ElasticSearch document type of the anomalies.
+		Type string
+		// PayerOnly is true when the dimension is only relevant for
+		// payer accounts, whose bills contain the costs of other
+		// accounts.
+		PayerOnly bool
+	}
+
+	// esAnomalyCost contains the cost data
+	esAnomalyCost struct {
+		Value       float64 `json:"value"`
+		MaxExpected float64 `json:"maxExpected"`
+	}
+
+	// esAnomaly is used to ingest in ElasticSearch. The value of the
+	// dimension is stored in a field named after it, so that product
+	// anomalies keep their 'product' field.
+	esAnomaly map[string]interface{}
+
+	// esDimensionDatesBucket is used to store the raw ElasticSearch response.
+	esDimensionDatesBucket struct {
+		Key  string `json:"key_as_string"`
+		Cost struct {
+			Value float64 `json:"value"`
+		} `json:"cost"`
+	}
+
+	// esDimensionTypedResult is used to store the raw ElasticSearch response.
+	esDimensionTypedResult struct {
+		Keys struct {
+			Buckets []struct {
+				Key   string `json:"key"`
+				Dates struct {
+					Buckets []esDimensionDatesBucket `json:"buckets"`
+				} `json:"dates"`
+			} `json:"buckets"`
+		}
+		Currency struct {
+			Buckets []struct {
+				Key string `json:"key"`
+			} `json:"buckets"`
+		}
+	}
+
+	// costWithKey is used when a cost has to be wrapped by a dimension value.
+	costWithKey struct {
+		key  string
+		cost float64
+	}
+
+	// totalCostByDay is a named type for total cost for each day.
+	totalCostByDay map[string]float64
+
+	// highestSpendersByDay contains the more costly dimension values podium for each day.
+	highestSpendersByDay map[string][]string
+)
+
+// Dimensions is the list of dimensions anomalies are detected on.
+var Dimensions = []Dimension{
+	{Name: "product", Field: "productCode", Type: TypeProductAnomaliesDetection},
+	{Name: "region", Field: "region", Type: TypeRegionAnomaliesDetection},
+	{Name: "usageType", Field: "usageType", Type: TypeUsageTypeAnomaliesDetection},
+	{Name: "usageAccountId", Field: "usageAccountId", Type: TypeUsageAccountAnomaliesDetection, PayerOnly: true},
+}
+
+// GetDimension returns the dimension named name.
+func GetDimension(name string) (Dimension, bool) {
+	for _, d := range Dimensions {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Dimension{}, false
+}
+
+// runAnomaliesDetectionForDimension will get data from ElasticSearch,
+// compute anomalies along a dimension, ingest the result in ElasticSearch
+// and notify the AWS account about the new anomalies.
+func runAnomaliesDetectionForDimension(parsedParams AnomalyEsQueryParams, account aws.AwsAccount, dimension Dimension, detector Detector, params DetectorParams, ctx context.Context, tx *sql.Tx) (err error) {
+	var res AnalyzedCosts
+	if res, err = dimensionGetAnomaliesData(ctx, parsedParams, dimension, detector, params); err != nil {
+	} else if err = dimensionSaveAnomaliesData(ctx, res, account, dimension); err != nil {
+	} else if err = notifyAnomalies(ctx, tx, res, account, dimension); err != nil {
+	}
+	return err
+}
+
+// dimensionSaveAnomaliesData will save anomalies in ElasticSearch.
+// If the index doesn't exist, it will be created.
+// Anomalies are unique and will replace the existing ones if
+// they changed (cost or upper band).
+func dimensionSaveAnomaliesData(ctx context.Context, aCosts AnalyzedCosts, account aws.AwsAccount, dimension Dimension) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Updating anomalies for AWS account.", map[string]interface{}{
+		"awsAccount": account,
+		"dimension":  dimension.Name,
+	})
+	index := es.IndexNameForUserId(account.UserId, IndexPrefixAnomaliesDetection)
+	bp, err := utils.GetBulkProcessor(ctx)
+	if err != nil {
+		logger.Error("Failed to get bulk processor.", err.Error())
+		return err
+	}
+	for _, aCost := range aCosts {
+		meta := aCost.Meta.AdditionalMeta.(AnalyzedCostDimensionMeta)
+		key := meta.Key
+		doc := esAnomaly{
+			"account":      account.AwsIdentity,
+			"date":         aCost.Meta.Date,
+			dimension.Name: key,
+			"abnormal":     aCost.Anomaly,
+			"currency":     meta.Currency,
+			"cost": esAnomalyCost{
+				Value:       aCost.Cost,
+				MaxExpected: aCost.UpperBand,
+			},
+		}
+		id, err := dimensionGenerateElasticSearchDocumentId(account.AwsIdentity, aCost.Meta.Date, dimension, key)
+		if err != nil {
+			logger.Error("Error when marshaling anomalies var", err.Error())
+			return err
+		}
+		bp = addDocToBulkProcessor(bp, doc, dimension.Type, index, id)
+	}
+	bp.Flush()
+	err = bp.Close()
+	if err != nil {
+		logger.Error("Failed when putting anomalies in ES", err.Error())
+		return err
+	}
+	logger.Info("Anomalies put in ES", nil)
+	return nil
+}
+
+// dimensionGenerateElasticSearchDocumentId is used to generate the document id ingested in ElasticSearch.
+// The document id is not dependent on cost or upper band: if one of them change,
+// it will update the document in ElasticSearch instead of recreating one.
+func dimensionGenerateElasticSearchDocumentId(account, date string, dimension Dimension, key string) (id string, err error) {
+	var ji []byte
+	ji, err = json.Marshal(map[string]string{
+		"account":      account,
+		"date":         date,
+		dimension.Name: key,
+	})
+	if err != nil {
+		return
+	}
+	hash := md5.Sum(ji)
+	id = base64.URLEncoding.EncodeToString(hash[:])
+	return
+}
+
+// dimensionClearDisturbances clears fake alerts with thresholds in config.
+func dimensionClearDisturbances(aCosts AnalyzedCosts, totalCostByDay totalCostByDay, highestSpendersByDay highestSpendersByDay) AnalyzedCosts {
+	for index, aCost := range aCosts {
+		if aCost.Anomaly {
+			date := aCost.Meta.Date
+			increaseAmount := aCost.Cost - aCost.UpperBand
+			if increaseAmount < totalCostByDay[date]*config.AnomalyDetectionDisturbanceCleaningMinPercentOfDailyBill/100 ||
+				aCost.Cost < config.AnomalyDetectionDisturbanceCleaningMinAbsoluteCost {
+				aCosts[index].Anomaly = false
+			} else {
+				spenderInPodium := false
+				for _, spender := range highestSpendersByDay[date] {
+					if spender == aCost.Meta.AdditionalMeta.(AnalyzedCostDimensionMeta).Key {
+						spenderInPodium = true
+						break
+					}
+				}
+				aCosts[index].Anomaly = spenderInPodium
+			}
+		}
+	}
+	return aCosts
+}
+
+// dimensionAddCostToCosts is a tool used by dimensionGetHighestSpendersByDay.
+func dimensionAddCostToCosts(key string, cost float64, costs []costWithKey) []costWithKey {
+	for idx := range costs {
+		if costs[idx].key == key {
+			costs[idx].cost += cost
+			return costs
+		}
+	}
+	return append(costs, costWithKey{key, cost})
+}
+
+// dimensionGetHighestSpendersByDay gets a podium of the highest spenders.
+func dimensionGetHighestSpendersByDay(typedDocument esDimensionTypedResult) highestSpendersByDay {
+	costByDayByKey := map[string][]costWithKey{}
+	for _, key := range typedDocument.Keys.Buckets {
+		for _, date := range key.Dates.Buckets {
+			costByDayByKey[date.Key] = dimensionAddCostToCosts(key.Key, date.Cost.Value, costByDayByKey[date.Key])
+		}
+	}
+	highestSpendersByDay := make(highestSpendersByDay)
+	for day, keys := range costByDayByKey {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].cost > keys[j].cost
+		})
+		for i := 0; i < config.AnomalyDetectionDisturbanceCleaningHighestSpendingMinRank && i < len(keys); i++ {
+			highestSpendersByDay[day] = append(highestSpendersByDay[day], keys[i].key)
+		}
+	}
+	return highestSpendersByDay
+}
+
+// dimensionGetTotalCostByDay gets the total cost for each day.
+func dimensionGetTotalCostByDay(typedDocument esDimensionTypedResult) totalCostByDay {
+	totalCostByDay := totalCostByDay{}
+	for _, key := range typedDocument.Keys.Buckets {
+		for _, date := range key.Dates.Buckets {
+			totalCostByDay[date.Key] += date.Cost.Value
+		}
+	}
+	return totalCostByDay
+}
+
+// dimensionGetAnomaliesData returns the anomalies along a dimension based on query params.
+func dimensionGetAnomaliesData(ctx context.Context, params AnomalyEsQueryParams, dimension Dimension, detector Detector, detectorParams DetectorParams) (AnalyzedCosts, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	queryParams := params
+	queryParams.DateBegin = params.DateBegin.AddDate(0, 0, -detector.History(detectorParams))
+	sr, _, err := makeElasticSearchRequest(ctx, getDimensionElasticSearchParams(dimension, params.BillRepositoryIds), queryParams)
+	if err != nil {
+		return nil, err
+	}
+	var typedDocument esDimensionTypedResult
+	if err := json.Unmarshal(*sr.Aggregations["keys"], &typedDocument.Keys); err != nil {
+		logger.Error("Failed to parse elasticsearch document.", err.Error())
+		return nil, err
+	}
+	currency := currencies.BaseCurrency
+	if raw, ok := sr.Aggregations["currency"]; ok && raw != nil {
+		if err := json.Unmarshal(*raw, &typedDocument.Currency); err != nil {
+			logger.Error("Failed to parse elasticsearch document.", err.Error())
+			return nil, err
+		} else if len(typedDocument.Currency.Buckets) > 0 {
+			currency = typedDocument.Currency.Buckets[0].Key
+		}
+	}
+	totalAnalyzedCosts := make(AnalyzedCosts, 0)
+	totalCostsByDay := dimensionGetTotalCostByDay(typedDocument)
+	highestSpendersByDay := dimensionGetHighestSpendersByDay(typedDocument)
+	for _, key := range typedDocument.Keys.Buckets {
+		aCosts := make(AnalyzedCosts, 0, len(key.Dates.Buckets))
+		for _, date := range key.Dates.Buckets {
+			aCosts = append(aCosts, AnalyzedCost{
+				Meta: AnalyzedCostEssentialMeta{
+					AdditionalMeta: AnalyzedCostDimensionMeta{
+						Dimension: dimension.Name,
+						Key:       key.Key,
+						Currency:  currency,
+					},
+					Date: date.Key,
+				},
+				Cost:    date.Cost.Value,
+				Anomaly: false,
+			})
+		}
+		aCosts = computeAnomalies(ctx, aCosts, queryParams.DateBegin, detector, detectorParams)
+		aCosts = deleteOffset(aCosts, params.DateBegin)
+		totalAnalyzedCosts = append(totalAnalyzedCosts, aCosts...)
+	}
+	totalAnalyzedCosts = dimensionClearDisturbances(totalAnalyzedCosts, totalCostsByDay, highestSpendersByDay)
+	return totalAnalyzedCosts, nil
+}
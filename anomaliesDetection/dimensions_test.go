@@ -0,0 +1,65 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTemplateAnomaliesDetectionIsValid(t *testing.T) {
+	var template struct {
+		Mappings map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(TemplateAnomaliesDetection), &template); err != nil {
+		t.Fatal(err)
+	}
+	for _, dimension := range Dimensions {
+		if mapping, ok := template.Mappings[dimension.Type]; !ok {
+			t.Fatalf("Expected a mapping for %v", dimension.Type)
+		} else if _, ok := mapping.Properties[dimension.Name]; !ok {
+			t.Fatalf("Expected the mapping %v to have the field %v", dimension.Type, dimension.Name)
+		}
+	}
+}
+
+func TestProductDocumentIdIsUnchanged(t *testing.T) {
+	dimension, _ := GetDimension("product")
+	expectedResult := "IZ02NEjNmbU4e5ubkA_8lQ=="
+	res, err := dimensionGenerateElasticSearchDocumentId("123456", "2018-04-16T00:00:00.000Z", dimension, "AmazonEC2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, res)
+	}
+}
+
+func TestBillRepositoryFilter(t *testing.T) {
+	expectedResult := `{"terms":{"billRepositoryId":[1,2]}}`
+	src, err := createQueryBillRepositoryFilter([]int{1, 2}).Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonRes, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jsonRes) != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, string(jsonRes))
+	}
+}
@@ -19,7 +19,7 @@ import (
 
 	"gopkg.in/olivere/elastic.v5"
 
-	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/currencies"
 )
 
 // aggregationMaxSize is the maximum size of an Elastic Search Aggregation
@@ -32,39 +32,58 @@ func createQueryAccountFilter(account string) *elastic.TermQuery {
 
 // createQueryTimeRange creates and return a new *elastic.RangeQuery based on the duration
 // defined by durationBegin and durationEnd.
-// durationBegin is reduced by period. This offset is deleted later.
+// durationBegin is expected to be offset by the history needed by the detector.
+// This offset is deleted later.
 func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elastic.RangeQuery {
-	periodDuration := time.Duration(config.AnomalyDetectionBollingerBandPeriod) * 24 * time.Hour
-	durationBegin = durationBegin.Add(-periodDuration - 1)
 	return elastic.NewRangeQuery("usageStartDate").
 		From(durationBegin).To(durationEnd)
 }
 
-// GetProductElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService
-// used to retrieve the average cost by usageType for each day.
-// It takes as parameters :
-// 	- accountList []string : A slice of string representing aws account number, in the format of the field
-//	'awsdetailedlineitem.linked_account_id'
+// createQueryBillRepositoryFilter creates and return a new *elastic.TermsQuery on the
+// billRepositoryIds array
+func createQueryBillRepositoryFilter(billRepositoryIds []int) *elastic.TermsQuery {
+	billRepositoryIdsFormatted := make([]interface{}, len(billRepositoryIds))
+	for i, v := range billRepositoryIds {
+		billRepositoryIdsFormatted[i] = v
+	}
+	return elastic.NewTermsQuery("billRepositoryId", billRepositoryIdsFormatted...)
+}
+
+// getDimensionElasticSearchParams returns an ElasticSearchFunction used to construct an
+// ElasticSearch *elastic.SearchService used to retrieve the cost by value of the dimension
+// for each day, along with the currency the costs are billed in. An AWS account is billed in
+// a single currency.
+// If billRepositoryIds is not empty, the line items of these bill repositories are
+// retrieved instead of the ones of the account.
+// The returned function takes as parameters :
+// 	- account string : A string representing the aws account number, in the format of the field
+//	'usageAccountId'
 //	- durationBeing time.Time : A time.Time struct representing the begining of the time range in the query
 //	- durationEnd time.Time : A time.Time struct representing the end of the time range in the query
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
-//	- index string : The Elastic Search index on wich to execute the query. In this context the default value
-//	should be "awsdetailedlineitems"
+//	- index string : The Elastic Search index on wich to execute the query.
 // This function excepts arguments passed to it to be sanitize. If they are not, the following cases will make
 // it crash :
 //	- If the client is nil or malconfigured, it will crash
 //	- If the index is not an index present in the ES, it will crash
-func getProductElasticSearchParams(account string, durationBegin time.Time,
-	durationEnd time.Time, aggregationPeriod string, client *elastic.Client, index string) *elastic.SearchService {
-	query := elastic.NewBoolQuery()
-	query = query.Filter(createQueryAccountFilter(account))
-	query = query.Filter(createQueryTimeRange(durationBegin, durationEnd))
-	search := client.Search().Index(index).Size(0).Query(query)
+func getDimensionElasticSearchParams(dimension Dimension, billRepositoryIds []int) ElasticSearchFunction {
+	return func(account string, durationBegin time.Time,
+		durationEnd time.Time, aggregationPeriod string, client *elastic.Client, index string) *elastic.SearchService {
+		query := elastic.NewBoolQuery()
+		if len(billRepositoryIds) > 0 {
+			query = query.Filter(createQueryBillRepositoryFilter(billRepositoryIds))
+		} else {
+			query = query.Filter(createQueryAccountFilter(account))
+		}
+		query = query.Filter(createQueryTimeRange(durationBegin, durationEnd))
+		search := client.Search().Index(index).Size(0).Query(query)
 
-	search.Aggregation("products", elastic.NewTermsAggregation().Field("productCode").Size(aggregationMaxSize).
-		SubAggregation("dates", elastic.NewDateHistogramAggregation().Field("usageStartDate").ExtendedBounds(durationBegin, durationEnd).Interval(aggregationPeriod).
-			SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost"))))
-	return search
+		search.Aggregation("keys", elastic.NewTermsAggregation().Field(dimension.Field).Size(aggregationMaxSize).
+			SubAggregation("dates", elastic.NewDateHistogramAggregation().Field("usageStartDate").ExtendedBounds(durationBegin, durationEnd).Interval(aggregationPeriod).
+				SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost"))))
+		search.Aggregation("currency", elastic.NewTermsAggregation().Field("currencyCode").Missing(currencies.BaseCurrency).Size(1))
+		return search
+	}
 }
 
 // addDocToBulkProcessor adds a document in a bulk processor to ingest them in ES
@@ -0,0 +1,250 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/models"
+)
+
+const (
+	// AnomalyStateOpen is the state of an anomaly nobody gave feedback on.
+	AnomalyStateOpen = "open"
+	// AnomalyStateAcknowledged is the state of an anomaly a user is aware of.
+	AnomalyStateAcknowledged = "acknowledged"
+	// AnomalyStateDismissed is the state of an anomaly a user marked as
+	// expected.
+	AnomalyStateDismissed = "dismissed"
+	// AnomalyStateSnoozed is the state of an anomaly whose dimension value
+	// was snoozed.
+	AnomalyStateSnoozed = "snoozed"
+)
+
+var (
+	ErrInvalidAnomalyState = errors.New("state must be one of open, acknowledged or dismissed")
+	ErrInvalidDimension    = errors.New("dimension must be one of product, region, usageType or usageAccountId")
+	ErrMissingKey          = errors.New("key is required")
+	ErrSnoozeNotFound      = errors.New("snooze not found")
+	ErrInvalidSnoozeEnd    = errors.New("snooze end must be in the future")
+)
+
+type (
+	// AnomalyFeedback is the state a user gave to an anomaly, along with
+	// an optional comment explaining it.
+	AnomalyFeedback struct {
+		Dimension string    `json:"dimension"`
+		Key       string    `json:"key"`
+		Date      time.Time `json:"date"`
+		State     string    `json:"state"`
+		Comment   string    `json:"comment"`
+	}
+
+	// Snooze hides the anomalies of a value of a dimension until a date.
+	Snooze struct {
+		Id        int       `json:"id"`
+		Dimension string    `json:"dimension"`
+		Key       string    `json:"key"`
+		Until     time.Time `json:"until"`
+		Comment   string    `json:"comment"`
+		Created   time.Time `json:"created"`
+	}
+
+	// AnomalyStates holds the feedback and snoozes of the anomalies of an
+	// AWS account along a dimension.
+	AnomalyStates struct {
+		feedback map[string]AnomalyFeedback
+		snoozes  map[string]Snooze
+	}
+)
+
+// feedbackKey returns the key of the feedback on the anomaly of a dimension
+// value at a date in AnomalyStates.
+func feedbackKey(key string, date time.Time) string {
+	return key + "@" + date.UTC().Format(time.RFC3339)
+}
+
+// validateDimensionAndKey checks the dimension exists and the key is set.
+func validateDimensionAndKey(dimension, key string) error {
+	if _, ok := GetDimension(dimension); !ok {
+		return ErrInvalidDimension
+	} else if key == "" {
+		return ErrMissingKey
+	}
+	return nil
+}
+
+// Validate checks an AnomalyFeedback is well-formed.
+func (fb AnomalyFeedback) Validate() error {
+	switch fb.State {
+	case AnomalyStateOpen, AnomalyStateAcknowledged, AnomalyStateDismissed:
+		return validateDimensionAndKey(fb.Dimension, fb.Key)
+	default:
+		return ErrInvalidAnomalyState
+	}
+}
+
+// SetAnomalyFeedback saves the feedback a user gave on an anomaly of an
+// AwsAccount. Setting the state back to open deletes the feedback.
+func SetAnomalyFeedback(aa aws.AwsAccount, userId int, fb AnomalyFeedback, tx *sql.Tx) error {
+	date := fb.Date.UTC()
+	dbFeedback, err := models.AnomalyFeedbackByAwsAccountIDDimensionDimensionKeyDate(tx, aa.Id, fb.Dimension, fb.Key, date)
+	if err == sql.ErrNoRows {
+		if fb.State == AnomalyStateOpen {
+			return nil
+		}
+		dbFeedback = &models.AnomalyFeedback{
+			AwsAccountID: aa.Id,
+			Dimension:    fb.Dimension,
+			DimensionKey: fb.Key,
+			Date:         date,
+		}
+	} else if err != nil {
+		return err
+	} else if fb.State == AnomalyStateOpen {
+		return dbFeedback.Delete(tx)
+	}
+	dbFeedback.State = fb.State
+	dbFeedback.Comment = fb.Comment
+	dbFeedback.UserID = userId
+	dbFeedback.Updated = time.Now()
+	return dbFeedback.Save(tx)
+}
+
+// SnoozeAnomalies hides the anomalies of a dimension value of an AwsAccount
+// until a date. An existing snooze of the same value is replaced.
+func SnoozeAnomalies(aa aws.AwsAccount, userId int, s Snooze, tx *sql.Tx) (Snooze, error) {
+	if err := validateDimensionAndKey(s.Dimension, s.Key); err != nil {
+		return s, err
+	} else if !s.Until.After(time.Now()) {
+		return s, ErrInvalidSnoozeEnd
+	}
+	dbSnooze, err := models.AnomalySnoozeByAwsAccountIDDimensionDimensionKey(tx, aa.Id, s.Dimension, s.Key)
+	if err == sql.ErrNoRows {
+		dbSnooze = &models.AnomalySnooze{
+			AwsAccountID: aa.Id,
+			Dimension:    s.Dimension,
+			DimensionKey: s.Key,
+		}
+	} else if err != nil {
+		return s, err
+	}
+	dbSnooze.Until = s.Until.UTC()
+	dbSnooze.Comment = s.Comment
+	dbSnooze.UserID = userId
+	dbSnooze.Created = time.Now()
+	if err = dbSnooze.Save(tx); err != nil {
+		return s, err
+	}
+	return snoozeFromDbSnooze(*dbSnooze), nil
+}
+
+// GetSnoozes retrieves the snoozes of an AwsAccount which did not end yet.
+func GetSnoozes(aa aws.AwsAccount, tx *sql.Tx) ([]Snooze, error) {
+	dbSnoozes, err := models.AnomalySnoozesByAwsAccountID(tx, aa.Id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	res := make([]Snooze, 0, len(dbSnoozes))
+	for _, dbSnooze := range dbSnoozes {
+		if dbSnooze.Until.After(now) {
+			res = append(res, snoozeFromDbSnooze(*dbSnooze))
+		}
+	}
+	return res, nil
+}
+
+// DeleteSnooze deletes a snooze of an AwsAccount.
+func DeleteSnooze(aa aws.AwsAccount, snoozeId int, tx *sql.Tx) error {
+	dbSnooze, err := models.AnomalySnoozeByID(tx, snoozeId)
+	if err == sql.ErrNoRows {
+		return ErrSnoozeNotFound
+	} else if err != nil {
+		return err
+	} else if dbSnooze.AwsAccountID != aa.Id {
+		return ErrSnoozeNotFound
+	}
+	return dbSnooze.Delete(tx)
+}
+
+// GetAnomalyStates retrieves the feedback and snoozes of the anomalies of an
+// AwsAccount along a dimension.
+func GetAnomalyStates(awsAccountId int, dimension string, tx *sql.Tx) (AnomalyStates, error) {
+	states := AnomalyStates{
+		feedback: make(map[string]AnomalyFeedback),
+		snoozes:  make(map[string]Snooze),
+	}
+	dbFeedback, err := models.AnomalyFeedbacksByAwsAccountIDAndDimension(tx, awsAccountId, dimension)
+	if err != nil {
+		return states, err
+	}
+	for _, fb := range dbFeedback {
+		states.feedback[feedbackKey(fb.DimensionKey, fb.Date)] = AnomalyFeedback{
+			Dimension: fb.Dimension,
+			Key:       fb.DimensionKey,
+			Date:      fb.Date,
+			State:     fb.State,
+			Comment:   fb.Comment,
+		}
+	}
+	dbSnoozes, err := models.AnomalySnoozesByAwsAccountID(tx, awsAccountId)
+	if err != nil {
+		return states, err
+	}
+	for _, dbSnooze := range dbSnoozes {
+		if dbSnooze.Dimension == dimension {
+			states.snoozes[dbSnooze.DimensionKey] = snoozeFromDbSnooze(*dbSnooze)
+		}
+	}
+	return states, nil
+}
+
+// State returns the state of the anomaly of a dimension value at a date and
+// the comment explaining it. Feedback given on the anomaly itself prevails
+// over a snooze of its dimension value. A snooze applies to the anomalies
+// which happened before its end.
+func (s AnomalyStates) State(key string, date time.Time) (string, string) {
+	if fb, ok := s.feedback[feedbackKey(key, date)]; ok {
+		return fb.State, fb.Comment
+	} else if snooze, ok := s.snoozes[key]; ok && date.Before(snooze.Until) {
+		return AnomalyStateSnoozed, snooze.Comment
+	}
+	return AnomalyStateOpen, ""
+}
+
+// IsNotifiable returns whether the anomaly of a dimension value at a date
+// should be notified to the users, which is only the case if nobody gave
+// feedback on it and its dimension value is not snoozed. Every path sending
+// anomalies to users must filter them with it; notifiableAnomalies does so
+// for the notification endpoints.
+func (s AnomalyStates) IsNotifiable(key string, date time.Time) bool {
+	state, _ := s.State(key, date)
+	return state == AnomalyStateOpen
+}
+
+func snoozeFromDbSnooze(dbSnooze models.AnomalySnooze) Snooze {
+	return Snooze{
+		Id:        dbSnooze.ID,
+		Dimension: dbSnooze.Dimension,
+		Key:       dbSnooze.DimensionKey,
+		Until:     dbSnooze.Until,
+		Comment:   dbSnooze.Comment,
+		Created:   dbSnooze.Created,
+	}
+}
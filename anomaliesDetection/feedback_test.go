@@ -0,0 +1,108 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalyStates(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, time.June, d, 0, 0, 0, 0, time.UTC) }
+	states := AnomalyStates{
+		feedback: map[string]AnomalyFeedback{
+			feedbackKey("AmazonEC2", day(3)): {State: AnomalyStateDismissed, Comment: "expected"},
+			feedbackKey("AmazonS3", day(3)):  {State: AnomalyStateAcknowledged},
+		},
+		snoozes: map[string]Snooze{
+			"AmazonEC2": {Until: day(10), Comment: "migration"},
+		},
+	}
+	cases := []struct {
+		key     string
+		date    time.Time
+		state   string
+		comment string
+	}{
+		{"AmazonEC2", day(3), AnomalyStateDismissed, "expected"},
+		{"AmazonEC2", day(4), AnomalyStateSnoozed, "migration"},
+		{"AmazonEC2", day(10), AnomalyStateOpen, ""},
+		{"AmazonS3", day(3), AnomalyStateAcknowledged, ""},
+		{"AmazonS3", day(4), AnomalyStateOpen, ""},
+	}
+	for _, c := range cases {
+		state, comment := states.State(c.key, c.date)
+		if state != c.state || comment != c.comment {
+			t.Errorf("%s on %s: expected (%s, %q), got (%s, %q)", c.key, c.date, c.state, c.comment, state, comment)
+		}
+		if notifiable := states.IsNotifiable(c.key, c.date); notifiable != (c.state == AnomalyStateOpen) {
+			t.Errorf("%s on %s: expected notifiable to be %t", c.key, c.date, !notifiable)
+		}
+	}
+}
+
+func TestAnomalyStatesEmpty(t *testing.T) {
+	var states AnomalyStates
+	if state, _ := states.State("AmazonEC2", time.Now()); state != AnomalyStateOpen {
+		t.Errorf("Expected an anomaly without feedback to be open, got %s.", state)
+	}
+}
+
+func TestAnomalyFeedbackValidate(t *testing.T) {
+	valid := AnomalyFeedback{Dimension: "region", Key: "us-east-1", State: AnomalyStateAcknowledged}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected feedback to be valid, got %s.", err)
+	}
+	invalid := []AnomalyFeedback{
+		{Dimension: "region", Key: "us-east-1", State: AnomalyStateSnoozed},
+		{Dimension: "az", Key: "us-east-1a", State: AnomalyStateDismissed},
+		{Dimension: "product", State: AnomalyStateDismissed},
+	}
+	for _, fb := range invalid {
+		if err := fb.Validate(); err == nil {
+			t.Errorf("Expected feedback %v to be invalid.", fb)
+		}
+	}
+}
+
+func TestNotifiableAnomalies(t *testing.T) {
+	now := time.Date(2018, time.June, 12, 12, 0, 0, 0, time.UTC)
+	aCost := func(key, date string, anomaly bool) AnalyzedCost {
+		return AnalyzedCost{
+			Meta:    AnalyzedCostEssentialMeta{AnalyzedCostDimensionMeta{"product", key, "EUR"}, date},
+			Cost:    100,
+			Anomaly: anomaly,
+		}
+	}
+	aCosts := AnalyzedCosts{
+		aCost("AmazonEC2", "2018-06-08T00:00:00.000Z", true),
+		aCost("AmazonEC2", "2018-06-11T00:00:00.000Z", true),
+		aCost("AmazonEC2", "2018-06-12T00:00:00.000Z", false),
+		aCost("AmazonS3", "2018-06-12T00:00:00.000Z", true),
+		aCost("AmazonRDS", "2018-06-12T00:00:00.000Z", true),
+	}
+	states := AnomalyStates{
+		feedback: map[string]AnomalyFeedback{
+			feedbackKey("AmazonS3", time.Date(2018, time.June, 12, 0, 0, 0, 0, time.UTC)): {State: AnomalyStateAcknowledged},
+		},
+		snoozes: map[string]Snooze{
+			"AmazonRDS": {Until: now.AddDate(0, 0, 7)},
+		},
+	}
+	res := notifiableAnomalies(aCosts, states, now)
+	if len(res) != 1 || res[0].Key != "AmazonEC2" || res[0].Date.Day() != 11 || res[0].Currency != "EUR" {
+		t.Errorf("Expected only the recent EC2 anomaly to be notifiable, got %v.", res)
+	}
+}
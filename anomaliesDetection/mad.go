@@ -0,0 +1,113 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"sort"
+)
+
+// madScaleFactor makes the median absolute deviation a consistent estimator
+// of the standard deviation for normally distributed costs.
+const madScaleFactor = 1.4826
+
+// register the EWMA/MAD detector.
+func init() {
+	Detector{
+		Name:        "ewma-mad",
+		Description: "EWMA and median absolute deviation: a day is abnormal when its cost exceeds the exponentially weighted moving average of the previous days by more than a multiple of their median absolute deviation. It is less sensitive to past spikes than Bollinger Bands.",
+		DefaultParams: DetectorParams{
+			"period":               14,
+			"alpha":                0.3,
+			"coefficient":          3,
+			"upperBandCoefficient": 1.05,
+		},
+		ParamRanges: map[string]ParamRange{
+			"period": periodRange,
+			"alpha":  {Min: 0, Max: 1, MinExcluded: true},
+		},
+		History: func(params DetectorParams) int { return int(params["period"]) },
+		Func:    analyseEwmaMadAnomalies,
+	}.Register()
+}
+
+// ewma calculates the exponentially weighted moving average of a
+// CostAnomaly slice, the latest costs weighing the most.
+func ewma(aCosts AnalyzedCosts, alpha float64) float64 {
+	if alpha > 1 {
+		alpha = 1
+	}
+	var res float64
+	for i, a := range aCosts {
+		if i == 0 {
+			res = a.Cost
+		} else {
+			res = alpha*a.Cost + (1-alpha)*res
+		}
+	}
+	return res
+}
+
+// median calculates the median of a float64 slice. The slice is sorted in
+// place.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n == 0 {
+		return 0
+	} else if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// medianAbsoluteDeviation calculates the median absolute deviation of a
+// CostAnomaly slice.
+func medianAbsoluteDeviation(aCosts AnalyzedCosts) float64 {
+	values := make([]float64, len(aCosts))
+	for i, a := range aCosts {
+		values[i] = a.Cost
+	}
+	m := median(values)
+	for i, a := range aCosts {
+		if a.Cost > m {
+			values[i] = a.Cost - m
+		} else {
+			values[i] = m - a.Cost
+		}
+	}
+	return median(values)
+}
+
+// analyseEwmaMadAnomalies calculates anomalies with an exponentially
+// weighted moving average and the median absolute deviation of the
+// previous days.
+func analyseEwmaMadAnomalies(aCosts AnalyzedCosts, params DetectorParams) AnalyzedCosts {
+	period := int(params["period"])
+	if period < 1 {
+		period = 1
+	}
+	for index := range aCosts {
+		if index > 0 {
+			a := &aCosts[index]
+			tempSlice := aCosts[index-min(index, period) : index]
+			deviation := medianAbsoluteDeviation(tempSlice) * madScaleFactor
+			a.UpperBand = ewma(tempSlice, params["alpha"])*params["upperBandCoefficient"] + deviation*params["coefficient"]
+			if a.Cost > a.UpperBand {
+				a.Anomaly = true
+			}
+		}
+	}
+	return aCosts
+}
@@ -24,6 +24,9 @@ import (
 )
 
 const TypeProductAnomaliesDetection = "product-anomalies-detection"
+const TypeRegionAnomaliesDetection = "region-anomalies-detection"
+const TypeUsageTypeAnomaliesDetection = "usage-type-anomalies-detection"
+const TypeUsageAccountAnomaliesDetection = "usage-account-anomalies-detection"
 const IndexPrefixAnomaliesDetection = "anomalies-detection"
 const TemplateNameAnomaliesDetection = "anomalies-detection"
 
@@ -39,12 +42,11 @@ func init() {
 	}
 }
 
-const TemplateAnomaliesDetection = `
-{
-	"template": "*-` + IndexPrefixAnomaliesDetection + `",
-	"version": 1,
-	"mappings": {
-		"` + TypeProductAnomaliesDetection + `": {
+// anomalyMapping returns the mapping of the document type docType, the value
+// of the dimension being stored in the field dimensionField.
+func anomalyMapping(docType, dimensionField string) string {
+	return `
+		"` + docType + `": {
 			"properties": {
 				"account": {
 					"type": "keyword"
@@ -52,12 +54,15 @@ const TemplateAnomaliesDetection = `
 				"date": {
 					"type": "date"
 				},
-				"product" : {
+				"` + dimensionField + `" : {
 					"type": "keyword"
 				},
 				"abnormal" : {
 					"type": "boolean"
 				},
+				"currency": {
+					"type": "keyword"
+				},
 				"cost": {
 					"type": "object",
 					"properties": {
@@ -75,7 +80,18 @@ const TemplateAnomaliesDetection = `
 			},
 			"numeric_detection": false,
 			"date_detection": false
-		}
+		}`
+}
+
+var TemplateAnomaliesDetection = `
+{
+	"template": "*-` + IndexPrefixAnomaliesDetection + `",
+	"version": 3,
+	"mappings": {` +
+	anomalyMapping(TypeProductAnomaliesDetection, "product") + `,` +
+	anomalyMapping(TypeRegionAnomaliesDetection, "region") + `,` +
+	anomalyMapping(TypeUsageTypeAnomaliesDetection, "usageType") + `,` +
+	anomalyMapping(TypeUsageAccountAnomaliesDetection, "usageAccountId") + `
 	}
 }
 `
@@ -0,0 +1,124 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/notifications"
+	"github.com/trackit/trackit-server/users"
+)
+
+// notificationWindow is how old an anomaly can be to be notified. Older
+// anomalies were already notified by previous runs.
+const notificationWindow = 48 * time.Hour
+
+// anomalyEventData is the data of the anomaly events sent to the
+// notification endpoints.
+type anomalyEventData struct {
+	Dimension string    `json:"dimension"`
+	Key       string    `json:"key"`
+	Date      time.Time `json:"date"`
+	Cost      float64   `json:"cost"`
+	UpperBand float64   `json:"upperBand"`
+	Currency  string    `json:"currency"`
+}
+
+// notifiableAnomalies returns the anomalies which happened during the
+// notificationWindow and nobody gave feedback on or snoozed.
+func notifiableAnomalies(aCosts AnalyzedCosts, states AnomalyStates, now time.Time) []anomalyEventData {
+	res := make([]anomalyEventData, 0)
+	for _, aCost := range aCosts {
+		if !aCost.Anomaly {
+			continue
+		}
+		date, err := time.Parse("2006-01-02T15:04:05.000Z", aCost.Meta.Date)
+		if err != nil || now.Sub(date) > notificationWindow {
+			continue
+		}
+		meta := aCost.Meta.AdditionalMeta.(AnalyzedCostDimensionMeta)
+		if states.IsNotifiable(meta.Key, date) {
+			res = append(res, anomalyEventData{meta.Dimension, meta.Key, date, aCost.Cost, aCost.UpperBand, meta.Currency})
+		}
+	}
+	return res
+}
+
+// displayAnomalyCosts converts the cost and upper band of an anomaly to the
+// display currency of the owner of its account, or leaves them in the
+// currency they were billed in if no rate is known for it.
+func displayAnomalyCosts(converter currencies.Converter, anomaly anomalyEventData) (float64, float64, string) {
+	converter, err := converter.For(anomaly.Currency)
+	if err != nil {
+		return anomaly.Cost, anomaly.UpperBand, anomaly.Currency
+	}
+	cost, err := converter.Convert(anomaly.Cost, anomaly.Currency)
+	if err != nil {
+		return anomaly.Cost, anomaly.UpperBand, anomaly.Currency
+	}
+	upperBand, err := converter.Convert(anomaly.UpperBand, anomaly.Currency)
+	if err != nil {
+		return anomaly.Cost, anomaly.UpperBand, anomaly.Currency
+	}
+	return cost, upperBand, converter.Currency
+}
+
+// notifyAnomalies sends the new anomalies along a dimension to the
+// notification endpoints of an AwsAccount. They are sent by a worker once
+// tx is committed, and failed deliveries are retried on the next run.
+func notifyAnomalies(ctx context.Context, tx *sql.Tx, aCosts AnalyzedCosts, account aws.AwsAccount, dimension Dimension) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	states, err := GetAnomalyStates(account.Id, dimension.Name, tx)
+	if err != nil {
+		return err
+	}
+	owner, err := users.GetUserWithId(tx, account.UserId)
+	if err != nil {
+		return err
+	}
+	converter, err := currencies.ConverterForUser(tx, owner)
+	if err != nil {
+		return err
+	}
+	for _, anomaly := range notifiableAnomalies(aCosts, states, time.Now()) {
+		cost, upperBand, currency := displayAnomalyCosts(converter, anomaly)
+		event := notifications.Event{
+			Type:    notifications.EventAnomaly,
+			Key:     fmt.Sprintf("anomaly:%s:%s:%s", anomaly.Dimension, anomaly.Key, anomaly.Date.Format("2006-01-02")),
+			Subject: fmt.Sprintf("Cost anomaly detected for %s %s", dimension.Name, anomaly.Key),
+			Message: fmt.Sprintf("Hi, the cost of %s \"%s\" on the AWS account \"%s\" reached %.2f %s on %s, "+
+				"while at most %.2f %s was expected. "+
+				"You can connect to your account to review it: https://re.trackit.io/",
+				dimension.Name, anomaly.Key, account.Pretty, cost, currency, anomaly.Date.Format("January 2, 2006"), upperBand, currency),
+			Date: anomaly.Date,
+			Data: anomaly,
+		}
+		if err := notifications.Notify(ctx, tx, account, event); err != nil {
+			logger.Warning("Failed to notify anomaly.", map[string]interface{}{
+				"awsAccountId": account.Id,
+				"anomaly":      anomaly,
+				"error":        err.Error(),
+			})
+		}
+	}
+	return nil
+}
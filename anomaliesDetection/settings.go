@@ -0,0 +1,92 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/models"
+)
+
+// DetectorSettings is the anomaly detector used for an AwsAccount along with
+// its parameters.
+type DetectorSettings struct {
+	Detector string         `json:"detector"`
+	Params   DetectorParams `json:"params"`
+}
+
+// GetDetectorSettings retrieves the detector settings of an AwsAccount. The
+// parameters it did not set are filled with their default values. If the
+// AwsAccount did not choose a detector, DefaultDetector is used.
+func GetDetectorSettings(aa aws.AwsAccount, tx *sql.Tx) (DetectorSettings, error) {
+	settings := DetectorSettings{Detector: DefaultDetector}
+	var params DetectorParams
+	dbSettings, err := models.AwsAccountAnomalyDetectorByAwsAccountID(tx, aa.Id)
+	if err == nil {
+		settings.Detector = dbSettings.Detector
+		if err = json.Unmarshal([]byte(dbSettings.Params), &params); err != nil {
+			return settings, err
+		}
+	} else if err != sql.ErrNoRows {
+		return settings, err
+	}
+	detector, err := GetDetector(settings.Detector)
+	if err != nil {
+		return settings, err
+	}
+	settings.Params, err = detector.Params(params)
+	return settings, err
+}
+
+// SetDetectorSettings validates and saves the detector settings of an
+// AwsAccount. It returns the settings with the default parameters filled.
+func SetDetectorSettings(aa aws.AwsAccount, settings DetectorSettings, tx *sql.Tx) (DetectorSettings, error) {
+	detector, err := GetDetector(settings.Detector)
+	if err != nil {
+		return settings, err
+	} else if _, err = detector.Params(settings.Params); err != nil {
+		return settings, err
+	}
+	params, err := json.Marshal(settings.Params)
+	if err != nil {
+		return settings, err
+	}
+	dbSettings, err := models.AwsAccountAnomalyDetectorByAwsAccountID(tx, aa.Id)
+	if err == sql.ErrNoRows {
+		dbSettings = &models.AwsAccountAnomalyDetector{AwsAccountID: aa.Id}
+	} else if err != nil {
+		return settings, err
+	}
+	dbSettings.Detector = settings.Detector
+	dbSettings.Params = string(params)
+	if err = dbSettings.Save(tx); err != nil {
+		return settings, err
+	}
+	return GetDetectorSettings(aa, tx)
+}
+
+// ResetDetectorSettings deletes the detector settings of an AwsAccount, so
+// that DefaultDetector is used with its default parameters.
+func ResetDetectorSettings(aa aws.AwsAccount, tx *sql.Tx) error {
+	dbSettings, err := models.AwsAccountAnomalyDetectorByAwsAccountID(tx, aa.Id)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return dbSettings.Delete(tx)
+}
@@ -0,0 +1,73 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"math"
+)
+
+// daysInWeek is the number of days between two days of the same weekday.
+const daysInWeek = 7
+
+// register the weekday z-score detector.
+func init() {
+	Detector{
+		Name:        "weekday-zscore",
+		Description: "Weekday z-score: a day is abnormal when its cost exceeds the average cost of the same weekday over the previous weeks by more than a number of standard deviations.",
+		DefaultParams: DetectorParams{
+			"weeks":                4,
+			"zScore":               3,
+			"upperBandCoefficient": 1.05,
+		},
+		ParamRanges: map[string]ParamRange{
+			"weeks": {Min: 1, Max: 12},
+		},
+		History: func(params DetectorParams) int { return int(params["weeks"]) * daysInWeek },
+		Func:    analyseWeekdayAnomalies,
+	}.Register()
+}
+
+// sameWeekdayCosts returns the costs of the same weekday as the one at
+// index over the previous weeks.
+func sameWeekdayCosts(aCosts AnalyzedCosts, index, weeks int) AnalyzedCosts {
+	var res AnalyzedCosts
+	for i := index - daysInWeek; i >= 0 && len(res) < weeks; i -= daysInWeek {
+		res = append(res, aCosts[i])
+	}
+	return res
+}
+
+// analyseWeekdayAnomalies calculates anomalies by comparing each day with
+// the same weekday of the previous weeks, so that weekly patterns such as
+// quieter weekends are not reported.
+func analyseWeekdayAnomalies(aCosts AnalyzedCosts, params DetectorParams) AnalyzedCosts {
+	weeks := int(params["weeks"])
+	if weeks < 1 {
+		weeks = 1
+	}
+	for index := range aCosts {
+		previous := sameWeekdayCosts(aCosts, index, weeks)
+		if len(previous) > 0 {
+			a := &aCosts[index]
+			avg := average(previous)
+			deviation := math.Sqrt(sigma(previous, avg) / float64(len(previous)))
+			a.UpperBand = avg*params["upperBandCoefficient"] + deviation*params["zScore"]
+			if a.Cost > a.UpperBand {
+				a.Anomaly = true
+			}
+		}
+	}
+	return aCosts
+}
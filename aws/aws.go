@@ -90,6 +90,9 @@ func GetAwsAccountsFromUser(u users.User, tx *sql.Tx) ([]AwsAccount, error) {
 		return nil, err
 	}
 	for _, key := range dbAwsAccounts {
+		if !u.CanAccessAwsAccount(key.ID) {
+			continue
+		}
 		res = append(res, AwsAccount{
 			key.ID,
 			key.UserID,
@@ -103,6 +106,9 @@ func GetAwsAccountsFromUser(u users.User, tx *sql.Tx) ([]AwsAccount, error) {
 			key.ParentID})
 	}
 	for _, key := range dbShareAccounts {
+		if !u.CanAccessAwsAccount(key.AccountID) {
+			continue
+		}
 		dbAwsAccountById, err := models.AwsAccountByID(tx, key.AccountID)
 		if err != nil {
 			return nil, err
@@ -139,7 +145,7 @@ func GetAwsAccountWithIdFromUser(u users.User, aaid int, tx *sql.Tx) (AwsAccount
 	var aaz AwsAccount
 	if aa, err := GetAwsAccountWithId(aaid, tx); err != nil {
 		return aaz, err
-	} else if aa.UserId == u.Id {
+	} else if aa.UserId == u.Id && u.CanAccessAwsAccount(aa.Id) {
 		return aa, nil
 	} else {
 		return aaz, errors.New("aws account does not belong to the user")
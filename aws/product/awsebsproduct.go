@@ -0,0 +1,96 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+type (
+	// EbsAttribute holds the attributes of an EBS "Storage" product, which
+	// is part of the EC2 offer file.
+	EbsAttribute struct {
+		LocationAttribute
+		VolumeApiName       string
+		VolumeType          string
+		MaxIopsvolume       string
+		MaxThroughputvolume string
+	}
+
+	// ebsFamily stores the "Storage" products of the EC2 offer file.
+	ebsFamily struct {
+		products models.AwsProductPricingEbsBulk
+		prices   models.AwsProductPricingEbsPriceBulk
+	}
+)
+
+func newEbsFamily() productFamily {
+	return &ebsFamily{
+		products: models.AwsProductPricingEbsBulk{BulkLimit: BulkLimit},
+		prices:   models.AwsProductPricingEbsPriceBulk{BulkLimit: BulkLimit},
+	}
+}
+
+func (f *ebsFamily) storeProduct(ctx context.Context, etag string, product Product, tx models.XODB) error {
+	var attributes EbsAttribute
+	if err := json.Unmarshal(product.Attributes, &attributes); err != nil {
+		return err
+	}
+	return f.products.AppendAndInsertIfLimitExceeded(models.AwsProductPricingEbs{
+		Sku:           string(product.Sku),
+		Etag:          etag,
+		Region:        attributes.region(ctx),
+		RegionCode:    attributes.RegionCode,
+		VolumeAPIName: attributes.VolumeApiName,
+		VolumeType:    attributes.VolumeType,
+		MaxIops:       attributes.MaxIopsvolume,
+		MaxThroughput: attributes.MaxThroughputvolume,
+	}, tx)
+}
+
+// storePrices stores the on-demand price of a volume. EBS volumes cannot be
+// reserved.
+func (f *ebsFamily) storePrices(ctx context.Context, etag string, sku Sku, prices []Price, tx models.XODB) error {
+	for _, price := range prices {
+		if price.TermType != TermTypeOnDemand {
+			continue
+		}
+		return f.prices.AppendAndInsertIfLimitExceeded(models.AwsProductPricingEbsPrice{
+			Sku:      string(sku),
+			Etag:     etag,
+			Unit:     price.Unit,
+			Price:    price.RecurringPrice,
+			Currency: price.Currency,
+		}, tx)
+	}
+	return nil
+}
+
+func (f *ebsFamily) flush(tx models.XODB) error {
+	if err := f.products.BulkInsertOrUpdate(tx); err != nil {
+		return err
+	}
+	return f.prices.BulkInsertOrUpdate(tx)
+}
+
+func (f *ebsFamily) purge(etag string, tx models.XODB) error {
+	if err := models.AwsProductPricingEbsPurgeWhenNotEtag(etag, tx); err != nil {
+		return err
+	}
+	return models.AwsProductPricingEbsPricePurgeWhenNotEtag(etag, tx)
+}
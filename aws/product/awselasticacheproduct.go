@@ -0,0 +1,100 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const ElastiCacheProductName = "elasticache"
+
+type (
+	// ElastiCacheAttribute holds the attributes of an ElastiCache "Cache
+	// Instance" product.
+	ElastiCacheAttribute struct {
+		LocationAttribute
+		InstanceType string
+		CacheEngine  string
+		Vcpu         string
+		Memory       string
+	}
+
+	// elastiCacheFamily stores the "Cache Instance" products of the
+	// ElastiCache offer file.
+	elastiCacheFamily struct {
+		products models.AwsProductPricingElasticacheBulk
+		prices   models.AwsProductPricingElasticachePriceBulk
+	}
+)
+
+func newElastiCacheFamily() productFamily {
+	return &elastiCacheFamily{
+		products: models.AwsProductPricingElasticacheBulk{BulkLimit: BulkLimit},
+		prices:   models.AwsProductPricingElasticachePriceBulk{BulkLimit: BulkLimit},
+	}
+}
+
+func (f *elastiCacheFamily) storeProduct(ctx context.Context, etag string, product Product, tx models.XODB) error {
+	var attributes ElastiCacheAttribute
+	if err := json.Unmarshal(product.Attributes, &attributes); err != nil {
+		return err
+	}
+	return f.products.AppendAndInsertIfLimitExceeded(models.AwsProductPricingElasticache{
+		Sku:          string(product.Sku),
+		Etag:         etag,
+		Region:       attributes.region(ctx),
+		RegionCode:   attributes.RegionCode,
+		InstanceType: attributes.InstanceType,
+		CacheEngine:  attributes.CacheEngine,
+		Vcpu:         parseVcpu(ctx, attributes.Vcpu),
+		Memory:       attributes.Memory,
+	}, tx)
+}
+
+func (f *elastiCacheFamily) storePrices(ctx context.Context, etag string, sku Sku, prices []Price, tx models.XODB) error {
+	for _, price := range prices {
+		if err := f.prices.AppendAndInsertIfLimitExceeded(models.AwsProductPricingElasticachePrice{
+			Sku:                 string(sku),
+			Etag:                etag,
+			TermType:            price.TermType,
+			LeaseContractLength: price.LeaseContractLength,
+			PurchaseOption:      price.PurchaseOption,
+			OfferingClass:       price.OfferingClass,
+			HourlyPrice:         price.RecurringPrice,
+			UpfrontPrice:        price.UpfrontPrice,
+			Currency:            price.Currency,
+		}, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *elastiCacheFamily) flush(tx models.XODB) error {
+	if err := f.products.BulkInsertOrUpdate(tx); err != nil {
+		return err
+	}
+	return f.prices.BulkInsertOrUpdate(tx)
+}
+
+func (f *elastiCacheFamily) purge(etag string, tx models.XODB) error {
+	if err := models.AwsProductPricingElasticachePurgeWhenNotEtag(etag, tx); err != nil {
+		return err
+	}
+	return models.AwsProductPricingElasticachePricePurgeWhenNotEtag(etag, tx)
+}
@@ -0,0 +1,110 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const EsProductName = "es"
+
+type (
+	// EsAttribute holds the attributes of an Elasticsearch Service instance
+	// product.
+	EsAttribute struct {
+		LocationAttribute
+		InstanceType string
+		Vcpu         string
+		MemoryGib    string
+		Storage      string
+	}
+
+	// esFamily stores the instance products of the Elasticsearch Service
+	// offer file.
+	esFamily struct {
+		products models.AwsProductPricingEsBulk
+		prices   models.AwsProductPricingEsPriceBulk
+	}
+)
+
+// EsOnDemandPrice returns the cheapest on-demand price of an Elasticsearch
+// Service instance type, or nil if it is unknown.
+func EsOnDemandPrice(tx models.XODB, instanceType, regionCode string) (*models.AwsProductPricingEsPrice, error) {
+	prices, err := models.AwsProductPricingEsOnDemandPrices(tx, instanceType, regionCode)
+	if err != nil || len(prices) == 0 {
+		return nil, err
+	}
+	return prices[0], nil
+}
+
+func newEsFamily() productFamily {
+	return &esFamily{
+		products: models.AwsProductPricingEsBulk{BulkLimit: BulkLimit},
+		prices:   models.AwsProductPricingEsPriceBulk{BulkLimit: BulkLimit},
+	}
+}
+
+func (f *esFamily) storeProduct(ctx context.Context, etag string, product Product, tx models.XODB) error {
+	var attributes EsAttribute
+	if err := json.Unmarshal(product.Attributes, &attributes); err != nil {
+		return err
+	}
+	return f.products.AppendAndInsertIfLimitExceeded(models.AwsProductPricingEs{
+		Sku:          string(product.Sku),
+		Etag:         etag,
+		Region:       attributes.region(ctx),
+		RegionCode:   attributes.RegionCode,
+		InstanceType: attributes.InstanceType,
+		Vcpu:         parseVcpu(ctx, attributes.Vcpu),
+		Memory:       attributes.MemoryGib,
+		Storage:      attributes.Storage,
+	}, tx)
+}
+
+func (f *esFamily) storePrices(ctx context.Context, etag string, sku Sku, prices []Price, tx models.XODB) error {
+	for _, price := range prices {
+		if err := f.prices.AppendAndInsertIfLimitExceeded(models.AwsProductPricingEsPrice{
+			Sku:                 string(sku),
+			Etag:                etag,
+			TermType:            price.TermType,
+			LeaseContractLength: price.LeaseContractLength,
+			PurchaseOption:      price.PurchaseOption,
+			OfferingClass:       price.OfferingClass,
+			HourlyPrice:         price.RecurringPrice,
+			UpfrontPrice:        price.UpfrontPrice,
+			Currency:            price.Currency,
+		}, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *esFamily) flush(tx models.XODB) error {
+	if err := f.products.BulkInsertOrUpdate(tx); err != nil {
+		return err
+	}
+	return f.prices.BulkInsertOrUpdate(tx)
+}
+
+func (f *esFamily) purge(etag string, tx models.XODB) error {
+	if err := models.AwsProductPricingEsPurgeWhenNotEtag(etag, tx); err != nil {
+		return err
+	}
+	return models.AwsProductPricingEsPricePurgeWhenNotEtag(etag, tx)
+}
@@ -0,0 +1,150 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const RdsProductName = "rds"
+
+type (
+	// RdsAttribute holds the attributes of an RDS "Database Instance"
+	// product.
+	RdsAttribute struct {
+		LocationAttribute
+		InstanceType     string
+		DatabaseEngine   string
+		DatabaseEdition  string
+		LicenseModel     string
+		DeploymentOption string
+		Vcpu             string
+		Memory           string
+	}
+
+	// rdsFamily stores the "Database Instance" products of the RDS offer
+	// file.
+	rdsFamily struct {
+		products models.AwsProductPricingRdsBulk
+		prices   models.AwsProductPricingRdsPriceBulk
+	}
+)
+
+// rdsEngines maps the engines of the RDS API to the database engines of the
+// offer file.
+var rdsEngines = map[string]string{
+	"aurora":            "Aurora MySQL",
+	"aurora-mysql":      "Aurora MySQL",
+	"aurora-postgresql": "Aurora PostgreSQL",
+	"mariadb":           "MariaDB",
+	"mysql":             "MySQL",
+	"postgres":          "PostgreSQL",
+}
+
+// RdsDatabaseEngine returns the database engine of the offer file for an
+// engine of the RDS API.
+func RdsDatabaseEngine(engine string) string {
+	if databaseEngine, ok := rdsEngines[engine]; ok {
+		return databaseEngine
+	} else if strings.HasPrefix(engine, "oracle") {
+		return "Oracle"
+	} else if strings.HasPrefix(engine, "sqlserver") {
+		return "SQL Server"
+	}
+	return engine
+}
+
+// RdsDeploymentOption returns the deployment option of the offer file for an
+// RDS instance.
+func RdsDeploymentOption(multiAZ bool) string {
+	if multiAZ {
+		return "Multi-AZ"
+	}
+	return "Single-AZ"
+}
+
+// RdsOnDemandPrice returns the cheapest on-demand price of an RDS instance
+// type, or nil if it is unknown.
+func RdsOnDemandPrice(tx models.XODB, instanceType, regionCode, engine string, multiAZ bool) (*models.AwsProductPricingRdsPrice, error) {
+	prices, err := models.AwsProductPricingRdsOnDemandPrices(tx, instanceType, regionCode, RdsDatabaseEngine(engine), RdsDeploymentOption(multiAZ))
+	if err != nil || len(prices) == 0 {
+		return nil, err
+	}
+	return prices[0], nil
+}
+
+func newRdsFamily() productFamily {
+	return &rdsFamily{
+		products: models.AwsProductPricingRdsBulk{BulkLimit: BulkLimit},
+		prices:   models.AwsProductPricingRdsPriceBulk{BulkLimit: BulkLimit},
+	}
+}
+
+func (f *rdsFamily) storeProduct(ctx context.Context, etag string, product Product, tx models.XODB) error {
+	var attributes RdsAttribute
+	if err := json.Unmarshal(product.Attributes, &attributes); err != nil {
+		return err
+	}
+	return f.products.AppendAndInsertIfLimitExceeded(models.AwsProductPricingRds{
+		Sku:              string(product.Sku),
+		Etag:             etag,
+		Region:           attributes.region(ctx),
+		RegionCode:       attributes.RegionCode,
+		InstanceType:     attributes.InstanceType,
+		DatabaseEngine:   attributes.DatabaseEngine,
+		DatabaseEdition:  attributes.DatabaseEdition,
+		LicenseModel:     attributes.LicenseModel,
+		DeploymentOption: attributes.DeploymentOption,
+		Vcpu:             parseVcpu(ctx, attributes.Vcpu),
+		Memory:           attributes.Memory,
+	}, tx)
+}
+
+func (f *rdsFamily) storePrices(ctx context.Context, etag string, sku Sku, prices []Price, tx models.XODB) error {
+	for _, price := range prices {
+		if err := f.prices.AppendAndInsertIfLimitExceeded(models.AwsProductPricingRdsPrice{
+			Sku:                 string(sku),
+			Etag:                etag,
+			TermType:            price.TermType,
+			LeaseContractLength: price.LeaseContractLength,
+			PurchaseOption:      price.PurchaseOption,
+			OfferingClass:       price.OfferingClass,
+			HourlyPrice:         price.RecurringPrice,
+			UpfrontPrice:        price.UpfrontPrice,
+			Currency:            price.Currency,
+		}, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *rdsFamily) flush(tx models.XODB) error {
+	if err := f.products.BulkInsertOrUpdate(tx); err != nil {
+		return err
+	}
+	return f.prices.BulkInsertOrUpdate(tx)
+}
+
+func (f *rdsFamily) purge(etag string, tx models.XODB) error {
+	if err := models.AwsProductPricingRdsPurgeWhenNotEtag(etag, tx); err != nil {
+		return err
+	}
+	return models.AwsProductPricingRdsPricePurgeWhenNotEtag(etag, tx)
+}
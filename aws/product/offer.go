@@ -0,0 +1,494 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/models"
+)
+
+// BulkLimit is the limit after which the
+// bulk is inserted to the database.
+const BulkLimit = 100
+
+const NsToMsVal = 1000000
+
+const (
+	TermTypeOnDemand = "OnDemand"
+	TermTypeReserved = "Reserved"
+
+	priceUnitQuantity = "Quantity"
+	localOfferScheme  = "file://"
+)
+
+var offsetParam = map[json.Token]int{
+	json.Delim('{'): 1,
+	json.Delim('}'): -1,
+}
+
+type (
+	Sku string
+
+	// Product is a product of an offer file. Its attributes depend on its
+	// family and are decoded by the productFamily storing it.
+	Product struct {
+		Sku           Sku
+		ProductFamily string
+		Attributes    json.RawMessage
+	}
+
+	// LocationAttribute holds the attributes locating a product.
+	LocationAttribute struct {
+		Location     string
+		LocationType string
+		RegionCode   string
+	}
+
+	// PriceDimension is a component of the price of an offer. Its unit is
+	// "Quantity" for upfront fees and a duration otherwise.
+	PriceDimension struct {
+		Unit         string
+		PricePerUnit map[string]string
+	}
+
+	// TermAttributes describes the conditions of a reserved offer.
+	TermAttributes struct {
+		LeaseContractLength string
+		OfferingClass       string
+		PurchaseOption      string
+	}
+
+	// Offer is a way to purchase a product, with its price.
+	Offer struct {
+		PriceDimensions map[string]PriceDimension
+		TermAttributes  TermAttributes
+	}
+
+	// Price is the price of a product for an offer, with the recurring part
+	// in Unit and the upfront part separated.
+	Price struct {
+		TermType            string
+		LeaseContractLength string
+		PurchaseOption      string
+		OfferingClass       string
+		Unit                string
+		RecurringPrice      float64
+		UpfrontPrice        float64
+		Currency            string
+	}
+
+	// productFamily stores the products of a family, such as "Compute
+	// Instance", with their prices.
+	productFamily interface {
+		// storeProduct stores the attributes of a product.
+		storeProduct(ctx context.Context, etag string, product Product, tx models.XODB) error
+		// storePrices stores the prices of a product.
+		storePrices(ctx context.Context, etag string, sku Sku, prices []Price, tx models.XODB) error
+		// flush inserts what remains in the bulks to the database. It is
+		// called once, after all products and prices were stored.
+		flush(tx models.XODB) error
+		// purge removes the products and prices of other versions of the
+		// offer file.
+		purge(etag string, tx models.XODB) error
+	}
+
+	// Service is an AWS offer file and the product families imported from
+	// it.
+	Service struct {
+		// Name identifies the service in aws_product_pricing_update.
+		Name string
+		// Url points to the configured URL of the offer file. Local files
+		// are read when it starts with "file://".
+		Url *string
+		// families builds the product families by product family name.
+		families func() map[string]productFamily
+	}
+)
+
+// Services lists the offer files which can be imported, by name.
+var Services = map[string]Service{
+	Ec2ProductName: {
+		Name: Ec2ProductName,
+		Url:  &config.UrlEc2Pricing,
+		families: func() map[string]productFamily {
+			return map[string]productFamily{
+				"Compute Instance": newEc2Family(),
+				"Storage":          newEbsFamily(),
+			}
+		},
+	},
+	RdsProductName: {
+		Name: RdsProductName,
+		Url:  &config.UrlRdsPricing,
+		families: func() map[string]productFamily {
+			return map[string]productFamily{
+				"Database Instance": newRdsFamily(),
+			}
+		},
+	},
+	EsProductName: {
+		Name: EsProductName,
+		Url:  &config.UrlEsPricing,
+		families: func() map[string]productFamily {
+			es := newEsFamily()
+			return map[string]productFamily{
+				"Elastic Search Instance":            es,
+				"Amazon OpenSearch Service Instance": es,
+			}
+		},
+	},
+	ElastiCacheProductName: {
+		Name: ElastiCacheProductName,
+		Url:  &config.UrlElastiCachePricing,
+		families: func() map[string]productFamily {
+			return map[string]productFamily{
+				"Cache Instance": newElastiCacheFamily(),
+			}
+		},
+	},
+}
+
+// region returns the name of the region a product is located in.
+func (la LocationAttribute) region(ctx context.Context) string {
+	if la.LocationType != "AWS Region" {
+		logger := jsonlog.LoggerFromContextOrDefault(ctx)
+		logger.Info("Unexpected value in LocationType when storing product data", la.LocationType)
+		return ""
+	}
+	return la.Location
+}
+
+// parseVcpu parses the vCPU count of a product, logging unexpected values.
+func parseVcpu(ctx context.Context, vcpu string) int {
+	val, err := strconv.Atoi(vcpu)
+	if err != nil {
+		logger := jsonlog.LoggerFromContextOrDefault(ctx)
+		logger.Info("Unexpected value in Vcpu when storing product data", vcpu)
+	}
+	return val
+}
+
+// distinctFamilies returns each product family of families once.
+func distinctFamilies(families map[string]productFamily) []productFamily {
+	seen := make(map[productFamily]bool, len(families))
+	res := make([]productFamily, 0, len(families))
+	for _, family := range families {
+		if !seen[family] {
+			seen[family] = true
+			res = append(res, family)
+		}
+	}
+	return res
+}
+
+// consumeJsonUntilKey consumes and ignores values until the key field at
+// depth 1, offset being the depth the decoder currently is at.
+func consumeJsonUntilKey(decoder *json.Decoder, key string, offset int) error {
+	for t, err := decoder.Token(); t != key || offset != 1; t, err = decoder.Token() {
+		if err != nil {
+			return err
+		}
+		if param, ok := offsetParam[t]; ok {
+			offset += param
+		}
+	}
+	decoder.Token()
+	return nil
+}
+
+// consumeJsonProducts consumes and imports products until
+// the end of the JSON products field's content. It returns the product
+// family of each imported product.
+func consumeJsonProducts(ctx context.Context, etag string, decoder *json.Decoder, families map[string]productFamily, tx models.XODB) (map[Sku]productFamily, error) {
+	var nbProduct int
+	skus := make(map[Sku]productFamily)
+
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	start := time.Now()
+	for t, err := decoder.Token(); t != json.Delim('}'); t, err = decoder.Token() {
+		if err != nil {
+			logger.Error("Error when detecting token in pricing JSON", err.Error())
+			return nil, err
+		}
+		var product Product
+
+		if err := decoder.Decode(&product); err != nil {
+			logger.Error("Error when decoding and storing json in the struct", err.Error())
+			return nil, err
+		}
+		if family, ok := families[product.ProductFamily]; ok {
+			if err := family.storeProduct(ctx, etag, product, tx); err != nil {
+				logger.Error("Error when inserting product in database", err.Error())
+				return nil, err
+			}
+			skus[product.Sku] = family
+			nbProduct++
+		}
+	}
+	logger.Info(fmt.Sprintf("%d product(s) successfully stored in %dms.", nbProduct, time.Now().Sub(start)/NsToMsVal), nil)
+	return skus, nil
+}
+
+// pricesFromOffers builds the prices of a product from its offers for a
+// given term type. An error is returned if an offer is priced in several
+// currencies, since a price has a single currency.
+func pricesFromOffers(termType string, offers map[string]Offer) ([]Price, error) {
+	prices := make([]Price, 0, len(offers))
+	for code, offer := range offers {
+		price := Price{
+			TermType:            termType,
+			LeaseContractLength: offer.TermAttributes.LeaseContractLength,
+			PurchaseOption:      offer.TermAttributes.PurchaseOption,
+			OfferingClass:       offer.TermAttributes.OfferingClass,
+		}
+		for _, dimension := range offer.PriceDimensions {
+			for currency, value := range dimension.PricePerUnit {
+				amount, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, err
+				}
+				if price.Currency != "" && price.Currency != currency {
+					return nil, fmt.Errorf("offer %s is priced in both %s and %s", code, price.Currency, currency)
+				}
+				price.Currency = currency
+				if dimension.Unit == priceUnitQuantity {
+					price.UpfrontPrice += amount
+				} else {
+					price.Unit = dimension.Unit
+					price.RecurringPrice += amount
+				}
+			}
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// consumeJsonTerms consumes and imports the OnDemand and Reserved prices of
+// the products in skus until the end of the JSON terms field's content.
+func consumeJsonTerms(ctx context.Context, etag string, skus map[Sku]productFamily, decoder *json.Decoder, tx models.XODB) error {
+	var nbPrice int
+
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	start := time.Now()
+	for t, err := decoder.Token(); t != json.Delim('}'); t, err = decoder.Token() {
+		if err != nil {
+			logger.Error("Error when detecting token in pricing JSON", err.Error())
+			return err
+		}
+		termType, _ := t.(string)
+		if _, err := decoder.Token(); err != nil {
+			logger.Error("Error when detecting token in pricing JSON", err.Error())
+			return err
+		}
+		for t, err := decoder.Token(); t != json.Delim('}'); t, err = decoder.Token() {
+			if err != nil {
+				logger.Error("Error when detecting token in pricing JSON", err.Error())
+				return err
+			}
+			sku, _ := t.(string)
+			var offers map[string]Offer
+			if err := decoder.Decode(&offers); err != nil {
+				logger.Error("Error when decoding and storing json in the struct", err.Error())
+				return err
+			}
+			family, ok := skus[Sku(sku)]
+			if !ok || (termType != TermTypeOnDemand && termType != TermTypeReserved) {
+				continue
+			}
+			prices, err := pricesFromOffers(termType, offers)
+			if err != nil {
+				logger.Error("Error when parsing the price of an offer", err.Error())
+				return err
+			}
+			if err := family.storePrices(ctx, etag, Sku(sku), prices, tx); err != nil {
+				logger.Error("Error when inserting price in database", err.Error())
+				return err
+			}
+			nbPrice += len(prices)
+		}
+	}
+	logger.Info(fmt.Sprintf("%d price(s) successfully stored in %dms.", nbPrice, time.Now().Sub(start)/NsToMsVal), nil)
+	return nil
+}
+
+// importResult parses the body returned by downloadJSON and
+// inserts the pricing to the database.
+func importResult(ctx context.Context, etag string, reader io.ReadCloser, families map[string]productFamily, tx models.XODB) error {
+	defer reader.Close()
+
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	decoder := json.NewDecoder(reader)
+	if err := consumeJsonUntilKey(decoder, "products", 0); err != nil {
+		logger.Error("Error when detecting token in pricing JSON", err.Error())
+		return err
+	}
+	skus, err := consumeJsonProducts(ctx, etag, decoder, families, tx)
+	if err != nil {
+		return err
+	}
+	if err := consumeJsonUntilKey(decoder, "terms", 1); err != nil {
+		logger.Error("Error when detecting token in pricing JSON", err.Error())
+		return err
+	}
+	if err := consumeJsonTerms(ctx, etag, skus, decoder, tx); err != nil {
+		return err
+	}
+	for _, family := range distinctFamilies(families) {
+		if err := family.flush(tx); err != nil {
+			logger.Error("Error when inserting pricing in database", err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// saveLastFetch saves in the database the last fetched Etag.
+// Thus, downloadJson will not download twice a same JSON.
+func saveLastFetch(lastFetch *models.AwsProductPricingUpdate, product string, newEtag string, tx models.XODB) error {
+	if lastFetch != nil {
+		lastFetch.Delete(tx)
+	}
+	dbAfp := models.AwsProductPricingUpdate{
+		Product: product,
+		Etag:    newEtag,
+	}
+	return dbAfp.Insert(tx)
+}
+
+// openLocalOffer opens an offer file from the local filesystem. Its etag is
+// derived from its modification date and size.
+func openLocalOffer(path string) (string, io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return "", nil, err
+	}
+	etag := fmt.Sprintf(`"%x-%x"`, stat.ModTime().UnixNano(), stat.Size())
+	return etag, file, nil
+}
+
+// requestOffer requests an offer file from AWS' API, unless it was not
+// modified since lastEtag. The returned body is nil if it was not.
+func requestOffer(ctx context.Context, url string, lastEtag string) (string, io.ReadCloser, error) {
+	hc := http.Client{}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if lastEtag != "" {
+		req.Header.Add("If-None-Match", lastEtag)
+	}
+	req = req.WithContext(ctx)
+	res, err := hc.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	if res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return lastEtag, nil, nil
+	} else if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return "", nil, fmt.Errorf("unexpected status %s when requesting offer file %s", res.Status, url)
+	}
+	return res.Header.Get("Etag"), res.Body, nil
+}
+
+// downloadJson requests AWS' API, or reads the local offer file, and returns
+// the etag from the json downloaded and its body after checking if there's
+// already the same version in the database.
+func downloadJson(ctx context.Context, service Service, tx models.XODB) (string, io.ReadCloser, error) {
+	var lastEtag string
+	var etag string
+	var body io.ReadCloser
+	var err error
+
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	lastFetch, err := models.AwsProductPricingUpdateByProduct(db.Db, service.Name)
+	if err == nil {
+		lastEtag = lastFetch.Etag
+	} else {
+		lastFetch = nil
+	}
+	start := time.Now()
+	if url := *service.Url; strings.HasPrefix(url, localOfferScheme) {
+		etag, body, err = openLocalOffer(strings.TrimPrefix(url, localOfferScheme))
+	} else {
+		etag, body, err = requestOffer(ctx, url, lastEtag)
+	}
+	if err != nil {
+		return lastEtag, nil, err
+	}
+	if body == nil || etag == lastEtag {
+		if body != nil {
+			body.Close()
+		}
+		logger.Info("JSON Stream halted: Already exists with ETag.\n", lastEtag)
+		return lastEtag, nil, nil
+	}
+	if err = saveLastFetch(lastFetch, service.Name, etag, tx); err != nil {
+		body.Close()
+		logger.Error("Error when saving the dbAfp", err.Error())
+		return lastEtag, nil, err
+	}
+	logger.Info(fmt.Sprintf("JSON streamed in %dms", time.Now().Sub(start)/NsToMsVal), nil)
+	return etag, body, nil
+}
+
+// ImportPricing downloads the offer file of a service from AWS and
+// stores it to the database.
+func ImportPricing(ctx context.Context, service Service, tx *sql.Tx) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	start := time.Now()
+	logger.Info("Attempting to stream JSON of pricing", service.Name)
+	etag, res, err := downloadJson(ctx, service, tx)
+	if err != nil {
+		logger.Error("Error when streaming the JSON file", err.Error())
+		return err
+	} else if res == nil {
+		return nil
+	}
+	families := service.families()
+	if err := importResult(ctx, etag, res, families, tx); err != nil {
+		logger.Error("Error when importing the result of the downloaded JSON", err.Error())
+		return err
+	}
+	for _, family := range distinctFamilies(families) {
+		if err := family.purge(etag, tx); err != nil {
+			logger.Error("Error when purging the old data of pricing", err.Error())
+			return err
+		}
+	}
+	logger.Info(fmt.Sprintf("%s pricing successfully imported in %dms.", service.Name, time.Now().Sub(start)/NsToMsVal), nil)
+	return nil
+}
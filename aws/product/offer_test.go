@@ -0,0 +1,225 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const sampleOffer = `{
+	"offerCode": "AmazonEC2",
+	"products": {
+		"SKU1": {
+			"sku": "SKU1",
+			"productFamily": "Compute Instance",
+			"attributes": {"instanceType": "m5.large", "regionCode": "us-east-1"}
+		},
+		"SKU2": {
+			"sku": "SKU2",
+			"productFamily": "Data Transfer",
+			"attributes": {"transferType": "AWS Outbound"}
+		}
+	},
+	"terms": {
+		"OnDemand": {
+			"SKU1": {
+				"SKU1.O": {
+					"priceDimensions": {
+						"SKU1.O.1": {"unit": "Hrs", "pricePerUnit": {"USD": "0.096"}}
+					},
+					"termAttributes": {}
+				}
+			},
+			"SKU2": {
+				"SKU2.O": {
+					"priceDimensions": {
+						"SKU2.O.1": {"unit": "GB", "pricePerUnit": {"USD": "0.09"}}
+					},
+					"termAttributes": {}
+				}
+			}
+		},
+		"Reserved": {
+			"SKU1": {
+				"SKU1.A": {
+					"priceDimensions": {
+						"SKU1.A.1": {"unit": "Quantity", "pricePerUnit": {"USD": "500"}},
+						"SKU1.A.2": {"unit": "Hrs", "pricePerUnit": {"USD": "0.05"}}
+					},
+					"termAttributes": {
+						"LeaseContractLength": "1yr",
+						"OfferingClass": "standard",
+						"PurchaseOption": "Partial Upfront"
+					}
+				}
+			}
+		}
+	}
+}`
+
+// recordingFamily is a productFamily which records what it is asked to
+// store.
+type recordingFamily struct {
+	products []Product
+	prices   map[Sku][]Price
+	flushes  int
+}
+
+func (f *recordingFamily) storeProduct(ctx context.Context, etag string, product Product, tx models.XODB) error {
+	f.products = append(f.products, product)
+	return nil
+}
+
+func (f *recordingFamily) storePrices(ctx context.Context, etag string, sku Sku, prices []Price, tx models.XODB) error {
+	f.prices[sku] = append(f.prices[sku], prices...)
+	return nil
+}
+
+func (f *recordingFamily) flush(tx models.XODB) error {
+	f.flushes++
+	return nil
+}
+
+func (f *recordingFamily) purge(etag string, tx models.XODB) error {
+	return nil
+}
+
+func writeSampleOffer(t *testing.T) string {
+	file, err := ioutil.TempFile("", "offer")
+	if err != nil {
+		t.Fatalf("Failed to create offer file: %s.", err.Error())
+	}
+	defer file.Close()
+	if _, err := file.WriteString(sampleOffer); err != nil {
+		t.Fatalf("Failed to write offer file: %s.", err.Error())
+	}
+	return file.Name()
+}
+
+func TestImportLocalOffer(t *testing.T) {
+	path := writeSampleOffer(t)
+	defer os.Remove(path)
+	etag, body, err := openLocalOffer(path)
+	if err != nil {
+		t.Fatalf("Failed to open offer file: %s.", err.Error())
+	}
+	family := &recordingFamily{prices: make(map[Sku][]Price)}
+	families := map[string]productFamily{"Compute Instance": family}
+	if err := importResult(context.Background(), etag, body, families, nil); err != nil {
+		t.Fatalf("Failed to import offer file: %s.", err.Error())
+	}
+	if len(family.products) != 1 || family.products[0].Sku != "SKU1" {
+		t.Errorf("Only SKU1 should be stored, got %#v.", family.products)
+	}
+	if len(family.prices) != 1 || len(family.prices["SKU1"]) != 2 {
+		t.Errorf("SKU1 should have 2 prices, got %#v.", family.prices)
+	}
+	if family.flushes != 1 {
+		t.Errorf("Family should be flushed once, was flushed %d times.", family.flushes)
+	}
+	if etag2, body2, err := openLocalOffer(path); err != nil {
+		t.Errorf("Failed to open offer file again: %s.", err.Error())
+	} else {
+		body2.Close()
+		if etag2 != etag {
+			t.Errorf("Etag of an unmodified file should be stable, got %s and %s.", etag, etag2)
+		}
+	}
+}
+
+func TestPricesFromReservedOffer(t *testing.T) {
+	offers := map[string]Offer{
+		"SKU1.A": {
+			PriceDimensions: map[string]PriceDimension{
+				"SKU1.A.1": {Unit: "Quantity", PricePerUnit: map[string]string{"USD": "500"}},
+				"SKU1.A.2": {Unit: "Hrs", PricePerUnit: map[string]string{"USD": "0.05"}},
+			},
+			TermAttributes: TermAttributes{"1yr", "standard", "Partial Upfront"},
+		},
+	}
+	prices, err := pricesFromOffers(TermTypeReserved, offers)
+	if err != nil {
+		t.Fatalf("Failed to build prices: %s.", err.Error())
+	}
+	if len(prices) != 1 {
+		t.Fatalf("Expected 1 price, got %d.", len(prices))
+	}
+	p := prices[0]
+	if p.UpfrontPrice != 500 || p.RecurringPrice != 0.05 || p.Unit != "Hrs" || p.Currency != "USD" {
+		t.Errorf("Unexpected amounts: %#v.", p)
+	}
+	if p.LeaseContractLength != "1yr" || p.PurchaseOption != "Partial Upfront" || p.OfferingClass != "standard" {
+		t.Errorf("Unexpected term attributes: %#v.", p)
+	}
+}
+
+func TestPricesFromInvalidOffer(t *testing.T) {
+	offers := map[string]Offer{
+		"SKU1.B": {PriceDimensions: map[string]PriceDimension{
+			"SKU1.B.1": {Unit: "Hrs", PricePerUnit: map[string]string{"USD": "abc"}},
+		}},
+	}
+	if _, err := pricesFromOffers(TermTypeOnDemand, offers); err == nil {
+		t.Errorf("Invalid price should fail.")
+	}
+}
+
+func TestPricesFromMixedCurrenciesOffer(t *testing.T) {
+	offers := map[string]Offer{
+		"SKU1.C": {PriceDimensions: map[string]PriceDimension{
+			"SKU1.C.1": {Unit: "Hrs", PricePerUnit: map[string]string{"USD": "0.1"}},
+			"SKU1.C.2": {Unit: "Quantity", PricePerUnit: map[string]string{"CNY": "500"}},
+		}},
+	}
+	if _, err := pricesFromOffers(TermTypeReserved, offers); err == nil {
+		t.Errorf("Offer priced in several currencies should fail.")
+	}
+}
+
+func TestRequestOfferStatus(t *testing.T) {
+	status := http.StatusNotModified
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	etag, body, err := requestOffer(context.Background(), server.URL, `"etag"`)
+	if err != nil || body != nil || etag != `"etag"` {
+		t.Errorf("Unmodified offer should be skipped, got etag %s and error %v.", etag, err)
+	}
+	status = http.StatusForbidden
+	if _, body, err := requestOffer(context.Background(), server.URL, `"etag"`); err == nil || body != nil {
+		t.Errorf("Offer requested with status %d should fail.", status)
+	}
+}
+
+func TestRdsDatabaseEngine(t *testing.T) {
+	for engine, expected := range map[string]string{
+		"postgres":      "PostgreSQL",
+		"aurora-mysql":  "Aurora MySQL",
+		"oracle-ee":     "Oracle",
+		"sqlserver-web": "SQL Server",
+	} {
+		if got := RdsDatabaseEngine(engine); got != expected {
+			t.Errorf("Engine %s should be %s, got %s.", engine, expected, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package product
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/models"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+type (
+	// Ec2Price is the price of an EC2 product for a purchase option.
+	Ec2Price struct {
+		TermType            string  `json:"termType"`
+		LeaseContractLength string  `json:"leaseContractLength,omitempty"`
+		PurchaseOption      string  `json:"purchaseOption,omitempty"`
+		OfferingClass       string  `json:"offeringClass,omitempty"`
+		HourlyPrice         float64 `json:"hourlyPrice"`
+		UpfrontPrice        float64 `json:"upfrontPrice"`
+		Currency            string  `json:"currency"`
+	}
+
+	// Ec2Pricing is an EC2 product with its prices.
+	Ec2Pricing struct {
+		Sku             string     `json:"sku"`
+		InstanceType    string     `json:"instanceType"`
+		Region          string     `json:"region"`
+		RegionCode      string     `json:"regionCode"`
+		OperatingSystem string     `json:"operatingSystem"`
+		Tenancy         string     `json:"tenancy"`
+		Vcpu            int        `json:"vcpu"`
+		Memory          string     `json:"memory"`
+		Prices          []Ec2Price `json:"prices"`
+	}
+)
+
+var (
+	regionQueryArg = routes.QueryArg{
+		Name:        "region",
+		Type:        routes.QueryArgString{},
+		Description: "The name or the code of the region.",
+	}
+	instanceTypeQueryArg = routes.QueryArg{
+		Name:        "instance-type",
+		Type:        routes.QueryArgString{},
+		Description: "The EC2 instance type.",
+	}
+	operatingSystemQueryArg = routes.QueryArg{
+		Name:        "operating-system",
+		Type:        routes.QueryArgString{},
+		Description: "The operating system of the instance.",
+		Optional:    true,
+	}
+	tenancyQueryArg = routes.QueryArg{
+		Name:        "tenancy",
+		Type:        routes.QueryArgString{},
+		Description: "The tenancy of the instance.",
+		Optional:    true,
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getEc2Pricing).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs{
+				regionQueryArg,
+				instanceTypeQueryArg,
+				operatingSystemQueryArg,
+				tenancyQueryArg,
+			},
+			routes.Documentation{
+				Summary:     "get the price of an EC2 instance type",
+				Description: "Responds with the on-demand and reserved prices of the EC2 products matching an instance type in a region.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary:     "look up EC2 prices",
+			Description: "Prices are imported from the AWS price list by the 'ingest-pricing' task.",
+		},
+	).Register("/pricing/ec2")
+}
+
+// getEc2Pricing is a handler which returns the prices of the EC2 products
+// matching the query arguments.
+func getEc2Pricing(r *http.Request, a routes.Arguments) (int, interface{}) {
+	tx := a[db.Transaction].(*sql.Tx)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	region := a[regionQueryArg].(string)
+	instanceType := a[instanceTypeQueryArg].(string)
+	operatingSystem, _ := a[operatingSystemQueryArg].(string)
+	tenancy, _ := a[tenancyQueryArg].(string)
+	products, err := models.AwsProductPricingEc2sByInstanceTypeRegion(tx, instanceType, region)
+	if err != nil {
+		l.Error("Failed to get EC2 products.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to get EC2 products")
+	}
+	res := make([]Ec2Pricing, 0, len(products))
+	for _, p := range products {
+		if (operatingSystem != "" && p.OperatingSystem != operatingSystem) || (tenancy != "" && p.Tenancy != tenancy) {
+			continue
+		}
+		prices, err := models.AwsProductPricingEc2PricesBySkuEtag(tx, p.Sku, p.Etag)
+		if err != nil {
+			l.Error("Failed to get EC2 prices.", err.Error())
+			return http.StatusInternalServerError, errors.New("failed to get EC2 prices")
+		}
+		res = append(res, ec2PricingFromDb(p, prices))
+	}
+	return http.StatusOK, res
+}
+
+// ec2PricingFromDb builds an Ec2Pricing from a product and its prices.
+func ec2PricingFromDb(p *models.AwsProductPricingEc2, prices []*models.AwsProductPricingEc2Price) Ec2Pricing {
+	res := Ec2Pricing{
+		Sku:             p.Sku,
+		InstanceType:    p.InstanceType,
+		Region:          p.Region,
+		RegionCode:      p.RegionCode,
+		OperatingSystem: p.OperatingSystem,
+		Tenancy:         p.Tenancy,
+		Vcpu:            p.Vcpu,
+		Memory:          p.Memory,
+		Prices:          make([]Ec2Price, len(prices)),
+	}
+	for i, price := range prices {
+		res.Prices[i] = Ec2Price{
+			TermType:            price.TermType,
+			LeaseContractLength: price.LeaseContractLength,
+			PurchaseOption:      price.PurchaseOption,
+			OfferingClass:       price.OfferingClass,
+			HourlyPrice:         price.HourlyPrice,
+			UpfrontPrice:        price.UpfrontPrice,
+			Currency:            price.Currency,
+		}
+	}
+	return res
+}
@@ -36,7 +36,7 @@ import (
 func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getBillRepositoryUpdates).With(
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "get user's bill repositories and info about their update status",
 				Description: "Gets the list of the user's bill repositories and info about when they have updated or will update.",
@@ -209,6 +209,18 @@ func AwsAccountsFromUserIDByAccountID(db models.XODB, userID int, accountIDs []i
 	return res, nil
 }
 
+// accessibleAwsAccountIds filters out the IDs of the AWS accounts the user
+// cannot access with the API key it authenticated with, if any.
+func accessibleAwsAccountIds(u users.User, accountIds []int) []int {
+	res := make([]int, 0, len(accountIds))
+	for _, id := range accountIds {
+		if u.CanAccessAwsAccount(id) {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
 type AwsAccountWithBillRepositories struct {
 	aws.AwsAccount
 	BillRepositories []s3.BillRepositoryWithPending   `json:"billRepositories"`
@@ -227,7 +239,11 @@ func getAwsAccount(r *http.Request, a routes.Arguments) (int, interface{}) {
 	tx := a[db.Transaction].(*sql.Tx)
 	l := jsonlog.LoggerFromContextOrDefault(r.Context())
 	if accountIds, ok := a[routes.AwsAccountIdsOptionalQueryArg]; ok {
-		awsAccounts, awsErr = AwsAccountsFromUserIDByAccountID(tx, u.Id, accountIds.([]int))
+		if ids := accessibleAwsAccountIds(u, accountIds.([]int)); len(ids) > 0 {
+			awsAccounts, awsErr = AwsAccountsFromUserIDByAccountID(tx, u.Id, ids)
+		} else {
+			awsAccounts = []aws.AwsAccount{}
+		}
 	} else {
 		awsAccounts, awsErr = aws.GetAwsAccountsFromUser(u, tx)
 	}
@@ -39,7 +39,7 @@ var DetailedOptionalQueryArg = routes.QueryArg{
 func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getAwsAccount).With(
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "get aws accounts' data",
 				Description: "Gets the data for all of the user's AWS accounts.",
@@ -50,7 +50,7 @@ func init() {
 			},
 		),
 		http.MethodPost: routes.H(postAwsAccount).With(
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyCannot},
 			routes.RequestContentType{"application/json"},
 			routes.RequestBody{postAwsAccountRequestBody{
 				RoleArn:  "arn:aws:iam::123456789012:role/example",
@@ -63,7 +63,7 @@ func init() {
 			},
 		),
 		http.MethodPatch: routes.H(patchAwsAccount).With(
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
 			routes.RequestContentType{"application/json"},
 			routes.QueryArgs{routes.AwsAccountIdQueryArg},
 			routes.Documentation{
@@ -72,7 +72,7 @@ func init() {
 			},
 		),
 		http.MethodDelete: routes.H(deleteAwsAccount).With(
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
 			routes.QueryArgs{routes.AwsAccountIdQueryArg},
 			aws.RequireAwsAccountId{},
 			routes.Documentation{
@@ -92,7 +92,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(aws.NextExternal).With(
 			db.RequestTransaction{db.Db},
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyCannot},
 			routes.Documentation{
 				Summary:     "get data to add next aws account",
 				Description: "Gets data the user must have in order to successfully set up their account with the product.",
@@ -105,7 +105,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getAwsAccountsStatus).With(
 			db.RequestTransaction{db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "get status of aws accounts",
 				Description: "Gets status of AWS Accounts and their bill repositories.",
@@ -36,7 +36,7 @@ import (
 func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getBillRepository).With(
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			aws.RequireAwsAccountId{},
 			routes.Documentation{
 				Summary:     "get aws account's bill repositories",
@@ -44,7 +44,7 @@ func init() {
 			},
 		),
 		http.MethodPost: routes.H(postBillRepository).With(
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
 			aws.RequireAwsAccountId{},
 			routes.RequestContentType{"application/json"},
 			routes.RequestBody{postBillRepositoryBody{
@@ -57,7 +57,7 @@ func init() {
 			},
 		),
 		http.MethodPatch: routes.H(patchBillRepository).With(
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
 			aws.RequireAwsAccountId{},
 			routes.RequestContentType{"application/json"},
 			routes.QueryArgs{routes.BillPositoryQueryArg},
@@ -71,7 +71,7 @@ func init() {
 			},
 		),
 		http.MethodDelete: routes.H(deleteBillRepository).With(
-			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
 			aws.RequireAwsAccountId{},
 			routes.RequestContentType{"application/json"},
 			routes.QueryArgs{routes.BillPositoryQueryArg},
@@ -0,0 +1,107 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import (
+	"errors"
+	"strconv"
+)
+
+const (
+	CostTypeUnblended = "unblended"
+	CostTypeBlended   = "blended"
+	CostTypeAmortized = "amortized"
+	CostTypeNet       = "net"
+)
+
+// ErrInvalidCostType is returned when a cost type is not one of the known
+// cost types.
+var ErrInvalidCostType = errors.New("Cost type must be one of unblended, blended, amortized or net.")
+
+// costTypeFields maps cost types to the LineItem field holding them in
+// ElasticSearch.
+var costTypeFields = map[string]string{
+	CostTypeUnblended: "unblendedCost",
+	CostTypeBlended:   "blendedCost",
+	CostTypeAmortized: "amortizedCost",
+	CostTypeNet:       "netUnblendedCost",
+}
+
+// CostTypeField returns the name of the ElasticSearch field to aggregate on
+// for a cost type. An empty cost type yields the unblended cost.
+func CostTypeField(costType string) (string, error) {
+	if costType == "" {
+		costType = CostTypeUnblended
+	}
+	if field, ok := costTypeFields[costType]; ok {
+		return field, nil
+	}
+	return "", ErrInvalidCostType
+}
+
+// computeCostTypes fills the cost columns derived from others. The amortized
+// cost spreads reservation and savings plan fees over the usage they cover:
+// covered usage is billed at its effective cost, upfront and recurring fees
+// are only kept for the unused part of the commitment.
+func computeCostTypes(li LineItem) LineItem {
+	if li.NetUnblendedCost == "" {
+		li.NetUnblendedCost = li.UnblendedCost
+	}
+	if li.BlendedCost == "" {
+		li.BlendedCost = li.UnblendedCost
+	}
+	switch li.LineItemType {
+	case "DiscountedUsage":
+		li.AmortizedCost = li.EffectiveCost
+	case "SavingsPlanCoveredUsage":
+		li.AmortizedCost = li.SavingsPlanCost
+	case "SavingsPlanNegation", "SavingsPlanUpfrontFee":
+		li.AmortizedCost = "0"
+	case "SavingsPlanRecurringFee":
+		li.AmortizedCost = sumCosts(
+			li.TotalCommitment,
+			"-"+li.UsedCommitment,
+		)
+	case "RIFee":
+		li.AmortizedCost = sumCosts(
+			li.UnusedUpfrontFee,
+			li.UnusedRecurringFee,
+		)
+	case "Fee":
+		if li.ReservationArn != "" {
+			li.AmortizedCost = "0"
+		} else {
+			li.AmortizedCost = li.UnblendedCost
+		}
+	default:
+		li.AmortizedCost = li.UnblendedCost
+	}
+	if li.AmortizedCost == "" {
+		li.AmortizedCost = "0"
+	}
+	return li
+}
+
+// sumCosts adds up costs formatted as in usage and cost reports. Empty or
+// malformed values count as zero.
+func sumCosts(costs ...string) string {
+	var total float64
+	for _, c := range costs {
+		if f, err := strconv.ParseFloat(c, 64); err == nil {
+			total += f
+		}
+	}
+	return strconv.FormatFloat(total, 'f', -1, 64)
+}
@@ -0,0 +1,62 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import (
+	"testing"
+)
+
+func TestComputeCostTypes(t *testing.T) {
+	cases := []struct {
+		name     string
+		li       LineItem
+		expected string
+	}{
+		{"usage", LineItem{LineItemType: "Usage", UnblendedCost: "1.5"}, "1.5"},
+		{"reserved usage", LineItem{LineItemType: "DiscountedUsage", UnblendedCost: "0", EffectiveCost: "0.42"}, "0.42"},
+		{"savings plan usage", LineItem{LineItemType: "SavingsPlanCoveredUsage", UnblendedCost: "2", SavingsPlanCost: "1.2"}, "1.2"},
+		{"savings plan negation", LineItem{LineItemType: "SavingsPlanNegation", UnblendedCost: "-2"}, "0"},
+		{"savings plan fee", LineItem{LineItemType: "SavingsPlanRecurringFee", UnblendedCost: "10", TotalCommitment: "10", UsedCommitment: "7.5"}, "2.5"},
+		{"reservation fee", LineItem{LineItemType: "RIFee", UnblendedCost: "30", UnusedUpfrontFee: "1.25", UnusedRecurringFee: "2"}, "3.25"},
+		{"reservation upfront fee", LineItem{LineItemType: "Fee", UnblendedCost: "1000", ReservationArn: "arn:aws:ec2:us-east-1:123456789012:reserved-instances/abc"}, "0"},
+		{"other fee", LineItem{LineItemType: "Fee", UnblendedCost: "5"}, "5"},
+	}
+	for _, c := range cases {
+		li := computeCostTypes(c.li)
+		if li.AmortizedCost != c.expected {
+			t.Errorf("%s: amortized cost should be %s, is %s instead.", c.name, c.expected, li.AmortizedCost)
+		}
+		if li.NetUnblendedCost != c.li.UnblendedCost {
+			t.Errorf("%s: net unblended cost should default to %s, is %s instead.", c.name, c.li.UnblendedCost, li.NetUnblendedCost)
+		}
+	}
+}
+
+func TestCostTypeField(t *testing.T) {
+	for costType, expected := range map[string]string{
+		"":          "unblendedCost",
+		"unblended": "unblendedCost",
+		"blended":   "blendedCost",
+		"amortized": "amortizedCost",
+		"net":       "netUnblendedCost",
+	} {
+		if field, err := CostTypeField(costType); err != nil || field != expected {
+			t.Errorf("Cost type %q should map to %s, got %s (%v).", costType, expected, field, err)
+		}
+	}
+	if _, err := CostTypeField("list"); err != ErrInvalidCostType {
+		t.Errorf("Unknown cost type should fail with ErrInvalidCostType, got %v.", err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/trackit/trackit-server/aws"
 	"github.com/trackit/trackit-server/es"
+	"github.com/trackit/trackit-server/metrics"
 )
 
 const (
@@ -43,6 +44,13 @@ const (
 	tagPrefix = `resourceTags/user:`
 )
 
+// lineItemsRead counts the LineItems read from bills to be indexed. The
+// number indexed is counted by the bulk processors.
+var lineItemsRead = metrics.NewCounter(
+	"trackit_ingestion_line_items_read_total",
+	"Number of line items read from bills to be indexed.",
+)
+
 // ReportUpdateConclusion represents the results of a bill ingestion job.
 type ReportUpdateConclusion struct {
 	BillRepository       BillRepository
@@ -146,7 +154,8 @@ func UpdateReport(ctx context.Context, aa aws.AwsAccount, br BillRepository) (la
 	}
 }
 
-// getBulkProcessor builds a bulk processor for ElasticSearch.
+// getBulkProcessor builds a bulk processor for ElasticSearch. Its pending
+// requests are aborted when ctx is cancelled.
 func getBulkProcessor(ctx context.Context) (*elastic.BulkProcessor, error) {
 	bps := elastic.NewBulkProcessorService(es.Client)
 	bps = bps.BulkActions(-1)
@@ -154,7 +163,8 @@ func getBulkProcessor(ctx context.Context) (*elastic.BulkProcessor, error) {
 	bps = bps.Workers(esBulkInsertWorkers)
 	bps = bps.Before(beforeBulk(ctx))
 	bps = bps.After(afterBulk(ctx))
-	return bps.Do(context.Background()) // use of background context is not an error
+	bps = bps.Backoff(es.BulkBackoff(ctx))
+	return bps.Do(ctx)
 }
 
 // ingestLineItems returns an OnLineItem handler which ingests LineItems in an
@@ -162,11 +172,14 @@ func getBulkProcessor(ctx context.Context) (*elastic.BulkProcessor, error) {
 func ingestLineItems(ctx context.Context, bp *elastic.BulkProcessor, index string, br BillRepository) OnLineItem {
 	return func(li LineItem, ok bool) {
 		if ok {
+			lineItemsRead.Inc()
 			if li.LineItemType == "Tax" {
 				li.AvailabilityZone = "taxes"
 				li.Region = "taxes"
 			}
 			li.BillRepositoryId = br.Id
+			li = computeCostTypes(li)
+			li = extractInstanceFamily(li)
 			li = extractTags(li)
 			rq := elastic.NewBulkIndexRequest()
 			rq = rq.Index(index)
@@ -216,6 +229,18 @@ func extractTags(li LineItem) LineItem {
 	return li
 }
 
+// extractInstanceFamily sets the instance family of a LineItem from its
+// instance type, such as "m5" for "m5.large" or "db.r5" for "db.r5.xlarge".
+func extractInstanceFamily(li LineItem) LineItem {
+	parts := strings.Split(li.InstanceType, ".")
+	if len(parts) > 2 && (parts[0] == "db" || parts[0] == "cache") {
+		li.InstanceFamily = parts[0] + "." + parts[1]
+	} else if len(parts) > 1 {
+		li.InstanceFamily = parts[0]
+	}
+	return li
+}
+
 func beforeBulk(ctx context.Context) func(int64, []elastic.BulkableRequest) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	return func(execId int64, reqs []elastic.BulkableRequest) {
@@ -229,6 +254,7 @@ func beforeBulk(ctx context.Context) func(int64, []elastic.BulkableRequest) {
 func afterBulk(ctx context.Context) func(int64, []elastic.BulkableRequest, *elastic.BulkResponse, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	return func(execId int64, reqs []elastic.BulkableRequest, resp *elastic.BulkResponse, err error) {
+		es.RecordBulk("lineItems", reqs, resp, err)
 		if err != nil {
 			logger.Error("Failed bulk ElasticSearch requests.", map[string]interface{}{
 				"executionId": execId,
@@ -0,0 +1,36 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import (
+	"testing"
+)
+
+func TestExtractInstanceFamily(t *testing.T) {
+	for instanceType, expected := range map[string]string{
+		"m5.large":               "m5",
+		"t3.micro":               "t3",
+		"db.r5.xlarge":           "db.r5",
+		"cache.m4.large":         "cache.m4",
+		"r5.large.elasticsearch": "r5",
+		"":                       "",
+		"NoInstanceType":         "",
+	} {
+		li := extractInstanceFamily(LineItem{InstanceType: instanceType})
+		if li.InstanceFamily != expected {
+			t.Errorf("Instance family of %q should be %q, is %q instead.", instanceType, expected, li.InstanceFamily)
+		}
+	}
+}
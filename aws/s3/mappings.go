@@ -42,7 +42,7 @@ func init() {
 const TemplateLineItem = `
 {
 	"template": "*-lineitems",
-	"version": 8,
+	"version": 10,
 	"mappings": {
 		"lineitem": {
 			"properties": {
@@ -109,6 +109,68 @@ const TemplateLineItem = `
 					"type": "float",
 					"index": false
 				},
+				"blendedCost": {
+					"type": "float",
+					"index": false
+				},
+				"netUnblendedCost": {
+					"type": "float",
+					"index": false
+				},
+				"effectiveCost": {
+					"type": "float",
+					"index": false
+				},
+				"savingsPlanEffectiveCost": {
+					"type": "float",
+					"index": false
+				},
+				"amortizedCost": {
+					"type": "float",
+					"index": false
+				},
+				"pricingTerm": {
+					"type": "keyword",
+					"norms": false
+				},
+				"instanceType": {
+					"type": "keyword",
+					"norms": false
+				},
+				"instanceFamily": {
+					"type": "keyword",
+					"norms": false
+				},
+				"reservationArn": {
+					"type": "keyword",
+					"norms": false
+				},
+				"reservationEndTime": {
+					"type": "date"
+				},
+				"unusedRecurringFee": {
+					"type": "float",
+					"index": false
+				},
+				"unusedUpfrontFee": {
+					"type": "float",
+					"index": false
+				},
+				"savingsPlanArn": {
+					"type": "keyword",
+					"norms": false
+				},
+				"savingsPlanEndTime": {
+					"type": "date"
+				},
+				"totalCommitment": {
+					"type": "float",
+					"index": false
+				},
+				"usedCommitment": {
+					"type": "float",
+					"index": false
+				},
 				"taxType": {
 					"type": "keyword",
 					"norms": false
@@ -67,12 +67,14 @@ func (t *billTime) UnmarshalJSON(b []byte) error {
 }
 
 type manifest struct {
-	SourceBucket  string   `json:"sourceBucket"`
-	Bucket        string   `json:"bucket"`
-	ReportKeys    []string `json:"reportKeys"`
-	Compression   string   `json:"compression"`
-	ReportName    string   `json:"reportName"`
-	Account       string   `json:"account"`
+	SourceBucket  string           `json:"sourceBucket"`
+	Bucket        string           `json:"bucket"`
+	ReportKeys    []string         `json:"reportKeys"`
+	Compression   string           `json:"compression"`
+	ContentType   string           `json:"contentType"`
+	Columns       []manifestColumn `json:"columns"`
+	ReportName    string           `json:"reportName"`
+	Account       string           `json:"account"`
 	BillingPeriod struct {
 		Start billTime `json:"start"`
 		End   billTime `json:"end"`
@@ -80,6 +82,12 @@ type manifest struct {
 	LastModified time.Time
 }
 
+// manifestColumn describes a column of the reports of a manifest.
+type manifestColumn struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+}
+
 // BillKey is a key where a bill object may be found.
 type BillKey struct {
 	Region       string
@@ -114,6 +122,22 @@ type LineItem struct {
 	ServiceCode        string            `csv:"product/servicecode"          json:"serviceCode"`
 	CurrencyCode       string            `csv:"lineItem/CurrencyCode"        json:"currencyCode"`
 	UnblendedCost      string            `csv:"lineItem/UnblendedCost"       json:"unblendedCost"`
+	BlendedCost        string            `csv:"lineItem/BlendedCost"         json:"blendedCost,omitempty"`
+	NetUnblendedCost   string            `csv:"lineItem/NetUnblendedCost"    json:"netUnblendedCost,omitempty"`
+	EffectiveCost      string            `csv:"reservation/EffectiveCost"    json:"effectiveCost,omitempty"`
+	SavingsPlanCost    string            `csv:"savingsPlan/SavingsPlanEffectiveCost" json:"savingsPlanEffectiveCost,omitempty"`
+	AmortizedCost      string            `csv:"-"                            json:"amortizedCost,omitempty"`
+	PricingTerm        string            `csv:"pricing/term"                 json:"pricingTerm,omitempty"`
+	InstanceType       string            `csv:"product/instanceType"         json:"instanceType,omitempty"`
+	InstanceFamily     string            `csv:"-"                            json:"instanceFamily,omitempty"`
+	ReservationArn     string            `csv:"reservation/ReservationARN"   json:"reservationArn,omitempty"`
+	ReservationEndTime string            `csv:"reservation/EndTime"          json:"reservationEndTime,omitempty"`
+	UnusedRecurringFee string            `csv:"reservation/UnusedRecurringFee" json:"unusedRecurringFee,omitempty"`
+	UnusedUpfrontFee   string            `csv:"reservation/UnusedAmortizedUpfrontFeeForBillingPeriod" json:"unusedUpfrontFee,omitempty"`
+	SavingsPlanArn     string            `csv:"savingsPlan/SavingsPlanARN"   json:"savingsPlanArn,omitempty"`
+	SavingsPlanEndTime string            `csv:"savingsPlan/EndTime"          json:"savingsPlanEndTime,omitempty"`
+	TotalCommitment    string            `csv:"savingsPlan/TotalCommitmentToDate" json:"totalCommitment,omitempty"`
+	UsedCommitment     string            `csv:"savingsPlan/UsedCommitment"   json:"usedCommitment,omitempty"`
 	TaxType            string            `csv:"lineItem/TaxType"             json:"taxType"`
 	Any                map[string]string `csv:",any"                         json:"-"`
 	Tags               []LineItemTags    `csv:"-"                            json:"tags,omitempty"`
@@ -132,7 +156,8 @@ type OnLineItem func(LineItem, bool)
 type ManifestPredicate func(manifest, bool) bool
 
 // ReadBills reads all LineItems from new bills in a BillRepository, and runs
-// `oli` for each one.
+// `oli` for each one. If ctx is cancelled, it stops reading bills and returns
+// the error of ctx, so that the bills are read again by the next update.
 func ReadBills(ctx context.Context, aa taws.AwsAccount, br BillRepository, oli OnLineItem, mp ManifestPredicate) (time.Time, error) {
 	var lastManifest time.Time
 	s3svc, brr, err := getServiceForRepository(ctx, aa, br)
@@ -146,7 +171,11 @@ func ReadBills(ctx context.Context, aa taws.AwsAccount, br BillRepository, oli O
 	mc, lastManifestPromise := selectManifests(mp, mc)
 	es.CleanCurrentMonthBillByBillRepositoryId(ctx, aa.UserId, br.Id)
 	importBills(ctx, s3svc, mc, oli, mp)
-	return <-lastManifestPromise, nil
+	lastManifest = <-lastManifestPromise
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return lastManifest, nil
 }
 
 // selectManifests returns a channel of all AWS manifest files which match
@@ -172,11 +201,16 @@ func selectManifests(mp ManifestPredicate, mc <-chan manifest) (<-chan manifest,
 }
 
 // importBills imports LineItems for bill files described in manifests sent to
-// the `manifests` channel.
+// the `manifests` channel. Once ctx is cancelled, the remaining manifests and
+// LineItems are drained without being imported so that the pipeline's
+// goroutines return.
 func importBills(ctx context.Context, s3svc *s3.S3, manifests <-chan manifest, oli OnLineItem, mp ManifestPredicate) {
 	l := jsonlog.LoggerFromContextOrDefault(ctx)
 	outs, out := mergecdLineItem()
 	for m := range manifests {
+		if ctx.Err() != nil {
+			continue
+		}
 		l.Debug("Will attempt ingesting bills.", m)
 		for _, s := range m.ReportKeys {
 			l.Debug("Will attempt ingesting bill part.", map[string]interface{}{"key": s, "manifest": m})
@@ -185,7 +219,12 @@ func importBills(ctx context.Context, s3svc *s3.S3, manifests <-chan manifest, o
 	}
 	close(outs)
 	for lineItem := range out {
-		oli(lineItem, true)
+		if ctx.Err() == nil {
+			oli(lineItem, true)
+		}
+	}
+	if ctx.Err() != nil {
+		l.Warning("Bill import cancelled.", ctx.Err().Error())
 	}
 	oli(LineItem{}, false)
 }
@@ -197,6 +236,11 @@ func importBill(ctx context.Context, s3svc *s3.S3, s string, m manifest, mp Mani
 		defer close(outs)
 		ctx, cancel := context.WithCancel(ctx)
 		l := jsonlog.LoggerFromContextOrDefault(ctx)
+		if m.isParquet() {
+			l.Debug("Reading Parquet bill.", map[string]interface{}{"key": s, "manifest": m})
+			outs <- readParquetBill(ctx, cancel, s3svc, s, m, mp)
+			return
+		}
 		reader, err := getBillReader(ctx, s3svc, s, m)
 		if err != nil {
 			l.Error("Failed to read bill.", err.Error())
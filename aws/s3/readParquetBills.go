@@ -0,0 +1,274 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/trackit/jsonlog"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/types"
+
+	"github.com/trackit/trackit-server/util/csv"
+)
+
+const (
+	// parquetFormat is the compression and content type of the manifests
+	// of Parquet reports.
+	parquetFormat = "Parquet"
+	// parquetBatchSize is the number of rows read at once from each column
+	// of a Parquet report.
+	parquetBatchSize = 1000
+	// parquetReaderParallelism is the number of goroutines decoding the
+	// pages of a Parquet report.
+	parquetReaderParallelism = 4
+	// parquetTimeFormat is the format of the dates in the CSV reports,
+	// used for the timestamps of the Parquet reports.
+	parquetTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+// parquetColumn is a column of a Parquet report with the name it has in the
+// CSV reports.
+type parquetColumn struct {
+	path          string
+	csvName       string
+	physicalType  parquet.Type
+	convertedType parquet.ConvertedType
+}
+
+// isParquet returns whether the reports of a manifest are Parquet files.
+func (m manifest) isParquet() bool {
+	return strings.EqualFold(m.Compression, parquetFormat) || strings.EqualFold(m.ContentType, parquetFormat)
+}
+
+// csvColumnNames maps the names of the columns of the Parquet reports of a
+// manifest to their names in the CSV reports, such as
+// "line_item_usage_account_id" to "lineItem/UsageAccountId".
+func (m manifest) csvColumnNames() map[string]string {
+	res := make(map[string]string, len(m.Columns))
+	for _, c := range m.Columns {
+		res[parquetColumnName(c.Category)+"_"+parquetColumnName(c.Name)] = c.Category + "/" + c.Name
+	}
+	return res
+}
+
+// parquetColumnName converts the category or the name of a CSV report
+// column the way AWS does for Parquet reports: words are lower cased and
+// separated by underscores, and other characters are replaced by
+// underscores.
+func parquetColumnName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			b.WriteRune('_')
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// readParquetBill returns a channel of all LineItems in a single Parquet bill
+// file. The file is downloaded to a temporary file first as Parquet files
+// can not be read sequentially.
+func readParquetBill(ctx context.Context, cancel context.CancelFunc, s3svc *s3.S3, s string, m manifest, mp ManifestPredicate) <-chan LineItem {
+	out := make(chan LineItem)
+	go func() {
+		defer close(out)
+		l := jsonlog.LoggerFromContextOrDefault(ctx)
+		fileName, err := downloadBillToTempFile(ctx, s3svc, s, m)
+		if err != nil {
+			l.Error("Failed to download Parquet bill.", map[string]interface{}{"key": s, "error": err.Error()})
+			return
+		}
+		defer os.Remove(fileName)
+		for r := range parquetRecords(ctx, fileName, m) {
+			if mp(m, false) || r.InvoiceId == "" {
+				out <- r
+			}
+		}
+	}()
+	return out
+}
+
+// downloadBillToTempFile downloads a bill file to a temporary file and
+// returns its name.
+func downloadBillToTempFile(ctx context.Context, s3svc *s3.S3, s string, m manifest) (string, error) {
+	reader, err := getRawBillReader(ctx, s3svc, s, m)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	file, err := ioutil.TempFile("", "bill-")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, reader); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// parquetRecords returns a channel of all LineItems in a Parquet file.
+func parquetRecords(ctx context.Context, fileName string, m manifest) <-chan LineItem {
+	out := make(chan LineItem)
+	log := jsonlog.LoggerFromContextOrDefault(ctx)
+	go func() {
+		defer close(out)
+		file, err := local.NewLocalFileReader(fileName)
+		if err != nil {
+			log.Error("Failed to open Parquet bill.", err.Error())
+			return
+		}
+		defer file.Close()
+		pr, err := reader.NewParquetColumnReader(file, parquetReaderParallelism)
+		if err != nil {
+			log.Error("Failed to read Parquet footer.", err.Error())
+			return
+		}
+		defer pr.ReadStop()
+		columns := getParquetColumns(pr, m)
+		d := csv.NewDecoder(nil)
+		d.SetHeader(parquetHeader(columns))
+		for read := int64(0); read < pr.GetNumRows(); read += parquetBatchSize {
+			rows, err := readParquetRows(pr, columns, parquetBatchSize)
+			if err != nil {
+				log.Error("Error reading Parquet rows.", err.Error())
+				return
+			}
+			for _, row := range rows {
+				var record LineItem
+				if err := d.DecodeRecord(row, &record); err != nil {
+					log.Error("Error decoding Parquet row.", err.Error())
+					return
+				}
+				select {
+				case out <- record:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// getParquetColumns lists the columns of a Parquet file. Columns which are
+// not described by the manifest keep their Parquet name.
+func getParquetColumns(pr *reader.ParquetReader, m manifest) []parquetColumn {
+	csvNames := m.csvColumnNames()
+	sh := pr.SchemaHandler
+	columns := make([]parquetColumn, 0, len(sh.ValueColumns))
+	for _, path := range sh.ValueColumns {
+		exPath := strings.Split(sh.InPathToExPath[path], "\x01")
+		name := exPath[len(exPath)-1]
+		if csvName, ok := csvNames[name]; ok {
+			name = csvName
+		}
+		element := sh.SchemaElements[sh.MapIndex[path]]
+		column := parquetColumn{path: path, csvName: name, physicalType: element.GetType(), convertedType: -1}
+		if element.IsSetConvertedType() {
+			column.convertedType = element.GetConvertedType()
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// parquetHeader returns the CSV names of Parquet columns.
+func parquetHeader(columns []parquetColumn) []string {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.csvName
+	}
+	return header
+}
+
+// readParquetRows reads up to num rows from each column of a Parquet file
+// and formats their values as they would be in a CSV report.
+func readParquetRows(pr *reader.ParquetReader, columns []parquetColumn, num int64) ([][]string, error) {
+	var rows [][]string
+	for i, c := range columns {
+		values, _, _, err := pr.ReadColumnByPath(c.path, num)
+		if err != nil {
+			return nil, err
+		}
+		if rows == nil {
+			rows = make([][]string, len(values))
+			for j := range rows {
+				rows[j] = make([]string, len(columns))
+			}
+		}
+		for j := 0; j < len(values) && j < len(rows); j++ {
+			rows[j][i] = formatParquetValue(values[j], c)
+		}
+	}
+	return rows, nil
+}
+
+// formatParquetValue formats a value read from a Parquet file as it would be
+// in a CSV report.
+func formatParquetValue(value interface{}, c parquetColumn) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if c.physicalType == parquet.Type_INT96 {
+			return types.INT96ToTime(v).UTC().Format(parquetTimeFormat)
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int64:
+		return formatParquetInt(v, c.convertedType)
+	case int32:
+		return formatParquetInt(int64(v), c.convertedType)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// formatParquetInt formats an integer read from a Parquet file, converting
+// it to a date if it is a timestamp.
+func formatParquetInt(v int64, convertedType parquet.ConvertedType) string {
+	switch convertedType {
+	case parquet.ConvertedType_TIMESTAMP_MILLIS:
+		return time.Unix(0, v*int64(time.Millisecond)).UTC().Format(parquetTimeFormat)
+	case parquet.ConvertedType_TIMESTAMP_MICROS:
+		return time.Unix(0, v*int64(time.Microsecond)).UTC().Format(parquetTimeFormat)
+	default:
+		return strconv.FormatInt(v, 10)
+	}
+}
@@ -0,0 +1,158 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// testParquetLineItem is a row of a Parquet report as AWS writes them.
+type testParquetLineItem struct {
+	LineItemId     string  `parquet:"name=identity_line_item_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TimeInterval   string  `parquet:"name=identity_time_interval, type=BYTE_ARRAY, convertedtype=UTF8"`
+	InvoiceId      *string `parquet:"name=bill_invoice_id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	UsageAccountId string  `parquet:"name=line_item_usage_account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	UsageStartDate int64   `parquet:"name=line_item_usage_start_date, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	ProductCode    string  `parquet:"name=line_item_product_code, type=BYTE_ARRAY, convertedtype=UTF8"`
+	UnblendedCost  float64 `parquet:"name=line_item_unblended_cost, type=DOUBLE"`
+	Region         string  `parquet:"name=product_region, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TeamTag        string  `parquet:"name=resource_tags_user_team, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+var testParquetManifest = manifest{
+	Compression: "Parquet",
+	ContentType: "Parquet",
+	Columns: []manifestColumn{
+		{"identity", "LineItemId"},
+		{"identity", "TimeInterval"},
+		{"bill", "InvoiceId"},
+		{"lineItem", "UsageAccountId"},
+		{"lineItem", "UsageStartDate"},
+		{"lineItem", "ProductCode"},
+		{"lineItem", "UnblendedCost"},
+		{"product", "region"},
+		{"resourceTags", "user:Team"},
+	},
+}
+
+func TestParquetColumnName(t *testing.T) {
+	cases := map[string]string{
+		"identity":       "identity",
+		"lineItem":       "line_item",
+		"UsageAccountId": "usage_account_id",
+		"region":         "region",
+		"resourceTags":   "resource_tags",
+		"user:Team":      "user_team",
+		"Ec2Instance":    "ec2_instance",
+	}
+	for name, expected := range cases {
+		if res := parquetColumnName(name); res != expected {
+			t.Errorf("Expected %s to become %s, got %s.", name, expected, res)
+		}
+	}
+}
+
+func TestIsParquet(t *testing.T) {
+	if !testParquetManifest.isParquet() {
+		t.Errorf("Expected manifest to describe Parquet reports.")
+	}
+	if (manifest{Compression: "GZIP", ContentType: "text/csv"}).isParquet() {
+		t.Errorf("Expected manifest to describe CSV reports.")
+	}
+}
+
+// writeTestParquetBill writes a Parquet report with count rows to a
+// temporary file and returns its name.
+func writeTestParquetBill(t *testing.T, count int) string {
+	file, err := ioutil.TempFile("", "bill-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary file: %s", err)
+	}
+	file.Close()
+	pf, err := local.NewLocalFileWriter(file.Name())
+	if err != nil {
+		t.Fatalf("Failed to open temporary file: %s", err)
+	}
+	pw, err := writer.NewParquetWriter(pf, new(testParquetLineItem), 1)
+	if err != nil {
+		t.Fatalf("Failed to create Parquet writer: %s", err)
+	}
+	start := time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC)
+	invoiceId := "123456789"
+	for i := 0; i < count; i++ {
+		row := testParquetLineItem{
+			LineItemId:     "id",
+			TimeInterval:   "2018-06-01T00:00:00Z/2018-06-01T01:00:00Z",
+			UsageAccountId: "123456789012",
+			UsageStartDate: start.Unix() * 1000,
+			ProductCode:    "AmazonEC2",
+			UnblendedCost:  0.0125,
+			Region:         "eu-west-1",
+			TeamTag:        "backend",
+		}
+		if i%2 == 1 {
+			row.InvoiceId = &invoiceId
+		}
+		if err := pw.Write(row); err != nil {
+			t.Fatalf("Failed to write Parquet row: %s", err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		t.Fatalf("Failed to write Parquet footer: %s", err)
+	}
+	pf.Close()
+	return file.Name()
+}
+
+func TestParquetRecords(t *testing.T) {
+	const count = parquetBatchSize*2 + 10
+	fileName := writeTestParquetBill(t, count)
+	defer os.Remove(fileName)
+	var records []LineItem
+	for li := range parquetRecords(context.Background(), fileName, testParquetManifest) {
+		records = append(records, li)
+	}
+	if len(records) != count {
+		t.Fatalf("Expected %d line items, got %d.", count, len(records))
+	}
+	li := records[0]
+	expected := LineItem{
+		LineItemId:     "id",
+		TimeInterval:   "2018-06-01T00:00:00Z/2018-06-01T01:00:00Z",
+		UsageAccountId: "123456789012",
+		UsageStartDate: "2018-06-01T00:00:00Z",
+		ProductCode:    "AmazonEC2",
+		UnblendedCost:  "0.0125",
+		Region:         "eu-west-1",
+		Any:            map[string]string{"resourceTags/user:Team": "backend"},
+	}
+	if !reflect.DeepEqual(li, expected) {
+		t.Errorf("Expected line item %#v, got %#v.", expected, li)
+	}
+	if tags := extractTags(records[0]).Tags; len(tags) != 1 || tags[0].Key != "Team" || tags[0].Tag != "backend" {
+		t.Errorf("Expected the Team tag, got %v.", tags)
+	}
+	if records[0].InvoiceId != "" || records[1].InvoiceId != "123456789" {
+		t.Errorf("Expected optional invoice IDs to be read, got %q and %q.", records[0].InvoiceId, records[1].InvoiceId)
+	}
+}
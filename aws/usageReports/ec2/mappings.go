@@ -42,7 +42,7 @@ func init() {
 const TemplateLineItem = `
 {
 	"template": "*-ec2-reports",
-	"version": 6,
+	"version": 7,
 	"mappings": {
 		"ec2-report": {
 			"properties": {
@@ -75,6 +75,9 @@ const TemplateLineItem = `
 						"type": {
 							"type": "keyword"
 						},
+						"platform": {
+							"type": "keyword"
+						},
 						"tags": {
 							"type": "nested",
 							"properties": {
@@ -100,6 +100,7 @@ func getInstanceInfoFromES(ctx context.Context, instance utils.CostPerResource,
 			inst.Purchasing = docType.Instance.Purchasing
 			inst.KeyPair = docType.Instance.KeyPair
 			inst.Type = docType.Instance.Type
+			inst.Platform = docType.Instance.Platform
 			inst.Tags = docType.Instance.Tags
 		}
 	}
@@ -135,6 +136,7 @@ func fetchMonthlyInstancesList(ctx context.Context, creds *credentials.Credentia
 					Purchasing: getPurchasingOption(instance),
 					KeyPair:    aws.StringValue(instance.KeyName),
 					Type:       aws.StringValue(instance.InstanceType),
+					Platform:   aws.StringValue(instance.Platform),
 				},
 				Tags:  getInstanceTag(instance.Tags),
 				Costs: costs,
@@ -46,6 +46,7 @@ type (
 		Purchasing string `json:"purchasing"`
 		KeyPair    string `json:"keyPair"`
 		Type       string `json:"type"`
+		Platform   string `json:"platform"`
 	}
 
 	// Instance contains all the information of an EC2 instance
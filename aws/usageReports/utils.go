@@ -75,7 +75,8 @@ func AddDocToBulkProcessor(bp *elastic.BulkProcessor, doc interface{}, docType,
 	return bp
 }
 
-// GetBulkProcessor builds a bulk processor for ElasticSearch.
+// GetBulkProcessor builds a bulk processor for ElasticSearch. Its pending
+// requests are aborted when ctx is cancelled.
 func GetBulkProcessor(ctx context.Context) (*elastic.BulkProcessor, error) {
 	bps := elastic.NewBulkProcessorService(es.Client)
 	bps = bps.BulkActions(-1)
@@ -83,7 +84,8 @@ func GetBulkProcessor(ctx context.Context) (*elastic.BulkProcessor, error) {
 	bps = bps.Workers(esBulkInsertWorkers)
 	bps = bps.Before(beforeBulk(ctx))
 	bps = bps.After(afterBulk(ctx))
-	return bps.Do(context.Background()) // use of background context is not an error
+	bps = bps.Backoff(es.BulkBackoff(ctx))
+	return bps.Do(ctx)
 }
 
 // beforeBulk returns a function that will be called before a bulk to log it
@@ -101,6 +103,7 @@ func beforeBulk(ctx context.Context) func(int64, []elastic.BulkableRequest) {
 func afterBulk(ctx context.Context) func(int64, []elastic.BulkableRequest, *elastic.BulkResponse, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	return func(execId int64, reqs []elastic.BulkableRequest, resp *elastic.BulkResponse, err error) {
+		es.RecordBulk("usageReports", reqs, resp, err)
 		if err != nil {
 			logger.Error("Failed bulk ElasticSearch requests.", map[string]interface{}{
 				"executionId": execId,
@@ -0,0 +1,190 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/models"
+	"github.com/trackit/trackit-server/notifications"
+	"github.com/trackit/trackit-server/users"
+)
+
+var (
+	// actualThresholds are the percentages of a budget's amount which
+	// trigger an alert when reached by the actual spend.
+	actualThresholds = []int{50, 80, 100}
+	// forecastedThresholds are the percentages of a budget's amount which
+	// trigger an alert when reached by the forecasted spend.
+	forecastedThresholds = []int{100}
+)
+
+// CheckBudgets computes the spend of every budget and notifies the AWS
+// account when a threshold is reached for the first time in the month.
+func CheckBudgets(ctx context.Context, tx *sql.Tx) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbBudgets, err := models.Budgets(tx)
+	if err != nil {
+		logger.Error("Failed to retrieve budgets.", err.Error())
+		return err
+	}
+	now := time.Now().UTC()
+	for _, dbBudget := range dbBudgets {
+		b := budgetFromDbBudget(*dbBudget)
+		if err := checkBudget(ctx, tx, b, now); err != nil {
+			logger.Error("Failed to check budget.", map[string]interface{}{
+				"budget": b,
+				"error":  err.Error(),
+			})
+		}
+	}
+	return nil
+}
+
+// checkBudget computes the spend of a single budget and sends the alerts
+// which were not sent yet.
+func checkBudget(ctx context.Context, tx *sql.Tx, b Budget, now time.Time) error {
+	aa, err := aws.GetAwsAccountWithId(b.AwsAccountId, tx)
+	if err != nil {
+		return err
+	}
+	user, err := users.GetUserWithId(tx, aa.UserId)
+	if err != nil {
+		return err
+	}
+	converter, err := currencies.ConverterForUser(tx, user)
+	if err != nil {
+		return err
+	}
+	spend, err := GetBudgetSpend(ctx, b, aa, converter, now)
+	if err != nil {
+		return err
+	} else if spend.Unconvertible {
+		return nil
+	}
+	if err = alertOnThresholds(ctx, tx, b, aa, user, spend, false); err != nil {
+		return err
+	}
+	return alertOnThresholds(ctx, tx, b, aa, user, spend, true)
+}
+
+// reachedThreshold returns the highest threshold reached by the spend, or
+// zero if none was.
+func reachedThreshold(spend Spend, forecasted bool) int {
+	percentage, thresholds := spend.Percentage, actualThresholds
+	if forecasted {
+		percentage, thresholds = spend.ForecastedPercentage, forecastedThresholds
+	}
+	reached := 0
+	for _, threshold := range thresholds {
+		if percentage >= float64(threshold) {
+			reached = threshold
+		}
+	}
+	return reached
+}
+
+// alertOnThresholds sends an alert for the highest threshold reached by the
+// spend if it was not already sent this month, and records it along with
+// every lower threshold so that they will not be sent later on.
+func alertOnThresholds(ctx context.Context, tx *sql.Tx, b Budget, aa aws.AwsAccount, user users.User, spend Spend, forecasted bool) error {
+	reached := reachedThreshold(spend, forecasted)
+	if reached == 0 {
+		return nil
+	}
+	alreadyEmailed, err := models.IsBudgetAlertAlreadyEmailed(tx, b.Id, reached, forecasted, spend.Period)
+	if err != nil || alreadyEmailed {
+		return err
+	}
+	if err = sendBudgetAlert(ctx, tx, b, aa, spend, reached, forecasted); err != nil {
+		return err
+	}
+	thresholds := actualThresholds
+	if forecasted {
+		thresholds = forecastedThresholds
+	}
+	for _, threshold := range thresholds {
+		if threshold > reached {
+			break
+		} else if err = recordBudgetAlert(tx, b, user, spend, threshold, forecasted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordBudgetAlert saves a sent alert in the database if it is not already
+// there.
+func recordBudgetAlert(tx *sql.Tx, b Budget, user users.User, spend Spend, threshold int, forecasted bool) error {
+	if alreadyEmailed, err := models.IsBudgetAlertAlreadyEmailed(tx, b.Id, threshold, forecasted, spend.Period); err != nil || alreadyEmailed {
+		return err
+	}
+	dbAlert := models.EmailedBudgetAlert{
+		BudgetID:   b.Id,
+		Threshold:  threshold,
+		Forecasted: forecasted,
+		Period:     spend.Period,
+		Recipient:  user.Email,
+		Date:       time.Now(),
+	}
+	return dbAlert.Insert(tx)
+}
+
+// budgetAlertData is the data of the budget events sent to the
+// notification endpoints.
+type budgetAlertData struct {
+	BudgetId   int     `json:"budgetId"`
+	Name       string  `json:"name"`
+	Amount     float64 `json:"amount"`
+	Threshold  int     `json:"threshold"`
+	Forecasted bool    `json:"forecasted"`
+	Spend      Spend   `json:"spend"`
+}
+
+// sendBudgetAlert notifies a budget's AWS account about a reached threshold.
+// The alert is sent once tx is committed.
+func sendBudgetAlert(ctx context.Context, tx *sql.Tx, b Budget, aa aws.AwsAccount, spend Spend, threshold int, forecasted bool) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	event := notifications.Event{
+		Type: notifications.EventBudget,
+		Key:  fmt.Sprintf("budget:%d:%s:%d:%t", b.Id, spend.Period.Format("2006-01"), threshold, forecasted),
+		Data: budgetAlertData{b.Id, b.Name, b.Amount, threshold, forecasted, spend},
+	}
+	if forecasted {
+		event.Subject = fmt.Sprintf("Budget \"%s\" is forecasted to exceed %d%%", b.Name, threshold)
+		event.Message = fmt.Sprintf("Hi, the spend of your budget \"%s\" on the AWS account \"%s\" is forecasted "+
+			"to reach %.2f %s by the end of %s, which is %.0f%% of its %.2f %s amount. "+
+			"You can connect to your account to review it: https://re.trackit.io/",
+			b.Name, aa.Pretty, spend.Forecasted, spend.Currency, spend.Period.Format("January 2006"), spend.ForecastedPercentage, b.Amount, spend.Currency)
+	} else {
+		event.Subject = fmt.Sprintf("Budget \"%s\" has reached %d%%", b.Name, threshold)
+		event.Message = fmt.Sprintf("Hi, the spend of your budget \"%s\" on the AWS account \"%s\" has reached "+
+			"%.2f %s for %s, which is %.0f%% of its %.2f %s amount. "+
+			"You can connect to your account to review it: https://re.trackit.io/",
+			b.Name, aa.Pretty, spend.Actual, spend.Currency, spend.Period.Format("January 2006"), spend.Percentage, b.Amount, spend.Currency)
+	}
+	err := notifications.Notify(ctx, tx, aa, event)
+	if err != nil {
+		logger.Error("Failed to queue budget alert.", err.Error())
+	}
+	return err
+}
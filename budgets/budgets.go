@@ -0,0 +1,173 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/models"
+)
+
+const (
+	// ScopeAccount limits a budget to the spend of one AWS account. When
+	// no value is given, the identity of the budget's AWS account is used.
+	ScopeAccount = "account"
+	// ScopeProduct limits a budget to the spend of one product code.
+	ScopeProduct = "product"
+	// ScopeRegion limits a budget to the spend of one region.
+	ScopeRegion = "region"
+	// ScopeTag limits a budget to the spend of resources with a given tag.
+	ScopeTag = "tag"
+)
+
+var (
+	ErrBudgetNotFound   = errors.New("budget not found")
+	ErrInvalidScopeType = errors.New("scope type must be one of account, product, region or tag")
+	ErrMissingScopeKey  = errors.New("tag scope requires a key")
+	ErrMissingScope     = errors.New("scope requires a value")
+	ErrInvalidAmount    = errors.New("amount must be greater than zero")
+)
+
+type (
+	// Scope selects the line items a budget applies to.
+	Scope struct {
+		Type  string `json:"type"`
+		Key   string `json:"key,omitempty"`
+		Value string `json:"value"`
+	}
+
+	// Budget is a monthly spending limit for an AwsAccount.
+	Budget struct {
+		Id           int       `json:"id"`
+		AwsAccountId int       `json:"awsAccountId"`
+		Name         string    `json:"name"`
+		Amount       float64   `json:"amount"`
+		Scope        Scope     `json:"scope"`
+		Created      time.Time `json:"created"`
+	}
+)
+
+// validate checks a Scope is well-formed.
+func (s Scope) validate() error {
+	switch s.Type {
+	case ScopeAccount:
+		return nil
+	case ScopeProduct, ScopeRegion:
+		if s.Value == "" {
+			return ErrMissingScope
+		}
+		return nil
+	case ScopeTag:
+		if s.Key == "" {
+			return ErrMissingScopeKey
+		} else if s.Value == "" {
+			return ErrMissingScope
+		}
+		return nil
+	default:
+		return ErrInvalidScopeType
+	}
+}
+
+// CreateBudget creates a Budget for an AwsAccount.
+func CreateBudget(aa aws.AwsAccount, b Budget, tx *sql.Tx) (Budget, error) {
+	dbBudget := models.Budget{
+		AwsAccountID: aa.Id,
+		Name:         b.Name,
+		Amount:       b.Amount,
+		ScopeType:    b.Scope.Type,
+		ScopeKey:     b.Scope.Key,
+		ScopeValue:   b.Scope.Value,
+		Created:      time.Now(),
+	}
+	var out Budget
+	err := dbBudget.Insert(tx)
+	if err == nil {
+		out = budgetFromDbBudget(dbBudget)
+	}
+	return out, err
+}
+
+// GetBudgetsForAwsAccount retrieves from the database all the Budgets for an
+// AwsAccount.
+func GetBudgetsForAwsAccount(aa aws.AwsAccount, tx *sql.Tx) ([]Budget, error) {
+	dbBudgets, err := models.BudgetsByAwsAccountID(tx, aa.Id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Budget, len(dbBudgets))
+	for i := range out {
+		out[i] = budgetFromDbBudget(*dbBudgets[i])
+	}
+	return out, nil
+}
+
+// getDbBudgetForAwsAccount gets a budget from the database, ensuring it
+// belongs to the provided AwsAccount.
+func getDbBudgetForAwsAccount(aa aws.AwsAccount, budgetId int, tx *sql.Tx) (*models.Budget, error) {
+	dbBudget, err := models.BudgetByID(tx, budgetId)
+	if err == sql.ErrNoRows {
+		return nil, ErrBudgetNotFound
+	} else if err != nil {
+		return nil, err
+	} else if dbBudget.AwsAccountID != aa.Id {
+		return nil, ErrBudgetNotFound
+	}
+	return dbBudget, nil
+}
+
+// UpdateBudget updates a Budget belonging to an AwsAccount.
+func UpdateBudget(aa aws.AwsAccount, b Budget, tx *sql.Tx) (Budget, error) {
+	dbBudget, err := getDbBudgetForAwsAccount(aa, b.Id, tx)
+	if err != nil {
+		return Budget{}, err
+	}
+	dbBudget.Name = b.Name
+	dbBudget.Amount = b.Amount
+	dbBudget.ScopeType = b.Scope.Type
+	dbBudget.ScopeKey = b.Scope.Key
+	dbBudget.ScopeValue = b.Scope.Value
+	if err = dbBudget.Update(tx); err != nil {
+		return Budget{}, err
+	}
+	return budgetFromDbBudget(*dbBudget), nil
+}
+
+// DeleteBudget deletes a Budget belonging to an AwsAccount.
+func DeleteBudget(aa aws.AwsAccount, budgetId int, tx *sql.Tx) error {
+	dbBudget, err := getDbBudgetForAwsAccount(aa, budgetId, tx)
+	if err != nil {
+		return err
+	}
+	return dbBudget.Delete(tx)
+}
+
+func budgetFromDbBudget(dbBudget models.Budget) Budget {
+	return Budget{
+		Id:           dbBudget.ID,
+		AwsAccountId: dbBudget.AwsAccountID,
+		Name:         dbBudget.Name,
+		Amount:       dbBudget.Amount,
+		Scope: Scope{
+			Type:  dbBudget.ScopeType,
+			Key:   dbBudget.ScopeKey,
+			Value: dbBudget.ScopeValue,
+		},
+		Created: dbBudget.Created,
+	}
+}
@@ -0,0 +1,220 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+// budgetRequestBody is the expected request body for the postBudget and
+// patchBudget request handlers.
+type budgetRequestBody struct {
+	Name   string  `json:"name"   req:"nonzero"`
+	Amount float64 `json:"amount" req:"nonzero"`
+	Scope  Scope   `json:"scope"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getBudgets).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.Documentation{
+				Summary:     "get aws account's budgets",
+				Description: "Gets the list of budgets for an AWS account along with their spend for the current month. If the spend cannot be converted to the currency of the budget, it is flagged as unconvertible, has no percentage and does not trigger alerts.",
+			},
+		),
+		http.MethodPost: routes.H(postBudget).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{budgetRequestBody{
+				Name:   "EC2 monthly budget",
+				Amount: 1000,
+				Scope: Scope{
+					Type:  ScopeProduct,
+					Value: "AmazonEC2",
+				},
+			}},
+			routes.Documentation{
+				Summary:     "add a new budget to an aws account",
+				Description: "Adds a monthly budget to an AWS account. A budget can be scoped to an account, a product, a region or a tag.",
+			},
+		),
+		http.MethodPatch: routes.H(patchBudget).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.RequestContentType{"application/json"},
+			routes.QueryArgs{routes.BudgetIdQueryArg},
+			routes.RequestBody{budgetRequestBody{
+				Name:   "Team budget",
+				Amount: 500,
+				Scope: Scope{
+					Type:  ScopeTag,
+					Key:   "Team",
+					Value: "backend",
+				},
+			}},
+			routes.Documentation{
+				Summary:     "edit a budget of an aws account",
+				Description: "Edits a budget of an AWS account.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteBudget).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.QueryArgs{routes.BudgetIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete a budget from an aws account",
+				Description: "Deletes a budget from an AWS account.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.QueryArgs{routes.AwsAccountIdQueryArg},
+		routes.Documentation{
+			Summary:     "interact with aws account's budgets",
+			Description: "A budget is a monthly spending limit, in the display currency of the owner of the AWS account. Alerts are sent to the notification endpoints of the AWS account when 50%, 80% and 100% of it are reached, and when the month's forecasted spend exceeds it.",
+		},
+	).Register("/budgets")
+}
+
+// isBudgetValid checks the request body describes a valid budget.
+func isBudgetValid(body budgetRequestBody) error {
+	if body.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return body.Scope.validate()
+}
+
+// getBudgets is a route handler which lists the budgets of an AwsAccount
+// with their spend for the current month.
+func getBudgets(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	budgets, err := GetBudgetsForAwsAccount(aa, tx)
+	if err != nil {
+		l.Error("Failed to retrieve budgets.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to retrieve budgets")
+	}
+	owner, err := users.GetUserWithId(tx, aa.UserId)
+	if err != nil {
+		l.Error("Failed to retrieve the owner of the AWS account.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to retrieve budgets")
+	}
+	converter, err := currencies.ConverterForUser(tx, owner)
+	if err != nil {
+		l.Error("Failed to get currency rates.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to get currency rates")
+	}
+	now := time.Now().UTC()
+	res := make([]BudgetWithSpend, len(budgets))
+	for i, b := range budgets {
+		spend, err := GetBudgetSpend(r.Context(), b, aa, converter, now)
+		if _, ok := err.(currencies.UnknownCurrencyError); ok {
+			return http.StatusBadRequest, err
+		} else if err != nil {
+			return http.StatusInternalServerError, errors.New("failed to compute budget spend")
+		}
+		res[i] = BudgetWithSpend{b, spend}
+	}
+	return http.StatusOK, res
+}
+
+// postBudget is a route handler which lets the user add a Budget to an
+// AwsAccount.
+func postBudget(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body budgetRequestBody
+	routes.MustRequestBody(a, &body)
+	if body.Scope.Type == "" {
+		body.Scope.Type = ScopeAccount
+	}
+	if err := isBudgetValid(body); err != nil {
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	}
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	b, err := CreateBudget(aa, Budget{Name: body.Name, Amount: body.Amount, Scope: body.Scope}, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to create budget.", map[string]interface{}{
+			"budget": body,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to create budget")
+	}
+	return http.StatusOK, b
+}
+
+// patchBudget is a route handler which lets the user edit a Budget of an
+// AwsAccount.
+func patchBudget(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body budgetRequestBody
+	routes.MustRequestBody(a, &body)
+	if body.Scope.Type == "" {
+		body.Scope.Type = ScopeAccount
+	}
+	if err := isBudgetValid(body); err != nil {
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	}
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	budgetId := a[routes.BudgetIdQueryArg].(int)
+	b, err := UpdateBudget(aa, Budget{Id: budgetId, Name: body.Name, Amount: body.Amount, Scope: body.Scope}, tx)
+	if err == ErrBudgetNotFound {
+		return http.StatusNotFound, err
+	} else if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to update budget.", map[string]interface{}{
+			"budgetId": budgetId,
+			"error":    err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to update budget")
+	}
+	return http.StatusOK, b
+}
+
+// deleteBudget is a route handler which lets the user delete a Budget of an
+// AwsAccount.
+func deleteBudget(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	budgetId := a[routes.BudgetIdQueryArg].(int)
+	err := DeleteBudget(aa, budgetId, tx)
+	if err == ErrBudgetNotFound {
+		return http.StatusNotFound, err
+	} else if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to delete budget.", map[string]interface{}{
+			"budgetId": budgetId,
+			"error":    err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to delete budget")
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,62 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/currencies"
+)
+
+const aggregationMaxSize = 0x7FFFFFFF
+
+// createQueryScopeFilter creates and returns the query restricting the line
+// items to the scope of a budget.
+func createQueryScopeFilter(scope Scope, identity string) []elastic.Query {
+	var filters []elastic.Query
+	switch scope.Type {
+	case ScopeAccount:
+		if scope.Value != "" {
+			identity = scope.Value
+		}
+	case ScopeProduct:
+		filters = append(filters, elastic.NewTermQuery("productCode", scope.Value))
+	case ScopeRegion:
+		filters = append(filters, elastic.NewTermQuery("region", scope.Value))
+	case ScopeTag:
+		filters = append(filters, elastic.NewNestedQuery("tags", elastic.NewBoolQuery().Filter(
+			elastic.NewTermQuery("tags.key", scope.Key),
+			elastic.NewTermQuery("tags.tag", scope.Value),
+		)))
+	}
+	return append(filters, elastic.NewTermQuery("usageAccountId", identity))
+}
+
+// getBudgetSpendElasticSearchParams is used to construct an ElasticSearch
+// *elastic.SearchService used to retrieve the spend in the scope of a budget
+// between durationBegin and durationEnd.
+func getBudgetSpendElasticSearchParams(scope Scope, identity string, durationBegin time.Time,
+	durationEnd time.Time, client *elastic.Client, index string) *elastic.SearchService {
+	query := elastic.NewBoolQuery()
+	query = query.Filter(createQueryScopeFilter(scope, identity)...)
+	query = query.Filter(elastic.NewRangeQuery("usageStartDate").
+		From(durationBegin).To(durationEnd).IncludeUpper(false))
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("currencies", elastic.NewTermsAggregation().Field("currencyCode").Missing(currencies.BaseCurrency).Size(aggregationMaxSize).
+		SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost")))
+	return search
+}
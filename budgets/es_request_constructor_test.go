@@ -0,0 +1,94 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func scopeFilterToJson(t *testing.T, filters []elastic.Query) string {
+	sources := make([]interface{}, len(filters))
+	for i, filter := range filters {
+		src, err := filter.Source()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources[i] = src
+	}
+	jsonRes, err := json.Marshal(sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(jsonRes)
+}
+
+func TestQueryScopeFilterAccountDefaultsToIdentity(t *testing.T) {
+	expectedResult := `[{"term":{"usageAccountId":"123456789012"}}]`
+	res := scopeFilterToJson(t, createQueryScopeFilter(Scope{Type: ScopeAccount}, "123456789012"))
+	if res != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, res)
+	}
+}
+
+func TestQueryScopeFilterAccount(t *testing.T) {
+	expectedResult := `[{"term":{"usageAccountId":"210987654321"}}]`
+	res := scopeFilterToJson(t, createQueryScopeFilter(Scope{Type: ScopeAccount, Value: "210987654321"}, "123456789012"))
+	if res != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, res)
+	}
+}
+
+func TestQueryScopeFilterProduct(t *testing.T) {
+	expectedResult := `[{"term":{"productCode":"AmazonEC2"}},{"term":{"usageAccountId":"123456789012"}}]`
+	res := scopeFilterToJson(t, createQueryScopeFilter(Scope{Type: ScopeProduct, Value: "AmazonEC2"}, "123456789012"))
+	if res != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, res)
+	}
+}
+
+func TestQueryScopeFilterTag(t *testing.T) {
+	expectedResult := `[{"nested":{"path":"tags","query":{"bool":{"filter":[{"term":{"tags.key":"Team"}},{"term":{"tags.tag":"backend"}}]}}}},{"term":{"usageAccountId":"123456789012"}}]`
+	res := scopeFilterToJson(t, createQueryScopeFilter(Scope{Type: ScopeTag, Key: "Team", Value: "backend"}, "123456789012"))
+	if res != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, res)
+	}
+}
+
+func TestForecastMonthlySpend(t *testing.T) {
+	begin, end := monthBounds(time.Date(2018, time.April, 10, 12, 0, 0, 0, time.UTC))
+	if forecast := forecastMonthlySpend(100, begin, end, begin.AddDate(0, 0, 10)); forecast != 300 {
+		t.Fatalf("Expected %v but got %v", 300.0, forecast)
+	}
+	if forecast := forecastMonthlySpend(100, begin, end, begin.Add(time.Hour)); forecast != 3000 {
+		t.Fatalf("Expected %v but got %v", 3000.0, forecast)
+	}
+	if forecast := forecastMonthlySpend(100, begin, end, end); forecast != 100 {
+		t.Fatalf("Expected %v but got %v", 100.0, forecast)
+	}
+}
+
+func TestReachedThreshold(t *testing.T) {
+	spend := Spend{Percentage: 85, ForecastedPercentage: 99}
+	if reached := reachedThreshold(spend, false); reached != 80 {
+		t.Fatalf("Expected %v but got %v", 80, reached)
+	}
+	if reached := reachedThreshold(spend, true); reached != 0 {
+		t.Fatalf("Expected %v but got %v", 0, reached)
+	}
+}
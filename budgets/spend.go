@@ -0,0 +1,125 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"context"
+	"time"
+
+	"github.com/trackit/jsonlog"
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/aws/s3"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/es"
+)
+
+type (
+	// Spend is the spend of a budget over the current month.
+	Spend struct {
+		Period               time.Time `json:"period"`
+		Actual               float64   `json:"actual"`
+		Forecasted           float64   `json:"forecasted"`
+		Percentage           float64   `json:"percentage"`
+		ForecastedPercentage float64   `json:"forecastedPercentage"`
+		// Currency is the currency of the actual and forecasted spend
+		// and of the budget's amount.
+		Currency string `json:"currency"`
+		// Unconvertible is true when the spend could not be converted to
+		// the currency of the budget's amount. Currency is then the
+		// currency of the spend and no percentage is computed.
+		Unconvertible bool `json:"unconvertible"`
+	}
+
+	// BudgetWithSpend is a Budget along with its current spend.
+	BudgetWithSpend struct {
+		Budget
+		Spend Spend `json:"spend"`
+	}
+)
+
+// monthBounds returns the beginning of the month containing date and the
+// beginning of the following one.
+func monthBounds(date time.Time) (time.Time, time.Time) {
+	begin := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return begin, begin.AddDate(0, 1, 0)
+}
+
+// forecastMonthlySpend projects the spend of a whole month from the spend
+// observed between the beginning of the month and now. At least a day is
+// considered elapsed so that the projection stays sane right after the
+// month started.
+func forecastMonthlySpend(actual float64, begin, end, now time.Time) float64 {
+	elapsed := now.Sub(begin)
+	if elapsed < 24*time.Hour {
+		elapsed = 24 * time.Hour
+	}
+	total := end.Sub(begin)
+	if elapsed >= total {
+		return actual
+	}
+	return actual * float64(total) / float64(elapsed)
+}
+
+// GetBudgetSpend computes the actual and forecasted spend of a Budget for the
+// month containing now, converted by converter. The amount of a budget is
+// expressed in the display currency of its owner.
+func GetBudgetSpend(ctx context.Context, b Budget, aa aws.AwsAccount, converter currencies.Converter, now time.Time) (Spend, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	begin, end := monthBounds(now)
+	spend := Spend{Period: begin, Currency: converter.Currency}
+	index := es.IndexNameForUserId(aa.UserId, s3.IndexPrefixLineItem)
+	search := getBudgetSpendElasticSearchParams(b.Scope, aa.AwsIdentity, begin, end, es.Client, index)
+	res, err := search.Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			logger.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
+				"index": index,
+				"error": err.Error(),
+			})
+			return spend, nil
+		}
+		logger.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
+		return spend, err
+	}
+	amounts := currencies.Amounts{}
+	if byCurrency, found := res.Aggregations.Terms("currencies"); found {
+		for _, bucket := range byCurrency.Buckets {
+			if cost, found := bucket.Sum("cost"); found && cost.Value != nil {
+				amounts.Add(bucket.Key.(string), *cost.Value)
+			}
+		}
+	}
+	if converter, err = converter.For(amounts.Currencies()...); err != nil {
+		return spend, err
+	} else if converter.Currency != spend.Currency {
+		logger.Warning("Missing currency rate, budget spend is not converted.", map[string]interface{}{
+			"from": converter.Currency,
+			"to":   spend.Currency,
+		})
+		spend.Currency = converter.Currency
+		spend.Unconvertible = true
+	}
+	if spend.Actual, err = converter.Sum(amounts); err != nil {
+		return spend, err
+	}
+	spend.Forecasted = forecastMonthlySpend(spend.Actual, begin, end, now)
+	if b.Amount > 0 && !spend.Unconvertible {
+		spend.Percentage = spend.Actual * 100 / b.Amount
+		spend.ForecastedPercentage = spend.Forecasted * 100 / b.Amount
+	}
+	return spend, nil
+}
@@ -16,6 +16,7 @@ package config
 
 import (
 	"flag"
+	"time"
 )
 
 const (
@@ -31,8 +32,18 @@ var (
 	SqlAddress string
 	// AuthIssuer is the issuer included in JWT tokens.
 	AuthIssuer string
-	// AuthSecret is the secret used to sign and verify JWT tokens.
+	// AuthSecret is the secret used to sign and verify JWT tokens when no
+	// signing keys are configured.
 	AuthSecret string
+	// AuthKeys is a directory of PEM encoded keys used to sign and verify
+	// JWT tokens, each named after its key ID.
+	AuthKeys string
+	// AuthSigningKey is the ID of the key new JWT tokens are signed with.
+	AuthSigningKey string
+	// AuthAccessTokenTtl is how long access tokens are valid.
+	AuthAccessTokenTtl time.Duration
+	// AuthRefreshTokenTtl is how long a session lasts without being refreshed.
+	AuthRefreshTokenTtl time.Duration
 	// AwsRegion is the AWS region the product operates in.
 	AwsRegion string
 	// BackendId is an identifier for the current instance of the server.
@@ -68,10 +79,20 @@ var (
 	SmtpSender string
 	// UrlEc2Pricing is the URL used by downloadJson to fetch the EC2 pricing.
 	UrlEc2Pricing string
+	// UrlRdsPricing is the URL used by downloadJson to fetch the RDS pricing.
+	UrlRdsPricing string
+	// UrlEsPricing is the URL used by downloadJson to fetch the Elasticsearch
+	// Service pricing.
+	UrlEsPricing string
+	// UrlElastiCachePricing is the URL used by downloadJson to fetch the
+	// ElastiCache pricing.
+	UrlElastiCachePricing string
 	// Task is the task to be run. "server", by default.
 	Task string
 	// Periodics, if true, indicates periodic tasks should be run in goroutines within the process.
 	Periodics bool
+	// NotificationsWorker, if true, indicates notifications should be sent by a worker running within the server process.
+	NotificationsWorker bool
 	// Aws Market place product code
 	MarketPlaceProductCode string
 	// AnomalyDetectionBollingerBandPeriod is the period in day used to generate the upper band.
@@ -94,6 +115,49 @@ var (
 	AnomalyDetectionPrettyLevels string
 	// AnomalyEmailingMinLevel is the minimum level required for the mail to be sent.
 	AnomalyEmailingMinLevel int
+	// AdminEmails is the comma-separated list of the emails of the users allowed to use administration routes.
+	AdminEmails string
+	// CurrencyRatesFile is the path to a JSON file of currency conversion rates loaded at startup.
+	CurrencyRatesFile string
+	// RightsizingMaxPeakCpu is the highest CPU peak percentage expected on an instance type suggested by the EC2 rightsizing.
+	RightsizingMaxPeakCpu float64
+	// RightsizingMaxAverageCpu is the highest CPU average percentage expected on an instance type suggested by the EC2 rightsizing.
+	RightsizingMaxAverageCpu float64
+	// RightsizingMinMemoryRatio is the lowest ratio of the memory of an instance type suggested by the EC2 rightsizing to the memory of the current one.
+	RightsizingMinMemoryRatio float64
+	// WorkerConcurrency is the number of jobs the worker task runs at once.
+	WorkerConcurrency int
+	// JobLease is how long a job is claimed by a worker without renewal before another worker may retry it.
+	JobLease time.Duration
+	// JobMaxAttempts is the number of times a job is attempted before it is set aside as dead.
+	JobMaxAttempts int
+	// LeaderLease is how long the backend running periodic tasks stays leader without renewal.
+	LeaderLease time.Duration
+	// ShutdownTimeout is how long the server waits for requests and tasks to end when asked to stop.
+	ShutdownTimeout time.Duration
+	// MetricsToken, if set, is the bearer token required to read the metrics.
+	MetricsToken string
+	// OidcIssuer is the issuer of the OpenID Connect provider users can log
+	// in with. Single sign-on is disabled if it is empty.
+	OidcIssuer string
+	// OidcClientId is the client ID registered with the OpenID Connect provider.
+	OidcClientId string
+	// OidcClientSecret is the client secret registered with the OpenID Connect provider.
+	OidcClientSecret string
+	// OidcRedirectUrl is the URL the OpenID Connect provider redirects users to after they log in.
+	OidcRedirectUrl string
+	// OidcScopes is the space-separated list of scopes requested from the OpenID Connect provider.
+	OidcScopes string
+	// OidcAllowedDomains is the comma-separated list of the email domains allowed to log in with single sign-on.
+	OidcAllowedDomains string
+	// OidcGroupsClaim is the ID token claim listing the groups of a user.
+	OidcGroupsClaim string
+	// OidcOwnerGroups is the comma-separated list of the groups whose members are provisioned as account owners.
+	OidcOwnerGroups string
+	// OidcViewerGroups is the comma-separated list of the groups whose members are provisioned as viewers of OidcViewerParent.
+	OidcViewerGroups string
+	// OidcViewerParent is the email of the user viewers provisioned with single sign-on are attached to.
+	OidcViewerParent string
 )
 
 func init() {
@@ -101,7 +165,11 @@ func init() {
 	flag.StringVar(&SqlProtocol, "sql-protocol", "mysql", "The protocol used to communicate with the SQL database.")
 	flag.StringVar(&SqlAddress, "sql-address", "trackit:trackitpassword@tcp(127.0.0.1)/trackit?parseTime=true", "The address (username, password, transport, address and database) for the SQL database.")
 	flag.StringVar(&AuthIssuer, "auth-issuer", "trackit", "The 'iss' field for the JWT tokens.")
-	flag.StringVar(&AuthSecret, "auth-secret", "trackitdefaultsecret", "The secret used to sign and verify JWT tokens.")
+	flag.StringVar(&AuthSecret, "auth-secret", "trackitdefaultsecret", "The secret used to sign and verify JWT tokens when no signing keys are configured.")
+	flag.StringVar(&AuthKeys, "auth-keys", "", "A directory of PEM encoded RSA or ECDSA keys named '<key ID>.pem' used to sign and verify JWT tokens. Public keys are only used to verify tokens. JWT tokens are signed with the auth secret if left empty.")
+	flag.StringVar(&AuthSigningKey, "auth-signing-key", "", "The ID of the private key JWT tokens are signed with. Can be left empty if there is a single key.")
+	flag.DurationVar(&AuthAccessTokenTtl, "auth-access-token-ttl", 15*time.Minute, "Duration access tokens are valid.")
+	flag.DurationVar(&AuthRefreshTokenTtl, "auth-refresh-token-ttl", 30*24*time.Hour, "Duration a session lasts without being refreshed.")
 	flag.StringVar(&AwsRegion, "aws-region", "us-east-1", "The AWS region the server operates in.")
 	flag.StringVar(&BackendId, "backend-id", "", "The ID to be sent to clients through the 'X-Backend-ID' field. Generated if left empty.")
 	flag.StringVar(&ReportsBucket, "reports-bucket", "", "The bucket name where the reports are stored. The feature is disabled if left empty.")
@@ -114,6 +182,9 @@ func init() {
 	flag.Var(&EsAddress, "es-address", "The address of the ElasticSearch database.")
 	flag.BoolVar(&PrettyJsonResponses, "pretty-json-responses", false, "JSON HTTP responses should be pretty.")
 	flag.StringVar(&UrlEc2Pricing, "url-ec2-pricing", "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/index.json", "The URL used to download the EC2 pricing.")
+	flag.StringVar(&UrlRdsPricing, "url-rds-pricing", "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonRDS/current/index.json", "The URL used to download the RDS pricing.")
+	flag.StringVar(&UrlEsPricing, "url-es-pricing", "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonES/current/index.json", "The URL used to download the Elasticsearch Service pricing.")
+	flag.StringVar(&UrlElastiCachePricing, "url-elasticache-pricing", "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonElastiCache/current/index.json", "The URL used to download the ElastiCache pricing.")
 	flag.StringVar(&SmtpAddress, "smtp-address", "", "The address of the SMTP server.")
 	flag.StringVar(&SmtpPort, "smtp-port", "", "The port of the SMTP server.")
 	flag.StringVar(&SmtpUser, "smtp-user", "", "The user for the SMTP server.")
@@ -121,6 +192,7 @@ func init() {
 	flag.StringVar(&SmtpSender, "smtp-sender", "", "The mail address used to send mails.")
 	flag.StringVar(&Task, "task", "server", "The task to be run.")
 	flag.BoolVar(&Periodics, "periodics", true, "Periodic jobs should be run by the process.")
+	flag.BoolVar(&NotificationsWorker, "notifications-worker", true, "Notifications should be sent by the server process rather than only by 'worker' tasks.")
 	flag.StringVar(&MarketPlaceProductCode, "market-place-product-code", "productcode", "Aws market place product code.")
 	flag.IntVar(&AnomalyDetectionBollingerBandPeriod, "anomaly-detection-bollinger-band-period", 3, "Period used by the Bollinger Band algorithm.")
 	flag.Float64Var(&AnomalyDetectionBollingerBandStandardDeviationCoefficient, "anomaly-detection-bollinger-band-standard-deviation-coefficient", 3.0, "Coefficient used by the Bollinger Band algorithm to generate the standard deviation.")
@@ -132,6 +204,27 @@ func init() {
 	flag.StringVar(&AnomalyDetectionLevels, "anomaly-detection-levels", "0,120,150,200", "Rules to generate the levels.")
 	flag.StringVar(&AnomalyDetectionPrettyLevels, "anomaly-detection-pretty-levels", "low,medium,high,critical", "Pretty names of the levels.")
 	flag.IntVar(&AnomalyEmailingMinLevel, "anomaly-emailing-min-level", 2, "Minimum level for the mail to be sent.")
+	flag.StringVar(&AdminEmails, "admin-emails", "", "Comma-separated emails of the users allowed to use administration routes.")
+	flag.StringVar(&CurrencyRatesFile, "currency-rates-file", "", "JSON file of currency conversion rates loaded at startup. No rates are loaded if left empty.")
+	flag.Float64Var(&RightsizingMaxPeakCpu, "rightsizing-max-peak-cpu", 80, "Highest CPU peak percentage expected on a suggested instance type.")
+	flag.Float64Var(&RightsizingMaxAverageCpu, "rightsizing-max-average-cpu", 40, "Highest CPU average percentage expected on a suggested instance type.")
+	flag.Float64Var(&RightsizingMinMemoryRatio, "rightsizing-min-memory-ratio", 1, "Lowest ratio of the memory of a suggested instance type to the memory of the current one.")
+	flag.IntVar(&WorkerConcurrency, "worker-concurrency", 4, "Number of jobs run at once by the worker task.")
+	flag.DurationVar(&JobLease, "job-lease", 5*time.Minute, "Duration a job is claimed by a worker without renewal.")
+	flag.IntVar(&JobMaxAttempts, "job-max-attempts", 5, "Number of attempts before a job is set aside as dead.")
+	flag.DurationVar(&LeaderLease, "leader-lease", 30*time.Second, "Duration the backend running periodic tasks stays leader without renewal.")
+	flag.DurationVar(&ShutdownTimeout, "shutdown-timeout", 25*time.Second, "Duration the server waits for requests and tasks to end when asked to stop.")
+	flag.StringVar(&MetricsToken, "metrics-token", "", "Bearer token required to read the metrics. Metrics are public if empty.")
+	flag.StringVar(&OidcIssuer, "oidc-issuer", "", "The issuer URL of the OpenID Connect provider. Single sign-on is disabled if left empty.")
+	flag.StringVar(&OidcClientId, "oidc-client-id", "", "The client ID registered with the OpenID Connect provider.")
+	flag.StringVar(&OidcClientSecret, "oidc-client-secret", "", "The client secret registered with the OpenID Connect provider. Can be left empty for public clients.")
+	flag.StringVar(&OidcRedirectUrl, "oidc-redirect-url", "", "The URL the OpenID Connect provider redirects users to after they log in.")
+	flag.StringVar(&OidcScopes, "oidc-scopes", "openid email profile", "Space-separated scopes requested from the OpenID Connect provider.")
+	flag.StringVar(&OidcAllowedDomains, "oidc-allowed-domains", "", "Comma-separated email domains allowed to log in with single sign-on. All domains are allowed if left empty.")
+	flag.StringVar(&OidcGroupsClaim, "oidc-groups-claim", "groups", "The ID token claim listing the groups of a user.")
+	flag.StringVar(&OidcOwnerGroups, "oidc-owner-groups", "", "Comma-separated groups whose members are provisioned as account owners. All users are owners if neither owner nor viewer groups are set.")
+	flag.StringVar(&OidcViewerGroups, "oidc-viewer-groups", "", "Comma-separated groups whose members are provisioned as viewers of the OIDC viewer parent.")
+	flag.StringVar(&OidcViewerParent, "oidc-viewer-parent", "", "The email of the user viewers provisioned with single sign-on are attached to.")
 	flag.Parse()
 	if len(EsAddress) == 0 {
 		EsAddress = stringArray{"http://127.0.0.1:9200"}
@@ -28,9 +28,11 @@ import (
 	"encoding/json"
 	"github.com/trackit/trackit-server/anomaliesDetection"
 	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/currencies"
 	"github.com/trackit/trackit-server/db"
 	"github.com/trackit/trackit-server/errors"
 	"github.com/trackit/trackit-server/es"
+	"github.com/trackit/trackit-server/models"
 	"github.com/trackit/trackit-server/routes"
 	"github.com/trackit/trackit-server/users"
 	"strconv"
@@ -44,20 +46,28 @@ type (
 		AccountList []string
 		IndexList   []string
 		AnomalyType string
+		Dimension   string
 	}
 
-	// productAnomaly represents one anomaly returned.
+	// productAnomaly represents one anomaly returned. Cost and UpperBand
+	// are in Currency, OriginalCost is in the currency the AWS account is
+	// billed in.
 	productAnomaly struct {
-		Date        time.Time `json:"date"`
-		Cost        float64   `json:"cost"`
-		UpperBand   float64   `json:"upper_band"`
-		Abnormal    bool      `json:"abnormal"`
-		Level       int       `json:"level"`
-		PrettyLevel string    `json:"pretty_level"`
+		Date             time.Time `json:"date"`
+		Cost             float64   `json:"cost"`
+		UpperBand        float64   `json:"upper_band"`
+		Currency         string    `json:"currency"`
+		OriginalCost     float64   `json:"original_cost"`
+		OriginalCurrency string    `json:"original_currency"`
+		Abnormal         bool      `json:"abnormal"`
+		Level            int       `json:"level"`
+		PrettyLevel      string    `json:"pretty_level"`
+		State            string    `json:"state"`
+		Comment          string    `json:"comment,omitempty"`
 	}
 
 	// productAnomalies is used to respond to the request.
-	// Key is a product name.
+	// Key is a value of the requested dimension, such as a product name.
 	productAnomalies map[string][]productAnomaly
 
 	// anomaliesDetectionResponse is used to respond to the request.
@@ -65,12 +75,17 @@ type (
 	anomaliesDetectionResponse map[string]productAnomalies
 
 	// esProductAnomalyTypedResult is used to store the raw ElasticSearch response.
+	// Only the field of the requested dimension is set.
 	esProductAnomalyTypedResult struct {
-		Account  string `json:"account"`
-		Date     string `json:"date"`
-		Product  string `json:"product"`
-		Abnormal bool   `json:"abnormal"`
-		Cost     struct {
+		Account        string `json:"account"`
+		Date           string `json:"date"`
+		Product        string `json:"product"`
+		Region         string `json:"region"`
+		UsageType      string `json:"usageType"`
+		UsageAccountId string `json:"usageAccountId"`
+		Abnormal       bool   `json:"abnormal"`
+		Currency       string `json:"currency"`
+		Cost           struct {
 			Value       float64 `json:"value"`
 			MaxExpected float64 `json:"maxExpected"`
 		} `json:"cost"`
@@ -82,13 +97,19 @@ var anomalyQueryArgs = []routes.QueryArg{
 	routes.AwsAccountsOptionalQueryArg,
 	routes.DateBeginQueryArg,
 	routes.DateEndQueryArg,
+	routes.QueryArg{
+		Name:        "by",
+		Description: "Dimension of the anomalies. Possible values are product, region, usageType, usageAccountId. Defaults to product.",
+		Type:        routes.QueryArgString{},
+		Optional:    true,
+	},
 }
 
 func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getAnomaliesData).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(anomalyQueryArgs),
 			routes.Documentation{
 				Summary:     "get the cost anomalies",
@@ -152,10 +173,54 @@ func getAnomalyLevel(typedDocument esProductAnomalyTypedResult) (int, string) {
 	return len(levels) - 1, prettyLevels[len(levels)-1]
 }
 
-func formatAnomaliesData(raw *elastic.SearchResult, ctx context.Context) (anomaliesDetectionResponse, error) {
+// dimensionKey returns the value of the dimension of an anomaly.
+func dimensionKey(typedDocument esProductAnomalyTypedResult, dimension string) string {
+	switch dimension {
+	case "region":
+		return typedDocument.Region
+	case "usageType":
+		return typedDocument.UsageType
+	case "usageAccountId":
+		return typedDocument.UsageAccountId
+	default:
+		return typedDocument.Product
+	}
+}
+
+// getAnomalyStates retrieves the feedback and snoozes of the anomalies of the
+// AWS accounts of a user along a dimension. Key is an AWS Account Identity.
+func getAnomalyStates(user users.User, dimension string, tx *sql.Tx) (map[string]anomalies.AnomalyStates, error) {
+	accountIds := make(map[string]int)
+	if sharedAccounts, err := models.SharedAccountsWithRoleByUserID(tx, user.Id); err != nil {
+		return nil, err
+	} else {
+		for _, sa := range sharedAccounts {
+			accountIds[sa.AwsIdentity] = sa.AccountID
+		}
+	}
+	if ownedAccounts, err := models.AwsAccountsByUserID(tx, user.Id); err != nil {
+		return nil, err
+	} else {
+		for _, aa := range ownedAccounts {
+			accountIds[aa.AwsIdentity] = aa.ID
+		}
+	}
+	res := make(map[string]anomalies.AnomalyStates, len(accountIds))
+	for identity, id := range accountIds {
+		states, err := anomalies.GetAnomalyStates(id, dimension, tx)
+		if err != nil {
+			return nil, err
+		}
+		res[identity] = states
+	}
+	return res, nil
+}
+
+func formatAnomaliesData(raw *elastic.SearchResult, dimension string, states map[string]anomalies.AnomalyStates, converter currencies.Converter, ctx context.Context) (anomaliesDetectionResponse, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	res := make(anomaliesDetectionResponse)
 	logger.Info("res", res)
+	var warned bool
 	for i := range raw.Hits.Hits {
 		var typedDocument esProductAnomalyTypedResult
 		if err := json.Unmarshal(*raw.Hits.Hits[i].Source, &typedDocument); err != nil {
@@ -165,18 +230,46 @@ func formatAnomaliesData(raw *elastic.SearchResult, ctx context.Context) (anomal
 		if _, ok := res[typedDocument.Account]; !ok {
 			res[typedDocument.Account] = make(productAnomalies)
 		}
-		if _, ok := res[typedDocument.Account][typedDocument.Product]; !ok {
-			res[typedDocument.Account][typedDocument.Product] = make([]productAnomaly, 0)
+		key := dimensionKey(typedDocument, dimension)
+		if _, ok := res[typedDocument.Account][key]; !ok {
+			res[typedDocument.Account][key] = make([]productAnomaly, 0)
 		}
 		level, prettyLevel := getAnomalyLevel(typedDocument)
+		if typedDocument.Currency == "" {
+			typedDocument.Currency = currencies.BaseCurrency
+		}
+		documentConverter, err := converter.For(typedDocument.Currency)
+		if err != nil {
+			return nil, err
+		} else if documentConverter.Currency != converter.Currency && !warned {
+			logger.Warning("Missing currency rate, anomalies are not converted.", map[string]interface{}{
+				"from": documentConverter.Currency,
+				"to":   converter.Currency,
+			})
+			warned = true
+		}
+		cost, err := documentConverter.Convert(typedDocument.Cost.Value, typedDocument.Currency)
+		if err != nil {
+			return nil, err
+		}
+		upperBand, err := documentConverter.Convert(typedDocument.Cost.MaxExpected, typedDocument.Currency)
+		if err != nil {
+			return nil, err
+		}
 		if date, err := time.Parse("2006-01-02T15:04:05.000Z", typedDocument.Date); err == nil {
-			res[typedDocument.Account][typedDocument.Product] = append(res[typedDocument.Account][typedDocument.Product], productAnomaly{
-				Date:        date,
-				Cost:        typedDocument.Cost.Value,
-				UpperBand:   typedDocument.Cost.MaxExpected,
-				Abnormal:    typedDocument.Abnormal,
-				Level:       level,
-				PrettyLevel: prettyLevel,
+			state, comment := states[typedDocument.Account].State(key, date)
+			res[typedDocument.Account][key] = append(res[typedDocument.Account][key], productAnomaly{
+				Date:             date,
+				Cost:             cost,
+				UpperBand:        upperBand,
+				Currency:         documentConverter.Currency,
+				OriginalCost:     typedDocument.Cost.Value,
+				OriginalCurrency: typedDocument.Currency,
+				Abnormal:         typedDocument.Abnormal,
+				Level:            level,
+				PrettyLevel:      prettyLevel,
+				State:            state,
+				Comment:          comment,
 			})
 		}
 	}
@@ -194,6 +287,14 @@ func getAnomaliesData(request *http.Request, a routes.Arguments) (int, interface
 	if a[anomalyQueryArgs[0]] != nil {
 		parsedParams.AccountList = a[anomalyQueryArgs[0]].([]string)
 	}
+	parsedParams.Dimension = "product"
+	if a[anomalyQueryArgs[3]] != nil {
+		parsedParams.Dimension = a[anomalyQueryArgs[3]].(string)
+	}
+	dimension, ok := anomalies.GetDimension(parsedParams.Dimension)
+	if !ok {
+		return http.StatusBadRequest, fmt.Errorf("invalid dimension : %s", parsedParams.Dimension)
+	}
 	tx := a[db.Transaction].(*sql.Tx)
 	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.AccountList, user, tx, anomalies.IndexPrefixAnomaliesDetection)
 	if err != nil {
@@ -201,7 +302,7 @@ func getAnomaliesData(request *http.Request, a routes.Arguments) (int, interface
 	}
 	parsedParams.AccountList = accountsAndIndexes.Accounts
 	parsedParams.IndexList = accountsAndIndexes.Indexes
-	parsedParams.AnomalyType = anomalies.TypeProductAnomaliesDetection
+	parsedParams.AnomalyType = dimension.Type
 	raw, returnCode, err := makeElasticSearchRequest(request.Context(), parsedParams)
 	if err != nil {
 		if returnCode == http.StatusOK {
@@ -210,7 +311,22 @@ func getAnomaliesData(request *http.Request, a routes.Arguments) (int, interface
 			return http.StatusInternalServerError, err
 		}
 	}
-	res, err := formatAnomaliesData(raw, request.Context())
+	states, err := getAnomalyStates(user, parsedParams.Dimension, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(request.Context())
+		l.Error("Failed to retrieve anomaly states.", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.GetErrorMessage(request.Context(), err)
+	}
+	converter, err := currencies.ConverterForUser(tx, user)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(request.Context())
+		l.Error("Failed to get currency rates.", err.Error())
+		return http.StatusInternalServerError, errors.GetErrorMessage(request.Context(), err)
+	}
+	res, err := formatAnomaliesData(raw, parsedParams.Dimension, states, converter, request.Context())
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
@@ -0,0 +1,163 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/anomaliesDetection"
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+type (
+	// detectorDescription describes a registered detector.
+	detectorDescription struct {
+		Name          string                   `json:"name"`
+		Description   string                   `json:"description"`
+		DefaultParams anomalies.DetectorParams `json:"defaultParams"`
+	}
+
+	// detectorSettingsResponse is used to respond to the requests on the
+	// detector settings of an AWS account.
+	detectorSettingsResponse struct {
+		anomalies.DetectorSettings
+		Available []detectorDescription `json:"available"`
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getDetectorSettings).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.Documentation{
+				Summary:     "get the anomaly detector of an aws account",
+				Description: "Responds with the anomaly detector used for an AWS account, its parameters and the list of available detectors.",
+			},
+		),
+		http.MethodPut: routes.H(putDetectorSettings).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{anomalies.DetectorSettings{
+				Detector: "weekday-zscore",
+				Params: anomalies.DetectorParams{
+					"weeks":  4,
+					"zScore": 3,
+				},
+			}},
+			routes.Documentation{
+				Summary:     "set the anomaly detector of an aws account",
+				Description: "Sets the anomaly detector used for an AWS account. Parameters which are not given keep their default value.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteDetectorSettings).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.Documentation{
+				Summary:     "reset the anomaly detector of an aws account",
+				Description: "Resets the anomaly detector of an AWS account to the default one with its default parameters.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.QueryArgs{routes.AwsAccountIdQueryArg},
+		routes.Documentation{
+			Summary:     "interact with the anomaly detector of an aws account",
+			Description: "Each AWS account can choose the algorithm detecting its cost anomalies and tune its parameters.",
+		},
+	).Register("/costs/anomalies/detector")
+}
+
+// availableDetectors lists the registered detectors, sorted by name.
+func availableDetectors() []detectorDescription {
+	res := make([]detectorDescription, 0, len(anomalies.RegisteredDetectors))
+	for _, d := range anomalies.RegisteredDetectors {
+		res = append(res, detectorDescription{d.Name, d.Description, d.DefaultParams})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// getDetectorSettings is a route handler which responds with the anomaly
+// detector settings of an AwsAccount.
+func getDetectorSettings(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	settings, err := anomalies.GetDetectorSettings(aa, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to retrieve anomaly detector settings.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to retrieve anomaly detector settings")
+	}
+	return http.StatusOK, detectorSettingsResponse{settings, availableDetectors()}
+}
+
+// putDetectorSettings is a route handler which lets the user choose the
+// anomaly detector of an AwsAccount.
+func putDetectorSettings(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body anomalies.DetectorSettings
+	routes.MustRequestBody(a, &body)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	detector, err := anomalies.GetDetector(body.Detector)
+	if err == nil {
+		_, err = detector.Params(body.Params)
+	}
+	if err != nil {
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	}
+	settings, err := anomalies.SetDetectorSettings(aa, body, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to save anomaly detector settings.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"settings":     body,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to save anomaly detector settings")
+	}
+	return http.StatusOK, detectorSettingsResponse{settings, availableDetectors()}
+}
+
+// deleteDetectorSettings is a route handler which resets the anomaly
+// detector of an AwsAccount to the default one.
+func deleteDetectorSettings(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	if err := anomalies.ResetDetectorSettings(aa, tx); err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to reset anomaly detector settings.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to reset anomaly detector settings")
+	}
+	return http.StatusOK, nil
+}
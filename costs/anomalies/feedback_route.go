@@ -0,0 +1,196 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/anomaliesDetection"
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+// snoozeIdQueryArg is the id of the snooze to delete.
+var snoozeIdQueryArg = routes.QueryArg{
+	Name:        "snooze-id",
+	Type:        routes.QueryArgInt{},
+	Description: "The ID of the snooze.",
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(postAnomalyFeedback).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{anomalies.AnomalyFeedback{
+				Dimension: "product",
+				Key:       "AmazonEC2",
+				Date:      time.Date(2018, time.June, 12, 0, 0, 0, 0, time.UTC),
+				State:     anomalies.AnomalyStateAcknowledged,
+				Comment:   "Load test of the new release.",
+			}},
+			routes.Documentation{
+				Summary:     "give feedback on an anomaly",
+				Description: "Acknowledges or dismisses an anomaly of an AWS account, with an optional comment. Setting the state back to open removes the feedback.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.QueryArgs{routes.AwsAccountIdQueryArg},
+		routes.Documentation{
+			Summary:     "interact with the feedback on anomalies",
+			Description: "Users can acknowledge or dismiss the cost anomalies of an AWS account.",
+		},
+	).Register("/costs/anomalies/feedback")
+
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getSnoozes).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.QueryArgs{routes.AwsAccountIdQueryArg},
+			routes.Documentation{
+				Summary:     "get the snoozes of an aws account",
+				Description: "Responds with the snoozes of an AWS account which did not end yet.",
+			},
+		),
+		http.MethodPost: routes.H(postSnooze).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.QueryArgs{routes.AwsAccountIdQueryArg},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{anomalies.Snooze{
+				Dimension: "region",
+				Key:       "eu-west-1",
+				Until:     time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC),
+				Comment:   "Migration to eu-west-1 in progress.",
+			}},
+			routes.Documentation{
+				Summary:     "snooze the anomalies of a dimension value",
+				Description: "Hides the anomalies of a value of a dimension until a date, replacing any snooze of the same value.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteSnooze).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.QueryArgs{routes.AwsAccountIdQueryArg, snoozeIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete a snooze",
+				Description: "Deletes a snooze of an AWS account, so that its anomalies are shown again.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary:     "interact with the snoozes of anomalies",
+			Description: "Users can hide the anomalies of a product, region, usage type or linked account for some time.",
+		},
+	).Register("/costs/anomalies/snooze")
+}
+
+// postAnomalyFeedback is a route handler which saves the feedback of the
+// user on an anomaly.
+func postAnomalyFeedback(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body anomalies.AnomalyFeedback
+	routes.MustRequestBody(a, &body)
+	user := a[users.AuthenticatedUser].(users.User)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	if err := body.Validate(); err != nil {
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	}
+	if err := anomalies.SetAnomalyFeedback(aa, user.Id, body, tx); err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to save anomaly feedback.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"feedback":     body,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to save anomaly feedback")
+	}
+	return http.StatusOK, body
+}
+
+// getSnoozes is a route handler which responds with the snoozes of an
+// AwsAccount.
+func getSnoozes(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	snoozes, err := anomalies.GetSnoozes(aa, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to retrieve anomaly snoozes.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to retrieve anomaly snoozes")
+	}
+	return http.StatusOK, snoozes
+}
+
+// postSnooze is a route handler which snoozes the anomalies of a dimension
+// value of an AwsAccount.
+func postSnooze(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body anomalies.Snooze
+	routes.MustRequestBody(a, &body)
+	user := a[users.AuthenticatedUser].(users.User)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	snooze, err := anomalies.SnoozeAnomalies(aa, user.Id, body, tx)
+	switch err {
+	case nil:
+		return http.StatusOK, snooze
+	case anomalies.ErrInvalidDimension, anomalies.ErrMissingKey, anomalies.ErrInvalidSnoozeEnd:
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	default:
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to snooze anomalies.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"snooze":       body,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to snooze anomalies")
+	}
+}
+
+// deleteSnooze is a route handler which deletes a snooze of an AwsAccount.
+func deleteSnooze(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	snoozeId := a[snoozeIdQueryArg].(int)
+	err := anomalies.DeleteSnooze(aa, snoozeId, tx)
+	switch err {
+	case nil:
+		return http.StatusOK, nil
+	case anomalies.ErrSnoozeNotFound:
+		return http.StatusNotFound, err
+	default:
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to delete anomaly snooze.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"snoozeId":     snoozeId,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to delete anomaly snooze")
+	}
+}
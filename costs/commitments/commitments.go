@@ -0,0 +1,446 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package commitments
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/currencies"
+)
+
+const (
+	// CommitmentTypeReservation is the type of Reserved Instances.
+	CommitmentTypeReservation = "reservation"
+	// CommitmentTypeSavingsPlan is the type of Savings Plans.
+	CommitmentTypeSavingsPlan = "savingsPlan"
+)
+
+type (
+	// Utilization is the use of the reservations or savings plans of an
+	// account, for an instance family in a region. Purchased and Used are
+	// instance hours for reservations and commitment amounts for savings
+	// plans. Utilization is a percentage and UnusedCost is in Currency.
+	Utilization struct {
+		Account        string  `json:"account"`
+		InstanceFamily string  `json:"instanceFamily"`
+		Region         string  `json:"region"`
+		Type           string  `json:"type"`
+		Purchased      float64 `json:"purchased"`
+		Used           float64 `json:"used"`
+		Utilization    float64 `json:"utilization"`
+		UnusedCost     float64 `json:"unusedCost"`
+		Currency       string  `json:"currency"`
+	}
+
+	// Commitment is a single reservation or savings plan, with its
+	// expiration date and its utilization over the requested period.
+	Commitment struct {
+		Arn         string    `json:"arn"`
+		Account     string    `json:"account"`
+		Type        string    `json:"type"`
+		EndDate     time.Time `json:"endDate"`
+		Utilization float64   `json:"utilization"`
+		UnusedCost  float64   `json:"unusedCost"`
+		Currency    string    `json:"currency"`
+	}
+
+	// UtilizationReport is the response of the utilization route.
+	UtilizationReport struct {
+		Utilization []Utilization `json:"utilization"`
+		Commitments []Commitment  `json:"commitments"`
+	}
+
+	// Coverage is the share of the instance hours of an account, for an
+	// instance family in a region, covered by reservations and savings
+	// plans. Coverages are percentages.
+	Coverage struct {
+		Account             string  `json:"account"`
+		InstanceFamily      string  `json:"instanceFamily"`
+		Region              string  `json:"region"`
+		TotalHours          float64 `json:"totalHours"`
+		OnDemandHours       float64 `json:"onDemandHours"`
+		ReservedHours       float64 `json:"reservedHours"`
+		SavingsPlanHours    float64 `json:"savingsPlanHours"`
+		ReservationCoverage float64 `json:"reservationCoverage"`
+		SavingsPlanCoverage float64 `json:"savingsPlanCoverage"`
+	}
+)
+
+type (
+	esValue struct {
+		Value *float64 `json:"value"`
+	}
+
+	esKeyBuckets struct {
+		Buckets []struct {
+			Key string `json:"key"`
+		} `json:"buckets"`
+	}
+
+	esCurrencyBuckets struct {
+		Buckets []struct {
+			Key                string  `json:"key"`
+			UsageAmount        esValue `json:"usageAmount"`
+			UnusedRecurringFee esValue `json:"unusedRecurringFee"`
+			UnusedUpfrontFee   esValue `json:"unusedUpfrontFee"`
+			TotalCommitment    esValue `json:"totalCommitment"`
+			UsedCommitment     esValue `json:"usedCommitment"`
+		} `json:"buckets"`
+	}
+
+	esBreakdown struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			Families struct {
+				Buckets []struct {
+					Key     string `json:"key"`
+					Regions struct {
+						Buckets []struct {
+							Key   string `json:"key"`
+							Types struct {
+								Buckets []struct {
+									Key        string            `json:"key"`
+									Currencies esCurrencyBuckets `json:"currencies"`
+								} `json:"buckets"`
+							} `json:"types"`
+						} `json:"buckets"`
+					} `json:"regions"`
+				} `json:"buckets"`
+			} `json:"families"`
+		} `json:"buckets"`
+	}
+
+	esReservations struct {
+		Buckets []struct {
+			Key        string       `json:"key"`
+			Accounts   esKeyBuckets `json:"accounts"`
+			Currencies esKeyBuckets `json:"currencies"`
+			EndTime    esValue      `json:"endTime"`
+			Types      struct {
+				Buckets []struct {
+					Key                string  `json:"key"`
+					UsageAmount        esValue `json:"usageAmount"`
+					UnusedRecurringFee esValue `json:"unusedRecurringFee"`
+					UnusedUpfrontFee   esValue `json:"unusedUpfrontFee"`
+				} `json:"buckets"`
+			} `json:"types"`
+		} `json:"buckets"`
+	}
+
+	esSavingsPlans struct {
+		Buckets []struct {
+			Key             string       `json:"key"`
+			Accounts        esKeyBuckets `json:"accounts"`
+			Currencies      esKeyBuckets `json:"currencies"`
+			EndTime         esValue      `json:"endTime"`
+			TotalCommitment esValue      `json:"totalCommitment"`
+			UsedCommitment  esValue      `json:"usedCommitment"`
+		} `json:"buckets"`
+	}
+
+	// sums holds the usage and costs of line items, with costs converted
+	// to a single currency.
+	sums struct {
+		usage              float64
+		unusedRecurringFee float64
+		unusedUpfrontFee   float64
+		totalCommitment    float64
+		usedCommitment     float64
+	}
+
+	// lineItemTypeSums holds the sums of each line item type.
+	lineItemTypeSums map[string]sums
+)
+
+// value returns the value of an ElasticSearch metric aggregation, or 0 if it
+// has none.
+func (v esValue) value() float64 {
+	if v.Value == nil {
+		return 0
+	}
+	return *v.Value
+}
+
+// first returns the first key of an ElasticSearch terms aggregation.
+func (b esKeyBuckets) first() string {
+	if len(b.Buckets) == 0 {
+		return ""
+	}
+	return b.Buckets[0].Key
+}
+
+// percentage returns the percentage of part in total, or 0 if total is 0.
+func percentage(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+// endDate converts the value of a max aggregation on a date field to a time.
+func endDate(v esValue) time.Time {
+	if v.Value == nil {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(*v.Value)*int64(time.Millisecond)).UTC()
+}
+
+// unmarshalAggregation unmarshals the named aggregation of a search result.
+func unmarshalAggregation(sr *elastic.SearchResult, name string, v interface{}) error {
+	raw, ok := sr.Aggregations[name]
+	if !ok || raw == nil {
+		return fmt.Errorf("missing aggregation %s", name)
+	}
+	return json.Unmarshal(*raw, v)
+}
+
+// sumCurrencies sums the usage and converts the costs of the currency
+// buckets of a line item type.
+func sumCurrencies(buckets esCurrencyBuckets, converter currencies.Converter) (s sums, err error) {
+	for _, bucket := range buckets.Buckets {
+		s.usage += bucket.UsageAmount.value()
+		for _, cost := range []struct {
+			dst *float64
+			src esValue
+		}{
+			{&s.unusedRecurringFee, bucket.UnusedRecurringFee},
+			{&s.unusedUpfrontFee, bucket.UnusedUpfrontFee},
+			{&s.totalCommitment, bucket.TotalCommitment},
+			{&s.usedCommitment, bucket.UsedCommitment},
+		} {
+			converted, err := converter.Convert(cost.src.value(), bucket.Key)
+			if err != nil {
+				return s, err
+			}
+			*cost.dst += converted
+		}
+	}
+	return
+}
+
+// walkBreakdown calls fn for each account, instance family and region of a
+// breakdown aggregation, with the sums of each line item type.
+func walkBreakdown(breakdown esBreakdown, converter currencies.Converter, fn func(account, family, region string, typeSums lineItemTypeSums)) error {
+	for _, account := range breakdown.Buckets {
+		for _, family := range account.Families.Buckets {
+			for _, region := range family.Regions.Buckets {
+				typeSums := make(lineItemTypeSums, len(region.Types.Buckets))
+				for _, lineItemType := range region.Types.Buckets {
+					s, err := sumCurrencies(lineItemType.Currencies, converter)
+					if err != nil {
+						return err
+					}
+					typeSums[lineItemType.Key] = s
+				}
+				fn(account.Key, family.Key, region.Key, typeSums)
+			}
+		}
+	}
+	return nil
+}
+
+// computeUtilization computes the utilization of reservations and savings
+// plans from a breakdown aggregation. Reservations are used by
+// DiscountedUsage line items and paid for by RIFee line items, savings plans
+// report their used and total commitment on SavingsPlanRecurringFee line
+// items.
+func computeUtilization(breakdown esBreakdown, converter currencies.Converter) ([]Utilization, error) {
+	res := []Utilization{}
+	err := walkBreakdown(breakdown, converter, func(account, family, region string, typeSums lineItemTypeSums) {
+		if fee, ok := typeSums[lineItemTypeRIFee]; ok {
+			used := typeSums[lineItemTypeDiscountedUsage].usage
+			res = append(res, Utilization{
+				Account:        account,
+				InstanceFamily: family,
+				Region:         region,
+				Type:           CommitmentTypeReservation,
+				Purchased:      fee.usage,
+				Used:           used,
+				Utilization:    percentage(used, fee.usage),
+				UnusedCost:     fee.unusedRecurringFee + fee.unusedUpfrontFee,
+				Currency:       converter.Currency,
+			})
+		}
+		if fee, ok := typeSums[lineItemTypeSavingsPlanRecurringFee]; ok {
+			res = append(res, Utilization{
+				Account:        account,
+				InstanceFamily: family,
+				Region:         region,
+				Type:           CommitmentTypeSavingsPlan,
+				Purchased:      fee.totalCommitment,
+				Used:           fee.usedCommitment,
+				Utilization:    percentage(fee.usedCommitment, fee.totalCommitment),
+				UnusedCost:     fee.totalCommitment - fee.usedCommitment,
+				Currency:       converter.Currency,
+			})
+		}
+	})
+	return res, err
+}
+
+// computeCoverage computes the share of instance hours covered by
+// reservations and savings plans from a breakdown aggregation.
+func computeCoverage(breakdown esBreakdown, converter currencies.Converter) ([]Coverage, error) {
+	res := []Coverage{}
+	err := walkBreakdown(breakdown, converter, func(account, family, region string, typeSums lineItemTypeSums) {
+		c := Coverage{
+			Account:          account,
+			InstanceFamily:   family,
+			Region:           region,
+			OnDemandHours:    typeSums[lineItemTypeUsage].usage,
+			ReservedHours:    typeSums[lineItemTypeDiscountedUsage].usage,
+			SavingsPlanHours: typeSums[lineItemTypeSavingsPlanCoveredUsage].usage,
+		}
+		c.TotalHours = c.OnDemandHours + c.ReservedHours + c.SavingsPlanHours
+		c.ReservationCoverage = percentage(c.ReservedHours, c.TotalHours)
+		c.SavingsPlanCoverage = percentage(c.SavingsPlanHours, c.TotalHours)
+		res = append(res, c)
+	})
+	return res, err
+}
+
+// computeCommitments lists the reservations and savings plans found in the
+// line items, sorted by expiration date.
+func computeCommitments(reservations esReservations, savingsPlans esSavingsPlans, converter currencies.Converter) ([]Commitment, error) {
+	res := []Commitment{}
+	for _, reservation := range reservations.Buckets {
+		var purchased, used, unused float64
+		for _, lineItemType := range reservation.Types.Buckets {
+			switch lineItemType.Key {
+			case lineItemTypeRIFee:
+				purchased += lineItemType.UsageAmount.value()
+				unused += lineItemType.UnusedRecurringFee.value() + lineItemType.UnusedUpfrontFee.value()
+			case lineItemTypeDiscountedUsage:
+				used += lineItemType.UsageAmount.value()
+			}
+		}
+		unusedCost, err := converter.Convert(unused, reservation.Currencies.first())
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Commitment{
+			Arn:         reservation.Key,
+			Account:     reservation.Accounts.first(),
+			Type:        CommitmentTypeReservation,
+			EndDate:     endDate(reservation.EndTime),
+			Utilization: percentage(used, purchased),
+			UnusedCost:  unusedCost,
+			Currency:    converter.Currency,
+		})
+	}
+	for _, savingsPlan := range savingsPlans.Buckets {
+		total, used := savingsPlan.TotalCommitment.value(), savingsPlan.UsedCommitment.value()
+		unusedCost, err := converter.Convert(total-used, savingsPlan.Currencies.first())
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Commitment{
+			Arn:         savingsPlan.Key,
+			Account:     savingsPlan.Accounts.first(),
+			Type:        CommitmentTypeSavingsPlan,
+			EndDate:     endDate(savingsPlan.EndTime),
+			Utilization: percentage(used, total),
+			UnusedCost:  unusedCost,
+			Currency:    converter.Currency,
+		})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].EndDate.Before(res[j].EndDate)
+	})
+	return res, nil
+}
+
+// parseUtilization builds the utilization report from the result of the
+// utilization query.
+func parseUtilization(sr *elastic.SearchResult, converter currencies.Converter) (UtilizationReport, error) {
+	var breakdown esBreakdown
+	var reservations esReservations
+	var savingsPlans esSavingsPlans
+	var err error
+	if err = unmarshalAggregation(sr, "accounts", &breakdown); err != nil {
+		return UtilizationReport{}, err
+	} else if err = unmarshalAggregation(sr, "reservations", &reservations); err != nil {
+		return UtilizationReport{}, err
+	} else if err = unmarshalAggregation(sr, "savingsPlans", &savingsPlans); err != nil {
+		return UtilizationReport{}, err
+	}
+	amounts := breakdownCurrencies(breakdown)
+	for _, reservation := range reservations.Buckets {
+		amounts.Add(reservation.Currencies.first(), 0)
+	}
+	for _, savingsPlan := range savingsPlans.Buckets {
+		amounts.Add(savingsPlan.Currencies.first(), 0)
+	}
+	if converter, err = converter.For(amounts.Currencies()...); err != nil {
+		return UtilizationReport{}, err
+	}
+	var report UtilizationReport
+	if report.Utilization, err = computeUtilization(breakdown, converter); err != nil {
+		return UtilizationReport{}, err
+	}
+	if report.Commitments, err = computeCommitments(reservations, savingsPlans, converter); err != nil {
+		return UtilizationReport{}, err
+	}
+	return report, nil
+}
+
+// parseCoverage builds the coverage report from the result of the coverage
+// query.
+func parseCoverage(sr *elastic.SearchResult, converter currencies.Converter) ([]Coverage, error) {
+	var breakdown esBreakdown
+	if err := unmarshalAggregation(sr, "accounts", &breakdown); err != nil {
+		return nil, err
+	}
+	converter, err := converter.For(breakdownCurrencies(breakdown).Currencies()...)
+	if err != nil {
+		return nil, err
+	}
+	return computeCoverage(breakdown, converter)
+}
+
+// breakdownCurrencies returns the currencies of the costs of a breakdown
+// aggregation.
+func breakdownCurrencies(breakdown esBreakdown) currencies.Amounts {
+	amounts := currencies.Amounts{}
+	for _, account := range breakdown.Buckets {
+		for _, family := range account.Families.Buckets {
+			for _, region := range family.Regions.Buckets {
+				for _, lineItemType := range region.Types.Buckets {
+					for _, bucket := range lineItemType.Currencies.Buckets {
+						amounts.Add(bucket.Key, 0)
+					}
+				}
+			}
+		}
+	}
+	return amounts
+}
+
+// ExpiringCommitments returns the commitments expiring between now and
+// now + within, sorted by expiration date.
+func ExpiringCommitments(commitments []Commitment, now time.Time, within time.Duration) []Commitment {
+	res := []Commitment{}
+	for _, c := range commitments {
+		if !c.EndDate.Before(now) && c.EndDate.Before(now.Add(within)) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,208 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package commitments
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/jsonlog"
+	"github.com/trackit/trackit-server/aws/s3"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/errors"
+	"github.com/trackit/trackit-server/es"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+// QueryParams are the parameters of the utilization and coverage reports.
+type QueryParams struct {
+	AccountList []string
+	DateBegin   time.Time
+	DateEnd     time.Time
+}
+
+// commitmentsQueryArgs allows to get required queryArgs params
+var commitmentsQueryArgs = []routes.QueryArg{
+	routes.AwsAccountsOptionalQueryArg,
+	routes.DateBeginQueryArg,
+	routes.DateEndQueryArg,
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getUtilization).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs(commitmentsQueryArgs),
+			routes.Documentation{
+				Summary:     "get the reserved instances and savings plans utilization",
+				Description: "Responds with the utilization and unused cost of reserved instances and savings plans per account, instance family and region, and with the list of commitments and their expiration dates",
+			},
+		),
+	}.H().Register("/costs/commitments/utilization")
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getCoverage).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs(commitmentsQueryArgs),
+			routes.Documentation{
+				Summary:     "get the reserved instances and savings plans coverage",
+				Description: "Responds with the share of instance hours covered by reserved instances and savings plans per account, instance family and region",
+			},
+		),
+	}.H().Register("/costs/commitments/coverage")
+}
+
+// makeElasticSearchRequest runs a search built by getSearch. As in the
+// other cost routes, a missing index is not an error for the user: the
+// returned status code is then http.StatusOK with a nil result.
+func makeElasticSearchRequest(ctx context.Context, parsedParams esQueryParams,
+	getSearch func(esQueryParams, *elastic.Client, string) *elastic.SearchService) (*elastic.SearchResult, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(ctx)
+	index := strings.Join(parsedParams.indexList, ",")
+	res, err := getSearch(parsedParams, es.Client, index).Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			l.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
+				"index": index,
+				"error": err.Error(),
+			})
+			return nil, http.StatusOK, errors.GetErrorMessage(ctx, err)
+		} else if cast, ok := err.(*elastic.Error); ok && cast.Details.Type == "search_phase_execution_exception" {
+			l.Error("Error while getting data from ES", map[string]interface{}{
+				"type":  fmt.Sprintf("%T", err),
+				"error": err,
+			})
+		} else {
+			l.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
+		}
+		return nil, http.StatusInternalServerError, errors.GetErrorMessage(ctx, err)
+	}
+	return res, http.StatusOK, nil
+}
+
+// makeQueryParams resolves the accounts and indexes of the user and their
+// display currency.
+func makeQueryParams(ctx context.Context, params QueryParams, user users.User, tx *sql.Tx) (esQueryParams, int, error) {
+	parsedParams := esQueryParams{
+		dateBegin: params.DateBegin,
+		dateEnd:   params.DateEnd,
+	}
+	var err error
+	if parsedParams.converter, err = currencies.ConverterForUser(tx, user); err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(ctx)
+		l.Error("Failed to get currency rates.", err.Error())
+		return parsedParams, http.StatusInternalServerError, fmt.Errorf("failed to get currency rates")
+	}
+	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(params.AccountList, user, tx, s3.IndexPrefixLineItem)
+	if err != nil {
+		return parsedParams, returnCode, err
+	}
+	parsedParams.accountList = accountsAndIndexes.Accounts
+	parsedParams.indexList = accountsAndIndexes.Indexes
+	return parsedParams, http.StatusOK, nil
+}
+
+// GetUtilizationData returns the utilization of the reservations and savings
+// plans of the accounts of a user, and the list of these commitments.
+func GetUtilizationData(ctx context.Context, params QueryParams, user users.User, tx *sql.Tx) (int, UtilizationReport, error) {
+	empty := UtilizationReport{Utilization: []Utilization{}, Commitments: []Commitment{}}
+	parsedParams, returnCode, err := makeQueryParams(ctx, params, user, tx)
+	if err != nil {
+		return returnCode, empty, err
+	}
+	sr, returnCode, err := makeElasticSearchRequest(ctx, parsedParams, getElasticSearchUtilizationParams)
+	if err != nil {
+		if returnCode == http.StatusOK {
+			return returnCode, empty, nil
+		}
+		return returnCode, empty, err
+	}
+	report, err := parseUtilization(sr, parsedParams.converter)
+	if _, ok := err.(currencies.UnknownCurrencyError); ok {
+		return http.StatusBadRequest, empty, err
+	} else if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(ctx)
+		l.Error("Failed to compute commitments utilization.", err.Error())
+		return http.StatusInternalServerError, empty, fmt.Errorf("failed to compute commitments utilization")
+	}
+	return http.StatusOK, report, nil
+}
+
+// GetCoverageData returns the coverage of the instance hours of the accounts
+// of a user by reservations and savings plans.
+func GetCoverageData(ctx context.Context, params QueryParams, user users.User, tx *sql.Tx) (int, []Coverage, error) {
+	parsedParams, returnCode, err := makeQueryParams(ctx, params, user, tx)
+	if err != nil {
+		return returnCode, nil, err
+	}
+	sr, returnCode, err := makeElasticSearchRequest(ctx, parsedParams, getElasticSearchCoverageParams)
+	if err != nil {
+		if returnCode == http.StatusOK {
+			return returnCode, []Coverage{}, nil
+		}
+		return returnCode, nil, err
+	}
+	coverage, err := parseCoverage(sr, parsedParams.converter)
+	if _, ok := err.(currencies.UnknownCurrencyError); ok {
+		return http.StatusBadRequest, nil, err
+	} else if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(ctx)
+		l.Error("Failed to compute commitments coverage.", err.Error())
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to compute commitments coverage")
+	}
+	return http.StatusOK, coverage, nil
+}
+
+// parseQueryParams builds the QueryParams from the arguments of a request.
+func parseQueryParams(a routes.Arguments) QueryParams {
+	params := QueryParams{
+		AccountList: []string{},
+		DateBegin:   a[commitmentsQueryArgs[1]].(time.Time),
+		DateEnd:     a[commitmentsQueryArgs[2]].(time.Time).Add(time.Hour*time.Duration(23) + time.Minute*time.Duration(59) + time.Second*time.Duration(59)),
+	}
+	if a[commitmentsQueryArgs[0]] != nil {
+		params.AccountList = a[commitmentsQueryArgs[0]].([]string)
+	}
+	return params
+}
+
+func getUtilization(request *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	returnCode, report, err := GetUtilizationData(request.Context(), parseQueryParams(a), user, tx)
+	if err != nil {
+		return returnCode, err
+	}
+	return returnCode, report
+}
+
+func getCoverage(request *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	returnCode, coverage, err := GetCoverageData(request.Context(), parseQueryParams(a), user, tx)
+	if err != nil {
+		return returnCode, err
+	}
+	return returnCode, coverage
+}
@@ -0,0 +1,151 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package commitments
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/currencies"
+)
+
+var testConverter = currencies.Converter{
+	Rates:    currencies.Rates{"EUR": 0.5},
+	Currency: "USD",
+}
+
+const testUtilizationAggregations = `{
+	"accounts": {"buckets": [{"key": "123456789012", "families": {"buckets": [
+		{"key": "m5", "regions": {"buckets": [{"key": "us-east-1", "types": {"buckets": [
+			{"key": "RIFee", "currencies": {"buckets": [{"key": "USD",
+				"usageAmount": {"value": 1460}, "unusedRecurringFee": {"value": 20}, "unusedUpfrontFee": {"value": 5},
+				"totalCommitment": {"value": 0}, "usedCommitment": {"value": 0}}]}},
+			{"key": "DiscountedUsage", "currencies": {"buckets": [{"key": "USD",
+				"usageAmount": {"value": 1095}, "unusedRecurringFee": {"value": 0}, "unusedUpfrontFee": {"value": 0},
+				"totalCommitment": {"value": 0}, "usedCommitment": {"value": 0}}]}}
+		]}}]}},
+		{"key": "", "regions": {"buckets": [{"key": "eu-west-1", "types": {"buckets": [
+			{"key": "SavingsPlanRecurringFee", "currencies": {"buckets": [{"key": "EUR",
+				"usageAmount": {"value": 730}, "unusedRecurringFee": {"value": 0}, "unusedUpfrontFee": {"value": 0},
+				"totalCommitment": {"value": 100}, "usedCommitment": {"value": 80}}]}}
+		]}}]}}
+	]}}]},
+	"reservations": {"buckets": [{"key": "arn:ri",
+		"accounts": {"buckets": [{"key": "123456789012"}]},
+		"currencies": {"buckets": [{"key": "USD"}]},
+		"endTime": {"value": 1546300800000},
+		"types": {"buckets": [
+			{"key": "RIFee", "usageAmount": {"value": 730}, "unusedRecurringFee": {"value": 10}, "unusedUpfrontFee": {"value": 0}},
+			{"key": "DiscountedUsage", "usageAmount": {"value": 365}, "unusedRecurringFee": {"value": 0}, "unusedUpfrontFee": {"value": 0}}
+		]}
+	}]},
+	"savingsPlans": {"buckets": [{"key": "arn:sp",
+		"accounts": {"buckets": [{"key": "123456789012"}]},
+		"currencies": {"buckets": [{"key": "EUR"}]},
+		"endTime": {"value": 1514764800000},
+		"totalCommitment": {"value": 100},
+		"usedCommitment": {"value": 80}
+	}]}
+}`
+
+const testCoverageAggregations = `{
+	"accounts": {"buckets": [{"key": "123456789012", "families": {"buckets": [
+		{"key": "m5", "regions": {"buckets": [{"key": "us-east-1", "types": {"buckets": [
+			{"key": "Usage", "currencies": {"buckets": [{"key": "USD", "usageAmount": {"value": 50}}]}},
+			{"key": "DiscountedUsage", "currencies": {"buckets": [{"key": "USD", "usageAmount": {"value": 100}}]}},
+			{"key": "SavingsPlanCoveredUsage", "currencies": {"buckets": [
+				{"key": "USD", "usageAmount": {"value": 30}},
+				{"key": "EUR", "usageAmount": {"value": 20}}
+			]}}
+		]}}]}}
+	]}}]}
+}`
+
+func testSearchResult(t *testing.T, aggregations string) *elastic.SearchResult {
+	var sr elastic.SearchResult
+	if err := json.Unmarshal([]byte(aggregations), &sr.Aggregations); err != nil {
+		t.Fatal(err)
+	}
+	return &sr
+}
+
+func TestParseUtilization(t *testing.T) {
+	report, err := parseUtilization(testSearchResult(t, testUtilizationAggregations), testConverter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Utilization{
+		{"123456789012", "m5", "us-east-1", CommitmentTypeReservation, 1460, 1095, 75, 25, "USD"},
+		{"123456789012", "", "eu-west-1", CommitmentTypeSavingsPlan, 200, 160, 80, 40, "USD"},
+	}
+	if len(report.Utilization) != len(expected) {
+		t.Fatalf("Utilization should have %d entries, has %d instead.", len(expected), len(report.Utilization))
+	}
+	for i := range expected {
+		if report.Utilization[i] != expected[i] {
+			t.Errorf("Utilization should be %#v, is %#v instead.", expected[i], report.Utilization[i])
+		}
+	}
+	if len(report.Commitments) != 2 {
+		t.Fatalf("Commitments should have 2 entries, has %d instead.", len(report.Commitments))
+	}
+	sp, ri := report.Commitments[0], report.Commitments[1]
+	if sp.Arn != "arn:sp" || !sp.EndDate.Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("The savings plan should expire first on 2018-01-01, got %#v.", sp)
+	}
+	if sp.Utilization != 80 || sp.UnusedCost != 40 {
+		t.Errorf("The savings plan should be 80%% used with 40 USD unused, got %#v.", sp)
+	}
+	if ri.Arn != "arn:ri" || ri.Account != "123456789012" || ri.Type != CommitmentTypeReservation {
+		t.Errorf("The reservation should be second, got %#v.", ri)
+	}
+	if ri.Utilization != 50 || ri.UnusedCost != 10 {
+		t.Errorf("The reservation should be 50%% used with 10 USD unused, got %#v.", ri)
+	}
+}
+
+func TestParseCoverage(t *testing.T) {
+	coverage, err := parseCoverage(testSearchResult(t, testCoverageAggregations), testConverter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Coverage{"123456789012", "m5", "us-east-1", 200, 50, 100, 50, 50, 25}
+	if len(coverage) != 1 || coverage[0] != expected {
+		t.Errorf("Coverage should be %#v, is %#v instead.", expected, coverage)
+	}
+}
+
+func TestParseUnknownCurrency(t *testing.T) {
+	converter := currencies.Converter{Currency: "USD"}
+	if _, err := parseUtilization(testSearchResult(t, testUtilizationAggregations), converter); err == nil {
+		t.Error("Parsing costs in a currency without rate should fail.")
+	}
+}
+
+func TestExpiringCommitments(t *testing.T) {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	commitments := []Commitment{
+		{Arn: "expired", EndDate: now.AddDate(0, 0, -1)},
+		{Arn: "soon", EndDate: now.AddDate(0, 0, 10)},
+		{Arn: "later", EndDate: now.AddDate(0, 3, 0)},
+	}
+	res := ExpiringCommitments(commitments, now, 60*24*time.Hour)
+	if len(res) != 1 || res[0].Arn != "soon" {
+		t.Errorf("Only the commitment expiring in 10 days should be returned, got %#v.", res)
+	}
+}
@@ -0,0 +1,127 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package commitments
+
+import (
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/currencies"
+)
+
+// aggregationMaxSize is the maximum size of an Elastic Search Aggregation
+const aggregationMaxSize = 0x7FFFFFFF
+
+const (
+	lineItemTypeUsage                   = "Usage"
+	lineItemTypeDiscountedUsage         = "DiscountedUsage"
+	lineItemTypeRIFee                   = "RIFee"
+	lineItemTypeSavingsPlanCoveredUsage = "SavingsPlanCoveredUsage"
+	lineItemTypeSavingsPlanRecurringFee = "SavingsPlanRecurringFee"
+)
+
+// createQueryAccountFilter creates and return a new *elastic.TermsQuery on the accountList array
+func createQueryAccountFilter(accountList []string) *elastic.TermsQuery {
+	accountListFormatted := make([]interface{}, len(accountList))
+	for i, v := range accountList {
+		accountListFormatted[i] = v
+	}
+	return elastic.NewTermsQuery("usageAccountId", accountListFormatted...)
+}
+
+// createQueryLineItemTypeFilter creates and return a new *elastic.TermsQuery
+// on line item types
+func createQueryLineItemTypeFilter(lineItemTypes ...interface{}) *elastic.TermsQuery {
+	return elastic.NewTermsQuery("lineItemType", lineItemTypes...)
+}
+
+// createBaseQuery creates the query filtering line items on accounts, date
+// range and line item types
+func createBaseQuery(params esQueryParams, lineItemTypes ...interface{}) *elastic.BoolQuery {
+	query := elastic.NewBoolQuery()
+	if len(params.accountList) > 0 {
+		query = query.Filter(createQueryAccountFilter(params.accountList))
+	}
+	query = query.Filter(elastic.NewRangeQuery("usageStartDate").From(params.dateBegin).To(params.dateEnd))
+	return query.Filter(createQueryLineItemTypeFilter(lineItemTypes...))
+}
+
+// createCurrencyAggregation creates a terms aggregation on the currency of
+// line items, with sums of the given fields
+func createCurrencyAggregation(size int, sums ...string) *elastic.TermsAggregation {
+	agg := elastic.NewTermsAggregation().Field("currencyCode").Missing(currencies.BaseCurrency).Size(size)
+	for _, sum := range sums {
+		agg = agg.SubAggregation(sum, elastic.NewSumAggregation().Field(sum))
+	}
+	return agg
+}
+
+// createBreakdownAggregation breaks line items down per account, instance
+// family, region, line item type and currency, with sums at the deepest level
+func createBreakdownAggregation(sums ...string) elastic.Aggregation {
+	types := elastic.NewTermsAggregation().Field("lineItemType").Size(aggregationMaxSize).
+		SubAggregation("currencies", createCurrencyAggregation(aggregationMaxSize, sums...))
+	return elastic.NewTermsAggregation().Field("usageAccountId").Size(aggregationMaxSize).
+		SubAggregation("families", elastic.NewTermsAggregation().Field("instanceFamily").Missing("").Size(aggregationMaxSize).
+			SubAggregation("regions", elastic.NewTermsAggregation().Field("region").Missing("").Size(aggregationMaxSize).
+				SubAggregation("types", types)))
+}
+
+// getElasticSearchUtilizationParams is used to construct an ElasticSearch
+// *elastic.SearchService retrieving the usage and fees of reservations and
+// savings plans, broken down per account, instance family and region, and
+// per commitment.
+func getElasticSearchUtilizationParams(params esQueryParams, client *elastic.Client, index string) *elastic.SearchService {
+	query := createBaseQuery(params, lineItemTypeDiscountedUsage, lineItemTypeRIFee, lineItemTypeSavingsPlanRecurringFee)
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("accounts", createBreakdownAggregation("usageAmount", "unusedRecurringFee", "unusedUpfrontFee", "totalCommitment", "usedCommitment"))
+	search.Aggregation("reservations", elastic.NewTermsAggregation().Field("reservationArn").Size(aggregationMaxSize).
+		SubAggregation("accounts", elastic.NewTermsAggregation().Field("usageAccountId").Size(1)).
+		SubAggregation("endTime", elastic.NewMaxAggregation().Field("reservationEndTime")).
+		SubAggregation("currencies", createCurrencyAggregation(1)).
+		SubAggregation("types", elastic.NewTermsAggregation().Field("lineItemType").Size(aggregationMaxSize).
+			SubAggregation("usageAmount", elastic.NewSumAggregation().Field("usageAmount")).
+			SubAggregation("unusedRecurringFee", elastic.NewSumAggregation().Field("unusedRecurringFee")).
+			SubAggregation("unusedUpfrontFee", elastic.NewSumAggregation().Field("unusedUpfrontFee"))))
+	search.Aggregation("savingsPlans", elastic.NewTermsAggregation().Field("savingsPlanArn").Size(aggregationMaxSize).
+		SubAggregation("accounts", elastic.NewTermsAggregation().Field("usageAccountId").Size(1)).
+		SubAggregation("endTime", elastic.NewMaxAggregation().Field("savingsPlanEndTime")).
+		SubAggregation("currencies", createCurrencyAggregation(1)).
+		SubAggregation("totalCommitment", elastic.NewSumAggregation().Field("totalCommitment")).
+		SubAggregation("usedCommitment", elastic.NewSumAggregation().Field("usedCommitment")))
+	return search
+}
+
+// getElasticSearchCoverageParams is used to construct an ElasticSearch
+// *elastic.SearchService retrieving the instance hours used on demand,
+// covered by reservations and covered by savings plans, broken down per
+// account, instance family and region.
+func getElasticSearchCoverageParams(params esQueryParams, client *elastic.Client, index string) *elastic.SearchService {
+	query := createBaseQuery(params, lineItemTypeUsage, lineItemTypeDiscountedUsage, lineItemTypeSavingsPlanCoveredUsage)
+	query = query.Filter(elastic.NewExistsQuery("instanceType"))
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("accounts", createBreakdownAggregation("usageAmount"))
+	return search
+}
+
+// esQueryParams will store the parsed query params
+type esQueryParams struct {
+	dateBegin   time.Time
+	dateEnd     time.Time
+	accountList []string
+	indexList   []string
+	converter   currencies.Converter
+}
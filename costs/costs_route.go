@@ -26,6 +26,7 @@ import (
 
 	"github.com/trackit/jsonlog"
 	"github.com/trackit/trackit-server/aws/s3"
+	"github.com/trackit/trackit-server/currencies"
 	"github.com/trackit/trackit-server/db"
 	"github.com/trackit/trackit-server/errors"
 	"github.com/trackit/trackit-server/es"
@@ -54,6 +55,7 @@ type esQueryParams struct {
 	accountList       []string
 	indexList         []string
 	aggregationParams []string
+	costField         string
 }
 
 // costQueryArgs allows to get required queryArgs params
@@ -63,17 +65,18 @@ var costsQueryArgs = []routes.QueryArg{
 	routes.DateEndQueryArg,
 	routes.QueryArg{
 		Name:        "by",
-		Description: "Criteria for the ES aggregation, comma separated. Possible values are year, month, week, day, account, product, region, tag(soon)",
+		Description: "Criteria for the ES aggregation, comma separated. Possible values are year, month, week, day, account, product, region, availabilityzone, tag:<TAG_KEY>. Costs without the tag are under the '(untagged)' key, which collides with a tag value of '(untagged)'.",
 		Type:        routes.QueryArgStringSlice{},
 		Optional:    false,
 	},
+	routes.CostTypeQueryArg,
 }
 
 func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getCostData).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(costsQueryArgs),
 			routes.Documentation{
 				Summary:     "get the costs data",
@@ -86,14 +89,15 @@ func init() {
 // validateCriteraParam will validate the different criterions.
 // It validate the criterion by checking its presence in the simpleCriterionMap
 // or, in the case of the special criterion tag, will check if it is in the
-// correct format : 'tag:*' (with no more than one ':')
-// Right now the tags are not enabled and will generate an error if they are
-// used because they are not yet implemented in the new ElasticSearch mapping
+// correct format : 'tag:*' (with no more than one ':'). The characters '[', ']'
+// and '>' are not allowed in the tag key as they cannot be used in an
+// ElasticSearch aggregation name.
 func validateCriteriaParam(parsedParams esQueryParams) error {
 	for _, criterion := range parsedParams.aggregationParams {
 		if !simpleCriterionMap[criterion] {
-			if len(criterion) >= 5 && criterion[:4] == "tag:" && strings.Count(criterion, ":") == 1 {
-				return fmt.Errorf("tags not yet implemented")
+			if len(criterion) >= 5 && criterion[:4] == "tag:" && strings.Count(criterion, ":") == 1 &&
+				!strings.ContainsAny(criterion, "[]>") {
+				continue
 			}
 			return fmt.Errorf("Error parsing criterion : %s", criterion)
 		}
@@ -115,6 +119,7 @@ func makeElasticSearchRequestAndParseIt(ctx context.Context, parsedParams esQuer
 		parsedParams.dateBegin,
 		parsedParams.dateEnd,
 		parsedParams.aggregationParams,
+		parsedParams.costField,
 		es.Client,
 		index,
 	)
@@ -159,6 +164,13 @@ func getCostData(request *http.Request, a routes.Arguments) (int, interface{}) {
 	if err := validateCriteriaParam(parsedParams); err != nil {
 		return http.StatusBadRequest, err
 	}
+	costType, _ := a[costsQueryArgs[4]].(string)
+	costField, err := s3.CostTypeField(costType)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	parsedParams.costField = costField
+	parsedParams.aggregationParams = append(parsedParams.aggregationParams, currencyParam)
 	tx := a[db.Transaction].(*sql.Tx)
 	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
 	if err != nil {
@@ -166,6 +178,12 @@ func getCostData(request *http.Request, a routes.Arguments) (int, interface{}) {
 	}
 	parsedParams.accountList = accountsAndIndexes.Accounts
 	parsedParams.indexList = accountsAndIndexes.Indexes
+	converter, err := currencies.ConverterForUser(tx, user)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(request.Context())
+		l.Error("Failed to get currency rates.", err.Error())
+		return http.StatusInternalServerError, fmt.Errorf("failed to get currency rates")
+	}
 	simplifiedCostDocument, returnCode, err := makeElasticSearchRequestAndParseIt(request.Context(), parsedParams)
 	if err != nil {
 		if returnCode == http.StatusOK {
@@ -174,5 +192,23 @@ func getCostData(request *http.Request, a routes.Arguments) (int, interface{}) {
 			return returnCode, err
 		}
 	}
-	return http.StatusOK, simplifiedCostDocument.ToJsonable()
+	originalCosts := currencies.Amounts{}
+	addOriginalCosts(simplifiedCostDocument, originalCosts)
+	if fallback, err := converter.For(originalCosts.Currencies()...); err != nil {
+		return http.StatusBadRequest, err
+	} else if fallback.Currency != converter.Currency {
+		l := jsonlog.LoggerFromContextOrDefault(request.Context())
+		l.Warning("Missing currency rate, costs are not converted.", map[string]interface{}{
+			"from": fallback.Currency,
+			"to":   converter.Currency,
+		})
+		converter = fallback
+	}
+	if simplifiedCostDocument, err = convertCostsDocument(simplifiedCostDocument, converter); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	res := simplifiedCostDocument.ToJsonable()
+	res["currency"] = converter.Currency
+	res["originalCosts"] = originalCosts
+	return http.StatusOK, res
 }
@@ -0,0 +1,77 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package costs
+
+import (
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/es"
+)
+
+// currencyParam is the parameter splitting the costs by currency so that
+// they can be converted before being added up. It is always the last
+// parameter before the cost.
+const currencyParam = "currency"
+
+// createAggregationPerCurrency creates and returns a new []paramAggrAndName of size 1 which creates a
+// bucket aggregation on the field 'currencyCode'
+func createAggregationPerCurrency(_ []string) []paramAggrAndName {
+	return []paramAggrAndName{
+		paramAggrAndName{
+			name: "by-" + currencyParam,
+			aggr: elastic.NewTermsAggregation().
+				Field("currencyCode").Missing(currencies.BaseCurrency).Size(aggregationMaxSize),
+		},
+	}
+}
+
+// addOriginalCosts adds the costs by currency of a SimplifiedCostsDocument
+// to originals.
+func addOriginalCosts(scd es.SimplifiedCostsDocument, originals currencies.Amounts) {
+	if scd.ChildrenKind == currencyParam {
+		for _, child := range scd.Children {
+			originals.Add(child.Key, child.Value)
+		}
+		return
+	}
+	for _, child := range scd.Children {
+		addOriginalCosts(child, originals)
+	}
+}
+
+// convertCostsDocument replaces the costs by currency of a
+// SimplifiedCostsDocument with their sum converted by c.
+func convertCostsDocument(scd es.SimplifiedCostsDocument, c currencies.Converter) (es.SimplifiedCostsDocument, error) {
+	if scd.ChildrenKind == currencyParam {
+		amounts := currencies.Amounts{}
+		for _, child := range scd.Children {
+			amounts.Add(child.Key, child.Value)
+		}
+		value, err := c.Sum(amounts)
+		return es.SimplifiedCostsDocument{
+			Key:      scd.Key,
+			HasValue: true,
+			Value:    value,
+		}, err
+	}
+	for i := range scd.Children {
+		var err error
+		if scd.Children[i], err = convertCostsDocument(scd.Children[i], c); err != nil {
+			return scd, err
+		}
+	}
+	return scd, nil
+}
@@ -0,0 +1,59 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package costs
+
+import (
+	"testing"
+
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/es"
+)
+
+func TestConvertCostsDocument(t *testing.T) {
+	scd := es.SimplifiedCostsDocument{
+		ChildrenKind: "product",
+		Children: []es.SimplifiedCostsDocument{
+			{
+				Key:          "AmazonEC2",
+				ChildrenKind: currencyParam,
+				Children: []es.SimplifiedCostsDocument{
+					{Key: "USD", HasValue: true, Value: 10},
+					{Key: "EUR", HasValue: true, Value: 8},
+				},
+			},
+			{
+				Key:          "AmazonS3",
+				ChildrenKind: currencyParam,
+			},
+		},
+	}
+	originals := currencies.Amounts{}
+	addOriginalCosts(scd, originals)
+	c := currencies.Converter{currencies.Rates{"EUR": 0.8}, "EUR"}
+	res, err := convertCostsDocument(scd, c)
+	if err != nil {
+		t.Fatalf("Conversion should succeed, failed with %s.", err.Error())
+	}
+	ec2 := res.Children[0]
+	if !ec2.HasValue || ec2.Value != 16 {
+		t.Errorf("EC2 cost should be 16, is %v.", ec2)
+	}
+	if s3 := res.Children[1]; !s3.HasValue || s3.Value != 0 {
+		t.Errorf("S3 cost should be 0, is %v.", s3)
+	}
+	if originals["USD"] != 10 || originals["EUR"] != 8 {
+		t.Errorf("Original costs should be 10 USD and 8 EUR, are %v.", originals)
+	}
+}
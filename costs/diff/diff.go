@@ -24,14 +24,19 @@ import (
 	"gopkg.in/olivere/elastic.v5"
 
 	"github.com/trackit/jsonlog"
+	"github.com/trackit/trackit-server/currencies"
 	"github.com/trackit/trackit-server/errors"
 )
 
-// PricePoint struct stores elements for cost differentiator
+// PricePoint struct stores elements for cost differentiator.
+// Cost is in Currency, OriginalCosts are the costs in the currencies they
+// were billed in.
 type PricePoint struct {
 	Date             string
 	Cost             float64
 	PercentVariation float64
+	Currency         string
+	OriginalCosts    currencies.Amounts
 }
 
 type costDiff map[string][]PricePoint
@@ -80,30 +85,55 @@ func getVariations(pricePoints []PricePoint) []PricePoint {
 	return pricePoints
 }
 
-func parseDiffPricePoints(bucketData usageType) []PricePoint {
+// parseDiffCosts returns the costs by currency of a date bucket.
+func parseDiffCosts(bucketAgg usageType) currencies.Amounts {
+	amounts := currencies.Amounts{}
+	currencyAgg := bucketAgg["currency"].(usageType)
+	for _, currencyBucket := range currencyAgg["buckets"].([]interface{}) {
+		currencyBucket := currencyBucket.(usageType)
+		amounts.Add(
+			currencyBucket["key"].(string),
+			currencyBucket["cost"].(map[string]interface{})["value"].(float64),
+		)
+	}
+	return amounts
+}
+
+func parseDiffPricePoints(bucketData usageType, converter currencies.Converter) ([]PricePoint, error) {
 	pricePoints := []PricePoint{}
 	dateAgg := bucketData["dateAgg"].(usageType)
 	for _, bucketAgg := range dateAgg["buckets"].([]interface{}) {
+		amounts := parseDiffCosts(bucketAgg.(usageType))
+		cost, err := converter.Sum(amounts)
+		if err != nil {
+			return nil, err
+		}
 		pricePoints = append(pricePoints, PricePoint{
-			Date: bucketAgg.(usageType)["key_as_string"].(string),
-			Cost: bucketAgg.(usageType)["cost"].(map[string]interface{})["value"].(float64),
+			Date:          bucketAgg.(usageType)["key_as_string"].(string),
+			Cost:          cost,
+			Currency:      converter.Currency,
+			OriginalCosts: amounts,
 		})
 	}
-	return getVariations(pricePoints)
+	return getVariations(pricePoints), nil
 }
 
-func parseDiffUsageTypes(parsedDocument usageType) costDiff {
+func parseDiffUsageTypes(parsedDocument usageType, converter currencies.Converter) (costDiff, error) {
 	absolute := costDiff{}
 	bucketsField := parsedDocument["buckets"].([]interface{})
 	for _, bucketData := range bucketsField {
 		bucketData := bucketData.(usageType)
 		usageTypeName := bucketData["key"].(string)
-		absolute[usageTypeName] = parseDiffPricePoints(bucketData)
+		pricePoints, err := parseDiffPricePoints(bucketData, converter)
+		if err != nil {
+			return nil, err
+		}
+		absolute[usageTypeName] = pricePoints
 	}
-	return absolute
+	return absolute, nil
 }
 
-func prepareDiffData(ctx context.Context, sr *elastic.SearchResult) (costDiff, error) {
+func prepareDiffData(ctx context.Context, sr *elastic.SearchResult, converter currencies.Converter) (costDiff, error) {
 	var logger = jsonlog.LoggerFromContextOrDefault(ctx)
 	var parsedDocument usageType
 	err := json.Unmarshal(*sr.Aggregations["usageType"], &parsedDocument)
@@ -111,5 +141,33 @@ func prepareDiffData(ctx context.Context, sr *elastic.SearchResult) (costDiff, e
 		logger.Error("Failed to parse elasticsearch document.", err.Error())
 		return costDiff{}, errors.GetErrorMessage(ctx, err)
 	}
-	return parseDiffUsageTypes(parsedDocument), nil
+	fallback, err := converter.For(diffCurrencies(parsedDocument)...)
+	if err != nil {
+		return costDiff{}, err
+	} else if fallback.Currency != converter.Currency {
+		logger.Warning("Missing currency rate, costs are not converted.", map[string]interface{}{
+			"from": fallback.Currency,
+			"to":   converter.Currency,
+		})
+	}
+	res, err := parseDiffUsageTypes(parsedDocument, fallback)
+	if err != nil {
+		logger.Error("Failed to convert costs.", err.Error())
+		return costDiff{}, err
+	}
+	return res, nil
+}
+
+// diffCurrencies returns the currencies of all the costs of the usage types.
+func diffCurrencies(parsedDocument usageType) []string {
+	amounts := currencies.Amounts{}
+	for _, bucketData := range parsedDocument["buckets"].([]interface{}) {
+		dateAgg := bucketData.(usageType)["dateAgg"].(usageType)
+		for _, bucketAgg := range dateAgg["buckets"].([]interface{}) {
+			for currency := range parseDiffCosts(bucketAgg.(usageType)) {
+				amounts.Add(currency, 0)
+			}
+		}
+	}
+	return amounts.Currencies()
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/trackit/trackit-server/aws"
 	"github.com/trackit/trackit-server/aws/s3"
 	"github.com/trackit/trackit-server/aws/usageReports/history"
+	"github.com/trackit/trackit-server/currencies"
 	"github.com/trackit/trackit-server/db"
 	"github.com/trackit/trackit-server/errors"
 	"github.com/trackit/trackit-server/es"
@@ -51,6 +52,8 @@ type esQueryParams struct {
 	accountList       []string
 	indexList         []string
 	aggregationPeriod string
+	costField         string
+	converter         currencies.Converter
 }
 
 // diffQueryArgs allows to get required queryArgs params
@@ -64,13 +67,14 @@ var diffQueryArgs = []routes.QueryArg{
 		Type:        routes.QueryArgString{},
 		Optional:    false,
 	},
+	routes.CostTypeQueryArg,
 }
 
 func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(prepareGetDiffData).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(diffQueryArgs),
 			routes.Documentation{
 				Summary:     "get the cost diff",
@@ -94,6 +98,7 @@ func makeElasticSearchRequest(ctx context.Context, parsedParams esQueryParams) (
 		parsedParams.dateBegin,
 		parsedParams.dateEnd,
 		parsedParams.aggregationPeriod,
+		parsedParams.costField,
 		es.Client,
 		index,
 	)
@@ -128,8 +133,10 @@ func getDiffData(ctx context.Context, parsedParams esQueryParams) (int, interfac
 			return returnCode, err
 		}
 	}
-	res, err := prepareDiffData(ctx, sr)
-	if err != nil {
+	res, err := prepareDiffData(ctx, sr, parsedParams.converter)
+	if _, ok := err.(currencies.UnknownCurrencyError); ok {
+		return http.StatusBadRequest, err
+	} else if err != nil {
 		return http.StatusInternalServerError, err
 	}
 	return http.StatusOK, res
@@ -152,6 +159,7 @@ func TaskDiffData(ctx context.Context, aa aws.AwsAccount) (data costDiff, err er
 		dateBegin:         dateBegin,
 		dateEnd:           dateEnd,
 		aggregationPeriod: "day",
+		costField:         "unblendedCost",
 	}
 	var tx *sql.Tx
 	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
@@ -161,6 +169,9 @@ func TaskDiffData(ctx context.Context, aa aws.AwsAccount) (data costDiff, err er
 	if err != nil {
 		return
 	}
+	if parsedParams.converter, err = currencies.ConverterForUser(tx, user); err != nil {
+		return costDiff{}, err
+	}
 	accountsAndIndexes, _, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
 	if err != nil {
 		return costDiff{}, err
@@ -185,7 +196,18 @@ func prepareGetDiffData(request *http.Request, a routes.Arguments) (int, interfa
 	if _, ok := validAggregationPeriodMap[parsedParams.aggregationPeriod]; ok == false {
 		return http.StatusBadRequest, fmt.Errorf("invalid aggregation period : %s", parsedParams.aggregationPeriod)
 	}
+	costType, _ := a[diffQueryArgs[4]].(string)
+	costField, err := s3.CostTypeField(costType)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	parsedParams.costField = costField
 	tx := a[db.Transaction].(*sql.Tx)
+	if parsedParams.converter, err = currencies.ConverterForUser(tx, user); err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(request.Context())
+		l.Error("Failed to get currency rates.", err.Error())
+		return http.StatusInternalServerError, fmt.Errorf("failed to get currency rates")
+	}
 	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
 	if err != nil {
 		return returnCode, err
@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/currencies"
 )
 
 // aggregationMaxSize is the maximum size of an Elastic Search Aggregation
@@ -40,12 +42,13 @@ func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elast
 }
 
 // GetElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService
-// used to retrieve the cost by usageType for each week/month in the time range
+// used to retrieve the cost by usageType and currency for each week/month in the time range
 // It takes as paramters :
 // 	- accountList []string : A slice of string representing aws account number, in the format of the field
 //	'awsdetailedlineitem.linked_account_id'
 //	- durationBeing time.Time : A time.Time struct representing the begining of the time range in the query
 //	- durationEnd time.Time : A time.Time struct representing the end of the time range in the query
+//	- costField string : The name of the field holding the cost to sum, as returned by s3.CostTypeField
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
 //	- index string : The Elastic Search index on wich to execute the query. In this context the default value
 //	should be "awsdetailedlineitems"
@@ -54,7 +57,7 @@ func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elast
 //	- If the client is nil or malconfigured, it will crash
 //	- If the index is not an index present in the ES, it will crash
 func GetElasticSearchParams(accountList []string, durationBegin time.Time,
-	durationEnd time.Time, aggregationPeriod string, client *elastic.Client, index string) *elastic.SearchService {
+	durationEnd time.Time, aggregationPeriod string, costField string, client *elastic.Client, index string) *elastic.SearchService {
 	query := elastic.NewBoolQuery()
 	if len(accountList) > 0 {
 		query = query.Filter(createQueryAccountFilter(accountList))
@@ -64,6 +67,7 @@ func GetElasticSearchParams(accountList []string, durationBegin time.Time,
 
 	search.Aggregation("usageType", elastic.NewTermsAggregation().Field("usageType").Size(aggregationMaxSize).
 		SubAggregation("dateAgg", elastic.NewDateHistogramAggregation().Field("usageStartDate").MinDocCount(0).ExtendedBounds(durationBegin, durationEnd).Interval(aggregationPeriod).
-			SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost"))))
+			SubAggregation("currency", elastic.NewTermsAggregation().Field("currencyCode").Missing(currencies.BaseCurrency).Size(aggregationMaxSize).
+				SubAggregation("cost", elastic.NewSumAggregation().Field(costField)))))
 	return search
 }
@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/es"
 )
 
 // aggregationBuilder is an alias for the function type that is used in the
@@ -40,6 +42,7 @@ var paramNameToFuncPtr = map[string]aggregationBuilder{
 	"account":          createAggregationPerAccount,
 	"tag":              createAggregationPerTag,
 	"cost":             createCostSumAggregation,
+	"currency":         createAggregationPerCurrency,
 	"day":              createAggregationPerDay,
 	"week":             createAggregationPerWeek,
 	"month":            createAggregationPerMonth,
@@ -170,33 +173,82 @@ func createAggregationPerYear(_ []string) []paramAggrAndName {
 	}
 }
 
-// createAggregationPerTag creates and returns a new []paramAggrAndName of size 2 which consits
-// of two aggregations that are required for the tag param.
-// The first aggregation is a FilterAggregation on the field 'tag.key', and with a value of
-// the tag key passed in the parameter 'paramSplit' in the form "user:<TAG_KEY_VALUE>".
-// The second aggregation is a TermsAggregation that creates bucket aggregation on the field
-// 'tag.value'.
-// No SubAggregation is created in this function, as it needs to be created in the nestAggregation function
+// tagAggregation is an elastic.Aggregation creating a bucket per value of a
+// tag key. As tags are stored as nested documents, it is made of two
+// branches under a single FilterAggregation matching all documents:
+//	- a NestedAggregation on the path 'tags', filtered on the field 'tags.key'
+//	with a TermsAggregation on the field 'tags.tag', which goes back to the
+//	line items with a ReverseNestedAggregation
+//	- a FilterAggregation on the line items which do not have the tag key
+// SubAggregations are added to both the ReverseNestedAggregation and the
+// untagged FilterAggregation. es.SimplifyCostsDocument merges both branches
+// back into a single list of buckets.
+type tagAggregation struct {
+	key             string
+	subAggregations map[string]elastic.Aggregation
+}
+
+// newTagAggregation creates a new tagAggregation on the tag key 'key'.
+func newTagAggregation(key string) *tagAggregation {
+	return &tagAggregation{
+		key:             key,
+		subAggregations: make(map[string]elastic.Aggregation),
+	}
+}
+
+// SubAggregation adds a sub-aggregation to both branches of the
+// tagAggregation.
+func (a *tagAggregation) SubAggregation(name string, subAggregation elastic.Aggregation) *tagAggregation {
+	a.subAggregations[name] = subAggregation
+	return a
+}
+
+// Source returns the JSON-serializable data of the aggregation.
+func (a *tagAggregation) Source() (interface{}, error) {
+	reverse := elastic.NewReverseNestedAggregation()
+	untagged := elastic.NewFilterAggregation().
+		Filter(elastic.NewBoolQuery().MustNot(elastic.NewNestedQuery("tags", elastic.NewTermQuery("tags.key", a.key))))
+	for name, subAggregation := range a.subAggregations {
+		reverse = reverse.SubAggregation(name, subAggregation)
+		untagged = untagged.SubAggregation(name, subAggregation)
+	}
+	tagged := elastic.NewNestedAggregation().Path("tags").
+		SubAggregation(es.TagAggregationKey, elastic.NewFilterAggregation().
+			Filter(elastic.NewTermQuery("tags.key", a.key)).
+			SubAggregation(es.TagAggregationValues, elastic.NewTermsAggregation().
+				Field("tags.tag").Size(aggregationMaxSize).
+				SubAggregation(es.TagAggregationReverse, reverse)))
+	return elastic.NewFilterAggregation().
+		Filter(elastic.NewMatchAllQuery()).
+		SubAggregation(es.TagAggregationTagged, tagged).
+		SubAggregation(es.TagAggregationUntagged, untagged).
+		Source()
+}
+
+// createAggregationPerTag creates and returns a new []paramAggrAndName of size 1 which creates a
+// tagAggregation on the tag key passed in the parameter 'paramSplit' in the form "tag:<TAG_KEY>".
+// Its name is "by-tag:<TAG_KEY>", so that the results are keyed by the criterion in the response.
 func createAggregationPerTag(paramSplit []string) []paramAggrAndName {
 	return []paramAggrAndName{
 		paramAggrAndName{
-			name: "by-tag_key",
-			aggr: elastic.NewFilterAggregation().
-				Filter(elastic.NewTermQuery("tag.key", fmt.Sprintf("user:%v", paramSplit[1])))},
-		paramAggrAndName{
-			name: "tag_value",
-			aggr: elastic.NewTermsAggregation().
-				Field("tag.value").Size(aggregationMaxSize)},
+			name: fmt.Sprintf("by-tag:%s", paramSplit[1]),
+			aggr: newTagAggregation(paramSplit[1]),
+		},
 	}
 }
 
 // createCostSumAggregation : Creates and return a new []paramAggrAndName of size 1, which creates a
-// SumAggregation on the field 'cost'
-func createCostSumAggregation(_ []string) []paramAggrAndName {
+// SumAggregation on the cost field passed in the parameter 'paramSplit' in the form "cost:<FIELD>",
+// or on the field 'unblendedCost' if none is given
+func createCostSumAggregation(paramSplit []string) []paramAggrAndName {
+	field := "unblendedCost"
+	if len(paramSplit) > 1 {
+		field = paramSplit[1]
+	}
 	return []paramAggrAndName{
 		paramAggrAndName{
 			name: "value",
-			aggr: elastic.NewSumAggregation().Field("unblendedCost"),
+			aggr: elastic.NewSumAggregation().Field(field),
 		},
 	}
 }
@@ -213,8 +265,8 @@ func reverseAggregationArray(aggregationArray []paramAggrAndName) []paramAggrAnd
 // nestAggregation takes a slice of paramAggrAndName type, and will nest the different aggregations.
 // Aggregations are nested by creating a chain of SubAggregation
 // A type switch is required to simulate downcasting from the interface elastic.Aggregation.
-// Current types on the type switch are TermsAggregation, FilterAggregation, SumAggregation and
-// DateHistogramAggregation.
+// Current types on the type switch are TermsAggregation, FilterAggregation, DateHistogramAggregation
+// and tagAggregation.
 // If a new function creating a type that is not listed here is added to the paramNameToFuncPtr map
 // it should be added to the type switch, or the function will create bugged SubAggregations
 func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
@@ -231,6 +283,9 @@ func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
 		case *elastic.DateHistogramAggregation:
 			aggrBuff := assertedBaseAggr.SubAggregation(aggrToNest.name, aggrToNest.aggr)
 			aggrToNest = paramAggrAndName{name: baseAggr.name, aggr: aggrBuff}
+		case *tagAggregation:
+			aggrBuff := assertedBaseAggr.SubAggregation(aggrToNest.name, aggrToNest.aggr)
+			aggrToNest = paramAggrAndName{name: baseAggr.name, aggr: aggrBuff}
 		}
 	}
 	return aggrToNest.aggr
@@ -249,11 +304,11 @@ func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
 //		- "availabilityzone" : It will create a TermsAggregation on the field 'availability_zone'
 //		- "region" : It will create a TermsAggregation on the field 'region'
 //		- "account" : It will create a TermsAggregation on the field 'linked_account_id'
-//		- "tag:<TAG_KEY>" : It will create a FilterAggregation on the field 'tag.key',
-//		filtering on the value 'user:<TAG_KEY>'.
-//		It will then create a TermsAggregation on the field 'tag.value'
+//		- "tag:<TAG_KEY>" : It will create a tagAggregation, with a bucket for each value of the
+//		tag <TAG_KEY> and a bucket for the line items which are not tagged with it
 //		- "[day|week|month|year]": It will create a DateHistogramAggregation on the specified duration on
 //		the field 'usage_start_date'
+//	- costField string : The name of the field holding the cost to sum, as returned by s3.CostTypeField
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
 //	It needs to be fully configured and ready to execute a client.Search()
 //	- index string : The Elastic Search index on wich to execute the query. In this context the default value
@@ -266,14 +321,14 @@ func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
 //	- If the client is nil or malconfigured, it will crash
 //	- If the index is not an index present in the ES, it will crash
 func GetElasticSearchParams(accountList []string, durationBegin time.Time,
-	durationEnd time.Time, params []string, client *elastic.Client, index string) *elastic.SearchService {
+	durationEnd time.Time, params []string, costField string, client *elastic.Client, index string) *elastic.SearchService {
 	query := elastic.NewBoolQuery()
 	if len(accountList) > 0 {
 		query = query.Filter(createQueryAccountFilter(accountList))
 	}
 	query = query.Filter(createQueryTimeRange(durationBegin, durationEnd))
 	search := client.Search().Index(index).Size(0).Query(query)
-	params = append(params, "cost")
+	params = append(params, "cost:"+costField)
 	var allAggregationSlice []paramAggrAndName
 	for _, paramName := range params {
 		paramNameSplit := strings.Split(paramName, ":")
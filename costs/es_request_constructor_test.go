@@ -170,8 +170,24 @@ func TestAggregationPerMonth(t *testing.T) {
 }
 
 func TestCostSumAggregation(t *testing.T) {
-	res := createCostSumAggregation([]string{""})
-	expectedResult := `{"sum":{"field":"cost"}}`
+	res := createCostSumAggregation([]string{"cost"})
+	expectedResult := `{"sum":{"field":"unblendedCost"}}`
+	src, err := res[0].aggr.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonRes, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jsonRes) != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, string(jsonRes))
+	}
+}
+
+func TestCostSumAggregationWithField(t *testing.T) {
+	res := createCostSumAggregation([]string{"cost", "amortizedCost"})
+	expectedResult := `{"sum":{"field":"amortizedCost"}}`
 	src, err := res[0].aggr.Source()
 	if err != nil {
 		t.Fatal(err)
@@ -219,29 +235,23 @@ func TestAggregationPerYear(t *testing.T) {
 
 func TestAggregationPerTag(t *testing.T) {
 	res := createAggregationPerTag([]string{"tag", "test"})
-	expectedFirstResult := `{"filter":{"term":{"tag.key":"user:test"}}}`
-	expectedSecondResult := `{"terms":{"field":"tag.value","size":2147483647}}`
-	srcFirst, err := res[0].aggr.Source()
-	if err != nil {
-		t.Fatal(err)
+	expectedName := "by-tag:test"
+	expectedResult := `{"aggregations":{"tagged":{"aggregations":{"tag-key":{"aggregations":{"tag-values":{"aggregations":{"tag-reverse":{"reverse_nested":{}}},"terms":{"field":"tags.tag","size":2147483647}}},"filter":{"term":{"tags.key":"test"}}}},"nested":{"path":"tags"}},"untagged":{"filter":{"bool":{"must_not":{"nested":{"path":"tags","query":{"term":{"tags.key":"test"}}}}}}}},"filter":{"match_all":{}}}`
+	if len(res) != 1 {
+		t.Fatalf("Expected a single aggregation but got %d", len(res))
+	} else if res[0].name != expectedName {
+		t.Fatalf("Expected %v but got %v", expectedName, res[0].name)
 	}
-	srcSecond, err := res[1].aggr.Source()
-	if err != nil {
-		t.Fatal(err)
-	}
-	jsonResFirst, err := json.Marshal(srcFirst)
+	src, err := res[0].aggr.Source()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if string(jsonResFirst) != expectedFirstResult {
-		t.Fatalf("Expected %v but got %v", expectedFirstResult, string(jsonResFirst))
-	}
-	jsonResSecond, err := json.Marshal(srcSecond)
+	jsonRes, err := json.Marshal(src)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if string(jsonResSecond) != expectedSecondResult {
-		t.Fatalf("Expected %v but got %v", expectedSecondResult, string(jsonResSecond))
+	if string(jsonRes) != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, string(jsonRes))
 	}
 }
 
@@ -293,19 +303,69 @@ func TestAggregationNestingWithSingleElementSlice(t *testing.T) {
 
 func TestAggregationNestingWithCoupleElementsSlice(t *testing.T) {
 	coupleAggregationSlice := createAggregationPerTag([]string{"", "test"})
+	coupleAggregationSlice = append(coupleAggregationSlice, createCostSumAggregation([]string{""})...)
 	expectedResult := `{
 	"aggregations": {
-		"tag_value": {
-			"terms": {
-				"field": "tag.value",
-				"size": 2147483647
+		"tagged": {
+			"aggregations": {
+				"tag-key": {
+					"aggregations": {
+						"tag-values": {
+							"aggregations": {
+								"tag-reverse": {
+									"aggregations": {
+										"value": {
+											"sum": {
+												"field": "unblendedCost"
+											}
+										}
+									},
+									"reverse_nested": {}
+								}
+							},
+							"terms": {
+								"field": "tags.tag",
+								"size": 2147483647
+							}
+						}
+					},
+					"filter": {
+						"term": {
+							"tags.key": "test"
+						}
+					}
+				}
+			},
+			"nested": {
+				"path": "tags"
+			}
+		},
+		"untagged": {
+			"aggregations": {
+				"value": {
+					"sum": {
+						"field": "unblendedCost"
+					}
+				}
+			},
+			"filter": {
+				"bool": {
+					"must_not": {
+						"nested": {
+							"path": "tags",
+							"query": {
+								"term": {
+									"tags.key": "test"
+								}
+							}
+						}
+					}
+				}
 			}
 		}
 	},
 	"filter": {
-		"term": {
-			"tag.key": "user:test"
-		}
+		"match_all": {}
 	}
 }`
 	res := nestAggregation(coupleAggregationSlice)
@@ -324,28 +384,86 @@ func TestAggregationNestingWithCoupleElementsSlice(t *testing.T) {
 
 func TestAggregationNestingWithFewElementsSlice(t *testing.T) {
 	fewAggregationSlice := createAggregationPerTag([]string{"", "test"})
-	buffAggregation := createCostSumAggregation([]string{""})
-	fewAggregationSlice = append(fewAggregationSlice, buffAggregation...)
+	fewAggregationSlice = append(fewAggregationSlice, createAggregationPerProduct([]string{""})...)
+	fewAggregationSlice = append(fewAggregationSlice, createCostSumAggregation([]string{""})...)
 	expectedResult := `{
 	"aggregations": {
-		"tag_value": {
+		"tagged": {
 			"aggregations": {
-				"cost": {
-					"sum": {
-						"field": "cost"
+				"tag-key": {
+					"aggregations": {
+						"tag-values": {
+							"aggregations": {
+								"tag-reverse": {
+									"aggregations": {
+										"by-product": {
+											"aggregations": {
+												"value": {
+													"sum": {
+														"field": "unblendedCost"
+													}
+												}
+											},
+											"terms": {
+												"field": "productCode",
+												"size": 2147483647
+											}
+										}
+									},
+									"reverse_nested": {}
+								}
+							},
+							"terms": {
+								"field": "tags.tag",
+								"size": 2147483647
+							}
+						}
+					},
+					"filter": {
+						"term": {
+							"tags.key": "test"
+						}
+					}
+				}
+			},
+			"nested": {
+				"path": "tags"
+			}
+		},
+		"untagged": {
+			"aggregations": {
+				"by-product": {
+					"aggregations": {
+						"value": {
+							"sum": {
+								"field": "unblendedCost"
+							}
+						}
+					},
+					"terms": {
+						"field": "productCode",
+						"size": 2147483647
 					}
 				}
 			},
-			"terms": {
-				"field": "tag.value",
-				"size": 2147483647
+			"filter": {
+				"bool": {
+					"must_not": {
+						"nested": {
+							"path": "tags",
+							"query": {
+								"term": {
+									"tags.key": "test"
+								}
+							}
+						}
+					}
+				}
 			}
 		}
 	},
 	"filter": {
-		"term": {
-			"tag.key": "user:test"
-		}
+		"match_all": {}
 	}
 }`
 	res := nestAggregation(fewAggregationSlice)
@@ -368,33 +486,77 @@ func TestAggregationNestingWithAllHandledElasticAggregationTypes(t *testing.T) {
 	allTypesSlice = append(allTypesSlice, createAggregationPerProduct([]string{""})...)
 	expectedResult := `{
 	"aggregations": {
-		"tag_key": {
+		"by-tag:test": {
 			"aggregations": {
-				"tag_value": {
+				"tagged": {
+					"aggregations": {
+						"tag-key": {
+							"aggregations": {
+								"tag-values": {
+									"aggregations": {
+										"tag-reverse": {
+											"aggregations": {
+												"by-product": {
+													"terms": {
+														"field": "productCode",
+														"size": 2147483647
+													}
+												}
+											},
+											"reverse_nested": {}
+										}
+									},
+									"terms": {
+										"field": "tags.tag",
+										"size": 2147483647
+									}
+								}
+							},
+							"filter": {
+								"term": {
+									"tags.key": "test"
+								}
+							}
+						}
+					},
+					"nested": {
+						"path": "tags"
+					}
+				},
+				"untagged": {
 					"aggregations": {
-						"product": {
+						"by-product": {
 							"terms": {
-								"field": "product_name",
+								"field": "productCode",
 								"size": 2147483647
 							}
 						}
 					},
-					"terms": {
-						"field": "tag.value",
-						"size": 2147483647
+					"filter": {
+						"bool": {
+							"must_not": {
+								"nested": {
+									"path": "tags",
+									"query": {
+										"term": {
+											"tags.key": "test"
+										}
+									}
+								}
+							}
+						}
 					}
 				}
 			},
 			"filter": {
-				"term": {
-					"tag.key": "user:test"
-				}
+				"match_all": {}
 			}
 		}
 	},
 	"date_histogram": {
-		"field": "usage_start_date",
-		"interval": "year"
+		"field": "usageStartDate",
+		"interval": "year",
+		"min_doc_count": 0
 	}
 }`
 	res := nestAggregation(allTypesSlice)
@@ -428,7 +590,7 @@ func TestElasticSearchParamWithNoResults(t *testing.T) {
 		"buckets": []
 	}
 }`
-	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, client, index)
+	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, "unblendedCost", client, index)
 	res, err := searchService.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
@@ -537,7 +699,7 @@ func TestElasticSearchParamWithFewResultsAndNoAggregationNesting(t *testing.T) {
 		]
 	}
 }`
-	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, client, index)
+	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, "unblendedCost", client, index)
 	res, err := searchService.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
@@ -718,7 +880,7 @@ func TestElasticSearchParamWithFewResultsAndNesting(t *testing.T) {
 		]
 	}
 }`
-	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, client, index)
+	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, "unblendedCost", client, index)
 	res, err := searchService.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,54 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestQueryAccountFilters(t *testing.T) {
+	expectedResult := `{"terms":{"usageAccountId":["123456","98765432"]}}`
+	res := createQueryAccountFilter([]string{"123456", "98765432"})
+	src, err := res.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonRes, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jsonRes) != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, string(jsonRes))
+	}
+}
+
+func TestQueryTimeRange(t *testing.T) {
+	durationBegin, durationEnd := historyBounds(time.Date(2018, time.April, 16, 12, 0, 0, 0, time.UTC))
+	expectedResult := `{"range":{"usageStartDate":{"from":"2016-04-01T00:00:00Z","include_lower":true,"include_upper":false,"to":"2018-05-01T00:00:00Z"}}}`
+	res := createQueryTimeRange(durationBegin, durationEnd)
+	src, err := res.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonRes, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jsonRes) != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, string(jsonRes))
+	}
+}
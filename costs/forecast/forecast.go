@@ -0,0 +1,186 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package forecast projects the costs of the current month and of the
+// following ones from the costs of the previous months.
+package forecast
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/trackit/jsonlog"
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/errors"
+)
+
+// historyMonths is the number of complete months the models are fitted on.
+const historyMonths = 24
+
+type (
+	// Prediction is the forecasted cost of a month. Actual is the cost
+	// already spent in the month, which is only non-zero for the current
+	// month.
+	Prediction struct {
+		Month      string  `json:"month"`
+		Actual     float64 `json:"actual"`
+		Cost       float64 `json:"cost"`
+		LowerBound float64 `json:"lowerBound"`
+		UpperBound float64 `json:"upperBound"`
+	}
+
+	// accountForecast is the forecast of an account, as a whole and per
+	// product.
+	accountForecast struct {
+		Total    []Prediction            `json:"total"`
+		Products map[string][]Prediction `json:"products"`
+	}
+
+	// forecastResponse is used to respond to the request.
+	forecastResponse struct {
+		Model      string                     `json:"model"`
+		Confidence float64                    `json:"confidence"`
+		Accounts   map[string]accountForecast `json:"accounts"`
+	}
+
+	// esMonthlyCosts is used to store the raw ElasticSearch response.
+	esMonthlyCosts struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			Products struct {
+				Buckets []struct {
+					Key    string `json:"key"`
+					Months struct {
+						Buckets []struct {
+							Key  int64 `json:"key"`
+							Cost struct {
+								Value float64 `json:"value"`
+							} `json:"cost"`
+						} `json:"buckets"`
+					} `json:"months"`
+				} `json:"buckets"`
+			} `json:"products"`
+		} `json:"buckets"`
+	}
+)
+
+// ToCSVable generates the CSV content from a forecastResponse. The total of
+// each account is listed before its products.
+func (fr forecastResponse) ToCSVable() [][]string {
+	csv := [][]string{{"account", "product", "month", "actual", "cost", "lowerBound", "upperBound"}}
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	appendRows := func(account, product string, predictions []Prediction) {
+		for _, p := range predictions {
+			csv = append(csv, []string{account, product, p.Month, formatFloat(p.Actual),
+				formatFloat(p.Cost), formatFloat(p.LowerBound), formatFloat(p.UpperBound)})
+		}
+	}
+	accounts := make([]string, 0, len(fr.Accounts))
+	for account := range fr.Accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	for _, account := range accounts {
+		af := fr.Accounts[account]
+		appendRows(account, "total", af.Total)
+		products := make([]string, 0, len(af.Products))
+		for product := range af.Products {
+			products = append(products, product)
+		}
+		sort.Strings(products)
+		for _, product := range products {
+			appendRows(account, product, af.Products[product])
+		}
+	}
+	return csv
+}
+
+// historyBounds returns the beginning of the first month of history and the
+// beginning of the month following the current one.
+func historyBounds(now time.Time) (time.Time, time.Time) {
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return currentMonth.AddDate(0, -historyMonths, 0), currentMonth.AddDate(0, 1, 0)
+}
+
+// monthIndex returns the number of months between begin and date.
+func monthIndex(begin, date time.Time) int {
+	return (date.Year()-begin.Year())*12 + int(date.Month()) - int(begin.Month())
+}
+
+// forecastSeries forecasts the rest of the current month and the 'months'
+// following ones. The series holds the cost of each month of history
+// followed by the cost of the current month so far. The remaining part of
+// the current month is forecasted in proportion to the time left in it.
+func forecastSeries(model Model, series []float64, now time.Time, months int) []Prediction {
+	begin, end := historyBounds(now)
+	history, actual := series[:historyMonths], series[historyMonths]
+	for len(history) > 0 && history[0] == 0 {
+		history = history[1:]
+	}
+	estimates := model.Forecast(history, months+1)
+	currentMonth := end.AddDate(0, -1, 0)
+	remaining := float64(end.Sub(now)) / float64(end.Sub(currentMonth))
+	predictions := make([]Prediction, months+1)
+	for i, e := range estimates {
+		predictions[i] = Prediction{
+			Month:      begin.AddDate(0, historyMonths+i, 0).Format("2006-01"),
+			Cost:       e.Value,
+			LowerBound: e.Lower,
+			UpperBound: e.Upper,
+		}
+	}
+	predictions[0].Actual = actual
+	predictions[0].Cost = actual + estimates[0].Value*remaining
+	predictions[0].LowerBound = actual + estimates[0].Lower*remaining
+	predictions[0].UpperBound = actual + estimates[0].Upper*remaining
+	return predictions
+}
+
+// computeForecast parses the monthly costs from an ElasticSearch response
+// and forecasts them for each account and product.
+func computeForecast(ctx context.Context, sr *elastic.SearchResult, model Model, now time.Time, months int) (map[string]accountForecast, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	res := make(map[string]accountForecast)
+	if sr == nil || sr.Aggregations["accounts"] == nil {
+		return res, nil
+	}
+	var typedDocument esMonthlyCosts
+	if err := json.Unmarshal(*sr.Aggregations["accounts"], &typedDocument); err != nil {
+		logger.Error("Failed to parse elasticsearch document.", err.Error())
+		return nil, errors.GetErrorMessage(ctx, err)
+	}
+	begin, _ := historyBounds(now)
+	for _, account := range typedDocument.Buckets {
+		af := accountForecast{Products: make(map[string][]Prediction)}
+		total := make([]float64, historyMonths+1)
+		for _, product := range account.Products.Buckets {
+			series := make([]float64, historyMonths+1)
+			for _, month := range product.Months.Buckets {
+				i := monthIndex(begin, time.Unix(month.Key/1000, 0).UTC())
+				if i >= 0 && i < len(series) {
+					series[i] += month.Cost.Value
+					total[i] += month.Cost.Value
+				}
+			}
+			af.Products[product.Key] = forecastSeries(model, series, now, months)
+		}
+		af.Total = forecastSeries(model, total, now, months)
+		res[account.Key] = af
+	}
+	return res, nil
+}
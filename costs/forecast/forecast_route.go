@@ -0,0 +1,184 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trackit/jsonlog"
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/aws/s3"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/errors"
+	"github.com/trackit/trackit-server/es"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+const (
+	// defaultModel is the model used when none is given.
+	defaultModel = "linear"
+	// defaultMonths is the number of months forecasted after the current
+	// one when none is given.
+	defaultMonths = 3
+	// maxMonths is the maximum number of months which can be forecasted
+	// after the current one.
+	maxMonths = 12
+)
+
+// esQueryParams will store the parsed query params
+type esQueryParams struct {
+	dateBegin   time.Time
+	dateEnd     time.Time
+	accountList []string
+	indexList   []string
+}
+
+// forecastQueryArgs allows to get required queryArgs params
+var forecastQueryArgs = []routes.QueryArg{
+	routes.AwsAccountsOptionalQueryArg,
+	routes.QueryArg{
+		Name:        "months",
+		Description: fmt.Sprintf("Number of months to forecast after the current one, between 0 and %d. Defaults to %d.", maxMonths, defaultMonths),
+		Type:        routes.QueryArgInt{},
+		Optional:    true,
+	},
+	routes.QueryArg{
+		Name:        "model",
+		Description: "Forecasting model. Possible values are linear, seasonal. Defaults to linear.",
+		Type:        routes.QueryArgString{},
+		Optional:    true,
+	},
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getForecastData).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs(forecastQueryArgs),
+			routes.Documentation{
+				Summary:     "get the costs forecast",
+				Description: "Responds with the forecasted costs of the rest of the current month and of the following months, per account and per product, along with their 95% confidence interval",
+			},
+		),
+	}.H().Register("/costs/forecast")
+}
+
+// modelNames returns the sorted names of the available models.
+func modelNames() []string {
+	names := make([]string, 0, len(Models))
+	for name := range Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// makeElasticSearchRequest prepares and run the request to retrieve the monthly costs
+// It will return the data, an http status code (as int) and an error.
+// Because an error can be generated, but is not critical and is not needed to be known by
+// the user (e.g if the index does not exists because it was not yet indexed ) the error will
+// be returned, but instead of having a 500 status code, it will return the provided status code
+// with empy data
+func makeElasticSearchRequest(ctx context.Context, parsedParams esQueryParams) (*elastic.SearchResult, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(ctx)
+	index := strings.Join(parsedParams.indexList, ",")
+	searchService := getElasticSearchParams(
+		parsedParams.accountList,
+		parsedParams.dateBegin,
+		parsedParams.dateEnd,
+		es.Client,
+		index,
+	)
+	res, err := searchService.Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			l.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
+				"index": index,
+				"error": err.Error(),
+			})
+			return nil, http.StatusOK, errors.GetErrorMessage(ctx, err)
+		} else if cast, ok := err.(*elastic.Error); ok && cast.Details.Type == "search_phase_execution_exception" {
+			l.Error("Error while getting data from ES", map[string]interface{}{
+				"type":  fmt.Sprintf("%T", err),
+				"error": err,
+			})
+		} else {
+			l.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
+		}
+		return nil, http.StatusInternalServerError, errors.GetErrorMessage(ctx, err)
+	}
+	return res, http.StatusOK, nil
+}
+
+// getForecastData returns the costs forecast based on the query params, in JSON or CSV format.
+func getForecastData(request *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	now := time.Now().UTC()
+	parsedParams := esQueryParams{
+		accountList: []string{},
+	}
+	parsedParams.dateBegin, parsedParams.dateEnd = historyBounds(now)
+	if a[forecastQueryArgs[0]] != nil {
+		parsedParams.accountList = a[forecastQueryArgs[0]].([]string)
+	}
+	months := defaultMonths
+	if a[forecastQueryArgs[1]] != nil {
+		months = a[forecastQueryArgs[1]].(int)
+	}
+	if months < 0 || months > maxMonths {
+		return http.StatusBadRequest, fmt.Errorf("months must be between 0 and %d", maxMonths)
+	}
+	modelName := defaultModel
+	if a[forecastQueryArgs[2]] != nil {
+		modelName = a[forecastQueryArgs[2]].(string)
+	}
+	model, ok := Models[modelName]
+	if !ok {
+		return http.StatusBadRequest, fmt.Errorf("invalid model : %s, possible values are %s", modelName, strings.Join(modelNames(), ", "))
+	}
+	tx := a[db.Transaction].(*sql.Tx)
+	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
+	if err != nil {
+		return returnCode, err
+	}
+	parsedParams.accountList = accountsAndIndexes.Accounts
+	parsedParams.indexList = accountsAndIndexes.Indexes
+	res := forecastResponse{
+		Model:      modelName,
+		Confidence: 0.95,
+		Accounts:   map[string]accountForecast{},
+	}
+	sr, returnCode, err := makeElasticSearchRequest(request.Context(), parsedParams)
+	if err != nil {
+		if returnCode == http.StatusOK {
+			return returnCode, res
+		} else {
+			return returnCode, err
+		}
+	}
+	if res.Accounts, err = computeForecast(request.Context(), sr, model, now, months); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, res
+}
@@ -0,0 +1,117 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestLinearModelOnPerfectTrend(t *testing.T) {
+	series := []float64{10, 20, 30, 40}
+	expected := []float64{50, 60}
+	estimates := linearModel{}.Forecast(series, 2)
+	for i, e := range estimates {
+		if !almostEqual(e.Value, expected[i]) {
+			t.Fatalf("Expected %v but got %v", expected[i], e.Value)
+		} else if !almostEqual(e.Lower, e.Value) || !almostEqual(e.Upper, e.Value) {
+			t.Fatalf("Expected an empty confidence interval but got [%v, %v]", e.Lower, e.Upper)
+		}
+	}
+}
+
+func TestLinearModelIntervalWidensWithHorizon(t *testing.T) {
+	series := []float64{10, 14, 9, 15, 11, 16}
+	estimates := linearModel{}.Forecast(series, 3)
+	for i, e := range estimates {
+		if e.Lower > e.Value || e.Upper < e.Value {
+			t.Fatalf("Expected %v to be within [%v, %v]", e.Value, e.Lower, e.Upper)
+		} else if i > 0 && e.Upper-e.Lower <= estimates[i-1].Upper-estimates[i-1].Lower {
+			t.Fatalf("Expected the confidence interval to widen with the horizon")
+		}
+	}
+}
+
+func TestLinearModelIsNeverNegative(t *testing.T) {
+	series := []float64{30, 20, 10}
+	estimates := linearModel{}.Forecast(series, 3)
+	for _, e := range estimates {
+		if e.Value < 0 || e.Lower < 0 || e.Upper < 0 {
+			t.Fatalf("Expected non-negative estimates but got %v", e)
+		}
+	}
+}
+
+func TestSeasonalModelWithoutEnoughHistory(t *testing.T) {
+	series := []float64{5, 10, 20, 30}
+	estimates := seasonalModel{window: 3}.Forecast(series, 2)
+	for _, e := range estimates {
+		if !almostEqual(e.Value, 20) {
+			t.Fatalf("Expected the moving average 20 but got %v", e.Value)
+		}
+	}
+}
+
+func TestSeasonalModelFollowsSeason(t *testing.T) {
+	season := []float64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 40}
+	series := append(append([]float64{}, season...), season...)
+	estimates := seasonalModel{window: 3}.Forecast(series, seasonLength)
+	if estimates[11].Value <= estimates[0].Value*2 {
+		t.Fatalf("Expected the last month of the season to stand out but got %v and %v", estimates[11].Value, estimates[0].Value)
+	}
+}
+
+func TestForecastSeries(t *testing.T) {
+	now := time.Date(2018, time.April, 16, 0, 0, 0, 0, time.UTC)
+	series := make([]float64, historyMonths+1)
+	for i := historyMonths - 3; i < historyMonths; i++ {
+		series[i] = 30
+	}
+	series[historyMonths] = 10
+	expected := []Prediction{
+		{Month: "2018-04", Actual: 10, Cost: 25, LowerBound: 25, UpperBound: 25},
+		{Month: "2018-05", Cost: 30, LowerBound: 30, UpperBound: 30},
+	}
+	res := forecastSeries(linearModel{}, series, now, 1)
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Expected %v but got %v", expected, res)
+	}
+}
+
+func TestForecastResponseToCSVable(t *testing.T) {
+	prediction := []Prediction{{Month: "2018-04", Actual: 1.5, Cost: 3, LowerBound: 2, UpperBound: 4}}
+	fr := forecastResponse{
+		Model:      "linear",
+		Confidence: 0.95,
+		Accounts: map[string]accountForecast{
+			"123456": {Total: prediction, Products: map[string][]Prediction{"AmazonS3": prediction, "AmazonEC2": prediction}},
+		},
+	}
+	expected := [][]string{
+		{"account", "product", "month", "actual", "cost", "lowerBound", "upperBound"},
+		{"123456", "total", "2018-04", "1.5", "3", "2", "4"},
+		{"123456", "AmazonEC2", "2018-04", "1.5", "3", "2", "4"},
+		{"123456", "AmazonS3", "2018-04", "1.5", "3", "2", "4"},
+	}
+	if res := fr.ToCSVable(); !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Expected %v but got %v", expected, res)
+	}
+}
@@ -0,0 +1,187 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"math"
+)
+
+// confidenceZScore is the z-score of the 95% confidence intervals returned
+// with the forecasts.
+const confidenceZScore = 1.96
+
+// seasonLength is the number of months in a season.
+const seasonLength = 12
+
+type (
+	// Estimate is a forecasted value along with its confidence interval.
+	Estimate struct {
+		Value float64
+		Lower float64
+		Upper float64
+	}
+
+	// Model predicts the following values of a monthly series of costs.
+	Model interface {
+		// Forecast returns the estimates of the 'periods' values following
+		// 'series'.
+		Forecast(series []float64, periods int) []Estimate
+	}
+
+	// linearModel fits a least squares linear trend on the series.
+	linearModel struct{}
+
+	// seasonalModel projects the moving average of the last 'window'
+	// values, weighted by a seasonal index when at least two seasons of
+	// history are available.
+	seasonalModel struct {
+		window int
+	}
+)
+
+// Models maps the names accepted by the forecast route to their Model.
+var Models = map[string]Model{
+	"linear":   linearModel{},
+	"seasonal": seasonalModel{window: 3},
+}
+
+// newEstimate creates an Estimate from a value and the standard error of
+// the prediction. As costs cannot be negative, neither can the estimate.
+func newEstimate(value, stdErr float64) Estimate {
+	margin := confidenceZScore * stdErr
+	return Estimate{
+		Value: math.Max(value, 0),
+		Lower: math.Max(value-margin, 0),
+		Upper: math.Max(value+margin, 0),
+	}
+}
+
+// Forecast implements Model. The standard error of each prediction takes
+// into account both the residuals of the fit and the distance to the mean
+// of the series.
+func (linearModel) Forecast(series []float64, periods int) []Estimate {
+	estimates := make([]Estimate, periods)
+	n := float64(len(series))
+	if len(series) == 0 {
+		return estimates
+	}
+	var meanX, meanY float64
+	for i, y := range series {
+		meanX += float64(i)
+		meanY += y
+	}
+	meanX, meanY = meanX/n, meanY/n
+	var sxx, sxy float64
+	for i, y := range series {
+		sxx += (float64(i) - meanX) * (float64(i) - meanX)
+		sxy += (float64(i) - meanX) * (y - meanY)
+	}
+	slope := 0.0
+	if sxx > 0 {
+		slope = sxy / sxx
+	}
+	intercept := meanY - slope*meanX
+	sigma := 0.0
+	if len(series) > 2 {
+		var sse float64
+		for i, y := range series {
+			residual := y - (intercept + slope*float64(i))
+			sse += residual * residual
+		}
+		sigma = math.Sqrt(sse / (n - 2))
+	}
+	for h := range estimates {
+		x := n + float64(h)
+		leverage := 1 + 1/n
+		if sxx > 0 {
+			leverage += (x - meanX) * (x - meanX) / sxx
+		}
+		estimates[h] = newEstimate(intercept+slope*x, sigma*math.Sqrt(leverage))
+	}
+	return estimates
+}
+
+// seasonalIndices computes the ratio between the mean of each month of the
+// season and the mean of the series. All indices are 1 if there are less
+// than two complete seasons in the series.
+func seasonalIndices(series []float64) []float64 {
+	indices := make([]float64, seasonLength)
+	for i := range indices {
+		indices[i] = 1
+	}
+	if len(series) < 2*seasonLength {
+		return indices
+	}
+	var sums [seasonLength]float64
+	var counts [seasonLength]int
+	var total float64
+	for i, y := range series {
+		sums[i%seasonLength] += y
+		counts[i%seasonLength]++
+		total += y
+	}
+	mean := total / float64(len(series))
+	if mean <= 0 {
+		return indices
+	}
+	for i := range indices {
+		if sums[i] > 0 {
+			indices[i] = sums[i] / float64(counts[i]) / mean
+		}
+	}
+	return indices
+}
+
+// Forecast implements Model. The standard error is the root mean square of
+// the in-sample one step ahead errors, growing with the horizon.
+func (m seasonalModel) Forecast(series []float64, periods int) []Estimate {
+	estimates := make([]Estimate, periods)
+	if len(series) == 0 {
+		return estimates
+	}
+	indices := seasonalIndices(series)
+	deseasonalized := make([]float64, len(series))
+	for i, y := range series {
+		deseasonalized[i] = y / indices[i%seasonLength]
+	}
+	level := func(end int) float64 {
+		begin := end - m.window
+		if begin < 0 {
+			begin = 0
+		}
+		var sum float64
+		for _, y := range deseasonalized[begin:end] {
+			sum += y
+		}
+		return sum / float64(end-begin)
+	}
+	var sse float64
+	var errCount int
+	for i := m.window; i < len(series); i++ {
+		residual := series[i] - level(i)*indices[i%seasonLength]
+		sse += residual * residual
+		errCount++
+	}
+	sigma := 0.0
+	if errCount > 0 {
+		sigma = math.Sqrt(sse / float64(errCount))
+	}
+	current := level(len(series))
+	for h := range estimates {
+		index := indices[(len(series)+h)%seasonLength]
+		estimates[h] = newEstimate(current*index, sigma*index*math.Sqrt(float64(h+1)))
+	}
+	return estimates
+}
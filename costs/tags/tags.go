@@ -31,7 +31,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getTagsValues).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(tagsValuesQueryArgs),
 			routes.Documentation{
 				Summary:     "get the tag values and their cost with a filter",
@@ -42,7 +42,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getTagsKeys).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(tagsKeysQueryArgs),
 			routes.Documentation{
 				Summary:     "get every tag keys",
@@ -0,0 +1,219 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package currencies converts costs between the currencies AWS bills in.
+package currencies
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+// BaseCurrency is the currency rates are expressed against. Its rate is
+// always 1.
+const BaseCurrency = "USD"
+
+var (
+	ErrInvalidCurrency = errors.New("currency must be a three letter ISO 4217 code")
+	ErrInvalidRate     = errors.New("rate must be greater than zero")
+)
+
+// UnknownCurrencyError is returned when an amount cannot be converted
+// because no rate is known for its currency.
+type UnknownCurrencyError string
+
+func (e UnknownCurrencyError) Error() string {
+	return fmt.Sprintf("no conversion rate for currency %s, an administrator must set it with /currencies/rates", string(e))
+}
+
+type (
+	// Rates maps currency codes to the amount of the currency one unit of
+	// the BaseCurrency is worth.
+	Rates map[string]float64
+
+	// Amounts maps currency codes to amounts in that currency.
+	Amounts map[string]float64
+
+	// Converter converts amounts to the currency they are displayed in.
+	Converter struct {
+		Rates    Rates
+		Currency string
+	}
+)
+
+// ValidCurrency reports whether a currency code is well-formed.
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks all currencies and rates are well-formed.
+func (r Rates) Validate() error {
+	for currency, rate := range r {
+		if !ValidCurrency(currency) {
+			return ErrInvalidCurrency
+		} else if rate <= 0 {
+			return ErrInvalidRate
+		}
+	}
+	return nil
+}
+
+// rate returns the rate of a currency. Line items without a currency are
+// considered to be in the BaseCurrency.
+func (r Rates) rate(currency string) (float64, error) {
+	if currency == "" || currency == BaseCurrency {
+		return 1, nil
+	} else if rate, ok := r[currency]; ok {
+		return rate, nil
+	}
+	return 0, UnknownCurrencyError(currency)
+}
+
+// Convert converts an amount from a currency to another.
+func (r Rates) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := r.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	return amount / fromRate * toRate, nil
+}
+
+// Sum converts amounts to a currency and adds them up.
+func (r Rates) Sum(amounts Amounts, to string) (float64, error) {
+	var total float64
+	for currency, amount := range amounts {
+		converted, err := r.Convert(amount, currency, to)
+		if err != nil {
+			return 0, err
+		}
+		total += converted
+	}
+	return total, nil
+}
+
+// Convert converts an amount from a currency to the Converter's currency.
+func (c Converter) Convert(amount float64, from string) (float64, error) {
+	return c.Rates.Convert(amount, from, c.Currency)
+}
+
+// Sum converts amounts to the Converter's currency and adds them up.
+func (c Converter) Sum(amounts Amounts) (float64, error) {
+	return c.Rates.Sum(amounts, c.Currency)
+}
+
+// For returns a Converter able to convert amounts in the given currencies.
+// When a rate is missing but the amounts are all in the same currency, the
+// returned Converter leaves them in that currency so that they can still be
+// shown along with the currency they were billed in. Otherwise an
+// UnknownCurrencyError is returned for the first currency without a rate.
+func (c Converter) For(currencies ...string) (Converter, error) {
+	if len(currencies) == 0 {
+		return c, nil
+	}
+	var missing error
+	for _, currency := range append([]string{c.Currency}, currencies...) {
+		if _, err := c.Rates.rate(currency); err != nil {
+			missing = err
+			break
+		}
+	}
+	if missing == nil {
+		return c, nil
+	}
+	amounts := Amounts{}
+	for _, currency := range currencies {
+		amounts.Add(currency, 0)
+	}
+	if len(amounts) == 1 {
+		return Converter{c.Rates, amounts.Currencies()[0]}, nil
+	}
+	return c, missing
+}
+
+// Add adds an amount in a currency.
+func (a Amounts) Add(currency string, amount float64) {
+	if currency == "" {
+		currency = BaseCurrency
+	}
+	a[currency] += amount
+}
+
+// Currencies returns the sorted currencies of the amounts.
+func (a Amounts) Currencies() []string {
+	currencies := make([]string, 0, len(a))
+	for currency := range a {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// GetRates returns the known conversion rates.
+func GetRates(tx *sql.Tx) (Rates, error) {
+	dbRates, err := models.AllCurrencyRates(tx)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(Rates, len(dbRates))
+	for _, dbRate := range dbRates {
+		rates[dbRate.Currency] = dbRate.Rate
+	}
+	return rates, nil
+}
+
+// SetRates creates or updates conversion rates. Rates for currencies which
+// are not given are kept.
+func SetRates(tx *sql.Tx, rates Rates) error {
+	if err := rates.Validate(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for currency, rate := range rates {
+		if currency == BaseCurrency {
+			continue
+		}
+		dbRate, err := models.CurrencyRateByCurrency(tx, currency)
+		if err == sql.ErrNoRows {
+			dbRate = &models.CurrencyRate{Currency: currency}
+		} else if err != nil {
+			return err
+		}
+		dbRate.Rate = rate
+		dbRate.Updated = now
+		if err = dbRate.Save(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
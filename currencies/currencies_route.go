@@ -0,0 +1,161 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package currencies
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+type (
+	// ratesRequestBody is the expected request body for the putRates
+	// request handler.
+	ratesRequestBody struct {
+		Rates Rates `json:"rates" req:"nonzero"`
+	}
+
+	// ratesResponseBody is the response body of the rates handlers.
+	ratesResponseBody struct {
+		Base  string `json:"base"`
+		Rates Rates  `json:"rates"`
+	}
+
+	// displayCurrencyBody is the request and response body of the display
+	// currency handlers.
+	displayCurrencyBody struct {
+		Currency string `json:"currency" req:"nonzero"`
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getRates).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.Documentation{
+				Summary:     "get the currency conversion rates",
+				Description: "Responds with the amount of each currency one unit of the base currency is worth.",
+			},
+		),
+		http.MethodPut: routes.H(putRates).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{ratesRequestBody{Rates{"EUR": 0.92, "GBP": 0.79}}},
+			routes.Documentation{
+				Summary:     "set currency conversion rates",
+				Description: "Creates or updates currency conversion rates. Only administrators can use this route.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary:     "interact with currency conversion rates",
+			Description: "Conversion rates are used to display costs billed in several currencies in a single one.",
+		},
+	).Register("/currencies/rates")
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getDisplayCurrency).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.Documentation{
+				Summary:     "get the display currency",
+				Description: "Responds with the currency costs are displayed in for the current user.",
+			},
+		),
+		http.MethodPut: routes.H(putDisplayCurrency).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{displayCurrencyBody{"EUR"}},
+			routes.Documentation{
+				Summary:     "set the display currency",
+				Description: "Sets the currency costs are displayed in for the current user. The currency must have a conversion rate.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary:     "interact with the display currency",
+			Description: "Costs are converted to the display currency of the user in the costs, diff, anomalies and reports.",
+		},
+	).Register("/currencies/display")
+}
+
+// getRates is a route handler which lists the currency conversion rates.
+func getRates(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	rates, err := GetRates(tx)
+	if err != nil {
+		l.Error("Failed to get currency rates.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to get currency rates")
+	}
+	return http.StatusOK, ratesResponseBody{BaseCurrency, rates}
+}
+
+// putRates is a route handler which creates or updates currency conversion
+// rates.
+func putRates(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body ratesRequestBody
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	if !users.IsAdmin(user) {
+		return http.StatusForbidden, errors.New("only administrators can set currency rates")
+	}
+	switch err := SetRates(tx, body.Rates); err {
+	case nil:
+	case ErrInvalidCurrency, ErrInvalidRate:
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	default:
+		l.Error("Failed to set currency rates.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to set currency rates")
+	}
+	return getRates(r, a)
+}
+
+// getDisplayCurrency is a route handler which responds with the display
+// currency of the current user.
+func getDisplayCurrency(r *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	return http.StatusOK, displayCurrencyBody{user.DisplayCurrency}
+}
+
+// putDisplayCurrency is a route handler which sets the display currency of
+// the current user.
+func putDisplayCurrency(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body displayCurrencyBody
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	user, err := SetDisplayCurrency(tx, user, body.Currency)
+	if err == nil {
+		return http.StatusOK, displayCurrencyBody{user.DisplayCurrency}
+	} else if _, ok := err.(UnknownCurrencyError); ok || err == ErrInvalidCurrency {
+		return http.StatusBadRequest, err
+	}
+	l.Error("Failed to set display currency.", map[string]interface{}{
+		"userId": user.Id,
+		"error":  err.Error(),
+	})
+	return http.StatusInternalServerError, errors.New("failed to set display currency")
+}
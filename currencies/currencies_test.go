@@ -0,0 +1,105 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package currencies
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+var testRates = Rates{"EUR": 0.8, "GBP": 0.5}
+
+func floatEquals(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		from     string
+		to       string
+		expected float64
+	}{
+		{10, "USD", "USD", 10},
+		{10, "USD", "EUR", 8},
+		{8, "EUR", "USD", 10},
+		{8, "EUR", "GBP", 5},
+		{10, "", "EUR", 8},
+		{10, "JPY", "JPY", 10},
+	}
+	for _, c := range cases {
+		if res, err := testRates.Convert(c.amount, c.from, c.to); err != nil {
+			t.Errorf("Converting %v %s to %s should succeed, failed with %s.", c.amount, c.from, c.to, err.Error())
+		} else if !floatEquals(res, c.expected) {
+			t.Errorf("Converting %v %s to %s should give %v, gave %v instead.", c.amount, c.from, c.to, c.expected, res)
+		}
+	}
+	if _, err := testRates.Convert(10, "JPY", "USD"); err != UnknownCurrencyError("JPY") {
+		t.Errorf("Converting from an unknown currency should fail with UnknownCurrencyError, got %v.", err)
+	}
+}
+
+func TestSum(t *testing.T) {
+	amounts := Amounts{}
+	amounts.Add("USD", 10)
+	amounts.Add("EUR", 8)
+	amounts.Add("", 2)
+	c := Converter{testRates, "USD"}
+	if res, err := c.Sum(amounts); err != nil || !floatEquals(res, 22) {
+		t.Errorf("Sum should be 22, is %v (%v).", res, err)
+	}
+	if currencies := amounts.Currencies(); strings.Join(currencies, ",") != "EUR,USD" {
+		t.Errorf("Currencies should be EUR,USD, are %v.", currencies)
+	}
+}
+
+func TestConverterFor(t *testing.T) {
+	c := Converter{testRates, "EUR"}
+	cases := []struct {
+		currencies []string
+		expected   string
+		err        error
+	}{
+		{nil, "EUR", nil},
+		{[]string{"USD", "GBP"}, "EUR", nil},
+		{[]string{"JPY"}, "JPY", nil},
+		{[]string{"JPY", "JPY"}, "JPY", nil},
+		{[]string{"JPY", "USD"}, "EUR", UnknownCurrencyError("JPY")},
+	}
+	for _, tc := range cases {
+		if res, err := c.For(tc.currencies...); err != tc.err || res.Currency != tc.expected {
+			t.Errorf("Converter for %v should be to %s (%v), is to %s (%v).", tc.currencies, tc.expected, tc.err, res.Currency, err)
+		}
+	}
+	if res, err := (Converter{Rates{}, "USD"}).For("EUR"); err != nil || res.Currency != "EUR" {
+		t.Errorf("Converter for EUR without rates should leave amounts in EUR, is to %s (%v).", res.Currency, err)
+	}
+}
+
+func TestReadRates(t *testing.T) {
+	rates, err := ReadRates(strings.NewReader(`{"EUR": 0.92, "GBP": 0.79}`))
+	if err != nil {
+		t.Fatalf("Reading rates should succeed, failed with %s.", err.Error())
+	} else if len(rates) != 2 || rates["EUR"] != 0.92 || rates["GBP"] != 0.79 {
+		t.Errorf("Unexpected rates %v.", rates)
+	}
+	if _, err := ReadRates(strings.NewReader(`{"euro": 0.92}`)); err != ErrInvalidCurrency {
+		t.Errorf("Reading an invalid currency should fail with ErrInvalidCurrency, got %v.", err)
+	}
+	if _, err := ReadRates(strings.NewReader(`{"EUR": 0}`)); err != ErrInvalidRate {
+		t.Errorf("Reading a zero rate should fail with ErrInvalidRate, got %v.", err)
+	}
+}
@@ -0,0 +1,47 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package currencies
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// ReadRates reads conversion rates from a JSON object mapping currency codes
+// to the amount of the currency one unit of the BaseCurrency is worth, such
+// as {"EUR": 0.92, "GBP": 0.79}.
+func ReadRates(r io.Reader) (Rates, error) {
+	var rates Rates
+	if err := json.NewDecoder(r).Decode(&rates); err != nil {
+		return nil, err
+	}
+	return rates, rates.Validate()
+}
+
+// LoadRatesFile reads conversion rates from a JSON file and stores them.
+func LoadRatesFile(tx *sql.Tx, path string) (Rates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rates, err := ReadRates(f)
+	if err != nil {
+		return nil, err
+	}
+	return rates, SetRates(tx, rates)
+}
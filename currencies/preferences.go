@@ -0,0 +1,54 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package currencies
+
+import (
+	"database/sql"
+
+	"github.com/trackit/trackit-server/models"
+	"github.com/trackit/trackit-server/users"
+)
+
+// SetDisplayCurrency sets the currency costs are displayed in for a user.
+// The currency must either be the BaseCurrency or have a known rate.
+func SetDisplayCurrency(tx *sql.Tx, user users.User, currency string) (users.User, error) {
+	if !ValidCurrency(currency) {
+		return user, ErrInvalidCurrency
+	}
+	rates, err := GetRates(tx)
+	if err != nil {
+		return user, err
+	} else if _, err = rates.rate(currency); err != nil {
+		return user, err
+	}
+	dbUser, err := models.UserByID(tx, user.Id)
+	if err != nil {
+		return user, err
+	}
+	dbUser.DisplayCurrency = currency
+	if err = dbUser.Update(tx); err != nil {
+		return user, err
+	}
+	return users.UserFromDbUser(*dbUser), nil
+}
+
+// ConverterForUser returns a Converter to the display currency of a user.
+func ConverterForUser(tx *sql.Tx, user users.User) (Converter, error) {
+	rates, err := GetRates(tx)
+	if err != nil {
+		return Converter{}, err
+	}
+	return Converter{Rates: rates, Currency: user.DisplayCurrency}, nil
+}
@@ -0,0 +1,38 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE budget (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	name           VARCHAR(255) NOT NULL,
+	amount         DOUBLE       NOT NULL,
+	scope_type     VARCHAR(32)  NOT NULL DEFAULT "account",
+	scope_key      VARCHAR(255) NOT NULL DEFAULT "",
+	scope_value    VARCHAR(255) NOT NULL DEFAULT "",
+	created        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE emailed_budget_alert (
+	id         INTEGER      NOT NULL AUTO_INCREMENT,
+	budget_id  INTEGER      NOT NULL,
+	threshold  INTEGER      NOT NULL,
+	forecasted BOOL         NOT NULL DEFAULT 0,
+	period     DATE         NOT NULL,
+	recipient  VARCHAR(255) NOT NULL,
+	date       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_budget FOREIGN KEY (budget_id) REFERENCES budget(id) ON DELETE CASCADE
+);
@@ -0,0 +1,42 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE notification_endpoint (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER       NOT NULL,
+	channel        VARCHAR(16)   NOT NULL,
+	target         VARCHAR(2048) NOT NULL,
+	secret         VARCHAR(255)  NOT NULL DEFAULT "",
+	events         VARCHAR(255)  NOT NULL DEFAULT "",
+	created        TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_delivery (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER       NOT NULL,
+	event          VARCHAR(32)   NOT NULL,
+	event_key      VARCHAR(255)  NOT NULL,
+	channel        VARCHAR(16)   NOT NULL,
+	target         VARCHAR(2048) NOT NULL,
+	status         VARCHAR(16)   NOT NULL,
+	attempts       INTEGER       NOT NULL,
+	error          TEXT          NOT NULL,
+	date           TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX delivery_event_key (aws_account_id, event_key),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
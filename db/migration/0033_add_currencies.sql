@@ -0,0 +1,25 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE currency_rate (
+	id       INTEGER   NOT NULL AUTO_INCREMENT,
+	currency CHAR(3)   NOT NULL,
+	rate     DOUBLE    NOT NULL,
+	updated  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_currency UNIQUE KEY (currency)
+);
+
+ALTER TABLE user ADD display_currency CHAR(3) NOT NULL DEFAULT "USD";
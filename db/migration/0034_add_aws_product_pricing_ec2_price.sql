@@ -0,0 +1,30 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+ALTER TABLE aws_product_pricing_ec2 ADD region_code VARCHAR(255) NOT NULL DEFAULT "";
+ALTER TABLE aws_product_pricing_ec2 ADD INDEX product_instance_type (instance_type, region);
+
+CREATE TABLE aws_product_pricing_ec2_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
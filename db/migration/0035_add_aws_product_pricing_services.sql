@@ -0,0 +1,117 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE aws_product_pricing_rds (
+	sku               VARCHAR(255) NOT NULL,
+	etag              VARCHAR(255) NOT NULL,
+	region            VARCHAR(255) NOT NULL,
+	region_code       VARCHAR(255) NOT NULL,
+	instance_type     VARCHAR(255) NOT NULL,
+	database_engine   VARCHAR(255) NOT NULL,
+	database_edition  VARCHAR(255) NOT NULL,
+	license_model     VARCHAR(255) NOT NULL,
+	deployment_option VARCHAR(255) NOT NULL,
+	vcpu              INTEGER      NOT NULL,
+	memory            VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_instance_type (instance_type, region_code)
+);
+
+CREATE TABLE aws_product_pricing_rds_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+CREATE TABLE aws_product_pricing_es (
+	sku           VARCHAR(255) NOT NULL,
+	etag          VARCHAR(255) NOT NULL,
+	region        VARCHAR(255) NOT NULL,
+	region_code   VARCHAR(255) NOT NULL,
+	instance_type VARCHAR(255) NOT NULL,
+	vcpu          INTEGER      NOT NULL,
+	memory        VARCHAR(255) NOT NULL,
+	storage       VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_instance_type (instance_type, region_code)
+);
+
+CREATE TABLE aws_product_pricing_es_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+CREATE TABLE aws_product_pricing_elasticache (
+	sku           VARCHAR(255) NOT NULL,
+	etag          VARCHAR(255) NOT NULL,
+	region        VARCHAR(255) NOT NULL,
+	region_code   VARCHAR(255) NOT NULL,
+	instance_type VARCHAR(255) NOT NULL,
+	cache_engine  VARCHAR(255) NOT NULL,
+	vcpu          INTEGER      NOT NULL,
+	memory        VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_instance_type (instance_type, region_code)
+);
+
+CREATE TABLE aws_product_pricing_elasticache_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+CREATE TABLE aws_product_pricing_ebs (
+	sku             VARCHAR(255) NOT NULL,
+	etag            VARCHAR(255) NOT NULL,
+	region          VARCHAR(255) NOT NULL,
+	region_code     VARCHAR(255) NOT NULL,
+	volume_api_name VARCHAR(255) NOT NULL,
+	volume_type     VARCHAR(255) NOT NULL,
+	max_iops        VARCHAR(255) NOT NULL,
+	max_throughput  VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_volume_api_name (volume_api_name, region_code)
+);
+
+CREATE TABLE aws_product_pricing_ebs_price (
+	sku      VARCHAR(255) NOT NULL,
+	etag     VARCHAR(255) NOT NULL,
+	unit     VARCHAR(32)  NOT NULL,
+	price    DOUBLE       NOT NULL,
+	currency CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku)
+);
@@ -0,0 +1,25 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE periodic_task_run (
+	id          INTEGER       NOT NULL AUTO_INCREMENT,
+	task        VARCHAR(255)  NOT NULL,
+	backend_id  VARCHAR(255)  NOT NULL DEFAULT "",
+	started     DATETIME(3)   NOT NULL,
+	duration_ms BIGINT        NOT NULL,
+	error       VARCHAR(255)  NOT NULL DEFAULT "",
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX task_started (task, started)
+);
@@ -0,0 +1,37 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE api_key (
+	id        INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id   INTEGER      NOT NULL,
+	name      VARCHAR(255) NOT NULL,
+	prefix    VARCHAR(16)  NOT NULL,
+	hash      CHAR(64)     NOT NULL,
+	read_only BOOLEAN      NOT NULL DEFAULT 0,
+	created   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires   DATETIME     NULL DEFAULT NULL,
+	last_used DATETIME     NULL DEFAULT NULL,
+	revoked   DATETIME     NULL DEFAULT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_hash UNIQUE KEY (hash),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE api_key_aws_account (
+	api_key_id     INTEGER NOT NULL,
+	aws_account_id INTEGER NOT NULL,
+	CONSTRAINT PRIMARY KEY (api_key_id, aws_account_id),
+	CONSTRAINT foreign_api_key FOREIGN KEY (api_key_id) REFERENCES api_key(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
@@ -0,0 +1,32 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE user_session (
+	id           INTEGER   NOT NULL AUTO_INCREMENT,
+	user_id      INTEGER   NOT NULL,
+	refresh_hash CHAR(64)  NOT NULL,
+	created      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires      DATETIME  NOT NULL,
+	revoked      DATETIME  NULL DEFAULT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_refresh_hash UNIQUE KEY (refresh_hash),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_session_rotated_refresh_hash (
+	refresh_hash CHAR(64) NOT NULL,
+	session_id   INTEGER  NOT NULL,
+	CONSTRAINT PRIMARY KEY (refresh_hash),
+	CONSTRAINT foreign_session FOREIGN KEY (session_id) REFERENCES user_session(id) ON DELETE CASCADE
+);
@@ -0,0 +1,34 @@
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+ALTER TABLE notification_delivery
+	ADD payload MEDIUMTEXT NOT NULL,
+	ADD INDEX delivery_status (aws_account_id, status);
+
+CREATE TABLE aws_account_notifications_job (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	created        TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	aws_account_id INTEGER       NOT NULL,
+	completed      TIMESTAMP     NOT NULL DEFAULT 0,
+	worker_id      VARCHAR(255)  NOT NULL DEFAULT "",
+	jobError       VARCHAR(255)  NOT NULL DEFAULT "",
+	status         VARCHAR(16)   NOT NULL DEFAULT "queued",
+	attempts       INTEGER       NOT NULL DEFAULT 0,
+	max_attempts   INTEGER       NOT NULL DEFAULT 5,
+	available      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expired        DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	INDEX job_status (status, available)
+);
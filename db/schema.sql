@@ -653,3 +653,551 @@ ALTER TABLE aws_account ADD next_update_anomalies_detection DATETIME NOT NULL DE
 CREATE VIEW anomalies_detection_due_update AS
 	SELECT * FROM aws_account WHERE next_update_anomalies_detection <= NOW()
 ;
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE budget (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	name           VARCHAR(255) NOT NULL,
+	amount         DOUBLE       NOT NULL,
+	scope_type     VARCHAR(32)  NOT NULL DEFAULT "account",
+	scope_key      VARCHAR(255) NOT NULL DEFAULT "",
+	scope_value    VARCHAR(255) NOT NULL DEFAULT "",
+	created        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE emailed_budget_alert (
+	id         INTEGER      NOT NULL AUTO_INCREMENT,
+	budget_id  INTEGER      NOT NULL,
+	threshold  INTEGER      NOT NULL,
+	forecasted BOOL         NOT NULL DEFAULT 0,
+	period     DATE         NOT NULL,
+	recipient  VARCHAR(255) NOT NULL,
+	date       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_budget FOREIGN KEY (budget_id) REFERENCES budget(id) ON DELETE CASCADE
+);
+
+CREATE TABLE aws_account_anomaly_detector (
+	id             INTEGER     NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER     NOT NULL,
+	detector       VARCHAR(64) NOT NULL,
+	params         TEXT        NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_aws_account UNIQUE KEY (aws_account_id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE anomaly_feedback (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	dimension      VARCHAR(32)  NOT NULL,
+	dimension_key  VARCHAR(255) NOT NULL,
+	date           DATETIME     NOT NULL,
+	state          VARCHAR(16)  NOT NULL,
+	comment        TEXT         NOT NULL,
+	user_id        INTEGER      NOT NULL,
+	updated        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_anomaly UNIQUE KEY (aws_account_id, dimension, dimension_key, date),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE anomaly_snooze (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	dimension      VARCHAR(32)  NOT NULL,
+	dimension_key  VARCHAR(255) NOT NULL,
+	until          DATETIME     NOT NULL,
+	comment        TEXT         NOT NULL,
+	user_id        INTEGER      NOT NULL,
+	created        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_snooze UNIQUE KEY (aws_account_id, dimension, dimension_key),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE notification_endpoint (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER       NOT NULL,
+	channel        VARCHAR(16)   NOT NULL,
+	target         VARCHAR(2048) NOT NULL,
+	secret         VARCHAR(255)  NOT NULL DEFAULT "",
+	events         VARCHAR(255)  NOT NULL DEFAULT "",
+	created        TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_delivery (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER       NOT NULL,
+	event          VARCHAR(32)   NOT NULL,
+	event_key      VARCHAR(255)  NOT NULL,
+	channel        VARCHAR(16)   NOT NULL,
+	target         VARCHAR(2048) NOT NULL,
+	status         VARCHAR(16)   NOT NULL,
+	attempts       INTEGER       NOT NULL,
+	error          TEXT          NOT NULL,
+	date           TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX delivery_event_key (aws_account_id, event_key),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE currency_rate (
+	id       INTEGER   NOT NULL AUTO_INCREMENT,
+	currency CHAR(3)   NOT NULL,
+	rate     DOUBLE    NOT NULL,
+	updated  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_currency UNIQUE KEY (currency)
+);
+
+ALTER TABLE user ADD display_currency CHAR(3) NOT NULL DEFAULT "USD";
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+ALTER TABLE aws_product_pricing_ec2 ADD region_code VARCHAR(255) NOT NULL DEFAULT "";
+ALTER TABLE aws_product_pricing_ec2 ADD INDEX product_instance_type (instance_type, region);
+
+CREATE TABLE aws_product_pricing_ec2_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE aws_product_pricing_rds (
+	sku               VARCHAR(255) NOT NULL,
+	etag              VARCHAR(255) NOT NULL,
+	region            VARCHAR(255) NOT NULL,
+	region_code       VARCHAR(255) NOT NULL,
+	instance_type     VARCHAR(255) NOT NULL,
+	database_engine   VARCHAR(255) NOT NULL,
+	database_edition  VARCHAR(255) NOT NULL,
+	license_model     VARCHAR(255) NOT NULL,
+	deployment_option VARCHAR(255) NOT NULL,
+	vcpu              INTEGER      NOT NULL,
+	memory            VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_instance_type (instance_type, region_code)
+);
+
+CREATE TABLE aws_product_pricing_rds_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+CREATE TABLE aws_product_pricing_es (
+	sku           VARCHAR(255) NOT NULL,
+	etag          VARCHAR(255) NOT NULL,
+	region        VARCHAR(255) NOT NULL,
+	region_code   VARCHAR(255) NOT NULL,
+	instance_type VARCHAR(255) NOT NULL,
+	vcpu          INTEGER      NOT NULL,
+	memory        VARCHAR(255) NOT NULL,
+	storage       VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_instance_type (instance_type, region_code)
+);
+
+CREATE TABLE aws_product_pricing_es_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+CREATE TABLE aws_product_pricing_elasticache (
+	sku           VARCHAR(255) NOT NULL,
+	etag          VARCHAR(255) NOT NULL,
+	region        VARCHAR(255) NOT NULL,
+	region_code   VARCHAR(255) NOT NULL,
+	instance_type VARCHAR(255) NOT NULL,
+	cache_engine  VARCHAR(255) NOT NULL,
+	vcpu          INTEGER      NOT NULL,
+	memory        VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_instance_type (instance_type, region_code)
+);
+
+CREATE TABLE aws_product_pricing_elasticache_price (
+	sku                   VARCHAR(255) NOT NULL,
+	etag                  VARCHAR(255) NOT NULL,
+	term_type             VARCHAR(16)  NOT NULL,
+	lease_contract_length VARCHAR(16)  NOT NULL,
+	purchase_option       VARCHAR(32)  NOT NULL,
+	offering_class        VARCHAR(16)  NOT NULL,
+	hourly_price          DOUBLE       NOT NULL,
+	upfront_price         DOUBLE       NOT NULL,
+	currency              CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku, term_type, lease_contract_length, purchase_option, offering_class)
+);
+
+CREATE TABLE aws_product_pricing_ebs (
+	sku             VARCHAR(255) NOT NULL,
+	etag            VARCHAR(255) NOT NULL,
+	region          VARCHAR(255) NOT NULL,
+	region_code     VARCHAR(255) NOT NULL,
+	volume_api_name VARCHAR(255) NOT NULL,
+	volume_type     VARCHAR(255) NOT NULL,
+	max_iops        VARCHAR(255) NOT NULL,
+	max_throughput  VARCHAR(255) NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku),
+	INDEX product_volume_api_name (volume_api_name, region_code)
+);
+
+CREATE TABLE aws_product_pricing_ebs_price (
+	sku      VARCHAR(255) NOT NULL,
+	etag     VARCHAR(255) NOT NULL,
+	unit     VARCHAR(32)  NOT NULL,
+	price    DOUBLE       NOT NULL,
+	currency CHAR(3)      NOT NULL,
+	CONSTRAINT PRIMARY KEY (etag, sku)
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+ALTER TABLE aws_account_update_job
+	ADD status       VARCHAR(16) NOT NULL DEFAULT "queued",
+	ADD attempts     INTEGER     NOT NULL DEFAULT 0,
+	ADD max_attempts INTEGER     NOT NULL DEFAULT 5,
+	ADD available    DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ADD expired      DATETIME    NOT NULL DEFAULT "1970-01-01 00:00:00",
+	ADD INDEX job_status (status, available);
+
+ALTER TABLE aws_account_plugins_job
+	ADD status       VARCHAR(16) NOT NULL DEFAULT "queued",
+	ADD attempts     INTEGER     NOT NULL DEFAULT 0,
+	ADD max_attempts INTEGER     NOT NULL DEFAULT 5,
+	ADD available    DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ADD expired      DATETIME    NOT NULL DEFAULT "1970-01-01 00:00:00",
+	ADD INDEX job_status (status, available);
+
+ALTER TABLE aws_account_reports_job
+	ADD status       VARCHAR(16) NOT NULL DEFAULT "queued",
+	ADD attempts     INTEGER     NOT NULL DEFAULT 0,
+	ADD max_attempts INTEGER     NOT NULL DEFAULT 5,
+	ADD available    DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ADD expired      DATETIME    NOT NULL DEFAULT "1970-01-01 00:00:00",
+	ADD INDEX job_status (status, available);
+
+UPDATE aws_account_update_job SET status = "done", attempts = 1;
+UPDATE aws_account_plugins_job SET status = "done", attempts = 1;
+UPDATE aws_account_reports_job SET status = "done", attempts = 1;
+
+CREATE TABLE aws_account_anomalies_job (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	created        TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	aws_account_id INTEGER       NOT NULL,
+	completed      TIMESTAMP     NOT NULL DEFAULT 0,
+	worker_id      VARCHAR(255)  NOT NULL DEFAULT "",
+	jobError       VARCHAR(255)  NOT NULL DEFAULT "",
+	status         VARCHAR(16)   NOT NULL DEFAULT "queued",
+	attempts       INTEGER       NOT NULL DEFAULT 0,
+	max_attempts   INTEGER       NOT NULL DEFAULT 5,
+	available      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expired        DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	INDEX job_status (status, available)
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE periodic_task_run (
+	id          INTEGER       NOT NULL AUTO_INCREMENT,
+	task        VARCHAR(255)  NOT NULL,
+	backend_id  VARCHAR(255)  NOT NULL DEFAULT "",
+	started     DATETIME(3)   NOT NULL,
+	duration_ms BIGINT        NOT NULL,
+	error       VARCHAR(255)  NOT NULL DEFAULT "",
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX task_started (task, started)
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+
+CREATE TABLE leader_lease (
+	name    VARCHAR(64)   NOT NULL,
+	holder  VARCHAR(255)  NOT NULL,
+	expires DATETIME(3)   NOT NULL,
+	CONSTRAINT PRIMARY KEY (name)
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE api_key (
+	id        INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id   INTEGER      NOT NULL,
+	name      VARCHAR(255) NOT NULL,
+	prefix    VARCHAR(16)  NOT NULL,
+	hash      CHAR(64)     NOT NULL,
+	read_only BOOLEAN      NOT NULL DEFAULT 0,
+	created   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires   DATETIME     NULL DEFAULT NULL,
+	last_used DATETIME     NULL DEFAULT NULL,
+	revoked   DATETIME     NULL DEFAULT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_hash UNIQUE KEY (hash),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE api_key_aws_account (
+	api_key_id     INTEGER NOT NULL,
+	aws_account_id INTEGER NOT NULL,
+	CONSTRAINT PRIMARY KEY (api_key_id, aws_account_id),
+	CONSTRAINT foreign_api_key FOREIGN KEY (api_key_id) REFERENCES api_key(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE user_session (
+	id           INTEGER   NOT NULL AUTO_INCREMENT,
+	user_id      INTEGER   NOT NULL,
+	refresh_hash CHAR(64)  NOT NULL,
+	created      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires      DATETIME  NOT NULL,
+	revoked      DATETIME  NULL DEFAULT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_refresh_hash UNIQUE KEY (refresh_hash),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_session_rotated_refresh_hash (
+	refresh_hash CHAR(64) NOT NULL,
+	session_id   INTEGER  NOT NULL,
+	CONSTRAINT PRIMARY KEY (refresh_hash),
+	CONSTRAINT foreign_session FOREIGN KEY (session_id) REFERENCES user_session(id) ON DELETE CASCADE
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE oidc_login (
+	id            INTEGER      NOT NULL AUTO_INCREMENT,
+	state_hash    CHAR(64)     NOT NULL,
+	code_verifier VARCHAR(128) NOT NULL,
+	nonce         VARCHAR(64)  NOT NULL,
+	created       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires       DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_state_hash UNIQUE KEY (state_hash)
+);
+
+CREATE TABLE user_oidc_identity (
+	id      INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id INTEGER      NOT NULL,
+	issuer  VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	created TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_issuer_subject UNIQUE KEY (issuer, subject),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+--   Copyright 2018 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+ALTER TABLE notification_delivery
+	ADD payload MEDIUMTEXT NOT NULL,
+	ADD INDEX delivery_status (aws_account_id, status);
+
+CREATE TABLE aws_account_notifications_job (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	created        TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	aws_account_id INTEGER       NOT NULL,
+	completed      TIMESTAMP     NOT NULL DEFAULT 0,
+	worker_id      VARCHAR(255)  NOT NULL DEFAULT "",
+	jobError       VARCHAR(255)  NOT NULL DEFAULT "",
+	status         VARCHAR(16)   NOT NULL DEFAULT "queued",
+	attempts       INTEGER       NOT NULL DEFAULT 0,
+	max_attempts   INTEGER       NOT NULL DEFAULT 5,
+	available      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expired        DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	INDEX job_status (status, available)
+);
@@ -78,13 +78,16 @@ func getAllAccountsAndIndexes(user users.User, tx *sql.Tx, indexPrefix string) (
 	}
 	// Add all the user accounts
 	for _, userAccount := range userAccounts {
+		if !user.CanAccessAwsAccount(userAccount.ID) {
+			continue
+		}
 		accountsAndIndexes.addAccount(userAccount.AwsIdentity)
 		accountsAndIndexes.addIndex(IndexNameForUserId(userAccount.UserID, indexPrefix))
 	}
 	// Add all the non duplicate shared accounts
 	for _, sharedAccount := range sharedAccounts {
 		// Do not add the account if the user already own the same account
-		if accountsAndIndexes.isAccountDuplicate(sharedAccount.AwsIdentity) == false {
+		if accountsAndIndexes.isAccountDuplicate(sharedAccount.AwsIdentity) == false && user.CanAccessAwsAccount(sharedAccount.AccountID) {
 			accountsAndIndexes.addAccount(sharedAccount.AwsIdentity)
 			accountsAndIndexes.addIndex(IndexNameForUserId(sharedAccount.OwnerID, indexPrefix))
 		}
@@ -122,7 +125,7 @@ func GetAccountsAndIndexes(accountList []string, user users.User, tx *sql.Tx, in
 		found_match := false
 		// Try to match in priority with the user's accounts
 		for _, userAccount := range userAccounts {
-			if userAccount.AwsIdentity == account {
+			if userAccount.AwsIdentity == account && user.CanAccessAwsAccount(userAccount.ID) {
 				found_match = true
 				accountsAndIndexes.addAccount(userAccount.AwsIdentity)
 				accountsAndIndexes.addIndex(IndexNameForUserId(userAccount.UserID, indexPrefix))
@@ -131,7 +134,7 @@ func GetAccountsAndIndexes(accountList []string, user users.User, tx *sql.Tx, in
 		// If no match is found in the user's accounts, try in the shared accounts
 		if found_match == false {
 			for _, sharedAccount := range sharedAccounts {
-				if sharedAccount.AwsIdentity == account {
+				if sharedAccount.AwsIdentity == account && user.CanAccessAwsAccount(sharedAccount.AccountID) {
 					found_match = true
 					if accountsAndIndexes.isAccountDuplicate(sharedAccount.AwsIdentity) == false {
 						accountsAndIndexes.addAccount(sharedAccount.AwsIdentity)
@@ -70,7 +70,7 @@ func NewSignedElasticClientOptions(endpoint string, creds *credentials.Credentia
 	} else {
 		return []elastic.ClientOptionFunc{
 			elastic.SetScheme("https"),
-			elastic.SetHttpClient(httpClient),
+			elastic.SetHttpClient(instrumentHttpClient(httpClient)),
 			elastic.SetSniff(false),
 		}, nil
 	}
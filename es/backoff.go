@@ -0,0 +1,44 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package es
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// contextBackoff is an elastic.Backoff which stops retrying once its context
+// is done.
+type contextBackoff struct {
+	ctx     context.Context
+	backoff elastic.Backoff
+}
+
+// Next implements elastic.Backoff.
+func (b contextBackoff) Next(retry int) (time.Duration, bool) {
+	if b.ctx.Err() != nil {
+		return 0, false
+	}
+	return b.backoff.Next(retry)
+}
+
+// BulkBackoff returns the backoff of bulk processors. It is the default
+// exponential backoff of elastic, except that failed bulks are not retried
+// once ctx is done.
+func BulkBackoff(ctx context.Context) elastic.Backoff {
+	return contextBackoff{ctx, elastic.NewExponentialBackoff(200*time.Millisecond, 10*time.Second)}
+}
@@ -17,6 +17,7 @@ package es
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -59,6 +60,7 @@ func init() {
 func getElasticSearchConfig() []elastic.ClientOptionFunc {
 	return []elastic.ClientOptionFunc{
 		getElasticSearchUrlConfig(),
+		elastic.SetHttpClient(instrumentHttpClient(http.DefaultClient)),
 		getElasticSearchAuthConfig(),
 	}
 }
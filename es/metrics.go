@@ -0,0 +1,102 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package es
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/trackit-server/metrics"
+)
+
+var (
+	requestDuration = metrics.NewHistogram(
+		"trackit_es_request_duration_seconds",
+		"Time taken by ElasticSearch requests, by operation and status code.",
+		metrics.DefaultBuckets,
+		"operation", "status",
+	)
+	bulkItems = metrics.NewCounter(
+		"trackit_es_bulk_items_total",
+		"Number of documents sent in bulk requests, by component and result.",
+		"component", "result",
+	)
+	bulkFailures = metrics.NewCounter(
+		"trackit_es_bulk_failures_total",
+		"Number of bulk requests which failed as a whole, by component.",
+		"component",
+	)
+)
+
+// instrumentedTransport is an http.RoundTripper recording the latency of
+// ElasticSearch requests.
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t instrumentedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(r)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+	requestDuration.Observe(time.Since(start).Seconds(), operation(r), status)
+	return res, err
+}
+
+// instrumentHttpClient returns a copy of an http.Client whose requests are
+// recorded in the metrics.
+func instrumentHttpClient(c *http.Client) *http.Client {
+	instrumented := *c
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	instrumented.Transport = instrumentedTransport{next}
+	return &instrumented
+}
+
+// operation names the ElasticSearch API called by a request after the last
+// component of its path starting with an underscore, such as "_search" or
+// "_bulk", falling back on the method for document and health check
+// requests.
+func operation(r *http.Request) string {
+	parts := strings.Split(r.URL.Path, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if strings.HasPrefix(parts[i], "_") {
+			return parts[i]
+		}
+	}
+	return r.Method
+}
+
+// RecordBulk records the result of a bulk request sent by a bulk processor of
+// a component, to be used in its After callback.
+func RecordBulk(component string, reqs []elastic.BulkableRequest, res *elastic.BulkResponse, err error) {
+	if err != nil || res == nil {
+		bulkFailures.Inc(component)
+		bulkItems.Add(float64(len(reqs)), component, "failed")
+		return
+	}
+	failed := len(res.Failed())
+	bulkItems.Add(float64(len(reqs)-failed), component, "indexed")
+	bulkItems.Add(float64(failed), component, "failed")
+}
@@ -0,0 +1,43 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOperation(t *testing.T) {
+	for _, c := range []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{http.MethodPost, "/123-lineitems/_search", "_search"},
+		{http.MethodPost, "/_bulk", "_bulk"},
+		{http.MethodPost, "/_msearch", "_msearch"},
+		{http.MethodGet, "/_nodes/http", "_nodes"},
+		{http.MethodPost, "/123-lineitems/_delete_by_query", "_delete_by_query"},
+		{http.MethodPut, "/_template/lineitems", "_template"},
+		{http.MethodGet, "/123-lineitems/lineitem/abc", http.MethodGet},
+		{http.MethodHead, "/", http.MethodHead},
+	} {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		if got := operation(r); got != c.expected {
+			t.Errorf("%s %s: expected %q, got %q", c.method, c.path, c.expected, got)
+		}
+	}
+}
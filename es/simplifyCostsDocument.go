@@ -67,6 +67,24 @@ const (
 	BucketKeyAsStringKey = "key_as_string"
 	BucketValueKey       = "value"
 	BucketValueValueKey  = "value"
+	BucketDocCountKey    = "doc_count"
+
+	// TagAggregationTagged, TagAggregationKey, TagAggregationValues and
+	// TagAggregationReverse are the names of the aggregations leading to
+	// the buckets of tag values in a tag aggregation.
+	TagAggregationTagged  = "tagged"
+	TagAggregationKey     = "tag-key"
+	TagAggregationValues  = "tag-values"
+	TagAggregationReverse = "tag-reverse"
+	// TagAggregationUntagged is the name of the aggregation of documents
+	// which do not have the tag in a tag aggregation.
+	TagAggregationUntagged = "untagged"
+	// UntaggedBucketKey is the key given to the bucket of documents which do
+	// not have the tag in a tag aggregation. AWS allows parentheses in tag
+	// values, so a tag whose value is "(untagged)" collides with it: both
+	// buckets get the same key in the simplified document and only one of
+	// them is kept.
+	UntaggedBucketKey = "(untagged)"
 )
 
 var (
@@ -164,7 +182,7 @@ func getChildren(ctx context.Context, doc bucket) (string, []SimplifiedCostsDocu
 		logger.Error(fmt.Sprintf("Failed to get buckets: value under '%s' is not an aggregation.", childKey), nil)
 		logger.Debug("Document is.", doc)
 		return "", nil, ErrFailedJsonParsing
-	} else if childAggsBuckets, ok := childAgg[AggBucketKey]; !ok {
+	} else if childAggsBuckets, ok := getBuckets(childAgg); !ok {
 		logger.Error(fmt.Sprintf("Failed to get buckets: value under '%s' does not have '%s' field.", childKey, AggBucketKey), nil)
 		logger.Debug("Document is.", doc)
 		return "", nil, ErrFailedJsonParsing
@@ -205,3 +223,56 @@ func getChildKey(ctx context.Context, doc map[string]interface{}) (string, error
 	}
 	return childKey, nil
 }
+
+// getBuckets returns the buckets of an aggregation. Tag aggregations have
+// their branches merged into a single slice of buckets.
+func getBuckets(agg aggregation) (interface{}, bool) {
+	if buckets, ok := agg[AggBucketKey]; ok {
+		return buckets, true
+	} else if _, ok := agg[TagAggregationTagged]; ok {
+		return getTagBuckets(agg)
+	}
+	return nil, false
+}
+
+// getTagBuckets flattens a tag aggregation into a slice of buckets, one for
+// each value of the tag. The documents which do not have the tag are put in
+// a bucket with the UntaggedBucketKey key, if there are any.
+func getTagBuckets(agg aggregation) (interface{}, bool) {
+	tagged, ok := agg[TagAggregationTagged].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	key, ok := tagged[TagAggregationKey].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	values, ok := key[TagAggregationValues].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	valuesBuckets, ok := values[AggBucketKey].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	buckets := make([]interface{}, 0, len(valuesBuckets)+1)
+	for _, valueBucket := range valuesBuckets {
+		tvalueBucket, ok := valueBucket.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		reverse, ok := tvalueBucket[TagAggregationReverse].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		reverse[BucketKeyKey] = tvalueBucket[BucketKeyKey]
+		buckets = append(buckets, reverse)
+	}
+	if untagged, ok := agg[TagAggregationUntagged].(map[string]interface{}); ok {
+		if count, ok := untagged[BucketDocCountKey].(float64); ok && count > 0 {
+			untagged[BucketKeyKey] = UntaggedBucketKey
+			buckets = append(buckets, untagged)
+		}
+	}
+	return buckets, true
+}
@@ -15,8 +15,11 @@
 package es
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+
+	"gopkg.in/olivere/elastic.v5"
 )
 
 func TestToJsonableEmptySimplifiedCostsDocument(t *testing.T) {
@@ -129,3 +132,53 @@ func TestToJsonableSimpleTwoLevelsResults(t *testing.T) {
 		t.Fatalf("Expected %s but got %s", expectedResult, string(marshalled))
 	}
 }
+
+func TestSimplifyCostsDocumentWithTagAggregation(t *testing.T) {
+	rawAggregation := json.RawMessage(`{
+	"buckets": [
+		{
+			"key_as_string": "2018-01-01T00:00:00.000Z",
+			"key": 1514764800000,
+			"doc_count": 6,
+			"by-tag:Team": {
+				"doc_count": 6,
+				"tagged": {
+					"doc_count": 8,
+					"tag-key": {
+						"doc_count": 4,
+						"tag-values": {
+							"buckets": [
+								{"key": "backend", "doc_count": 3, "tag-reverse": {"doc_count": 3, "value": {"value": 30}}},
+								{"key": "frontend", "doc_count": 1, "tag-reverse": {"doc_count": 1, "value": {"value": 10}}}
+							]
+						}
+					}
+				},
+				"untagged": {"doc_count": 2, "value": {"value": 5}}
+			}
+		}
+	]
+}`)
+	sr := &elastic.SearchResult{
+		Aggregations: elastic.Aggregations{"by-month": &rawAggregation},
+	}
+	scd, err := SimplifyCostsDocument(context.Background(), sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedResult := `{
+	"month": {
+		"2018-01-01T00:00:00.000Z": {
+			"tag:Team": {
+				"(untagged)": 5,
+				"backend": 30,
+				"frontend": 10
+			}
+		}
+	}
+}`
+	marshalled, _ := json.MarshalIndent(scd.ToJsonable(), "", "\t")
+	if string(marshalled) != expectedResult {
+		t.Fatalf("Expected %s but got %s", expectedResult, string(marshalled))
+	}
+}
@@ -0,0 +1,285 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package jobs implements a durable queue of jobs run on AWS accounts. Jobs
+// are stored in the aws_*_job tables, claimed by workers for a renewable
+// lease and retried with an exponential backoff until they are set aside as
+// dead.
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const (
+	// StatusQueued is the status of a job waiting to be claimed.
+	StatusQueued = "queued"
+	// StatusRunning is the status of a job claimed by a worker.
+	StatusRunning = "running"
+	// StatusDone is the status of a job which succeeded.
+	StatusDone = "done"
+	// StatusDead is the status of a job which failed too many times.
+	StatusDead = "dead"
+	// StatusCancelled is the status of a job interrupted by the shutdown
+	// of its worker. It may be claimed again at once.
+	StatusCancelled = "cancelled"
+
+	backoffBase = time.Minute
+	backoffMax  = time.Hour
+
+	// claimCandidates is the number of jobs a worker tries to claim at
+	// once, since other workers may claim them first.
+	claimCandidates = 8
+
+	// maxErrorLength is the size of the jobError column.
+	maxErrorLength = 255
+)
+
+var (
+	// ErrLeaseLost is returned when a job is no longer claimed by the
+	// worker, because its lease expired and another worker claimed it.
+	ErrLeaseLost = errors.New("job lease lost")
+	// ErrCancelled is the result of a job interrupted by the shutdown of
+	// its worker.
+	ErrCancelled = errors.New("job cancelled")
+)
+
+// Queue is a kind of job, stored in its own table.
+type Queue struct {
+	Name  string
+	Table string
+}
+
+var (
+	// ProcessAccount retrieves the data of an account from the AWS API.
+	ProcessAccount = Queue{"process-account", "aws_account_update_job"}
+	// ProcessAccountPlugins runs the account plugins.
+	ProcessAccountPlugins = Queue{"process-account-plugins", "aws_account_plugins_job"}
+	// AnomaliesDetection detects cost anomalies.
+	AnomaliesDetection = Queue{"anomalies-detection", "aws_account_anomalies_job"}
+	// GenerateSpreadsheet generates the monthly spreadsheet report.
+	GenerateSpreadsheet = Queue{"generate-spreadsheet", "aws_account_reports_job"}
+	// SendNotifications sends the pending notification deliveries.
+	SendNotifications = Queue{"send-notifications", "aws_account_notifications_job"}
+
+	// Queues lists all the queues.
+	Queues = []Queue{ProcessAccount, ProcessAccountPlugins, AnomaliesDetection, GenerateSpreadsheet, SendNotifications}
+)
+
+// QueueByName returns the queue with a given name.
+func QueueByName(name string) (Queue, bool) {
+	for _, q := range Queues {
+		if q.Name == name {
+			return q, true
+		}
+	}
+	return Queue{}, false
+}
+
+// Job is a job claimed by a worker. Attempts includes the current attempt.
+type Job struct {
+	Id           int64
+	Queue        Queue
+	AwsAccountId int
+	WorkerId     string
+	Attempts     int
+	MaxAttempts  int
+}
+
+// Backoff returns the delay before a job is retried after a number of failed
+// attempts. It doubles after each attempt, up to an hour.
+func Backoff(attempts int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempts && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	return delay
+}
+
+// outcome returns the status of a job after an attempt and when it may be
+// attempted again.
+func outcome(job Job, jobErr error, now time.Time) (string, time.Time) {
+	if jobErr == nil {
+		return StatusDone, now
+	} else if jobErr == ErrCancelled {
+		return StatusCancelled, now
+	} else if job.Attempts >= job.MaxAttempts {
+		return StatusDead, now
+	}
+	return StatusQueued, now.Add(Backoff(job.Attempts))
+}
+
+// errorString formats a job error to fit in the jobError column.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	} else if msg := err.Error(); len(msg) > maxErrorLength {
+		return msg[:maxErrorLength]
+	} else {
+		return msg
+	}
+}
+
+// Enqueue adds a job for an AWS account to a queue.
+func Enqueue(db models.XODB, q Queue, aaId int, maxAttempts int, now time.Time) (int64, error) {
+	sqlstr := `INSERT INTO ` + q.Table + ` (` +
+		`aws_account_id, worker_id, status, max_attempts, available` +
+		`) VALUES (?, "", ?, ?, ?)`
+	models.XOLog(sqlstr, aaId, StatusQueued, maxAttempts, now)
+	res, err := db.Exec(sqlstr, aaId, StatusQueued, maxAttempts, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// claimCandidate is a job which may be claimed.
+type claimCandidate struct {
+	Job
+	status string
+}
+
+// Claim claims the next job of a queue for a worker. Queued and cancelled
+// jobs are claimed once they are available, running jobs once their lease
+// expired. It returns false if there is no job to claim.
+func Claim(db models.XODB, q Queue, workerId string, lease time.Duration, now time.Time) (Job, bool, error) {
+	sqlstr := `SELECT id, aws_account_id, attempts, max_attempts, status ` +
+		`FROM ` + q.Table + ` ` +
+		`WHERE (status IN (?, ?) AND available <= ?) OR (status = ? AND expired <= ?) ` +
+		`ORDER BY available, id LIMIT ` + fmt.Sprint(claimCandidates)
+	models.XOLog(sqlstr, StatusQueued, StatusCancelled, now, StatusRunning, now)
+	rows, err := db.Query(sqlstr, StatusQueued, StatusCancelled, now, StatusRunning, now)
+	if err != nil {
+		return Job{}, false, err
+	}
+	var candidates []claimCandidate
+	for rows.Next() {
+		c := claimCandidate{Job: Job{Queue: q}}
+		if err = rows.Scan(&c.Id, &c.AwsAccountId, &c.Attempts, &c.MaxAttempts, &c.status); err != nil {
+			rows.Close()
+			return Job{}, false, err
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	for _, c := range candidates {
+		if c.status == StatusRunning && c.Attempts >= c.MaxAttempts {
+			if err = bury(db, c, now); err != nil {
+				return Job{}, false, err
+			}
+			continue
+		}
+		job, ok, err := claim(db, c, workerId, lease, now)
+		if err != nil || ok {
+			return job, ok, err
+		}
+	}
+	return Job{}, false, nil
+}
+
+// ClaimId claims a given queued job for a worker, regardless of when it is
+// available. It returns false if the job is not queued.
+func ClaimId(db models.XODB, q Queue, id int64, workerId string, lease time.Duration, now time.Time) (Job, bool, error) {
+	sqlstr := `SELECT id, aws_account_id, attempts, max_attempts, status ` +
+		`FROM ` + q.Table + ` ` +
+		`WHERE id = ?`
+	models.XOLog(sqlstr, id)
+	c := claimCandidate{Job: Job{Queue: q}}
+	err := db.QueryRow(sqlstr, id).Scan(&c.Id, &c.AwsAccountId, &c.Attempts, &c.MaxAttempts, &c.status)
+	if err == sql.ErrNoRows {
+		return Job{}, false, nil
+	} else if err != nil || c.status != StatusQueued {
+		return Job{}, false, err
+	}
+	return claim(db, c, workerId, lease, now)
+}
+
+// claim claims a candidate job unless another worker claimed it first.
+func claim(db models.XODB, c claimCandidate, workerId string, lease time.Duration, now time.Time) (Job, bool, error) {
+	sqlstr := `UPDATE ` + c.Queue.Table + ` SET ` +
+		`status = ?, worker_id = ?, attempts = attempts + 1, expired = ? ` +
+		`WHERE id = ? AND status = ? AND attempts = ?`
+	models.XOLog(sqlstr, StatusRunning, workerId, now.Add(lease), c.Id, c.status, c.Attempts)
+	res, err := db.Exec(sqlstr, StatusRunning, workerId, now.Add(lease), c.Id, c.status, c.Attempts)
+	if err != nil {
+		return Job{}, false, err
+	} else if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return Job{}, false, err
+	}
+	job := c.Job
+	job.WorkerId = workerId
+	job.Attempts++
+	return job, true, nil
+}
+
+// bury sets aside a job whose last attempt expired.
+func bury(db models.XODB, c claimCandidate, now time.Time) error {
+	sqlstr := `UPDATE ` + c.Queue.Table + ` SET ` +
+		`status = ?, completed = ?, jobError = ? ` +
+		`WHERE id = ? AND status = ? AND attempts = ?`
+	models.XOLog(sqlstr, StatusDead, now, ErrLeaseLost.Error(), c.Id, c.status, c.Attempts)
+	_, err := db.Exec(sqlstr, StatusDead, now, ErrLeaseLost.Error(), c.Id, c.status, c.Attempts)
+	return err
+}
+
+// Renew extends the lease of a job. It returns ErrLeaseLost if the worker no
+// longer holds the job.
+func Renew(db models.XODB, job Job, lease time.Duration, now time.Time) error {
+	sqlstr := `UPDATE ` + job.Queue.Table + ` SET ` +
+		`expired = ? ` +
+		`WHERE id = ? AND worker_id = ? AND status = ? AND attempts = ?`
+	models.XOLog(sqlstr, now.Add(lease), job.Id, job.WorkerId, StatusRunning, job.Attempts)
+	res, err := db.Exec(sqlstr, now.Add(lease), job.Id, job.WorkerId, StatusRunning, job.Attempts)
+	if err != nil {
+		return err
+	} else if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n != 1 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Complete records the result of an attempt. A failed job is queued again
+// after a backoff, or set aside as dead after its last attempt. A job failing
+// with ErrCancelled is marked as cancelled, to be claimed again at once. It returns
+// ErrLeaseLost if the worker no longer holds the job.
+func Complete(db models.XODB, job Job, jobErr error, now time.Time) error {
+	status, available := outcome(job, jobErr, now)
+	var completed interface{} = now
+	if status == StatusQueued || status == StatusCancelled {
+		completed = time.Time{}
+	}
+	sqlstr := `UPDATE ` + job.Queue.Table + ` SET ` +
+		`status = ?, available = ?, completed = ?, jobError = ? ` +
+		`WHERE id = ? AND worker_id = ? AND status = ? AND attempts = ?`
+	models.XOLog(sqlstr, status, available, completed, errorString(jobErr), job.Id, job.WorkerId, StatusRunning, job.Attempts)
+	res, err := db.Exec(sqlstr, status, available, completed, errorString(jobErr), job.Id, job.WorkerId, StatusRunning, job.Attempts)
+	if err != nil {
+		return err
+	} else if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n != 1 {
+		return ErrLeaseLost
+	}
+	return nil
+}
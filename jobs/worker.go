@@ -0,0 +1,213 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+// defaultPollInterval is the time a worker waits when no job is available.
+const defaultPollInterval = 10 * time.Second
+
+// Handler runs a job. Its context is cancelled if the worker loses the lease
+// of the job.
+type Handler func(context.Context, Job) error
+
+// store holds the jobs of the queues.
+type store interface {
+	claim(q Queue, workerId string, lease time.Duration, now time.Time) (Job, bool, error)
+	renew(job Job, lease time.Duration, now time.Time) error
+	complete(job Job, jobErr error, now time.Time) error
+}
+
+// dbStore stores jobs in the database.
+type dbStore struct {
+	db models.XODB
+}
+
+func (s dbStore) claim(q Queue, workerId string, lease time.Duration, now time.Time) (Job, bool, error) {
+	return Claim(s.db, q, workerId, lease, now)
+}
+
+func (s dbStore) renew(job Job, lease time.Duration, now time.Time) error {
+	return Renew(s.db, job, lease, now)
+}
+
+func (s dbStore) complete(job Job, jobErr error, now time.Time) error {
+	return Complete(s.db, job, jobErr, now)
+}
+
+// Worker claims jobs from the queues it has handlers for and runs up to
+// Concurrency of them at once.
+type Worker struct {
+	Id           string
+	Concurrency  int
+	Lease        time.Duration
+	PollInterval time.Duration
+	Handlers     map[Queue]Handler
+	store        store
+	now          func() time.Time
+}
+
+// NewWorker creates a worker using the database to store jobs.
+func NewWorker(db models.XODB, id string, concurrency int, lease time.Duration, handlers map[Queue]Handler) *Worker {
+	return &Worker{
+		Id:           id,
+		Concurrency:  concurrency,
+		Lease:        lease,
+		PollInterval: defaultPollInterval,
+		Handlers:     handlers,
+		store:        dbStore{db},
+		now:          time.Now,
+	}
+}
+
+// queues returns the queues the worker handles, in a stable order.
+func (w *Worker) queues() []Queue {
+	queues := make([]Queue, 0, len(w.Handlers))
+	for _, q := range Queues {
+		if _, ok := w.Handlers[q]; ok {
+			queues = append(queues, q)
+		}
+	}
+	return queues
+}
+
+// Run claims and runs jobs until the context is cancelled, then waits for the
+// running jobs to end. Their contexts are cancelled too, and those which fail
+// because of it are marked as cancelled.
+func (w *Worker) Run(ctx context.Context) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	queues := w.queues()
+	if len(queues) == 0 || w.Concurrency < 1 {
+		return fmt.Errorf("worker has no queue or no concurrency")
+	}
+	slots := make(chan struct{}, w.Concurrency)
+	var running sync.WaitGroup
+	defer running.Wait()
+	next := 0
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		job, ok, err := w.claimNext(queues, &next)
+		if err != nil {
+			logger.Error("Failed to claim job.", err.Error())
+		}
+		if !ok {
+			<-slots
+			select {
+			case <-time.After(w.PollInterval):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		running.Add(1)
+		go func(job Job) {
+			defer running.Done()
+			defer func() { <-slots }()
+			w.Execute(ctx, job)
+		}(job)
+	}
+}
+
+// claimNext claims a job from the queues, starting after the last queue a
+// job was claimed from so that no queue starves the others.
+func (w *Worker) claimNext(queues []Queue, next *int) (Job, bool, error) {
+	var firstErr error
+	for i := range queues {
+		q := queues[(*next+i)%len(queues)]
+		job, ok, err := w.store.claim(q, w.Id, w.Lease, w.now())
+		if err != nil && firstErr == nil {
+			firstErr = err
+		} else if ok {
+			*next = (*next + i + 1) % len(queues)
+			return job, true, nil
+		}
+	}
+	return Job{}, false, firstErr
+}
+
+// Execute runs a claimed job, renewing its lease while it runs, and records
+// its result. It returns the error of the job, or ErrCancelled if the job
+// failed because ctx was cancelled.
+func (w *Worker) Execute(ctx context.Context, job Job) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go w.renewLease(jobCtx, cancel, job, done)
+	logger.Info("Running job.", job)
+	err := w.handle(jobCtx, job)
+	if err != nil && ctx.Err() != nil {
+		err = ErrCancelled
+	}
+	if err != nil {
+		logger.Error("Job failed.", map[string]interface{}{
+			"job":   job,
+			"error": err.Error(),
+		})
+	}
+	if err := w.store.complete(job, err, w.now()); err != nil {
+		logger.Error("Failed to complete job.", map[string]interface{}{
+			"job":   job,
+			"error": err.Error(),
+		})
+	}
+	return err
+}
+
+// handle runs the handler of a job, turning panics into errors.
+func (w *Worker) handle(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("job panicked: %v", rec)
+		}
+	}()
+	return w.Handlers[job.Queue](ctx, job)
+}
+
+// renewLease renews the lease of a job until it is done. If the lease is
+// lost the job is cancelled.
+func (w *Worker) renewLease(ctx context.Context, cancel context.CancelFunc, job Job, done <-chan struct{}) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	ticker := time.NewTicker(w.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.store.renew(job, w.Lease, w.now()); err == ErrLeaseLost {
+				logger.Error("Lost job lease.", job)
+				cancel()
+				return
+			} else if err != nil {
+				logger.Warning("Failed to renew job lease.", err.Error())
+			}
+		case <-done:
+			return
+		}
+	}
+}
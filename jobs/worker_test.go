@@ -0,0 +1,289 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	expected := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		6:  32 * time.Minute,
+		7:  time.Hour,
+		50: time.Hour,
+	}
+	for attempts, delay := range expected {
+		if got := Backoff(attempts); got != delay {
+			t.Errorf("Backoff(%d): expected %s, got %s", attempts, delay, got)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("failure")
+	for _, c := range []struct {
+		attempts  int
+		err       error
+		status    string
+		available time.Time
+	}{
+		{1, nil, StatusDone, now},
+		{1, failure, StatusQueued, now.Add(time.Minute)},
+		{3, failure, StatusQueued, now.Add(4 * time.Minute)},
+		{5, failure, StatusDead, now},
+		{2, ErrCancelled, StatusCancelled, now},
+		{5, ErrCancelled, StatusCancelled, now},
+	} {
+		job := Job{Attempts: c.attempts, MaxAttempts: 5}
+		status, available := outcome(job, c.err, now)
+		if status != c.status || !available.Equal(c.available) {
+			t.Errorf("Attempt %d with error %v: expected %s at %s, got %s at %s", c.attempts, c.err, c.status, c.available, status, available)
+		}
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	long := make([]byte, 300)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if got := errorString(nil); got != "" {
+		t.Errorf("Expected empty string for no error, got %q", got)
+	}
+	if got := errorString(errors.New(string(long))); len(got) != maxErrorLength {
+		t.Errorf("Expected error truncated to %d, got %d", maxErrorLength, len(got))
+	}
+}
+
+// fakeJob is a job stored by fakeStore.
+type fakeJob struct {
+	Job
+	status    string
+	available time.Time
+}
+
+// fakeStore stores jobs in memory, following the same rules as the database.
+type fakeStore struct {
+	sync.Mutex
+	jobs []*fakeJob
+}
+
+func (s *fakeStore) add(q Queue, aaId int, maxAttempts int) {
+	s.Lock()
+	defer s.Unlock()
+	s.jobs = append(s.jobs, &fakeJob{
+		Job:    Job{Id: int64(len(s.jobs) + 1), Queue: q, AwsAccountId: aaId, MaxAttempts: maxAttempts},
+		status: StatusQueued,
+	})
+}
+
+func (s *fakeStore) claim(q Queue, workerId string, lease time.Duration, now time.Time) (Job, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	for _, j := range s.jobs {
+		if j.Queue == q && (j.status == StatusQueued || j.status == StatusCancelled) && !j.available.After(now) {
+			j.status = StatusRunning
+			j.WorkerId = workerId
+			j.Attempts++
+			return j.Job, true, nil
+		}
+	}
+	return Job{}, false, nil
+}
+
+func (s *fakeStore) renew(job Job, lease time.Duration, now time.Time) error {
+	return nil
+}
+
+func (s *fakeStore) complete(job Job, jobErr error, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	j := s.jobs[job.Id-1]
+	j.status, j.available = outcome(job, jobErr, now)
+	return nil
+}
+
+// settled returns whether all the jobs are done or dead.
+func (s *fakeStore) settled() bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, j := range s.jobs {
+		if j.status != StatusDone && j.status != StatusDead {
+			return false
+		}
+	}
+	return true
+}
+
+// runUntilSettled runs a worker until all the jobs of its store are settled.
+func runUntilSettled(t *testing.T, w *Worker, s *fakeStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() { result <- w.Run(ctx) }()
+	deadline := time.After(5 * time.Second)
+	for !s.settled() {
+		select {
+		case <-deadline:
+			cancel()
+			<-result
+			t.Fatal("Jobs did not settle in time")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-result; err != nil {
+		t.Errorf("Worker failed: %s", err.Error())
+	}
+}
+
+// newTestWorker creates a worker whose clock moves an hour forward at each
+// reading so that failed jobs are retried at once.
+func newTestWorker(s *fakeStore, concurrency int, handlers map[Queue]Handler) *Worker {
+	var mutex sync.Mutex
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &Worker{
+		Id:           "test",
+		Concurrency:  concurrency,
+		Lease:        time.Minute,
+		PollInterval: time.Millisecond,
+		Handlers:     handlers,
+		store:        s,
+		now: func() time.Time {
+			mutex.Lock()
+			defer mutex.Unlock()
+			now = now.Add(time.Hour)
+			return now
+		},
+	}
+}
+
+func TestWorkerBoundedConcurrency(t *testing.T) {
+	const concurrency = 3
+	s := &fakeStore{}
+	for i := 0; i < 20; i++ {
+		s.add(ProcessAccount, i, 5)
+		s.add(GenerateSpreadsheet, i, 5)
+	}
+	var mutex sync.Mutex
+	var running, maxRunning, runs int
+	handler := func(ctx context.Context, job Job) error {
+		mutex.Lock()
+		running++
+		runs++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(2 * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return nil
+	}
+	w := newTestWorker(s, concurrency, map[Queue]Handler{
+		ProcessAccount:      handler,
+		GenerateSpreadsheet: handler,
+	})
+	runUntilSettled(t, w, s)
+	if maxRunning > concurrency {
+		t.Errorf("Expected at most %d jobs at once, got %d", concurrency, maxRunning)
+	}
+	if runs != 40 {
+		t.Errorf("Expected 40 runs, got %d", runs)
+	}
+}
+
+func TestWorkerRetriesAndBuries(t *testing.T) {
+	s := &fakeStore{}
+	s.add(ProcessAccount, 1, 3)
+	s.add(ProcessAccount, 2, 3)
+	s.add(ProcessAccount, 3, 3)
+	var mutex sync.Mutex
+	attempts := map[int]int{}
+	w := newTestWorker(s, 2, map[Queue]Handler{
+		ProcessAccount: func(ctx context.Context, job Job) error {
+			mutex.Lock()
+			attempts[job.AwsAccountId]++
+			mutex.Unlock()
+			switch job.AwsAccountId {
+			case 1:
+				return nil
+			case 2:
+				if job.Attempts < 2 {
+					return errors.New("transient failure")
+				}
+				return nil
+			default:
+				panic("permanent failure")
+			}
+		},
+	})
+	runUntilSettled(t, w, s)
+	expected := map[int]struct {
+		attempts int
+		status   string
+	}{
+		1: {1, StatusDone},
+		2: {2, StatusDone},
+		3: {3, StatusDead},
+	}
+	for _, j := range s.jobs {
+		e := expected[j.AwsAccountId]
+		if j.status != e.status || j.Attempts != e.attempts || attempts[j.AwsAccountId] != e.attempts {
+			t.Errorf("Account %d: expected %s after %d attempts, got %s after %d attempts (%d runs)", j.AwsAccountId, e.status, e.attempts, j.status, j.Attempts, attempts[j.AwsAccountId])
+		}
+	}
+}
+
+func TestQueueByName(t *testing.T) {
+	if q, ok := QueueByName("anomalies-detection"); !ok || q != AnomaliesDetection {
+		t.Errorf("Expected anomalies detection queue, got %v", q)
+	}
+	if _, ok := QueueByName("unknown"); ok {
+		t.Error("Expected no queue for unknown name")
+	}
+}
+
+func TestWorkerCancelsOnShutdown(t *testing.T) {
+	s := &fakeStore{}
+	s.add(AnomaliesDetection, 1, 5)
+	started := make(chan struct{})
+	w := newTestWorker(s, 1, map[Queue]Handler{
+		AnomaliesDetection: func(ctx context.Context, job Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() { result <- w.Run(ctx) }()
+	<-started
+	cancel()
+	if err := <-result; err != nil {
+		t.Errorf("Worker failed: %s", err.Error())
+	}
+	if j := s.jobs[0]; j.status != StatusCancelled || j.Attempts != 1 {
+		t.Errorf("Expected job to be cancelled after 1 attempt, got %s after %d", j.status, j.Attempts)
+	}
+}
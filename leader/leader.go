@@ -0,0 +1,170 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package leader elects a leader among the backends sharing a database, using
+// a lease stored in the leader_lease table. The leader renews its lease
+// periodically; if it dies, another backend takes over once the lease
+// expired.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/trackit/jsonlog"
+)
+
+// Lease is the state of a leader lease.
+type Lease struct {
+	Name    string    `json:"name"`
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// store holds the leases.
+type store interface {
+	acquire(name, id string, lease time.Duration) (Lease, error)
+	release(name, id string) error
+}
+
+// dbStore stores leases in the database.
+type dbStore struct {
+	db *sql.DB
+}
+
+// acquire takes or renews the lease for id if it is free, expired or already
+// held by id, and returns the resulting lease. Expiry is computed by the
+// database so that backends do not depend on their clocks being in sync.
+func (s dbStore) acquire(name, id string, lease time.Duration) (Lease, error) {
+	const sqlstr = `INSERT INTO leader_lease(
+		name,
+		holder,
+		expires
+	) VALUES (?, ?, NOW(3) + INTERVAL ? MICROSECOND)
+	ON DUPLICATE KEY UPDATE
+		holder = IF(holder = VALUES(holder) OR expires < NOW(3), VALUES(holder), holder),
+		expires = IF(holder = VALUES(holder), VALUES(expires), expires)`
+	if _, err := s.db.Exec(sqlstr, name, id, int64(lease/time.Microsecond)); err != nil {
+		return Lease{}, err
+	}
+	return Current(s.db, name)
+}
+
+// release gives up the lease if it is held by id.
+func (s dbStore) release(name, id string) error {
+	const sqlstr = `UPDATE leader_lease SET
+		expires=NOW(3)
+	WHERE name=? AND holder=?`
+	_, err := s.db.Exec(sqlstr, name, id)
+	return err
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}
+
+// Current returns the current lease of an election. Its holder is empty if
+// there never was a leader, and it may be expired if the leader died.
+func Current(db queryRower, name string) (Lease, error) {
+	const sqlstr = `SELECT holder, expires FROM leader_lease WHERE name=?`
+	l := Lease{Name: name}
+	err := db.QueryRow(sqlstr, name).Scan(&l.Holder, &l.Expires)
+	if err == sql.ErrNoRows {
+		return l, nil
+	}
+	return l, err
+}
+
+// Elector takes part in an election. Once elected, it calls OnElected, and
+// OnDemoted when it loses the lease or stops.
+type Elector struct {
+	Name      string
+	Id        string
+	Lease     time.Duration
+	OnElected func()
+	OnDemoted func()
+	store     store
+	now       func() time.Time
+}
+
+// NewElector creates an Elector for the backend id using the database.
+func NewElector(db *sql.DB, name, id string, lease time.Duration, onElected, onDemoted func()) *Elector {
+	return &Elector{
+		Name:      name,
+		Id:        id,
+		Lease:     lease,
+		OnElected: onElected,
+		OnDemoted: onDemoted,
+		store:     dbStore{db},
+		now:       time.Now,
+	}
+}
+
+// Run takes part in the election until the context is cancelled, trying to
+// acquire or renew the lease three times per lease duration. When it stops,
+// a leader releases its lease so that another backend takes over at once.
+func (e *Elector) Run(ctx context.Context) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	ticker := time.NewTicker(e.Lease / 3)
+	defer ticker.Stop()
+	var leading bool
+	var renewed time.Time
+	for {
+		leading, renewed = e.campaign(ctx, leading, renewed)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if leading {
+				e.OnDemoted()
+				if err := e.store.release(e.Name, e.Id); err != nil {
+					logger.Error("Failed to release leader lease.", err.Error())
+				}
+			}
+			return
+		}
+	}
+}
+
+// campaign tries to acquire or renew the lease once and calls the callbacks
+// when the leadership changes. A leader which fails to renew its lease steps
+// down before the lease it last renewed expires, since another backend may
+// then take over.
+func (e *Elector) campaign(ctx context.Context, leading bool, renewed time.Time) (bool, time.Time) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	now := e.now()
+	lease, err := e.store.acquire(e.Name, e.Id, e.Lease)
+	if err != nil {
+		logger.Error("Failed to acquire leader lease.", err.Error())
+		if leading && now.Sub(renewed) >= e.Lease*2/3 {
+			logger.Warning("Leader lease about to expire, stepping down.", e.Id)
+			e.OnDemoted()
+			return false, renewed
+		}
+		return leading, renewed
+	}
+	elected := lease.Holder == e.Id
+	if elected && !leading {
+		logger.Info("Elected leader.", lease)
+		e.OnElected()
+	} else if !elected && leading {
+		logger.Warning("Lost leader lease.", lease)
+		e.OnDemoted()
+	}
+	if elected {
+		renewed = now
+	}
+	return elected, renewed
+}
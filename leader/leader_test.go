@@ -0,0 +1,144 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStore is a store holding a single lease in memory, following the same
+// rules as the database.
+type fakeStore struct {
+	lease Lease
+	now   *time.Time
+	err   error
+}
+
+func (s *fakeStore) acquire(name, id string, lease time.Duration) (Lease, error) {
+	if s.err != nil {
+		return Lease{}, s.err
+	}
+	if s.lease.Holder == id || !s.lease.Expires.After(*s.now) {
+		s.lease = Lease{name, id, s.now.Add(lease)}
+	}
+	return s.lease, nil
+}
+
+func (s *fakeStore) release(name, id string) error {
+	if s.lease.Holder == id {
+		s.lease.Expires = *s.now
+	}
+	return nil
+}
+
+// testElector is an Elector counting its elections and demotions.
+type testElector struct {
+	*Elector
+	leading   bool
+	elections int
+	demotions int
+}
+
+func newTestElector(s *fakeStore, id string) *testElector {
+	e := &testElector{}
+	e.Elector = &Elector{
+		Name:      "test",
+		Id:        id,
+		Lease:     30 * time.Second,
+		OnElected: func() { e.leading = true; e.elections++ },
+		OnDemoted: func() { e.leading = false; e.demotions++ },
+		store:     s,
+		now:       func() time.Time { return *s.now },
+	}
+	return e
+}
+
+func TestSingleLeader(t *testing.T) {
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &fakeStore{now: &now}
+	a, b := newTestElector(s, "a"), newTestElector(s, "b")
+	var aLeading, bLeading bool
+	var aRenewed, bRenewed time.Time
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		aLeading, aRenewed = a.campaign(ctx, aLeading, aRenewed)
+		bLeading, bRenewed = b.campaign(ctx, bLeading, bRenewed)
+		now = now.Add(10 * time.Second)
+	}
+	if !a.leading || b.leading || a.elections != 1 || b.elections != 0 {
+		t.Errorf("Expected a to be the only leader, got a=%#v b=%#v", a, b)
+	}
+	// a dies: b takes over once the lease expired.
+	for i := 0; i < 2; i++ {
+		bLeading, bRenewed = b.campaign(ctx, bLeading, bRenewed)
+		if b.leading {
+			t.Fatalf("b should not lead before the lease of a expired")
+		}
+		now = now.Add(10 * time.Second)
+	}
+	bLeading, bRenewed = b.campaign(ctx, bLeading, bRenewed)
+	if !b.leading || s.lease.Holder != "b" {
+		t.Errorf("Expected b to take over, got %#v", s.lease)
+	}
+	// a comes back and learns it lost the lease.
+	aLeading, aRenewed = a.campaign(ctx, aLeading, aRenewed)
+	if a.leading || a.demotions != 1 {
+		t.Errorf("Expected a to be demoted, got %#v", a)
+	}
+}
+
+func TestStepDownWhenRenewalFails(t *testing.T) {
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &fakeStore{now: &now}
+	a := newTestElector(s, "a")
+	ctx := context.Background()
+	leading, renewed := a.campaign(ctx, false, time.Time{})
+	s.err = errors.New("database unavailable")
+	now = now.Add(10 * time.Second)
+	leading, renewed = a.campaign(ctx, leading, renewed)
+	if !a.leading {
+		t.Errorf("Leader should survive a single failed renewal")
+	}
+	now = now.Add(10 * time.Second)
+	leading, renewed = a.campaign(ctx, leading, renewed)
+	if a.leading || leading {
+		t.Errorf("Leader should step down before its lease expires")
+	}
+	if !now.Before(s.lease.Expires) {
+		t.Errorf("Leader stepped down after its lease expired")
+	}
+}
+
+func TestReleaseOnStop(t *testing.T) {
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &fakeStore{now: &now}
+	a := newTestElector(s, "a")
+	a.Lease = 30 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { a.Run(ctx); close(done) }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+	if a.leading || a.elections != 1 || a.demotions != 1 {
+		t.Errorf("Expected a to be elected then demoted, got %#v", a)
+	}
+	if s.lease.Expires.After(now) {
+		t.Errorf("Expected the lease to be released, got %#v", s.lease)
+	}
+}
@@ -0,0 +1,263 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package metrics records counters and histograms about the server and
+// exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// DefaultBuckets are the histogram buckets for request latencies, in
+	// seconds.
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// TaskBuckets are the histogram buckets for long running tasks, in
+	// seconds.
+	TaskBuckets = []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200}
+)
+
+// DefaultRegistry is the registry metrics are created in.
+var DefaultRegistry = &Registry{}
+
+// Registry is a set of metric families.
+type Registry struct {
+	mutex    sync.Mutex
+	families []*family
+}
+
+// family is a metric with its help text and its series, one per set of label
+// values.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*series
+}
+
+// series is the value of a metric for a set of label values. Counters only
+// use sum; histograms also use counts, one per bucket, and count.
+type series struct {
+	labelValues []string
+	sum         float64
+	count       uint64
+	counts      []uint64
+}
+
+// register adds a family to the registry. It panics if a family with the same
+// name already exists, since metrics are created once at initialization.
+func (r *Registry) register(f *family) *family {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, e := range r.families {
+		if e.name == f.name {
+			panic(fmt.Sprintf("metrics: %s registered twice", f.name))
+		}
+	}
+	f.series = make(map[string]*series)
+	r.families = append(r.families, f)
+	return f
+}
+
+// get returns the series of a family for label values, creating it if needed.
+// The family must be locked.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(f.buckets)),
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter is a metric which only goes up.
+type Counter struct {
+	f *family
+}
+
+// NewCounter creates a counter in DefaultRegistry with labels.
+func NewCounter(name, help string, labels ...string) Counter {
+	return DefaultRegistry.NewCounter(name, help, labels...)
+}
+
+// NewCounter creates a counter with labels.
+func (r *Registry) NewCounter(name, help string, labels ...string) Counter {
+	return Counter{r.register(&family{name: name, help: help, kind: "counter", labels: labels})}
+}
+
+// Inc increments the counter for label values.
+func (c Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a positive value to the counter for label values.
+func (c Counter) Add(v float64, labelValues ...string) {
+	c.f.mutex.Lock()
+	defer c.f.mutex.Unlock()
+	c.f.get(labelValues).sum += v
+}
+
+// Histogram is a metric counting observations in buckets.
+type Histogram struct {
+	f *family
+}
+
+// NewHistogram creates a histogram in DefaultRegistry with buckets, sorted
+// upper bounds, and labels.
+func NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labels...)
+}
+
+// NewHistogram creates a histogram with buckets, sorted upper bounds, and
+// labels.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	return Histogram{r.register(&family{name: name, help: help, kind: "histogram", labels: labels, buckets: buckets})}
+}
+
+// Observe adds an observation to the histogram for label values.
+func (h Histogram) Observe(v float64, labelValues ...string) {
+	h.f.mutex.Lock()
+	defer h.f.mutex.Unlock()
+	s := h.f.get(labelValues)
+	s.sum += v
+	s.count++
+	for i, upper := range h.f.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+}
+
+// WriteText writes the metrics of the registry in the Prometheus text format.
+// Families are written in registration order and series in label values
+// order.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mutex.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+// writeText writes a family in the Prometheus text format.
+func (f *family) writeText(w *bufio.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := f.series[k]
+		labels := formatLabels(f.labels, s.labelValues)
+		if f.kind == "counter" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labels, formatFloat(s.sum))
+			continue
+		}
+		bucketLabels := make([]string, len(f.labels)+1)
+		bucketValues := make([]string, len(f.labels)+1)
+		copy(bucketLabels, f.labels)
+		copy(bucketValues, s.labelValues)
+		bucketLabels[len(f.labels)] = "le"
+		for i, upper := range f.buckets {
+			bucketValues[len(f.labels)] = formatFloat(upper)
+			le := formatLabels(bucketLabels, bucketValues)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, le, s.counts[i])
+		}
+		bucketValues[len(f.labels)] = "+Inf"
+		inf := formatLabels(bucketLabels, bucketValues)
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, inf, s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, s.count)
+	}
+}
+
+// formatLabels formats label names and values, or returns an empty string if
+// there are none.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, names[i], escapeLabelValue(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+// formatFloat formats a value as Prometheus expects it.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// Handler returns an http.Handler serving the metrics of DefaultRegistry. If
+// token is not empty, requests must carry it as a bearer token.
+func Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		DefaultRegistry.WriteText(w)
+	})
+}
@@ -0,0 +1,89 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := &Registry{}
+	c := r.NewCounter("test_requests_total", "Requests.\nServed.", "route", "status")
+	h := r.NewHistogram("test_duration_seconds", "Durations.", []float64{0.1, 1})
+	c.Inc("/b", "200")
+	c.Add(2, "/a", "500")
+	c.Inc("/b", "200")
+	c.Inc(`/"q"`, "200")
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	var b bytes.Buffer
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("Failed to write metrics: %s", err.Error())
+	}
+	expected := `# HELP test_requests_total Requests.\nServed.
+# TYPE test_requests_total counter
+test_requests_total{route="/\"q\"",status="200"} 1
+test_requests_total{route="/a",status="500"} 2
+test_requests_total{route="/b",status="200"} 2
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.1"} 1
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 5.55
+test_duration_seconds_count 3
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	r := &Registry{}
+	r.NewCounter("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Error("Registering a metric twice should panic")
+		}
+	}()
+	r.NewCounter("test_total", "Test.")
+}
+
+func TestHandlerToken(t *testing.T) {
+	for _, c := range []struct {
+		token  string
+		header string
+		status int
+	}{
+		{"", "", http.StatusOK},
+		{"secret", "", http.StatusUnauthorized},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+		{"secret", "Bearer secret", http.StatusOK},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if c.header != "" {
+			request.Header.Set("Authorization", c.header)
+		}
+		response := httptest.NewRecorder()
+		Handler(c.token).ServeHTTP(response, request)
+		if response.Code != c.status {
+			t.Errorf("Token %q with header %q: expected %d, got %d", c.token, c.header, c.status, response.Code)
+		}
+	}
+}
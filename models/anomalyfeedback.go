@@ -0,0 +1,44 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// AnomalyFeedbacksByAwsAccountIDAndDimension returns the feedback given on
+// the anomalies of an AWS account along a dimension.
+func AnomalyFeedbacksByAwsAccountIDAndDimension(db XODB, awsAccountID int, dimension string) ([]*AnomalyFeedback, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, aws_account_id, dimension, dimension_key, date, state, comment, user_id, updated ` +
+		`FROM trackit.anomaly_feedback ` +
+		`WHERE aws_account_id = ? AND dimension = ?`
+	XOLog(sqlstr, awsAccountID, dimension)
+	q, err := db.Query(sqlstr, awsAccountID, dimension)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AnomalyFeedback{}
+	for q.Next() {
+		af := AnomalyFeedback{
+			_exists: true,
+		}
+		err = q.Scan(&af.ID, &af.AwsAccountID, &af.Dimension, &af.DimensionKey, &af.Date, &af.State, &af.Comment, &af.UserID, &af.Updated)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &af)
+	}
+	return res, nil
+}
@@ -0,0 +1,44 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// AnomalySnoozesByAwsAccountID returns the snoozes of the anomalies of an AWS
+// account.
+func AnomalySnoozesByAwsAccountID(db XODB, awsAccountID int) ([]*AnomalySnooze, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, aws_account_id, dimension, dimension_key, until, comment, user_id, created ` +
+		`FROM trackit.anomaly_snooze ` +
+		`WHERE aws_account_id = ?`
+	XOLog(sqlstr, awsAccountID)
+	q, err := db.Query(sqlstr, awsAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AnomalySnooze{}
+	for q.Next() {
+		as := AnomalySnooze{
+			_exists: true,
+		}
+		err = q.Scan(&as.ID, &as.AwsAccountID, &as.Dimension, &as.DimensionKey, &as.Until, &as.Comment, &as.UserID, &as.Created)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &as)
+	}
+	return res, nil
+}
@@ -0,0 +1,60 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// APIKeyAwsAccountsByAPIKeyID returns the AWS accounts an API key is
+// restricted to.
+func APIKeyAwsAccountsByAPIKeyID(db XODB, apiKeyID int) ([]*APIKeyAwsAccount, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`api_key_id, aws_account_id ` +
+		`FROM trackit.api_key_aws_account ` +
+		`WHERE api_key_id = ?`
+	XOLog(sqlstr, apiKeyID)
+	q, err := db.Query(sqlstr, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*APIKeyAwsAccount{}
+	for q.Next() {
+		akaa := APIKeyAwsAccount{
+			_exists: true,
+		}
+		err = q.Scan(&akaa.APIKeyID, &akaa.AwsAccountID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &akaa)
+	}
+	return res, nil
+}
+
+// TouchAPIKey sets the time an API key was last used to the current time.
+func TouchAPIKey(db XODB, id int) error {
+	const sqlstr = `UPDATE trackit.api_key SET last_used = NOW() WHERE id = ?`
+	XOLog(sqlstr, id)
+	_, err := db.Exec(sqlstr, id)
+	return err
+}
+
+// RevokeUserAPIKeys revokes all the API keys of a user.
+func RevokeUserAPIKeys(db XODB, userID int) error {
+	const sqlstr = `UPDATE trackit.api_key SET revoked = NOW() WHERE user_id = ? AND revoked IS NULL`
+	XOLog(sqlstr, userID)
+	_, err := db.Exec(sqlstr, userID)
+	return err
+}
@@ -0,0 +1,104 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingEbsPurgeWhenNotEtag purges the AwsProductPricingEbs table from the database.
+func AwsProductPricingEbsPurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_ebs WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// ToSlice transforms AwsProductPricingEbs to an array of interface{}.
+func (appe *AwsProductPricingEbs) ToSlice() []interface{} {
+	res := make([]interface{}, 8)
+	res[0] = appe.Sku
+	res[1] = appe.Etag
+	res[2] = appe.Region
+	res[3] = appe.RegionCode
+	res[4] = appe.VolumeAPIName
+	res[5] = appe.VolumeType
+	res[6] = appe.MaxIops
+	res[7] = appe.MaxThroughput
+	return res
+}
+
+type AwsProductPricingEbsBulk struct {
+	Bulk      []AwsProductPricingEbs
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (appeb *AwsProductPricingEbsBulk) AppendAndInsertIfLimitExceeded(appe AwsProductPricingEbs, db XODB) error {
+	appeb.Bulk = append(appeb.Bulk, appe)
+	appeb._count++
+	if appeb._count < appeb.BulkLimit {
+		return nil
+	}
+	if err := appeb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	appeb.Bulk = nil
+	appeb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingEbs to the database or update
+// if key already exists.
+func (appeb *AwsProductPricingEbsBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(appeb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(appeb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_ebs (` +
+		`sku, etag, region, region_code, volume_api_name, volume_type, max_iops, max_throughput` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), region=VALUES(region), region_code=VALUES(region_code), volume_api_name=VALUES(volume_api_name), volume_type=VALUES(volume_type), max_iops=VALUES(max_iops), max_throughput=VALUES(max_throughput)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(appeb.Bulk); i++ {
+		nvalues = append(nvalues, appeb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(appeb.Bulk); i++ {
+		appeb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
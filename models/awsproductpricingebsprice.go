@@ -0,0 +1,129 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingEbsPricePurgeWhenNotEtag purges the AwsProductPricingEbsPrice table from the database.
+func AwsProductPricingEbsPricePurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_ebs_price WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// AwsProductPricingEbsPricesBySkuEtag returns the prices of the product with
+// a given SKU in a given version of the offer.
+func AwsProductPricingEbsPricesBySkuEtag(db XODB, sku string, etag string) ([]*AwsProductPricingEbsPrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`sku, etag, unit, price, currency ` +
+		`FROM trackit.aws_product_pricing_ebs_price ` +
+		`WHERE sku = ? AND etag = ?`
+	XOLog(sqlstr, sku, etag)
+	q, err := db.Query(sqlstr, sku, etag)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingEbsPrice{}
+	for q.Next() {
+		appep := AwsProductPricingEbsPrice{
+			_exists: true,
+		}
+		err = q.Scan(&appep.Sku, &appep.Etag, &appep.Unit, &appep.Price, &appep.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appep)
+	}
+	return res, nil
+}
+
+// ToSlice transforms AwsProductPricingEbsPrice to an array of interface{}.
+func (appep *AwsProductPricingEbsPrice) ToSlice() []interface{} {
+	res := make([]interface{}, 5)
+	res[0] = appep.Sku
+	res[1] = appep.Etag
+	res[2] = appep.Unit
+	res[3] = appep.Price
+	res[4] = appep.Currency
+	return res
+}
+
+type AwsProductPricingEbsPriceBulk struct {
+	Bulk      []AwsProductPricingEbsPrice
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (appepb *AwsProductPricingEbsPriceBulk) AppendAndInsertIfLimitExceeded(appep AwsProductPricingEbsPrice, db XODB) error {
+	appepb.Bulk = append(appepb.Bulk, appep)
+	appepb._count++
+	if appepb._count < appepb.BulkLimit {
+		return nil
+	}
+	if err := appepb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	appepb.Bulk = nil
+	appepb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingEbsPrice to the database or update
+// if key already exists.
+func (appepb *AwsProductPricingEbsPriceBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(appepb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(appepb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_ebs_price (` +
+		`sku, etag, unit, price, currency` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), unit=VALUES(unit), price=VALUES(price), currency=VALUES(currency)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(appepb.Bulk); i++ {
+		nvalues = append(nvalues, appepb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(appepb.Bulk); i++ {
+		appepb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
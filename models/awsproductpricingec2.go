@@ -30,9 +30,109 @@ func AwsProductPricingEc2PurgeWhenNotEtag(etag string, db XODB) error {
 	return err
 }
 
+// AwsProductPricingEc2sByInstanceTypeRegion returns the products of a given
+// instance type in a region. The region can be given either by its name or
+// by its code.
+func AwsProductPricingEc2sByInstanceTypeRegion(db XODB, instanceType string, region string) ([]*AwsProductPricingEc2, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu, region_code ` +
+		`FROM trackit.aws_product_pricing_ec2 ` +
+		`WHERE instance_type = ? AND (region = ? OR region_code = ?)`
+	XOLog(sqlstr, instanceType, region, region)
+	q, err := db.Query(sqlstr, instanceType, region, region)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingEc2{}
+	for q.Next() {
+		appe := AwsProductPricingEc2{
+			_exists: true,
+		}
+		err = q.Scan(&appe.Sku, &appe.Etag, &appe.Region, &appe.InstanceType, &appe.CurrentGeneration, &appe.Vcpu, &appe.Memory, &appe.Storage, &appe.NetworkPerformance, &appe.Tenancy, &appe.OperatingSystem, &appe.Ecu, &appe.RegionCode)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appe)
+	}
+	return res, nil
+}
+
+// AwsProductPricingEc2PricesByProduct returns the on-demand and reserved
+// prices of the products matching an instance type, region code, operating
+// system and tenancy.
+func AwsProductPricingEc2PricesByProduct(db XODB, instanceType string, regionCode string, operatingSystem string, tenancy string) ([]*AwsProductPricingEc2Price, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`p.sku, p.etag, p.term_type, p.lease_contract_length, p.purchase_option, p.offering_class, p.hourly_price, p.upfront_price, p.currency ` +
+		`FROM trackit.aws_product_pricing_ec2_price AS p ` +
+		`JOIN trackit.aws_product_pricing_ec2 AS a ON a.sku = p.sku AND a.etag = p.etag ` +
+		`WHERE a.instance_type = ? AND a.region_code = ? AND a.operating_system = ? AND a.tenancy = ?`
+	XOLog(sqlstr, instanceType, regionCode, operatingSystem, tenancy)
+	q, err := db.Query(sqlstr, instanceType, regionCode, operatingSystem, tenancy)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingEc2Price{}
+	for q.Next() {
+		appep := AwsProductPricingEc2Price{
+			_exists: true,
+		}
+		err = q.Scan(&appep.Sku, &appep.Etag, &appep.TermType, &appep.LeaseContractLength, &appep.PurchaseOption, &appep.OfferingClass, &appep.HourlyPrice, &appep.UpfrontPrice, &appep.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appep)
+	}
+	return res, nil
+}
+
+// AwsProductPricingEc2InstanceTypePrice is the cheapest on-demand price of an
+// instance type in a region. MemoryGib is Memory as a number of GiB.
+type AwsProductPricingEc2InstanceTypePrice struct {
+	InstanceType      string
+	Vcpu              int
+	Memory            string
+	MemoryGib         float64
+	CurrentGeneration bool
+	HourlyPrice       float64
+	Currency          string
+}
+
+// AwsProductPricingEc2InstanceTypePricesByRegionCode returns the cheapest
+// on-demand price of each instance type of a region for an operating system
+// and a tenancy.
+func AwsProductPricingEc2InstanceTypePricesByRegionCode(db XODB, regionCode string, operatingSystem string, tenancy string) ([]AwsProductPricingEc2InstanceTypePrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`a.instance_type, MAX(a.vcpu), MAX(a.memory), MAX(CAST(REPLACE(REPLACE(a.memory, ' GiB', ''), ',', '') AS DECIMAL(12, 3))), MAX(a.current_generation), MIN(p.hourly_price), MAX(p.currency) ` +
+		`FROM trackit.aws_product_pricing_ec2 AS a ` +
+		`JOIN trackit.aws_product_pricing_ec2_price AS p ON p.sku = a.sku AND p.etag = a.etag ` +
+		`WHERE a.region_code = ? AND a.operating_system = ? AND a.tenancy = ? AND p.term_type = "OnDemand" AND p.hourly_price > 0 ` +
+		`GROUP BY a.instance_type`
+	XOLog(sqlstr, regionCode, operatingSystem, tenancy)
+	q, err := db.Query(sqlstr, regionCode, operatingSystem, tenancy)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []AwsProductPricingEc2InstanceTypePrice{}
+	for q.Next() {
+		var appeitp AwsProductPricingEc2InstanceTypePrice
+		err = q.Scan(&appeitp.InstanceType, &appeitp.Vcpu, &appeitp.Memory, &appeitp.MemoryGib, &appeitp.CurrentGeneration, &appeitp.HourlyPrice, &appeitp.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, appeitp)
+	}
+	return res, nil
+}
+
 // ToSlice transforms AwsProductPricingEc2 to an array of interface{}.
 func (appe *AwsProductPricingEc2) ToSlice() []interface{} {
-	res := make([]interface{}, 12)
+	res := make([]interface{}, 13)
 	res[0] = appe.Sku
 	res[1] = appe.Etag
 	res[2] = appe.Region
@@ -45,6 +145,7 @@ func (appe *AwsProductPricingEc2) ToSlice() []interface{} {
 	res[9] = appe.Tenancy
 	res[10] = appe.OperatingSystem
 	res[11] = appe.Ecu
+	res[12] = appe.RegionCode
 	return res
 }
 
@@ -73,17 +174,20 @@ func (appeb *AwsProductPricingEc2Bulk) AppendAndInsertIfLimitExceeded(appe AwsPr
 // BulkInsertOrUpdate inserts the AwsProductEc2 to the database or update
 // if key already exists.
 func (appeb *AwsProductPricingEc2Bulk) BulkInsertOrUpdate(db XODB) error {
+	if len(appeb.Bulk) == 0 {
+		return nil
+	}
 	values := make([]string, 0)
 	for i := 0; i < len(appeb.Bulk); i++ {
-		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	}
 
 	// sql insert query, primary key must be provided
 	sqlstr := `INSERT INTO trackit.aws_product_pricing_ec2 (` +
-		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu` +
+		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu, region_code` +
 		`) VALUES` + strings.Join(values, ",") +
 		`ON DUPLICATE KEY UPDATE ` +
-		`sku=VALUES(sku), etag=VALUES(etag), region=VALUES(region), instance_type=VALUES(instance_type), current_generation=VALUES(current_generation), vcpu=VALUES(vcpu), memory=VALUES(memory), storage=VALUES(storage), network_performance=VALUES(network_performance), tenancy=VALUES(tenancy), operating_system=VALUES(operating_system), ecu=VALUES(ecu)`
+		`sku=VALUES(sku), etag=VALUES(etag), region=VALUES(region), instance_type=VALUES(instance_type), current_generation=VALUES(current_generation), vcpu=VALUES(vcpu), memory=VALUES(memory), storage=VALUES(storage), network_performance=VALUES(network_performance), tenancy=VALUES(tenancy), operating_system=VALUES(operating_system), ecu=VALUES(ecu), region_code=VALUES(region_code)`
 
 	nvalues := make([]interface{}, 0)
 	for i := 0; i < len(appeb.Bulk); i++ {
@@ -21,6 +21,7 @@ type AwsProductPricingEc2 struct {
 	Tenancy            string `json:"tenancy"`             // tenancy
 	OperatingSystem    string `json:"operating_system"`    // operating_system
 	Ecu                string `json:"ecu"`                 // ecu
+	RegionCode         string `json:"region_code"`         // region_code
 
 	// xo fields
 	_exists, _deleted bool
@@ -47,14 +48,14 @@ func (appe *AwsProductPricingEc2) Insert(db XODB) error {
 
 	// sql insert query, primary key must be provided
 	const sqlstr = `INSERT INTO trackit.aws_product_pricing_ec2 (` +
-		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu` +
+		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu, region_code` +
 		`) VALUES (` +
-		`?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?` +
+		`?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, appe.Sku, appe.Etag, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu)
-	_, err = db.Exec(sqlstr, appe.Sku, appe.Etag, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu)
+	XOLog(sqlstr, appe.Sku, appe.Etag, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu, appe.RegionCode)
+	_, err = db.Exec(sqlstr, appe.Sku, appe.Etag, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu, appe.RegionCode)
 	if err != nil {
 		return err
 	}
@@ -81,12 +82,12 @@ func (appe *AwsProductPricingEc2) Update(db XODB) error {
 
 	// sql query with composite primary key
 	const sqlstr = `UPDATE trackit.aws_product_pricing_ec2 SET ` +
-		`region = ?, instance_type = ?, current_generation = ?, vcpu = ?, memory = ?, storage = ?, network_performance = ?, tenancy = ?, operating_system = ?, ecu = ?` +
+		`region = ?, instance_type = ?, current_generation = ?, vcpu = ?, memory = ?, storage = ?, network_performance = ?, tenancy = ?, operating_system = ?, ecu = ?, region_code = ?` +
 		` WHERE sku = ? AND etag = ?`
 
 	// run query
-	XOLog(sqlstr, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu, appe.Sku, appe.Etag)
-	_, err = db.Exec(sqlstr, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu, appe.Sku, appe.Etag)
+	XOLog(sqlstr, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu, appe.RegionCode, appe.Sku, appe.Etag)
+	_, err = db.Exec(sqlstr, appe.Region, appe.InstanceType, appe.CurrentGeneration, appe.Vcpu, appe.Memory, appe.Storage, appe.NetworkPerformance, appe.Tenancy, appe.OperatingSystem, appe.Ecu, appe.RegionCode, appe.Sku, appe.Etag)
 	return err
 }
 
@@ -137,7 +138,7 @@ func AwsProductPricingEc2ByEtag(db XODB, etag string) (*AwsProductPricingEc2, er
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu ` +
+		`sku, etag, region, instance_type, current_generation, vcpu, memory, storage, network_performance, tenancy, operating_system, ecu, region_code ` +
 		`FROM trackit.aws_product_pricing_ec2 ` +
 		`WHERE etag = ?`
 
@@ -147,7 +148,7 @@ func AwsProductPricingEc2ByEtag(db XODB, etag string) (*AwsProductPricingEc2, er
 		_exists: true,
 	}
 
-	err = db.QueryRow(sqlstr, etag).Scan(&appe.Sku, &appe.Etag, &appe.Region, &appe.InstanceType, &appe.CurrentGeneration, &appe.Vcpu, &appe.Memory, &appe.Storage, &appe.NetworkPerformance, &appe.Tenancy, &appe.OperatingSystem, &appe.Ecu)
+	err = db.QueryRow(sqlstr, etag).Scan(&appe.Sku, &appe.Etag, &appe.Region, &appe.InstanceType, &appe.CurrentGeneration, &appe.Vcpu, &appe.Memory, &appe.Storage, &appe.NetworkPerformance, &appe.Tenancy, &appe.OperatingSystem, &appe.Ecu, &appe.RegionCode)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,104 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingElasticachePurgeWhenNotEtag purges the AwsProductPricingElasticache table from the database.
+func AwsProductPricingElasticachePurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_elasticache WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// ToSlice transforms AwsProductPricingElasticache to an array of interface{}.
+func (appe *AwsProductPricingElasticache) ToSlice() []interface{} {
+	res := make([]interface{}, 8)
+	res[0] = appe.Sku
+	res[1] = appe.Etag
+	res[2] = appe.Region
+	res[3] = appe.RegionCode
+	res[4] = appe.InstanceType
+	res[5] = appe.CacheEngine
+	res[6] = appe.Vcpu
+	res[7] = appe.Memory
+	return res
+}
+
+type AwsProductPricingElasticacheBulk struct {
+	Bulk      []AwsProductPricingElasticache
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (appeb *AwsProductPricingElasticacheBulk) AppendAndInsertIfLimitExceeded(appe AwsProductPricingElasticache, db XODB) error {
+	appeb.Bulk = append(appeb.Bulk, appe)
+	appeb._count++
+	if appeb._count < appeb.BulkLimit {
+		return nil
+	}
+	if err := appeb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	appeb.Bulk = nil
+	appeb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingElasticache to the database or update
+// if key already exists.
+func (appeb *AwsProductPricingElasticacheBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(appeb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(appeb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_elasticache (` +
+		`sku, etag, region, region_code, instance_type, cache_engine, vcpu, memory` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), region=VALUES(region), region_code=VALUES(region_code), instance_type=VALUES(instance_type), cache_engine=VALUES(cache_engine), vcpu=VALUES(vcpu), memory=VALUES(memory)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(appeb.Bulk); i++ {
+		nvalues = append(nvalues, appeb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(appeb.Bulk); i++ {
+		appeb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingElasticachePricePurgeWhenNotEtag purges the AwsProductPricingElasticachePrice table from the database.
+func AwsProductPricingElasticachePricePurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_elasticache_price WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// AwsProductPricingElasticachePricesBySkuEtag returns the prices of the product with
+// a given SKU in a given version of the offer.
+func AwsProductPricingElasticachePricesBySkuEtag(db XODB, sku string, etag string) ([]*AwsProductPricingElasticachePrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`sku, etag, term_type, lease_contract_length, purchase_option, offering_class, hourly_price, upfront_price, currency ` +
+		`FROM trackit.aws_product_pricing_elasticache_price ` +
+		`WHERE sku = ? AND etag = ?`
+	XOLog(sqlstr, sku, etag)
+	q, err := db.Query(sqlstr, sku, etag)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingElasticachePrice{}
+	for q.Next() {
+		appep := AwsProductPricingElasticachePrice{
+			_exists: true,
+		}
+		err = q.Scan(&appep.Sku, &appep.Etag, &appep.TermType, &appep.LeaseContractLength, &appep.PurchaseOption, &appep.OfferingClass, &appep.HourlyPrice, &appep.UpfrontPrice, &appep.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appep)
+	}
+	return res, nil
+}
+
+// ToSlice transforms AwsProductPricingElasticachePrice to an array of interface{}.
+func (appep *AwsProductPricingElasticachePrice) ToSlice() []interface{} {
+	res := make([]interface{}, 9)
+	res[0] = appep.Sku
+	res[1] = appep.Etag
+	res[2] = appep.TermType
+	res[3] = appep.LeaseContractLength
+	res[4] = appep.PurchaseOption
+	res[5] = appep.OfferingClass
+	res[6] = appep.HourlyPrice
+	res[7] = appep.UpfrontPrice
+	res[8] = appep.Currency
+	return res
+}
+
+type AwsProductPricingElasticachePriceBulk struct {
+	Bulk      []AwsProductPricingElasticachePrice
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (appepb *AwsProductPricingElasticachePriceBulk) AppendAndInsertIfLimitExceeded(appep AwsProductPricingElasticachePrice, db XODB) error {
+	appepb.Bulk = append(appepb.Bulk, appep)
+	appepb._count++
+	if appepb._count < appepb.BulkLimit {
+		return nil
+	}
+	if err := appepb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	appepb.Bulk = nil
+	appepb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingElasticachePrice to the database or update
+// if key already exists.
+func (appepb *AwsProductPricingElasticachePriceBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(appepb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(appepb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_elasticache_price (` +
+		`sku, etag, term_type, lease_contract_length, purchase_option, offering_class, hourly_price, upfront_price, currency` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), term_type=VALUES(term_type), lease_contract_length=VALUES(lease_contract_length), purchase_option=VALUES(purchase_option), offering_class=VALUES(offering_class), hourly_price=VALUES(hourly_price), upfront_price=VALUES(upfront_price), currency=VALUES(currency)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(appepb.Bulk); i++ {
+		nvalues = append(nvalues, appepb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(appepb.Bulk); i++ {
+		appepb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
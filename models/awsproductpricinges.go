@@ -0,0 +1,135 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingEsPurgeWhenNotEtag purges the AwsProductPricingEs table from the database.
+func AwsProductPricingEsPurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_es WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// AwsProductPricingEsOnDemandPrices returns the on-demand prices of the
+// Elasticsearch Service products matching an instance type and region code,
+// cheapest first.
+func AwsProductPricingEsOnDemandPrices(db XODB, instanceType string, regionCode string) ([]*AwsProductPricingEsPrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`p.sku, p.etag, p.term_type, p.lease_contract_length, p.purchase_option, p.offering_class, p.hourly_price, p.upfront_price, p.currency ` +
+		`FROM trackit.aws_product_pricing_es_price AS p ` +
+		`JOIN trackit.aws_product_pricing_es AS a ON a.sku = p.sku AND a.etag = p.etag ` +
+		`WHERE a.instance_type = ? AND a.region_code = ? AND p.term_type = "OnDemand" ` +
+		`ORDER BY p.hourly_price`
+	XOLog(sqlstr, instanceType, regionCode)
+	q, err := db.Query(sqlstr, instanceType, regionCode)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingEsPrice{}
+	for q.Next() {
+		appp := AwsProductPricingEsPrice{
+			_exists: true,
+		}
+		err = q.Scan(&appp.Sku, &appp.Etag, &appp.TermType, &appp.LeaseContractLength, &appp.PurchaseOption, &appp.OfferingClass, &appp.HourlyPrice, &appp.UpfrontPrice, &appp.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appp)
+	}
+	return res, nil
+}
+
+// ToSlice transforms AwsProductPricingEs to an array of interface{}.
+func (appe *AwsProductPricingEs) ToSlice() []interface{} {
+	res := make([]interface{}, 8)
+	res[0] = appe.Sku
+	res[1] = appe.Etag
+	res[2] = appe.Region
+	res[3] = appe.RegionCode
+	res[4] = appe.InstanceType
+	res[5] = appe.Vcpu
+	res[6] = appe.Memory
+	res[7] = appe.Storage
+	return res
+}
+
+type AwsProductPricingEsBulk struct {
+	Bulk      []AwsProductPricingEs
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (appeb *AwsProductPricingEsBulk) AppendAndInsertIfLimitExceeded(appe AwsProductPricingEs, db XODB) error {
+	appeb.Bulk = append(appeb.Bulk, appe)
+	appeb._count++
+	if appeb._count < appeb.BulkLimit {
+		return nil
+	}
+	if err := appeb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	appeb.Bulk = nil
+	appeb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingEs to the database or update
+// if key already exists.
+func (appeb *AwsProductPricingEsBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(appeb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(appeb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_es (` +
+		`sku, etag, region, region_code, instance_type, vcpu, memory, storage` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), region=VALUES(region), region_code=VALUES(region_code), instance_type=VALUES(instance_type), vcpu=VALUES(vcpu), memory=VALUES(memory), storage=VALUES(storage)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(appeb.Bulk); i++ {
+		nvalues = append(nvalues, appeb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(appeb.Bulk); i++ {
+		appeb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingRdsPurgeWhenNotEtag purges the AwsProductPricingRds table from the database.
+func AwsProductPricingRdsPurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_rds WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// AwsProductPricingRdsOnDemandPrices returns the on-demand prices of the RDS
+// products matching an instance type, region code, database engine and
+// deployment option, cheapest first.
+func AwsProductPricingRdsOnDemandPrices(db XODB, instanceType string, regionCode string, databaseEngine string, deploymentOption string) ([]*AwsProductPricingRdsPrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`p.sku, p.etag, p.term_type, p.lease_contract_length, p.purchase_option, p.offering_class, p.hourly_price, p.upfront_price, p.currency ` +
+		`FROM trackit.aws_product_pricing_rds_price AS p ` +
+		`JOIN trackit.aws_product_pricing_rds AS a ON a.sku = p.sku AND a.etag = p.etag ` +
+		`WHERE a.instance_type = ? AND a.region_code = ? AND a.database_engine = ? AND a.deployment_option = ? AND p.term_type = "OnDemand" ` +
+		`ORDER BY p.hourly_price`
+	XOLog(sqlstr, instanceType, regionCode, databaseEngine, deploymentOption)
+	q, err := db.Query(sqlstr, instanceType, regionCode, databaseEngine, deploymentOption)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingRdsPrice{}
+	for q.Next() {
+		appp := AwsProductPricingRdsPrice{
+			_exists: true,
+		}
+		err = q.Scan(&appp.Sku, &appp.Etag, &appp.TermType, &appp.LeaseContractLength, &appp.PurchaseOption, &appp.OfferingClass, &appp.HourlyPrice, &appp.UpfrontPrice, &appp.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appp)
+	}
+	return res, nil
+}
+
+// AwsProductPricingRdsPricesByProduct returns the on-demand and reserved
+// prices of the RDS products matching an instance type, region code,
+// database engine and deployment option.
+func AwsProductPricingRdsPricesByProduct(db XODB, instanceType string, regionCode string, databaseEngine string, deploymentOption string) ([]*AwsProductPricingRdsPrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`p.sku, p.etag, p.term_type, p.lease_contract_length, p.purchase_option, p.offering_class, p.hourly_price, p.upfront_price, p.currency ` +
+		`FROM trackit.aws_product_pricing_rds_price AS p ` +
+		`JOIN trackit.aws_product_pricing_rds AS a ON a.sku = p.sku AND a.etag = p.etag ` +
+		`WHERE a.instance_type = ? AND a.region_code = ? AND a.database_engine = ? AND a.deployment_option = ?`
+	XOLog(sqlstr, instanceType, regionCode, databaseEngine, deploymentOption)
+	q, err := db.Query(sqlstr, instanceType, regionCode, databaseEngine, deploymentOption)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingRdsPrice{}
+	for q.Next() {
+		appp := AwsProductPricingRdsPrice{
+			_exists: true,
+		}
+		err = q.Scan(&appp.Sku, &appp.Etag, &appp.TermType, &appp.LeaseContractLength, &appp.PurchaseOption, &appp.OfferingClass, &appp.HourlyPrice, &appp.UpfrontPrice, &appp.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &appp)
+	}
+	return res, nil
+}
+
+// ToSlice transforms AwsProductPricingRds to an array of interface{}.
+func (appr *AwsProductPricingRds) ToSlice() []interface{} {
+	res := make([]interface{}, 11)
+	res[0] = appr.Sku
+	res[1] = appr.Etag
+	res[2] = appr.Region
+	res[3] = appr.RegionCode
+	res[4] = appr.InstanceType
+	res[5] = appr.DatabaseEngine
+	res[6] = appr.DatabaseEdition
+	res[7] = appr.LicenseModel
+	res[8] = appr.DeploymentOption
+	res[9] = appr.Vcpu
+	res[10] = appr.Memory
+	return res
+}
+
+type AwsProductPricingRdsBulk struct {
+	Bulk      []AwsProductPricingRds
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (apprb *AwsProductPricingRdsBulk) AppendAndInsertIfLimitExceeded(appr AwsProductPricingRds, db XODB) error {
+	apprb.Bulk = append(apprb.Bulk, appr)
+	apprb._count++
+	if apprb._count < apprb.BulkLimit {
+		return nil
+	}
+	if err := apprb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	apprb.Bulk = nil
+	apprb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingRds to the database or update
+// if key already exists.
+func (apprb *AwsProductPricingRdsBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(apprb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(apprb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_rds (` +
+		`sku, etag, region, region_code, instance_type, database_engine, database_edition, license_model, deployment_option, vcpu, memory` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), region=VALUES(region), region_code=VALUES(region_code), instance_type=VALUES(instance_type), database_engine=VALUES(database_engine), database_edition=VALUES(database_edition), license_model=VALUES(license_model), deployment_option=VALUES(deployment_option), vcpu=VALUES(vcpu), memory=VALUES(memory)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(apprb.Bulk); i++ {
+		nvalues = append(nvalues, apprb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(apprb.Bulk); i++ {
+		apprb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+)
+
+// AwsProductPricingRdsPricePurgeWhenNotEtag purges the AwsProductPricingRdsPrice table from the database.
+func AwsProductPricingRdsPricePurgeWhenNotEtag(etag string, db XODB) error {
+	// sql query
+	const sqlstr = `DELETE FROM trackit.aws_product_pricing_rds_price WHERE etag != ?`
+
+	// run query
+	XOLog(sqlstr, etag)
+	_, err := db.Exec(sqlstr, etag)
+	return err
+}
+
+// AwsProductPricingRdsPricesBySkuEtag returns the prices of the product with
+// a given SKU in a given version of the offer.
+func AwsProductPricingRdsPricesBySkuEtag(db XODB, sku string, etag string) ([]*AwsProductPricingRdsPrice, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`sku, etag, term_type, lease_contract_length, purchase_option, offering_class, hourly_price, upfront_price, currency ` +
+		`FROM trackit.aws_product_pricing_rds_price ` +
+		`WHERE sku = ? AND etag = ?`
+	XOLog(sqlstr, sku, etag)
+	q, err := db.Query(sqlstr, sku, etag)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AwsProductPricingRdsPrice{}
+	for q.Next() {
+		apprp := AwsProductPricingRdsPrice{
+			_exists: true,
+		}
+		err = q.Scan(&apprp.Sku, &apprp.Etag, &apprp.TermType, &apprp.LeaseContractLength, &apprp.PurchaseOption, &apprp.OfferingClass, &apprp.HourlyPrice, &apprp.UpfrontPrice, &apprp.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &apprp)
+	}
+	return res, nil
+}
+
+// ToSlice transforms AwsProductPricingRdsPrice to an array of interface{}.
+func (apprp *AwsProductPricingRdsPrice) ToSlice() []interface{} {
+	res := make([]interface{}, 9)
+	res[0] = apprp.Sku
+	res[1] = apprp.Etag
+	res[2] = apprp.TermType
+	res[3] = apprp.LeaseContractLength
+	res[4] = apprp.PurchaseOption
+	res[5] = apprp.OfferingClass
+	res[6] = apprp.HourlyPrice
+	res[7] = apprp.UpfrontPrice
+	res[8] = apprp.Currency
+	return res
+}
+
+type AwsProductPricingRdsPriceBulk struct {
+	Bulk      []AwsProductPricingRdsPrice
+	BulkLimit int
+	_count    int
+}
+
+// AppendAndInsertIfLimitExceeded appends to the bulk and insert to
+// the database if the limit is exceeded.
+func (apprpb *AwsProductPricingRdsPriceBulk) AppendAndInsertIfLimitExceeded(apprp AwsProductPricingRdsPrice, db XODB) error {
+	apprpb.Bulk = append(apprpb.Bulk, apprp)
+	apprpb._count++
+	if apprpb._count < apprpb.BulkLimit {
+		return nil
+	}
+	if err := apprpb.BulkInsertOrUpdate(db); err != nil {
+		return err
+	}
+	apprpb.Bulk = nil
+	apprpb._count = 0
+	return nil
+}
+
+// BulkInsertOrUpdate inserts the AwsProductPricingRdsPrice to the database or update
+// if key already exists.
+func (apprpb *AwsProductPricingRdsPriceBulk) BulkInsertOrUpdate(db XODB) error {
+	if len(apprpb.Bulk) == 0 {
+		return nil
+	}
+	values := make([]string, 0)
+	for i := 0; i < len(apprpb.Bulk); i++ {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	}
+
+	// sql insert query, primary key must be provided
+	sqlstr := `INSERT INTO trackit.aws_product_pricing_rds_price (` +
+		`sku, etag, term_type, lease_contract_length, purchase_option, offering_class, hourly_price, upfront_price, currency` +
+		`) VALUES` + strings.Join(values, ",") +
+		`ON DUPLICATE KEY UPDATE ` +
+		`sku=VALUES(sku), etag=VALUES(etag), term_type=VALUES(term_type), lease_contract_length=VALUES(lease_contract_length), purchase_option=VALUES(purchase_option), offering_class=VALUES(offering_class), hourly_price=VALUES(hourly_price), upfront_price=VALUES(upfront_price), currency=VALUES(currency)`
+
+	nvalues := make([]interface{}, 0)
+	for i := 0; i < len(apprpb.Bulk); i++ {
+		nvalues = append(nvalues, apprpb.Bulk[i].ToSlice()...)
+	}
+	// run query
+	XOLog(sqlstr, nvalues...)
+	_, err := db.Exec(sqlstr, nvalues...)
+	if err != nil {
+		return err
+	}
+
+	// set existence
+	for i := 0; i < len(apprpb.Bulk); i++ {
+		apprpb.Bulk[i]._exists = true
+	}
+
+	return nil
+}
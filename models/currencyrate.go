@@ -0,0 +1,42 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// AllCurrencyRates returns all the currency conversion rates.
+func AllCurrencyRates(db XODB) ([]*CurrencyRate, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, currency, rate, updated ` +
+		`FROM trackit.currency_rate`
+	XOLog(sqlstr)
+	q, err := db.Query(sqlstr)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*CurrencyRate{}
+	for q.Next() {
+		cr := CurrencyRate{
+			_exists: true,
+		}
+		err = q.Scan(&cr.ID, &cr.Currency, &cr.Rate, &cr.Updated)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &cr)
+	}
+	return res, nil
+}
@@ -0,0 +1,37 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import "time"
+
+// IsBudgetAlertAlreadyEmailed checks if an alert for a budget threshold has
+// already been sent for a given period.
+func IsBudgetAlertAlreadyEmailed(db XODB, budgetId int, threshold int, forecasted bool, period time.Time) (bool, error) {
+	const sqlstr = `SELECT ` +
+		`id, budget_id, threshold, forecasted, period, recipient, date ` +
+		`FROM trackit.emailed_budget_alert ` +
+		`WHERE budget_id = ? AND threshold = ? AND forecasted = ? AND period = ?`
+	XOLog(sqlstr, budgetId, threshold, forecasted, period)
+	q, err := db.Query(sqlstr, budgetId, threshold, forecasted, period)
+	if err != nil {
+		return false, err
+	}
+	defer q.Close()
+	if q.Next() {
+		return true, nil
+	}
+	return false, nil
+}
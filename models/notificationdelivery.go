@@ -0,0 +1,65 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// LatestNotificationDeliveriesByAwsAccountID returns the latest deliveries of
+// notifications for an AWS account, most recent first.
+func LatestNotificationDeliveriesByAwsAccountID(db XODB, awsAccountID int, limit int) ([]*NotificationDelivery, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, aws_account_id, event, event_key, channel, target, status, attempts, error, date, payload ` +
+		`FROM trackit.notification_delivery ` +
+		`WHERE aws_account_id = ? ` +
+		`ORDER BY date DESC, id DESC LIMIT ?`
+	XOLog(sqlstr, awsAccountID, limit)
+	q, err := db.Query(sqlstr, awsAccountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*NotificationDelivery{}
+	for q.Next() {
+		nd := NotificationDelivery{
+			_exists: true,
+		}
+		err = q.Scan(&nd.ID, &nd.AwsAccountID, &nd.Event, &nd.EventKey, &nd.Channel, &nd.Target, &nd.Status, &nd.Attempts, &nd.Error, &nd.Date, &nd.Payload)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &nd)
+	}
+	return res, nil
+}
+
+// ClaimNotificationDelivery sets the status of a delivery to sending if it
+// is pending, or if it has been sending since before staleBefore. It returns
+// false if another worker claimed it first.
+func ClaimNotificationDelivery(db XODB, id int, pending string, sending string, now time.Time, staleBefore time.Time) (bool, error) {
+	const sqlstr = `UPDATE trackit.notification_delivery SET ` +
+		`status = ?, date = ? ` +
+		`WHERE id = ? AND (status = ? OR (status = ? AND date < ?))`
+	XOLog(sqlstr, sending, now, id, pending, sending, staleBefore)
+	res, err := db.Exec(sqlstr, sending, now, id, pending, sending, staleBefore)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
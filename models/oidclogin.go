@@ -0,0 +1,43 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// DeleteExpiredOidcLogins deletes the OIDC logins which were not completed in
+// time.
+func DeleteExpiredOidcLogins(db XODB, now time.Time) error {
+	const sqlstr = `DELETE FROM trackit.oidc_login WHERE expires < ?`
+	XOLog(sqlstr, now)
+	_, err := db.Exec(sqlstr, now)
+	return err
+}
+
+// DeleteOidcLoginByStateHash deletes the OIDC login with a state hash, and
+// tells whether it existed, so that concurrent completions of a login cannot
+// both succeed.
+func DeleteOidcLoginByStateHash(db XODB, stateHash string) (bool, error) {
+	const sqlstr = `DELETE FROM trackit.oidc_login WHERE state_hash = ?`
+	XOLog(sqlstr, stateHash)
+	res, err := db.Exec(sqlstr, stateHash)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	return deleted > 0, err
+}
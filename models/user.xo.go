@@ -17,6 +17,7 @@ type User struct {
 	ParentUserID           sql.NullInt64  `json:"parent_user_id"`           // parent_user_id
 	AwsCustomerIdentifier  string         `json:"aws_customer_identifier"`  // aws_customer_identifier
 	AwsCustomerEntitlement bool           `json:"aws_customer_entitlement"` // aws_customer_entitlement
+	DisplayCurrency        string         `json:"display_currency"`         // display_currency
 
 	// xo fields
 	_exists, _deleted bool
@@ -43,14 +44,14 @@ func (u *User) Insert(db XODB) error {
 
 	// sql insert query, primary key provided by autoincrement
 	const sqlstr = `INSERT INTO trackit.user (` +
-		`email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement` +
+		`email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement, display_currency` +
 		`) VALUES (` +
-		`?, ?, ?, ?, ?, ?` +
+		`?, ?, ?, ?, ?, ?, ?` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement)
-	res, err := db.Exec(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement)
+	XOLog(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement, u.DisplayCurrency)
+	res, err := db.Exec(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement, u.DisplayCurrency)
 	if err != nil {
 		return err
 	}
@@ -84,12 +85,12 @@ func (u *User) Update(db XODB) error {
 
 	// sql query
 	const sqlstr = `UPDATE trackit.user SET ` +
-		`email = ?, auth = ?, next_external = ?, parent_user_id = ?, aws_customer_identifier = ?, aws_customer_entitlement = ?` +
+		`email = ?, auth = ?, next_external = ?, parent_user_id = ?, aws_customer_identifier = ?, aws_customer_entitlement = ?, display_currency = ?` +
 		` WHERE id = ?`
 
 	// run query
-	XOLog(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement, u.ID)
-	_, err = db.Exec(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement, u.ID)
+	XOLog(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement, u.DisplayCurrency, u.ID)
+	_, err = db.Exec(sqlstr, u.Email, u.Auth, u.NextExternal, u.ParentUserID, u.AwsCustomerIdentifier, u.AwsCustomerEntitlement, u.DisplayCurrency, u.ID)
 	return err
 }
 
@@ -147,7 +148,7 @@ func UsersByParentUserID(db XODB, parentUserID sql.NullInt64) ([]*User, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement ` +
+		`id, email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement, display_currency ` +
 		`FROM trackit.user ` +
 		`WHERE parent_user_id = ?`
 
@@ -167,7 +168,7 @@ func UsersByParentUserID(db XODB, parentUserID sql.NullInt64) ([]*User, error) {
 		}
 
 		// scan
-		err = q.Scan(&u.ID, &u.Email, &u.Auth, &u.NextExternal, &u.ParentUserID, &u.AwsCustomerIdentifier, &u.AwsCustomerEntitlement)
+		err = q.Scan(&u.ID, &u.Email, &u.Auth, &u.NextExternal, &u.ParentUserID, &u.AwsCustomerIdentifier, &u.AwsCustomerEntitlement, &u.DisplayCurrency)
 		if err != nil {
 			return nil, err
 		}
@@ -186,7 +187,7 @@ func UserByEmail(db XODB, email string) (*User, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement ` +
+		`id, email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement, display_currency ` +
 		`FROM trackit.user ` +
 		`WHERE email = ?`
 
@@ -196,7 +197,7 @@ func UserByEmail(db XODB, email string) (*User, error) {
 		_exists: true,
 	}
 
-	err = db.QueryRow(sqlstr, email).Scan(&u.ID, &u.Email, &u.Auth, &u.NextExternal, &u.ParentUserID, &u.AwsCustomerIdentifier, &u.AwsCustomerEntitlement)
+	err = db.QueryRow(sqlstr, email).Scan(&u.ID, &u.Email, &u.Auth, &u.NextExternal, &u.ParentUserID, &u.AwsCustomerIdentifier, &u.AwsCustomerEntitlement, &u.DisplayCurrency)
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +213,7 @@ func UserByID(db XODB, id int) (*User, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement ` +
+		`id, email, auth, next_external, parent_user_id, aws_customer_identifier, aws_customer_entitlement, display_currency ` +
 		`FROM trackit.user ` +
 		`WHERE id = ?`
 
@@ -222,7 +223,7 @@ func UserByID(db XODB, id int) (*User, error) {
 		_exists: true,
 	}
 
-	err = db.QueryRow(sqlstr, id).Scan(&u.ID, &u.Email, &u.Auth, &u.NextExternal, &u.ParentUserID, &u.AwsCustomerIdentifier, &u.AwsCustomerEntitlement)
+	err = db.QueryRow(sqlstr, id).Scan(&u.ID, &u.Email, &u.Auth, &u.NextExternal, &u.ParentUserID, &u.AwsCustomerIdentifier, &u.AwsCustomerEntitlement, &u.DisplayCurrency)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,59 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// RotateUserSessionRefreshHash replaces the refresh hash of a session, keeping
+// the replaced one to detect its reuse as long as the session exists. It
+// fails, returning false, if the refresh hash is not the current one anymore,
+// so that a refresh token can only be used once even by concurrent requests.
+func RotateUserSessionRefreshHash(db XODB, id int, refreshHash, newRefreshHash string, expires time.Time) (bool, error) {
+	const sqlstr = `UPDATE trackit.user_session SET ` +
+		`refresh_hash = ?, expires = ? ` +
+		`WHERE id = ? AND refresh_hash = ? AND revoked IS NULL`
+	XOLog(sqlstr, newRefreshHash, expires, id, refreshHash)
+	res, err := db.Exec(sqlstr, newRefreshHash, expires, id, refreshHash)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return false, err
+	}
+	rotated := UserSessionRotatedRefreshHash{
+		RefreshHash: refreshHash,
+		SessionID:   id,
+	}
+	return true, rotated.Insert(db)
+}
+
+// RevokeUserSession revokes a session.
+func RevokeUserSession(db XODB, id int) error {
+	const sqlstr = `UPDATE trackit.user_session SET revoked = NOW() WHERE id = ? AND revoked IS NULL`
+	XOLog(sqlstr, id)
+	_, err := db.Exec(sqlstr, id)
+	return err
+}
+
+// RevokeUserSessions revokes all the sessions of a user.
+func RevokeUserSessions(db XODB, userID int) error {
+	const sqlstr = `UPDATE trackit.user_session SET revoked = NOW() WHERE user_id = ? AND revoked IS NULL`
+	XOLog(sqlstr, userID)
+	_, err := db.Exec(sqlstr, userID)
+	return err
+}
@@ -0,0 +1,207 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ChannelSendFunc delivers an event to an endpoint.
+type ChannelSendFunc func(ctx context.Context, endpoint Endpoint, event Event) error
+
+// Channel is a way to deliver events, such as emails or webhooks.
+type Channel struct {
+	Name        string
+	Description string
+	// Validate checks the target of an endpoint is valid for the channel.
+	Validate func(target string) error
+	Send     ChannelSendFunc
+}
+
+// RegisteredChannels contains all the registered channels, with their
+// name as the key.
+var RegisteredChannels = make(map[string]Channel)
+
+// ErrForbiddenAddress is returned when an HTTP target is not on the public
+// internet, so that users cannot reach the network of the server.
+var ErrForbiddenAddress = errors.New("target must be a public address")
+
+// forbiddenNetworks are the networks HTTP targets cannot be in: loopback,
+// private, shared, link-local, multicast and reserved addresses.
+var forbiddenNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// isAllowedIp tells whether HTTP requests can be sent to an IP address.
+var isAllowedIp = func(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupIPAddr resolves the host of HTTP targets.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// httpDialer connects the HTTP client to the resolved targets.
+var httpDialer = &net.Dialer{Timeout: 5 * time.Second}
+
+// httpClient is used by the channels sending HTTP requests. It only connects
+// to allowed addresses and does not follow redirections.
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         dialAllowed,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	res := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		res[i] = network
+	}
+	return res
+}
+
+// dialAllowed resolves the host of an address and connects to it, unless it
+// resolves to an address which is not allowed. The host is resolved again
+// since it can differ from when the target was validated.
+func dialAllowed(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	} else if len(addrs) == 0 {
+		return nil, fmt.Errorf("failed to resolve %s", host)
+	}
+	for _, addr := range addrs {
+		if !isAllowedIp(addr.IP) {
+			return nil, ErrForbiddenAddress
+		}
+	}
+	var conn net.Conn
+	for _, addr := range addrs {
+		if conn, err = httpDialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// Register registers a channel so that endpoints can use it.
+func (c Channel) Register() Channel {
+	RegisteredChannels[c.Name] = c
+	return c
+}
+
+// GetChannel returns the channel registered with a name.
+func GetChannel(name string) (Channel, error) {
+	if c, ok := RegisteredChannels[name]; ok {
+		return c, nil
+	}
+	return Channel{}, fmt.Errorf("unknown channel %q", name)
+}
+
+// validateHttpUrl checks a target is an absolute HTTP or HTTPS URL whose
+// host only resolves to allowed addresses.
+func validateHttpUrl(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("target must be an HTTP or HTTPS URL")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !isAllowedIp(ip) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+	addrs, err := lookupIPAddr(context.Background(), u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("failed to resolve %s", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !isAllowedIp(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// postJson sends a JSON body to an URL and fails unless the response status
+// code is 2xx. Redirections are failures too.
+func postJson(ctx context.Context, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return nil
+}
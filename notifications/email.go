@@ -0,0 +1,42 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	netmail "net/mail"
+
+	"github.com/trackit/trackit-server/mail"
+)
+
+// ChannelEmail emails events through the configured SMTP server.
+const ChannelEmail = "email"
+
+func init() {
+	Channel{
+		Name:        ChannelEmail,
+		Description: "Emails the event to the target address through SMTP.",
+		Validate: func(target string) error {
+			_, err := netmail.ParseAddress(target)
+			return err
+		},
+		Send: sendEmail,
+	}.Register()
+}
+
+// sendEmail emails an event to the address of an endpoint.
+func sendEmail(ctx context.Context, endpoint Endpoint, event Event) error {
+	return mail.SendMail(endpoint.Target, event.Subject, event.Message, ctx)
+}
@@ -0,0 +1,134 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/models"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("notification endpoint not found")
+)
+
+// Endpoint is a destination of the events of an AWS account, on a channel.
+type Endpoint struct {
+	Id           int    `json:"id"`
+	AwsAccountId int    `json:"awsAccountId"`
+	Channel      string `json:"channel"`
+	// Target is the address or URL the events are sent to, depending on
+	// the channel.
+	Target string `json:"target"`
+	// Secret signs the webhooks sent to the endpoint.
+	Secret string `json:"secret,omitempty"`
+	// Events are the types of the events sent to the endpoint. All of
+	// them are sent when it is empty.
+	Events  []string  `json:"events"`
+	Created time.Time `json:"created"`
+}
+
+// Validate checks an Endpoint is well-formed.
+func (e Endpoint) Validate() error {
+	channel, err := GetChannel(e.Channel)
+	if err != nil {
+		return err
+	} else if err = channel.Validate(e.Target); err != nil {
+		return fmt.Errorf("invalid target for channel %s: %s", e.Channel, err.Error())
+	}
+	for _, eventType := range e.Events {
+		if !isEventType(eventType) {
+			return fmt.Errorf("unknown event %q", eventType)
+		}
+	}
+	return nil
+}
+
+// Subscribes returns whether the events of a type are sent to the Endpoint.
+func (e Endpoint) Subscribes(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEndpointsForAwsAccount retrieves the notification endpoints of an
+// AwsAccount.
+func GetEndpointsForAwsAccount(aa aws.AwsAccount, tx *sql.Tx) ([]Endpoint, error) {
+	dbEndpoints, err := models.NotificationEndpointsByAwsAccountID(tx, aa.Id)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Endpoint, len(dbEndpoints))
+	for i, dbEndpoint := range dbEndpoints {
+		res[i] = endpointFromDbEndpoint(*dbEndpoint)
+	}
+	return res, nil
+}
+
+// CreateEndpoint adds a notification endpoint to an AwsAccount.
+func CreateEndpoint(aa aws.AwsAccount, e Endpoint, tx *sql.Tx) (Endpoint, error) {
+	dbEndpoint := models.NotificationEndpoint{
+		AwsAccountID: aa.Id,
+		Channel:      e.Channel,
+		Target:       e.Target,
+		Secret:       e.Secret,
+		Events:       strings.Join(e.Events, ","),
+		Created:      time.Now(),
+	}
+	if err := dbEndpoint.Insert(tx); err != nil {
+		return e, err
+	}
+	return endpointFromDbEndpoint(dbEndpoint), nil
+}
+
+// DeleteEndpoint deletes a notification endpoint of an AwsAccount.
+func DeleteEndpoint(aa aws.AwsAccount, endpointId int, tx *sql.Tx) error {
+	dbEndpoint, err := models.NotificationEndpointByID(tx, endpointId)
+	if err == sql.ErrNoRows {
+		return ErrEndpointNotFound
+	} else if err != nil {
+		return err
+	} else if dbEndpoint.AwsAccountID != aa.Id {
+		return ErrEndpointNotFound
+	}
+	return dbEndpoint.Delete(tx)
+}
+
+func endpointFromDbEndpoint(dbEndpoint models.NotificationEndpoint) Endpoint {
+	events := []string{}
+	if dbEndpoint.Events != "" {
+		events = strings.Split(dbEndpoint.Events, ",")
+	}
+	return Endpoint{
+		Id:           dbEndpoint.ID,
+		AwsAccountId: dbEndpoint.AwsAccountID,
+		Channel:      dbEndpoint.Channel,
+		Target:       dbEndpoint.Target,
+		Secret:       dbEndpoint.Secret,
+		Events:       events,
+		Created:      dbEndpoint.Created,
+	}
+}
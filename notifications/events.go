@@ -0,0 +1,64 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"time"
+)
+
+const (
+	// EventAnomaly is sent when a cost anomaly is detected.
+	EventAnomaly = "anomaly"
+	// EventBudget is sent when the spend of a budget reaches a threshold.
+	EventBudget = "budget"
+	// EventIngestionFailure is sent when the billing data of a bill
+	// repository could not be ingested.
+	EventIngestionFailure = "ingestion-failure"
+)
+
+// EventTypes lists the types of events endpoints can subscribe to.
+var EventTypes = []string{
+	EventAnomaly,
+	EventBudget,
+	EventIngestionFailure,
+}
+
+// Event is something which happened on an AWS account and is sent to the
+// notification endpoints of this account.
+type Event struct {
+	// Type is one of EventTypes.
+	Type string `json:"type"`
+	// Key identifies the event. An event is delivered once per endpoint
+	// for a given key.
+	Key            string    `json:"key"`
+	AwsAccountId   int       `json:"awsAccountId"`
+	AwsIdentity    string    `json:"awsIdentity"`
+	AwsAccountName string    `json:"awsAccountName"`
+	Subject        string    `json:"subject"`
+	Message        string    `json:"message"`
+	Date           time.Time `json:"date"`
+	// Data holds the details of the event, depending on its type.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// isEventType checks a string is one of EventTypes.
+func isEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+// deliveriesLimit is the number of deliveries returned by getDeliveries.
+const deliveriesLimit = 100
+
+// endpointRequestBody is the expected request body for the postEndpoint
+// request handler.
+type endpointRequestBody struct {
+	Channel string   `json:"channel" req:"nonzero"`
+	Target  string   `json:"target"  req:"nonzero"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getEndpoints).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.Documentation{
+				Summary:     "get aws account's notification endpoints",
+				Description: "Gets the list of the endpoints the events of an AWS account are sent to. Webhook secrets are not returned.",
+			},
+		),
+		http.MethodPost: routes.H(postEndpoint).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{endpointRequestBody{
+				Channel: ChannelWebhook,
+				Target:  "https://example.com/trackit",
+				Secret:  "s3cr3t",
+				Events:  []string{EventAnomaly, EventBudget},
+			}},
+			routes.Documentation{
+				Summary:     "add a notification endpoint to an aws account",
+				Description: "Adds an endpoint the events of an AWS account are sent to. The channel is one of email, webhook or slack. An endpoint without events receives all of them.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteEndpoint).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.QueryArgs{routes.NotificationEndpointIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete a notification endpoint from an aws account",
+				Description: "Deletes a notification endpoint from an AWS account.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.QueryArgs{routes.AwsAccountIdQueryArg},
+		routes.Documentation{
+			Summary:     "interact with aws account's notification endpoints",
+			Description: "Anomaly, budget and ingestion failure events are sent to every endpoint of an AWS account. When an account has no endpoint, they are emailed to its owner.",
+		},
+	).Register("/notifications/endpoints")
+
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getDeliveries).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			aws.RequireAwsAccountId{},
+			routes.Documentation{
+				Summary:     "get aws account's notification deliveries",
+				Description: "Gets the latest deliveries of the events of an AWS account, with their status and number of attempts.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.QueryArgs{routes.AwsAccountIdQueryArg},
+		routes.Documentation{
+			Summary:     "interact with aws account's notification delivery log",
+			Description: "Every delivery of an event to an endpoint is logged.",
+		},
+	).Register("/notifications/deliveries")
+}
+
+// getEndpoints is a route handler which lists the notification endpoints of
+// an AwsAccount.
+func getEndpoints(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	endpoints, err := GetEndpointsForAwsAccount(aa, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to retrieve notification endpoints.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to retrieve notification endpoints")
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return http.StatusOK, endpoints
+}
+
+// postEndpoint is a route handler which adds a notification endpoint to an
+// AwsAccount.
+func postEndpoint(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body endpointRequestBody
+	routes.MustRequestBody(a, &body)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	endpoint := Endpoint{
+		Channel: body.Channel,
+		Target:  body.Target,
+		Secret:  body.Secret,
+		Events:  body.Events,
+	}
+	if err := endpoint.Validate(); err != nil {
+		return http.StatusBadRequest, errors.New(fmt.Sprintf("Body is invalid (%s).", err.Error()))
+	}
+	endpoint, err := CreateEndpoint(aa, endpoint, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to create notification endpoint.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to create notification endpoint")
+	}
+	endpoint.Secret = ""
+	return http.StatusOK, endpoint
+}
+
+// deleteEndpoint is a route handler which deletes a notification endpoint
+// from an AwsAccount.
+func deleteEndpoint(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	endpointId := a[routes.NotificationEndpointIdQueryArg].(int)
+	err := DeleteEndpoint(aa, endpointId, tx)
+	switch err {
+	case nil:
+		return http.StatusOK, nil
+	case ErrEndpointNotFound:
+		return http.StatusNotFound, err
+	default:
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to delete notification endpoint.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"endpointId":   endpointId,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to delete notification endpoint")
+	}
+}
+
+// getDeliveries is a route handler which lists the latest deliveries of the
+// events of an AwsAccount.
+func getDeliveries(r *http.Request, a routes.Arguments) (int, interface{}) {
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	tx := a[db.Transaction].(*sql.Tx)
+	deliveries, err := GetDeliveries(aa, deliveriesLimit, tx)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(r.Context())
+		l.Error("Failed to retrieve notification deliveries.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("failed to retrieve notification deliveries")
+	}
+	return http.StatusOK, deliveries
+}
@@ -0,0 +1,315 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+var testEvent = Event{
+	Type:           EventBudget,
+	Key:            "budget:42:2018-06:80:false",
+	AwsAccountId:   1,
+	AwsIdentity:    "123456789012",
+	AwsAccountName: "Production",
+	Subject:        "Budget \"EC2\" has reached 80%",
+	Message:        "Hi, the spend of your budget \"EC2\" has reached $800.00.",
+	Date:           time.Date(2018, time.June, 12, 0, 0, 0, 0, time.UTC),
+}
+
+// allowLoopback lets the HTTP client reach test servers, which listen on the
+// loopback interface. The returned function restores the check.
+func allowLoopback() func() {
+	previous := isAllowedIp
+	isAllowedIp = func(ip net.IP) bool { return ip.IsLoopback() || previous(ip) }
+	return func() { isAllowedIp = previous }
+}
+
+// resolveTo makes every host resolve to ips. The returned function restores
+// the resolver.
+func resolveTo(ips ...string) func() {
+	previous := lookupIPAddr
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		addrs := make([]net.IPAddr, len(ips))
+		for i, ip := range ips {
+			addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+		}
+		return addrs, nil
+	}
+	return func() { lookupIPAddr = previous }
+}
+
+func TestWebhookIsSigned(t *testing.T) {
+	defer allowLoopback()()
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	endpoint := Endpoint{Channel: ChannelWebhook, Target: server.URL, Secret: "s3cr3t"}
+	if err := sendWebhook(context.Background(), endpoint, testEvent); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if headers.Get(HeaderEvent) != EventBudget || headers.Get(HeaderDelivery) != testEvent.Key {
+		t.Errorf("Unexpected event headers: %v", headers)
+	}
+	timestamp, err := strconv.ParseInt(headers.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("Invalid timestamp: %s", err)
+	}
+	if expected := SignWebhook("s3cr3t", timestamp, body); headers.Get(HeaderSignature) != expected {
+		t.Errorf("Expected signature %s, got %s.", expected, headers.Get(HeaderSignature))
+	}
+	var received Event
+	if err := json.Unmarshal(body, &received); err != nil || received.Key != testEvent.Key {
+		t.Errorf("Unexpected body: %s", body)
+	}
+}
+
+func TestWebhookWithoutSecretIsNotSigned(t *testing.T) {
+	defer allowLoopback()()
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+	}))
+	defer server.Close()
+	endpoint := Endpoint{Channel: ChannelWebhook, Target: server.URL}
+	if err := sendWebhook(context.Background(), endpoint, testEvent); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if signature := headers.Get(HeaderSignature); signature != "" {
+		t.Errorf("Expected no signature, got %s.", signature)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	expected := "sha256=dc31e2b40ba4a48c3e2d45ed648e7ce6f502954e6adc304381d7556494f1b2c7"
+	if signature := SignWebhook("key", 1528761600, []byte(`{}`)); signature != expected {
+		t.Errorf("Expected signature %s, got %s.", expected, signature)
+	}
+	if SignWebhook("key", 1528761600, []byte(`{}`)) == SignWebhook("key", 1528761601, []byte(`{}`)) {
+		t.Errorf("Expected the signature to depend on the timestamp.")
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	defer allowLoopback()()
+	var message slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&message)
+	}))
+	defer server.Close()
+	endpoint := Endpoint{Channel: ChannelSlack, Target: server.URL}
+	if err := sendSlack(context.Background(), endpoint, testEvent); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if message.Text != testEvent.Subject {
+		t.Errorf("Expected text %q, got %q.", testEvent.Subject, message.Text)
+	}
+	if len(message.Attachments) != 1 || message.Attachments[0].Text != testEvent.Message || message.Attachments[0].Ts != testEvent.Date.Unix() {
+		t.Errorf("Unexpected attachments: %v", message.Attachments)
+	}
+}
+
+func TestHttpChannelsFailOnErrorStatus(t *testing.T) {
+	defer allowLoopback()()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	endpoint := Endpoint{Target: server.URL}
+	if err := sendWebhook(context.Background(), endpoint, testEvent); err == nil {
+		t.Errorf("Expected webhook to fail.")
+	}
+	if err := sendSlack(context.Background(), endpoint, testEvent); err == nil {
+		t.Errorf("Expected Slack message to fail.")
+	}
+}
+
+func TestHttpChannelsRefuseInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	endpoint := Endpoint{Target: server.URL}
+	if err := sendWebhook(context.Background(), endpoint, testEvent); err == nil {
+		t.Errorf("Expected webhook to a loopback address to fail.")
+	}
+}
+
+func TestHttpChannelsDoNotFollowRedirections(t *testing.T) {
+	defer allowLoopback()()
+	var redirected bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+	endpoint := Endpoint{Target: server.URL}
+	if err := sendWebhook(context.Background(), endpoint, testEvent); err == nil {
+		t.Errorf("Expected redirected webhook to fail.")
+	}
+	if redirected {
+		t.Errorf("Expected redirection not to be followed.")
+	}
+}
+
+func TestIsAllowedIp(t *testing.T) {
+	cases := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.31.0.2", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+	}
+	for _, c := range cases {
+		if allowed := isAllowedIp(net.ParseIP(c.ip)); allowed != c.allowed {
+			t.Errorf("Allowed for %s should be %t, is %t instead.", c.ip, c.allowed, allowed)
+		}
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	defer func(delays []time.Duration) { retryDelays = delays }(retryDelays)
+	retryDelays = []time.Duration{0, 0}
+	var calls int
+	Channel{
+		Name:     "test-flaky",
+		Validate: func(string) error { return nil },
+		Send: func(ctx context.Context, endpoint Endpoint, event Event) error {
+			calls++
+			if calls < 3 {
+				return errors.New("unavailable")
+			}
+			return nil
+		},
+	}.Register()
+	defer delete(RegisteredChannels, "test-flaky")
+	delivery := deliver(context.Background(), Endpoint{Channel: "test-flaky"}, testEvent)
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 3 || delivery.Error != "" {
+		t.Errorf("Expected delivery after 3 attempts, got %v.", delivery)
+	}
+	calls = -10
+	delivery = deliver(context.Background(), Endpoint{Channel: "test-flaky"}, testEvent)
+	if delivery.Status != DeliveryFailed || delivery.Attempts != 3 || delivery.Error != "unavailable" {
+		t.Errorf("Expected failure after 3 attempts, got %v.", delivery)
+	}
+}
+
+func TestSendDeliveryLooksUpEndpoint(t *testing.T) {
+	defer func(delays []time.Duration) { retryDelays = delays }(retryDelays)
+	retryDelays = nil
+	var secret string
+	Channel{
+		Name:     "test-secret",
+		Validate: func(string) error { return nil },
+		Send: func(ctx context.Context, endpoint Endpoint, event Event) error {
+			secret = endpoint.Secret
+			if event.Key != testEvent.Key {
+				return errors.New("unexpected event")
+			}
+			return nil
+		},
+	}.Register()
+	defer delete(RegisteredChannels, "test-secret")
+	payload, _ := json.Marshal(testEvent)
+	dbDelivery := models.NotificationDelivery{
+		AwsAccountID: 1,
+		Event:        testEvent.Type,
+		EventKey:     testEvent.Key,
+		Channel:      "test-secret",
+		Target:       "target",
+		Status:       DeliveryPending,
+		Payload:      string(payload),
+	}
+	dbEndpoints := []*models.NotificationEndpoint{{AwsAccountID: 1, Channel: "test-secret", Target: "target", Secret: "s3cr3t"}}
+	if delivery := sendDelivery(context.Background(), dbEndpoints, dbDelivery); delivery.Status != DeliveryDelivered || secret != "s3cr3t" {
+		t.Errorf("Expected delivery with the endpoint's secret, got %v with secret %q.", delivery, secret)
+	}
+	dbEndpoints[0].Target = "other"
+	if delivery := sendDelivery(context.Background(), dbEndpoints, dbDelivery); delivery.Status != DeliveryFailed || delivery.Attempts != 0 {
+		t.Errorf("Expected failure without attempt for a deleted endpoint, got %v.", delivery)
+	}
+}
+
+func TestEndpointValidate(t *testing.T) {
+	defer resolveTo("93.184.216.34")()
+	valid := []Endpoint{
+		{Channel: ChannelEmail, Target: "ops@example.com"},
+		{Channel: ChannelWebhook, Target: "https://example.com/hook", Events: []string{EventAnomaly}},
+		{Channel: ChannelSlack, Target: "https://hooks.slack.com/services/T0/B0/X"},
+	}
+	for _, e := range valid {
+		if err := e.Validate(); err != nil {
+			t.Errorf("Expected %v to be valid, got %s.", e, err)
+		}
+	}
+	invalid := []Endpoint{
+		{Channel: "sms", Target: "+33600000000"},
+		{Channel: ChannelEmail, Target: "not an address"},
+		{Channel: ChannelWebhook, Target: "ftp://example.com"},
+		{Channel: ChannelSlack, Target: "https://hooks.slack.com", Events: []string{"unknown"}},
+		{Channel: ChannelWebhook, Target: "http://127.0.0.1:8080/hook"},
+		{Channel: ChannelWebhook, Target: "http://169.254.169.254/latest/meta-data"},
+		{Channel: ChannelWebhook, Target: "http://[::1]/hook"},
+	}
+	for _, e := range invalid {
+		if err := e.Validate(); err == nil {
+			t.Errorf("Expected %v to be invalid.", e)
+		}
+	}
+}
+
+func TestEndpointValidateResolvesHost(t *testing.T) {
+	defer resolveTo("93.184.216.34", "10.0.0.1")()
+	endpoint := Endpoint{Channel: ChannelWebhook, Target: "https://internal.example.com/hook"}
+	if err := endpoint.Validate(); err == nil {
+		t.Errorf("Expected %v to be invalid.", endpoint)
+	}
+}
+
+func TestEndpointSubscribes(t *testing.T) {
+	all := Endpoint{}
+	budgets := Endpoint{Events: []string{EventBudget}}
+	if !all.Subscribes(EventAnomaly) || !all.Subscribes(EventIngestionFailure) {
+		t.Errorf("Expected an endpoint without events to receive all of them.")
+	}
+	if !budgets.Subscribes(EventBudget) || budgets.Subscribes(EventAnomaly) {
+		t.Errorf("Expected an endpoint to receive only its events.")
+	}
+}
@@ -0,0 +1,315 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/jobs"
+	"github.com/trackit/trackit-server/models"
+	"github.com/trackit/trackit-server/users"
+)
+
+const (
+	// DeliveryPending is the status of a delivery waiting to be sent by a
+	// worker.
+	DeliveryPending = "pending"
+	// DeliverySending is the status of a delivery claimed by a worker.
+	DeliverySending = "sending"
+	// DeliveryDelivered is the status of a delivery which succeeded.
+	DeliveryDelivered = "delivered"
+	// DeliveryFailed is the status of a delivery which failed after every
+	// attempt.
+	DeliveryFailed = "failed"
+
+	// sendingTimeout is the time after which a delivery claimed by a
+	// worker which did not complete it may be claimed again.
+	sendingTimeout = time.Hour
+)
+
+var (
+	// retryDelays are the delays between the attempts to deliver an event
+	// to an endpoint.
+	retryDelays = []time.Duration{2 * time.Second, 10 * time.Second, 30 * time.Second}
+
+	// now returns the current time. It is replaced in tests.
+	now = time.Now
+)
+
+// Delivery is the outcome of the delivery of an event to an endpoint.
+type Delivery struct {
+	Id       int       `json:"id"`
+	Event    string    `json:"event"`
+	EventKey string    `json:"eventKey"`
+	Channel  string    `json:"channel"`
+	Target   string    `json:"target"`
+	Status   string    `json:"status"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+	Date     time.Time `json:"date"`
+}
+
+// Notify records the deliveries of an event to every endpoint of an
+// AwsAccount subscribed to its type as pending, and enqueues a job to send
+// them. The job is only visible to the workers once tx is committed, so that
+// no endpoint is contacted while tx is open and nothing is sent if tx is
+// rolled back. When the AwsAccount has no endpoint, the event is emailed to
+// the owner of the account. An event is only delivered once to an endpoint
+// for a given key, so that Notify can be called again for the endpoints
+// which failed.
+func Notify(ctx context.Context, tx *sql.Tx, aa aws.AwsAccount, event Event) error {
+	event.AwsAccountId = aa.Id
+	event.AwsIdentity = aa.AwsIdentity
+	event.AwsAccountName = aa.Pretty
+	if event.Date.IsZero() {
+		event.Date = now()
+	}
+	endpoints, err := endpointsForEvent(tx, aa, event)
+	if err != nil {
+		return err
+	}
+	delivered, err := deliveredTargets(tx, aa, event)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var pending int
+	for _, endpoint := range endpoints {
+		if delivered[endpoint.Channel+":"+endpoint.Target] {
+			continue
+		}
+		dbDelivery := models.NotificationDelivery{
+			AwsAccountID: aa.Id,
+			Event:        event.Type,
+			EventKey:     event.Key,
+			Channel:      endpoint.Channel,
+			Target:       endpoint.Target,
+			Status:       DeliveryPending,
+			Date:         now(),
+			Payload:      string(payload),
+		}
+		if err = dbDelivery.Insert(tx); err != nil {
+			return err
+		}
+		pending++
+	}
+	if pending > 0 {
+		_, err = jobs.Enqueue(tx, jobs.SendNotifications, aa.Id, config.JobMaxAttempts, now())
+	}
+	return err
+}
+
+// SendPendingDeliveries sends the pending deliveries of an AwsAccount,
+// retrying failed ones and recording their outcome in the delivery log. A
+// failed delivery is set back as pending and an error is returned so that
+// the job is retried later, unless this is its last attempt.
+func SendPendingDeliveries(ctx context.Context, db models.XODB, aaId int, lastAttempt bool) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbDeliveries, err := models.NotificationDeliveriesByAwsAccountIDStatus(db, aaId, DeliveryPending)
+	if err != nil {
+		return err
+	}
+	staleDbDeliveries, err := models.NotificationDeliveriesByAwsAccountIDStatus(db, aaId, DeliverySending)
+	if err != nil {
+		return err
+	}
+	dbDeliveries = append(dbDeliveries, staleDbDeliveries...)
+	dbEndpoints, err := models.NotificationEndpointsByAwsAccountID(db, aaId)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, dbDelivery := range dbDeliveries {
+		date := now()
+		if ok, err := models.ClaimNotificationDelivery(db, dbDelivery.ID, DeliveryPending, DeliverySending, date, date.Add(-sendingTimeout)); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		delivery := sendDelivery(ctx, dbEndpoints, *dbDelivery)
+		if delivery.Status != DeliveryDelivered {
+			failed++
+			logger.Error("Failed to deliver notification.", map[string]interface{}{
+				"awsAccountId": aaId,
+				"delivery":     delivery,
+			})
+			if !lastAttempt {
+				delivery.Status = DeliveryPending
+			}
+		}
+		dbDelivery.Status = delivery.Status
+		dbDelivery.Attempts += delivery.Attempts
+		dbDelivery.Error = delivery.Error
+		dbDelivery.Date = delivery.Date
+		if err := dbDelivery.Update(db); err != nil {
+			logger.Error("Failed to record notification delivery.", map[string]interface{}{
+				"awsAccountId": aaId,
+				"delivery":     delivery,
+				"error":        err.Error(),
+			})
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to deliver %d notification(s)", failed)
+	}
+	return nil
+}
+
+// sendDelivery sends the event of a pending delivery to its endpoint. The
+// endpoint is looked up again since it may have been deleted, or its secret
+// changed, since the delivery was recorded.
+func sendDelivery(ctx context.Context, dbEndpoints []*models.NotificationEndpoint, dbDelivery models.NotificationDelivery) Delivery {
+	var event Event
+	endpoint, found := Endpoint{AwsAccountId: dbDelivery.AwsAccountID, Channel: dbDelivery.Channel, Target: dbDelivery.Target}, false
+	for _, dbEndpoint := range dbEndpoints {
+		if dbEndpoint.Channel == dbDelivery.Channel && dbEndpoint.Target == dbDelivery.Target {
+			endpoint, found = endpointFromDbEndpoint(*dbEndpoint), true
+			break
+		}
+	}
+	var err error
+	if !found && (len(dbEndpoints) > 0 || dbDelivery.Channel != ChannelEmail) {
+		err = errors.New("the endpoint was deleted")
+	} else {
+		err = json.Unmarshal([]byte(dbDelivery.Payload), &event)
+	}
+	if err != nil {
+		return Delivery{
+			Event:    dbDelivery.Event,
+			EventKey: dbDelivery.EventKey,
+			Channel:  dbDelivery.Channel,
+			Target:   dbDelivery.Target,
+			Status:   DeliveryFailed,
+			Error:    err.Error(),
+			Date:     now(),
+		}
+	}
+	return deliver(ctx, endpoint, event)
+}
+
+// endpointsForEvent returns the endpoints of an AwsAccount subscribed to an
+// event, or an email endpoint for the owner of the account if it has none.
+func endpointsForEvent(tx *sql.Tx, aa aws.AwsAccount, event Event) ([]Endpoint, error) {
+	endpoints, err := GetEndpointsForAwsAccount(aa, tx)
+	if err != nil {
+		return nil, err
+	} else if len(endpoints) == 0 {
+		owner, err := users.GetUserWithId(tx, aa.UserId)
+		if err != nil {
+			return nil, err
+		}
+		return []Endpoint{{AwsAccountId: aa.Id, Channel: ChannelEmail, Target: owner.Email}}, nil
+	}
+	res := make([]Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(event.Type) {
+			res = append(res, endpoint)
+		}
+	}
+	return res, nil
+}
+
+// deliveredTargets returns the channels and targets an event was already
+// delivered to or is being delivered to, as "channel:target" strings.
+func deliveredTargets(tx *sql.Tx, aa aws.AwsAccount, event Event) (map[string]bool, error) {
+	dbDeliveries, err := models.NotificationDeliveriesByAwsAccountIDEventKey(tx, aa.Id, event.Key)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]bool)
+	for _, dbDelivery := range dbDeliveries {
+		if dbDelivery.Status != DeliveryFailed {
+			res[dbDelivery.Channel+":"+dbDelivery.Target] = true
+		}
+	}
+	return res, nil
+}
+
+// deliver sends an event to an endpoint, retrying after each of the
+// retryDelays if it fails.
+func deliver(ctx context.Context, endpoint Endpoint, event Event) Delivery {
+	delivery := Delivery{
+		Event:    event.Type,
+		EventKey: event.Key,
+		Channel:  endpoint.Channel,
+		Target:   endpoint.Target,
+		Status:   DeliveryFailed,
+	}
+	channel, err := GetChannel(endpoint.Channel)
+	if err == nil {
+		err = sendWithRetries(ctx, channel, endpoint, event, &delivery.Attempts)
+	}
+	if err == nil {
+		delivery.Status = DeliveryDelivered
+	} else {
+		delivery.Error = err.Error()
+	}
+	delivery.Date = now()
+	return delivery
+}
+
+// sendWithRetries sends an event to an endpoint on a channel until it
+// succeeds, waiting for each of the retryDelays between the attempts. It
+// gives up early if the context is done.
+func sendWithRetries(ctx context.Context, channel Channel, endpoint Endpoint, event Event, attempts *int) error {
+	for i := 0; ; i++ {
+		*attempts++
+		err := channel.Send(ctx, endpoint, event)
+		if err == nil || i >= len(retryDelays) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelays[i]):
+		}
+	}
+}
+
+// GetDeliveries retrieves the latest deliveries of the events of an
+// AwsAccount, most recent first.
+func GetDeliveries(aa aws.AwsAccount, limit int, tx *sql.Tx) ([]Delivery, error) {
+	dbDeliveries, err := models.LatestNotificationDeliveriesByAwsAccountID(tx, aa.Id, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Delivery, len(dbDeliveries))
+	for i, dbDelivery := range dbDeliveries {
+		res[i] = Delivery{
+			Id:       dbDelivery.ID,
+			Event:    dbDelivery.Event,
+			EventKey: dbDelivery.EventKey,
+			Channel:  dbDelivery.Channel,
+			Target:   dbDelivery.Target,
+			Status:   dbDelivery.Status,
+			Attempts: dbDelivery.Attempts,
+			Error:    dbDelivery.Error,
+			Date:     dbDelivery.Date,
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,91 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ChannelSlack posts events to a Slack-compatible incoming webhook.
+const ChannelSlack = "slack"
+
+type (
+	// slackMessage is the payload of a Slack incoming webhook.
+	slackMessage struct {
+		Text        string            `json:"text"`
+		Attachments []slackAttachment `json:"attachments"`
+	}
+
+	// slackAttachment is an attachment of a slackMessage.
+	slackAttachment struct {
+		Fallback string       `json:"fallback"`
+		Color    string       `json:"color"`
+		Text     string       `json:"text"`
+		Fields   []slackField `json:"fields"`
+		Footer   string       `json:"footer"`
+		Ts       int64        `json:"ts"`
+	}
+
+	// slackField is a field of a slackAttachment.
+	slackField struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+		Short bool   `json:"short"`
+	}
+)
+
+// slackColors are the colors of the attachments for each event type.
+var slackColors = map[string]string{
+	EventAnomaly:          "warning",
+	EventBudget:           "warning",
+	EventIngestionFailure: "danger",
+}
+
+func init() {
+	Channel{
+		Name:        ChannelSlack,
+		Description: "Posts the event to the target Slack incoming webhook URL, or any service accepting the same payload.",
+		Validate:    validateHttpUrl,
+		Send:        sendSlack,
+	}.Register()
+}
+
+// slackMessageForEvent builds the Slack payload describing an event.
+func slackMessageForEvent(event Event) slackMessage {
+	return slackMessage{
+		Text: event.Subject,
+		Attachments: []slackAttachment{{
+			Fallback: event.Message,
+			Color:    slackColors[event.Type],
+			Text:     event.Message,
+			Fields: []slackField{
+				{Title: "AWS account", Value: event.AwsAccountName, Short: true},
+				{Title: "Event", Value: event.Type, Short: true},
+			},
+			Footer: "TrackIt",
+			Ts:     event.Date.Unix(),
+		}},
+	}
+}
+
+// sendSlack posts an event to the incoming webhook URL of an endpoint.
+func sendSlack(ctx context.Context, endpoint Endpoint, event Event) error {
+	body, err := json.Marshal(slackMessageForEvent(event))
+	if err != nil {
+		return err
+	}
+	return postJson(ctx, endpoint.Target, body, nil)
+}
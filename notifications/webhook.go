@@ -0,0 +1,79 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+)
+
+// ChannelWebhook posts events as JSON to an URL.
+const ChannelWebhook = "webhook"
+
+const (
+	// HeaderEvent is the header containing the type of a webhook's event.
+	HeaderEvent = "X-Trackit-Event"
+	// HeaderDelivery is the header containing the key of a webhook's event.
+	HeaderDelivery = "X-Trackit-Delivery"
+	// HeaderTimestamp is the header containing the UNIX time at which a
+	// webhook was signed.
+	HeaderTimestamp = "X-Trackit-Timestamp"
+	// HeaderSignature is the header containing the signature of a webhook.
+	HeaderSignature = "X-Trackit-Signature"
+)
+
+func init() {
+	Channel{
+		Name:        ChannelWebhook,
+		Description: "Posts the event as JSON to the target URL. When the endpoint has a secret, the request is signed with HMAC-SHA256.",
+		Validate:    validateHttpUrl,
+		Send:        sendWebhook,
+	}.Register()
+}
+
+// SignWebhook returns the signature of a webhook's body sent at a UNIX
+// time. It is the hex encoded HMAC-SHA256 of the timestamp, a dot and the
+// body, keyed with the endpoint's secret and prefixed by "sha256=".
+// Receivers should compute it again and compare it with the
+// X-Trackit-Signature header.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhook posts an event to the URL of an endpoint.
+func sendWebhook(ctx context.Context, endpoint Endpoint, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	timestamp := now().Unix()
+	headers := map[string]string{
+		HeaderEvent:     event.Type,
+		HeaderDelivery:  event.Key,
+		HeaderTimestamp: strconv.FormatInt(timestamp, 10),
+	}
+	if endpoint.Secret != "" {
+		headers[HeaderSignature] = SignWebhook(endpoint.Secret, timestamp, body)
+	}
+	return postJson(ctx, endpoint.Target, body, headers)
+}
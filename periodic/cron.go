@@ -0,0 +1,207 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package periodic
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSearch bounds the search for the next time matching a cron
+// expression, so that expressions which never match (such as February 30th)
+// do not loop forever.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// ErrInvalidCron is returned when a cron expression cannot be parsed.
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// Schedule decides when a task runs.
+type Schedule interface {
+	// Next returns the first time strictly after t when the task runs. It
+	// returns the zero time if the task never runs again.
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// every is a Schedule running a task at a fixed period.
+type every time.Duration
+
+// Every returns a Schedule running a task every period p.
+func Every(p time.Duration) Schedule {
+	return every(p)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+// cronField is the set of values a field of a cron expression matches, as a
+// bit set.
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cron is a Schedule following a standard five fields cron expression:
+// minute, hour, day of month, month and day of week.
+type cron struct {
+	expr                                string
+	minutes, hours, days, months, weeks cronField
+	// anyDay and anyWeekday are set when the day of month or day of
+	// week field is a wildcard. When neither is, a day matches if
+	// either field matches it, as in cron(8).
+	anyDay, anyWeekday bool
+}
+
+// cronDescriptors are the shorthands accepted instead of a cron expression.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression. It accepts the five standard fields,
+// each being a wildcard, a value, a range or a comma separated list of those
+// with an optional step, and the @yearly, @monthly, @weekly, @daily and
+// @hourly shorthands. Days of week go from 0 to 7, both being Sunday. Times
+// are matched in the location of the time given to Next.
+func ParseCron(expr string) (Schedule, error) {
+	full := strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[full]; ok {
+		full = d
+	}
+	fields := strings.Fields(full)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%s: %q: expected 5 fields, got %d", ErrInvalidCron, expr, len(fields))
+	}
+	c := cron{expr: expr}
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+	} else if c.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+	} else if c.days, err = parseCronField(fields[2], 1, 31); err != nil {
+	} else if c.months, err = parseCronField(fields[3], 1, 12); err != nil {
+	} else if c.weeks, err = parseCronField(fields[4], 0, 7); err != nil {
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q: %s", ErrInvalidCron, expr, err.Error())
+	}
+	if c.weeks.has(7) {
+		c.weeks |= 1
+	}
+	c.anyDay = strings.HasPrefix(fields[2], "*")
+	c.anyWeekday = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// MustParseCron parses a cron expression and panics if it is invalid. It is
+// meant for expressions written in the code.
+func MustParseCron(expr string) Schedule {
+	s, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseCronField parses a field of a cron expression whose values go from
+// min to max.
+func parseCronField(field string, min, max int) (cronField, error) {
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+		low, high := min, max
+		if part != "*" {
+			var err error
+			bounds := strings.SplitN(part, "-", 2)
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+// matchesDay returns whether the day of t matches the cron expression.
+func (c cron) matchesDay(t time.Time) bool {
+	day := c.days.has(t.Day())
+	weekday := c.weeks.has(int(t.Weekday()))
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func (c cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxCronSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		if !c.months.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		} else if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		} else if !c.hours.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		} else if !c.minutes.has(t.Minute()) {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c cron) String() string {
+	return c.expr
+}
@@ -0,0 +1,85 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package periodic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2019, 3, 14, 10, 17, 42, 0, time.UTC)
+	for _, c := range []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2019, 3, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/10 * * * *", time.Date(2019, 3, 14, 10, 20, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2019, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2019, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2019, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"30 6,18 * * *", time.Date(2019, 3, 14, 18, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2019, 3, 14, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// March 14th, 2019 is a Thursday.
+		{"0 8 * * 1", time.Date(2019, 3, 18, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2019, 3, 17, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 0", time.Date(2019, 3, 17, 8, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week must match.
+		{"0 8 20 * 5", time.Date(2019, 3, 15, 8, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		s, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %s", c.expr, err.Error())
+		} else if next := s.Next(from); !next.Equal(c.expected) {
+			t.Errorf("%q: expected %s, got %s", c.expr, c.expected, next)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-2 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@often",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected %q to be invalid", expr)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	from := time.Date(2019, 3, 14, 10, 17, 42, 0, time.UTC)
+	s := Every(10 * time.Minute)
+	if next := s.Next(from); !next.Equal(from.Add(10 * time.Minute)) {
+		t.Errorf("Expected %s, got %s", from.Add(10*time.Minute), next)
+	}
+	if s.String() != "@every 10m0s" {
+		t.Errorf("Unexpected string %q", s.String())
+	}
+}
@@ -0,0 +1,99 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package periodic
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// maxErrorLength is the maximum length of the error of a Run.
+const maxErrorLength = 255
+
+// Run is a run of a task.
+type Run struct {
+	Task     string        `json:"task"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"-"`
+	// Error is the error of the run, empty if the run succeeded.
+	Error string `json:"error"`
+}
+
+// MarshalJSON marshals a Run, with its duration in seconds.
+func (r Run) MarshalJSON() ([]byte, error) {
+	type run Run
+	return json.Marshal(struct {
+		run
+		Duration float64 `json:"duration"`
+	}{run(r), r.Duration.Seconds()})
+}
+
+// TaskStatus is the state of a registered task.
+type TaskStatus struct {
+	Name         string  `json:"name"`
+	Schedule     string  `json:"schedule"`
+	Jitter       float64 `json:"jitter"`
+	Timeout      float64 `json:"timeout"`
+	AllowOverlap bool    `json:"allowOverlap"`
+	// Running is the number of runs currently running.
+	Running int `json:"running"`
+	// Skipped is the number of runs skipped since the process started,
+	// because the previous run was still running.
+	Skipped int `json:"skipped"`
+	// NextRun is the next scheduled time of the task, zero if the task
+	// is not ticking.
+	NextRun     time.Time `json:"nextRun"`
+	LastRun     *Run      `json:"lastRun"`
+	LastSuccess *Run      `json:"lastSuccess"`
+	LastError   *Run      `json:"lastError"`
+}
+
+// status returns the state of a taskRegistration.
+func (t *taskRegistration) status() TaskStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return TaskStatus{
+		Name:         t.Name,
+		Schedule:     t.schedule.String(),
+		Jitter:       t.options.Jitter.Seconds(),
+		Timeout:      t.options.Timeout.Seconds(),
+		AllowOverlap: t.options.AllowOverlap,
+		Running:      t.running,
+		Skipped:      t.skipped,
+		NextRun:      t.nextRun,
+		LastRun:      t.lastRun,
+		LastSuccess:  t.lastSuccess,
+		LastError:    t.lastError,
+	}
+}
+
+// latestRun returns the latest of two runs, which may be nil.
+func latestRun(a, b *Run) *Run {
+	if a == nil || (b != nil && b.Started.After(a.Started)) {
+		return b
+	}
+	return a
+}
+
+// errorString formats the error of a run.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	} else if msg := err.Error(); len(msg) > maxErrorLength {
+		return msg[:maxErrorLength]
+	} else {
+		return msg
+	}
+}
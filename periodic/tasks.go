@@ -18,10 +18,13 @@ package periodic
 
 import (
 	"context"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/metrics"
 )
 
 // contextKey is an unexported type to avoid collisions in context.Context
@@ -45,25 +48,81 @@ const (
 	taskStop = taskSignal(iota)
 )
 
+var (
+	taskDuration = metrics.NewHistogram(
+		"trackit_periodic_task_duration_seconds",
+		"Time taken by periodic task runs, by task and result.",
+		metrics.TaskBuckets,
+		"task", "result",
+	)
+	taskSkipped = metrics.NewCounter(
+		"trackit_periodic_task_skipped_total",
+		"Number of periodic task runs skipped because the previous run was still running, by task.",
+		"task",
+	)
+)
+
 // Task is a task that can be scheduled.
 type Task func(context.Context) error
 
+// Options tune how a registered task is run.
+type Options struct {
+	// Jitter is the maximum random delay added to each scheduled run,
+	// to spread the load of tasks scheduled at the same time.
+	Jitter time.Duration
+	// Timeout is the maximum duration of a run, after which the context
+	// of the task is cancelled. A zero Timeout never cancels the task.
+	Timeout time.Duration
+	// AllowOverlap lets a run start while the previous one is still
+	// running. By default such runs are skipped.
+	AllowOverlap bool
+}
+
+// History stores the runs of the tasks.
+type History interface {
+	// Record stores a run.
+	Record(Run) error
+	// Last returns the last successful and failed runs of a task, which
+	// may be nil.
+	Last(task string) (lastSuccess, lastError *Run, err error)
+}
+
 // taskRegistration is a task registration that may or may not be ticking.
 type taskRegistration struct {
-	Name    string `json:"name"`
-	task    Task
-	Period  time.Duration
-	ticker  *time.Ticker
-	control chan taskSignal
+	Name     string
+	task     Task
+	schedule Schedule
+	options  Options
+	history  History
+	control  chan taskSignal
+	// ctx is the context runs are derived from, and runs tracks them.
+	// Both are shared with the Scheduler.
+	ctx  context.Context
+	runs *sync.WaitGroup
+	// mutex protects the state below, which is read by Status while the
+	// task ticks.
+	mutex       sync.Mutex
+	running     int
+	skipped     int
+	nextRun     time.Time
+	lastRun     *Run
+	lastSuccess *Run
+	lastError   *Run
 }
 
 // Scheduler runs registered periodic tasks. Its zero value is a valid
 // Scheduler that doesn't tick and has no registered task. It may be used in
 // parallel.
 type Scheduler struct {
+	// History, if set before tasks are registered, stores their runs.
+	History       History
 	running       bool
-	registrations []taskRegistration
+	shutdown      bool
+	registrations []*taskRegistration
 	mutex         sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+	runs          sync.WaitGroup
 }
 
 // Ticking returns whether the Scheduler is currently ticking.
@@ -76,13 +135,38 @@ func (s *Scheduler) Ticking() bool {
 // Register registers a Task to the Scheduler to be run at period p. If the
 // Scheduler is ticking, the task starts ticking immediately.
 func (s *Scheduler) Register(t Task, p time.Duration, n string) {
-	r := taskRegistration{
-		task:   t,
-		Period: p,
-		Name:   n,
+	s.RegisterSchedule(t, Every(p), n, Options{})
+}
+
+// RegisterSchedule registers a Task to the Scheduler to be run following a
+// Schedule. If the Scheduler is ticking, the task starts ticking immediately.
+// The last runs of the task are loaded from the History of the Scheduler.
+func (s *Scheduler) RegisterSchedule(t Task, sch Schedule, n string, o Options) {
+	r := &taskRegistration{
+		task:     t,
+		schedule: sch,
+		options:  o,
+		Name:     n,
 	}
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	r.ctx = s.ctx
+	r.runs = &s.runs
+	if s.History != nil {
+		r.history = s.History
+		if lastSuccess, lastError, err := s.History.Last(n); err != nil {
+			jsonlog.Error("Failed to load task history.", map[string]interface{}{
+				"task":  n,
+				"error": err.Error(),
+			})
+		} else {
+			r.lastSuccess, r.lastError = lastSuccess, lastError
+			r.lastRun = latestRun(lastSuccess, lastError)
+		}
+	}
 	if s.running {
 		r.start()
 	}
@@ -96,72 +180,191 @@ func (s *Scheduler) Start() {
 	defer s.mutex.Unlock()
 	if s.running {
 		jsonlog.Error("Attempt to start already started scheduler. Ignoring.", nil)
+	} else if s.shutdown {
+		jsonlog.Error("Attempt to start shut down scheduler. Ignoring.", nil)
 	} else {
-		for i := range s.registrations {
-			s.registrations[i].start()
+		for _, r := range s.registrations {
+			r.start()
 		}
 		s.running = true
 	}
 }
 
 // Stop stops a Scheduler. Stopping an already stopped scheduler is
-// functionally a noop, though an error will be logged.
+// functionally a noop, though an error will be logged. Running tasks are
+// not cancelled, see Shutdown.
 func (s *Scheduler) Stop() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if s.running {
-		for i := range s.registrations {
-			s.registrations[i].stop()
+		for _, r := range s.registrations {
+			r.stop()
 		}
 		s.running = false
 	}
 }
 
+// Shutdown stops a Scheduler for good, cancels the context of the running
+// tasks and waits for them to return. It returns the error of ctx if ctx is
+// done before the tasks returned.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Stop()
+	s.mutex.Lock()
+	s.shutdown = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mutex.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status returns the state of the registered tasks, in registration order.
+func (s *Scheduler) Status() []TaskStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	status := make([]TaskStatus, len(s.registrations))
+	for i, r := range s.registrations {
+		status[i] = r.status()
+	}
+	return status
+}
+
 // start starts a taskRegistration, having it tick and run its task
-// periodically.
+// following its schedule.
 func (t *taskRegistration) start() {
 	if t.control == nil {
-		t.ticker = time.NewTicker(t.Period)
 		t.control = make(chan taskSignal)
-		go t.tick()
+		go t.tick(t.control)
 	} else {
-		jsonlog.Error("Attempt to start already started task. Ignoring.", t)
+		jsonlog.Error("Attempt to start already started task. Ignoring.", t.Name)
 	}
 }
 
-// run runs the taskRegistration's task in the current goroutine.
+// run runs the taskRegistration's task in the current goroutine, with the
+// timeout of the task, and records the run. The task is cancelled when the
+// Scheduler shuts down.
 func (t *taskRegistration) run(d time.Time) error {
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, TaskTime, d)
-	return t.task(ctx)
+	defer t.runs.Done()
+	ctx := context.WithValue(t.ctx, TaskTime, d)
+	if t.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.options.Timeout)
+		defer cancel()
+	}
+	started := time.Now()
+	err := t.task(ctx)
+	t.record(Run{
+		Task:     t.Name,
+		Started:  started,
+		Duration: time.Since(started),
+		Error:    errorString(err),
+	})
+	return err
 }
 
-// getTickerChan gets the taskRegistration's ticker channel, creating it if it
-// doesn’t exist yet. This is the channel where a time.Ticker outputs to run
-// the periodic tasks.
-func (t *taskRegistration) getTickerChan() <-chan time.Time {
-	c := t.ticker
-	if c == nil {
-		return nil
+// trigger starts a run of the task in its own goroutine, unless the previous
+// run is still going and the task does not allow overlaps.
+func (t *taskRegistration) trigger(d time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.running > 0 && !t.options.AllowOverlap {
+		t.skipped++
+		taskSkipped.Inc(t.Name)
+		jsonlog.Warning("Skipped task run since the previous one is still running.", t.Name)
+		return
+	}
+	t.running++
+	t.runs.Add(1)
+	go t.run(d)
+}
+
+// record updates the state of the task after a run and stores the run in the
+// history.
+func (t *taskRegistration) record(r Run) {
+	result := "success"
+	if r.Error != "" {
+		result = "error"
+	}
+	taskDuration.Observe(r.Duration.Seconds(), t.Name, result)
+	t.mutex.Lock()
+	t.running--
+	t.lastRun = &r
+	if r.Error == "" {
+		t.lastSuccess = &r
 	} else {
-		return c.C
+		t.lastError = &r
+		jsonlog.Error("Periodic task failed.", r)
 	}
+	t.mutex.Unlock()
+	if t.history != nil {
+		if err := t.history.Record(r); err != nil {
+			jsonlog.Error("Failed to record task run.", map[string]interface{}{
+				"task":  t.Name,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// setNextRun sets the time of the next run of the task.
+func (t *taskRegistration) setNextRun(next time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.nextRun = next
+}
+
+// delay returns how long to wait until a scheduled time, adding a random
+// jitter.
+func (t *taskRegistration) delay(next time.Time) time.Duration {
+	d := time.Until(next)
+	if t.options.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(t.options.Jitter)))
+	}
+	return d
 }
 
-// tick starts periodic tasks in response to ticks from t.ticker. The tasks are
-// started in their own goroutine using t.run.
-func (t *taskRegistration) tick() {
+// tick starts the task at the times of its schedule until it is told to
+// stop. Runs are scheduled from the previous scheduled time rather than from
+// when the previous run ended, so that periods do not drift. Runs missed
+// while the process was not running, such as during a system sleep, are not
+// caught up.
+func (t *taskRegistration) tick(control chan taskSignal) {
+	next := t.schedule.Next(time.Now())
 	for {
+		var fire <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			t.setNextRun(next)
+			timer = time.NewTimer(t.delay(next))
+			fire = timer.C
+		} else {
+			t.setNextRun(time.Time{})
+		}
 		select {
-		case d := <-t.getTickerChan():
-			go t.run(d)
-		case s := <-t.control:
+		case <-fire:
+			t.trigger(next)
+			next = t.schedule.Next(next)
+			if now := time.Now(); !next.IsZero() && next.Before(now) {
+				next = t.schedule.Next(now)
+			}
+		case s := <-control:
+			if timer != nil {
+				timer.Stop()
+			}
 			switch s {
 			case taskStop:
-				close(t.control)
-				t.control = nil
-				t.ticker.Stop()
-				t.ticker = nil
+				t.setNextRun(time.Time{})
+				close(control)
 				return
 			}
 		}
@@ -173,7 +376,8 @@ func (t *taskRegistration) tick() {
 func (t *taskRegistration) stop() {
 	if t.control != nil {
 		t.control <- taskStop
+		t.control = nil
 	} else {
-		jsonlog.Error("Attempt to stop an already stopped task. Ignoring.", t)
+		jsonlog.Error("Attempt to stop an already stopped task. Ignoring.", t.Name)
 	}
 }
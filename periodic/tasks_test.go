@@ -17,6 +17,8 @@ package periodic
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -110,3 +112,181 @@ out:
 		t.Errorf("Expected task count to be %#v, is %#v.", be, b)
 	}
 }
+
+// memoryHistory is a History kept in memory.
+type memoryHistory struct {
+	sync.Mutex
+	runs []Run
+}
+
+func (h *memoryHistory) Record(r Run) error {
+	h.Lock()
+	defer h.Unlock()
+	h.runs = append(h.runs, r)
+	return nil
+}
+
+func (h *memoryHistory) Last(task string) (lastSuccess, lastError *Run, err error) {
+	h.Lock()
+	defer h.Unlock()
+	for i := range h.runs {
+		if h.runs[i].Task != task {
+		} else if h.runs[i].Error == "" {
+			lastSuccess = &h.runs[i]
+		} else {
+			lastError = &h.runs[i]
+		}
+	}
+	return
+}
+
+func TestSkipIfRunning(t *testing.T) {
+	var s Scheduler
+	var runs int32
+	release := make(chan struct{})
+	s.Register(
+		func(_ context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return nil
+		},
+		50*time.Millisecond,
+		"Slow task",
+	)
+	s.Start()
+	time.Sleep(275 * time.Millisecond)
+	s.Stop()
+	close(release)
+	if r := atomic.LoadInt32(&runs); r != 1 {
+		t.Errorf("Slow task should run once, ran %d times.", r)
+	}
+	if status := s.Status()[0]; status.Skipped != 4 {
+		t.Errorf("Slow task should be skipped %d times, was skipped %d times.", 4, status.Skipped)
+	}
+}
+
+func TestAllowOverlap(t *testing.T) {
+	var s Scheduler
+	var runs int32
+	release := make(chan struct{})
+	s.RegisterSchedule(
+		func(_ context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return nil
+		},
+		Every(50*time.Millisecond),
+		"Overlapping task",
+		Options{AllowOverlap: true},
+	)
+	s.Start()
+	time.Sleep(275 * time.Millisecond)
+	s.Stop()
+	close(release)
+	if r := atomic.LoadInt32(&runs); r != 5 {
+		t.Errorf("Overlapping task should run %d times, ran %d times.", 5, r)
+	}
+}
+
+func TestTimeoutAndHistory(t *testing.T) {
+	h := &memoryHistory{}
+	h.Record(Run{Task: "Timed out task", Started: time.Now().Add(-time.Hour), Duration: time.Second})
+	s := Scheduler{History: h}
+	s.RegisterSchedule(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Every(50*time.Millisecond),
+		"Timed out task",
+		Options{Timeout: 20 * time.Millisecond},
+	)
+	if status := s.Status()[0]; status.LastSuccess == nil || status.LastRun != status.LastSuccess {
+		t.Errorf("Last success should be loaded from history, got %#v.", status)
+	}
+	s.Start()
+	time.Sleep(90 * time.Millisecond)
+	s.Stop()
+	status := s.Status()[0]
+	if status.LastError == nil || status.LastError.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Task should fail with a timeout, got %#v.", status.LastError)
+	} else if status.LastError.Duration < 20*time.Millisecond || status.LastError.Duration > 60*time.Millisecond {
+		t.Errorf("Task should run for its timeout, ran for %s.", status.LastError.Duration)
+	} else if status.LastRun != status.LastError {
+		t.Errorf("Last run should be the last error.")
+	}
+	if _, lastError, _ := h.Last("Timed out task"); lastError == nil {
+		t.Errorf("Failed run should be recorded in history.")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	var s Scheduler
+	c := make(chan int)
+	s.RegisterSchedule(
+		messageTask(c, 0),
+		Every(50*time.Millisecond),
+		"Jittery task",
+		Options{Jitter: 40 * time.Millisecond},
+	)
+	s.Start()
+	defer s.Stop()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		<-c
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond || elapsed > 330*time.Millisecond {
+		t.Errorf("Jitter should not drift the schedule, 5 runs took %s.", elapsed)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var s Scheduler
+	started := make(chan struct{})
+	s.Register(
+		func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			return ctx.Err()
+		},
+		10*time.Millisecond,
+		"Cancellable task",
+	)
+	s.Start()
+	<-started
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown should wait for the task, got %s.", err.Error())
+	}
+	status := s.Status()[0]
+	if status.Running != 0 || status.LastError == nil || status.LastError.Error != context.Canceled.Error() {
+		t.Errorf("Task should be cancelled and done, got %#v.", status)
+	}
+	s.Start()
+	if s.Ticking() {
+		t.Errorf("Shut down scheduler should not start again.")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var s Scheduler
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	s.Register(
+		func(_ context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+		10*time.Millisecond,
+		"Stubborn task",
+	)
+	s.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown should time out, got %v.", err)
+	}
+}
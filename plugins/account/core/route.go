@@ -45,7 +45,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getPluginsResults).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(pluginsQueryArgs),
 			routes.Documentation{
 				Summary:     "get the latests plugins results",
@@ -0,0 +1,75 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package recommendations
+
+import (
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// aggregationMaxSize is the maximum size of an Elastic Search Aggregation
+const aggregationMaxSize = 0x7FFFFFFF
+
+const (
+	productCodeEc2 = "AmazonEC2"
+	productCodeRds = "AmazonRDS"
+
+	lineItemTypeUsage                   = "Usage"
+	lineItemTypeDiscountedUsage         = "DiscountedUsage"
+	lineItemTypeSavingsPlanCoveredUsage = "SavingsPlanCoveredUsage"
+)
+
+// esQueryParams will store the parsed query params
+type esQueryParams struct {
+	dateBegin   time.Time
+	dateEnd     time.Time
+	accountList []string
+	indexList   []string
+}
+
+// createQueryAccountFilter creates and return a new *elastic.TermsQuery on the accountList array
+func createQueryAccountFilter(accountList []string) *elastic.TermsQuery {
+	accountListFormatted := make([]interface{}, len(accountList))
+	for i, v := range accountList {
+		accountListFormatted[i] = v
+	}
+	return elastic.NewTermsQuery("usageAccountId", accountListFormatted...)
+}
+
+// getElasticSearchUsageParams is used to construct an ElasticSearch
+// *elastic.SearchService retrieving the daily EC2 and RDS instance hours,
+// whether they were used on demand or covered by a reservation or a savings
+// plan.
+func getElasticSearchUsageParams(params esQueryParams, client *elastic.Client, index string) *elastic.SearchService {
+	query := elastic.NewBoolQuery()
+	if len(params.accountList) > 0 {
+		query = query.Filter(createQueryAccountFilter(params.accountList))
+	}
+	query = query.Filter(elastic.NewRangeQuery("usageStartDate").From(params.dateBegin).To(params.dateEnd))
+	query = query.Filter(elastic.NewTermsQuery("productCode", productCodeEc2, productCodeRds))
+	query = query.Filter(elastic.NewTermsQuery("lineItemType", lineItemTypeUsage, lineItemTypeDiscountedUsage, lineItemTypeSavingsPlanCoveredUsage))
+	query = query.Filter(elastic.NewExistsQuery("instanceType"))
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("products", elastic.NewTermsAggregation().Field("productCode").Size(aggregationMaxSize).
+		SubAggregation("regions", elastic.NewTermsAggregation().Field("region").Size(aggregationMaxSize).
+			SubAggregation("instanceTypes", elastic.NewTermsAggregation().Field("instanceType").Size(aggregationMaxSize).
+				SubAggregation("operations", elastic.NewTermsAggregation().Field("operation").Size(aggregationMaxSize).
+					SubAggregation("usageTypes", elastic.NewTermsAggregation().Field("usageType").Size(aggregationMaxSize).
+						SubAggregation("types", elastic.NewTermsAggregation().Field("lineItemType").Size(aggregationMaxSize).
+							SubAggregation("days", elastic.NewDateHistogramAggregation().Field("usageStartDate").Interval("day").
+								SubAggregation("usage", elastic.NewSumAggregation().Field("usageAmount")))))))))
+	return search
+}
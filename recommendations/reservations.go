@@ -0,0 +1,339 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package recommendations
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const (
+	hoursPerMonth = 730
+	hoursPerYear  = 8760
+	daysPerMonth  = hoursPerMonth / 24.0
+
+	// OfferingTypeReservation is the type of reserved instances.
+	OfferingTypeReservation = "reservation"
+	// OfferingTypeSavingsPlan is the type of EC2 Instance Savings Plans.
+	OfferingTypeSavingsPlan = "savingsPlan"
+
+	offeringClassStandard = "standard"
+)
+
+type (
+	// Offering is a commitment which can be purchased for an instance.
+	// Prices are per instance.
+	Offering struct {
+		Type                 string  `json:"type"`
+		Term                 string  `json:"term"`
+		PaymentOption        string  `json:"paymentOption"`
+		OfferingClass        string  `json:"offeringClass"`
+		UpfrontPrice         float64 `json:"upfrontPrice"`
+		HourlyPrice          float64 `json:"hourlyPrice"`
+		EffectiveHourlyPrice float64 `json:"effectiveHourlyPrice"`
+	}
+
+	// Projection is the expected outcome of a purchase over the usage of
+	// the analyzed period. Coverage and utilization are percentages, costs
+	// are monthly. BreakEvenMonths is nil if the purchase does not pay off
+	// within its term.
+	Projection struct {
+		CurrentCoverage      float64  `json:"currentCoverage"`
+		ProjectedCoverage    float64  `json:"projectedCoverage"`
+		ProjectedUtilization float64  `json:"projectedUtilization"`
+		OnDemandMonthlyCost  float64  `json:"onDemandMonthlyCost"`
+		ProjectedMonthlyCost float64  `json:"projectedMonthlyCost"`
+		MonthlySavings       float64  `json:"monthlySavings"`
+		TermSavings          float64  `json:"termSavings"`
+		BreakEvenMonths      *float64 `json:"breakEvenMonths"`
+	}
+
+	// PurchaseOption is an offering bought in a given quantity.
+	// HourlyCommitment is only set for savings plans.
+	PurchaseOption struct {
+		Offering
+		Quantity         int     `json:"quantity"`
+		HourlyCommitment float64 `json:"hourlyCommitment,omitempty"`
+		Projection
+	}
+
+	// Recommendation lists the purchases which would lower the cost of the
+	// on-demand usage of instances. Quantity is the number of instances
+	// which ran on demand during the whole analyzed period.
+	Recommendation struct {
+		UsageKey
+		Quantity            int              `json:"quantity"`
+		AverageOnDemand     float64          `json:"averageOnDemand"`
+		MinimumOnDemand     float64          `json:"minimumOnDemand"`
+		OnDemandHourlyPrice float64          `json:"onDemandHourlyPrice"`
+		Currency            string           `json:"currency"`
+		Options             []PurchaseOption `json:"options"`
+	}
+
+	// WhatIfPurchase is a purchase to simulate. OfferingClass defaults to
+	// standard and Type to reservation.
+	WhatIfPurchase struct {
+		UsageKey
+		Type          string `json:"type"`
+		Term          string `json:"term"`
+		PaymentOption string `json:"paymentOption"`
+		OfferingClass string `json:"offeringClass"`
+		Quantity      int    `json:"quantity"`
+	}
+
+	// WhatIfResult is the projection of a simulated purchase.
+	WhatIfResult struct {
+		Purchase WhatIfPurchase `json:"purchase"`
+		Offering Offering       `json:"offering"`
+		Currency string         `json:"currency"`
+		Projection
+	}
+)
+
+// price is a price of the offer file, whichever the service.
+type price struct {
+	sku                 string
+	termType            string
+	leaseContractLength string
+	purchaseOption      string
+	offeringClass       string
+	hourlyPrice         float64
+	upfrontPrice        float64
+	currency            string
+}
+
+// pricing holds the on-demand price of an instance and the offerings which
+// can be purchased for it.
+type pricing struct {
+	onDemandHourly float64
+	currency       string
+	offerings      []Offering
+}
+
+// termYears returns the number of years of a lease contract length such as
+// "1yr" or "3yr".
+func termYears(leaseContractLength string) int {
+	years, err := strconv.Atoi(strings.TrimSuffix(leaseContractLength, "yr"))
+	if err != nil || years <= 0 {
+		return 0
+	}
+	return years
+}
+
+// pricingFromPrices picks the cheapest product which can be reserved among
+// the prices of products matching a UsageKey. Products differing by
+// attributes which are not stored, such as pre-installed software, are more
+// expensive than the plain product.
+func pricingFromPrices(prices []price) (pricing, bool) {
+	onDemand := make(map[string]price)
+	reserved := make(map[string][]price)
+	for _, p := range prices {
+		if p.termType == "OnDemand" && p.hourlyPrice > 0 {
+			if cur, ok := onDemand[p.sku]; !ok || p.hourlyPrice < cur.hourlyPrice {
+				onDemand[p.sku] = p
+			}
+		} else if p.termType == "Reserved" && termYears(p.leaseContractLength) > 0 {
+			reserved[p.sku] = append(reserved[p.sku], p)
+		}
+	}
+	var best price
+	var found bool
+	for sku, p := range onDemand {
+		if len(reserved[sku]) > 0 && (!found || p.hourlyPrice < best.hourlyPrice || (p.hourlyPrice == best.hourlyPrice && sku < best.sku)) {
+			best, found = p, true
+		}
+	}
+	if !found {
+		return pricing{}, false
+	}
+	res := pricing{
+		onDemandHourly: best.hourlyPrice,
+		currency:       best.currency,
+	}
+	for _, p := range reserved[best.sku] {
+		res.offerings = append(res.offerings, Offering{
+			Type:                 OfferingTypeReservation,
+			Term:                 p.leaseContractLength,
+			PaymentOption:        p.purchaseOption,
+			OfferingClass:        p.offeringClass,
+			UpfrontPrice:         p.upfrontPrice,
+			HourlyPrice:          p.hourlyPrice,
+			EffectiveHourlyPrice: p.hourlyPrice + p.upfrontPrice/float64(termYears(p.leaseContractLength)*hoursPerYear),
+		})
+	}
+	sort.Slice(res.offerings, func(i, j int) bool {
+		return res.offerings[i].EffectiveHourlyPrice < res.offerings[j].EffectiveHourlyPrice
+	})
+	return res, true
+}
+
+// savingsPlanOffering returns the EC2 Instance Savings Plan equivalent to a
+// standard reservation. These savings plans are priced at the rates of
+// standard reserved instances.
+func savingsPlanOffering(o Offering) Offering {
+	o.Type = OfferingTypeSavingsPlan
+	o.OfferingClass = ""
+	return o
+}
+
+// findOffering finds the offering of a simulated purchase.
+func findOffering(p pricing, purchase WhatIfPurchase) (Offering, bool) {
+	class := purchase.OfferingClass
+	if purchase.Type == OfferingTypeSavingsPlan || class == "" {
+		class = offeringClassStandard
+	}
+	for _, o := range p.offerings {
+		if o.Term == purchase.Term && o.PaymentOption == purchase.PaymentOption && o.OfferingClass == class {
+			if purchase.Type == OfferingTypeSavingsPlan {
+				return savingsPlanOffering(o), true
+			}
+			return o, true
+		}
+	}
+	return Offering{}, false
+}
+
+// project computes the outcome of buying quantity instances of an offering
+// over daily usage. Each day, the purchased instances cover on-demand hours
+// up to their capacity.
+func project(days []dailyUsage, onDemandHourly float64, o Offering, quantity int) Projection {
+	var onDemand, covered, newlyCovered float64
+	capacity := float64(quantity) * 24
+	for _, day := range days {
+		onDemand += day.onDemand
+		covered += day.covered
+		newlyCovered += math.Min(day.onDemand, capacity)
+	}
+	var res Projection
+	if total := onDemand + covered; total > 0 {
+		res.CurrentCoverage = covered / total * 100
+		res.ProjectedCoverage = (covered + newlyCovered) / total * 100
+	}
+	if capacity > 0 && len(days) > 0 {
+		res.ProjectedUtilization = newlyCovered / (capacity * float64(len(days))) * 100
+	}
+	var dailyOnDemand, dailyNewlyCovered float64
+	if len(days) > 0 {
+		dailyOnDemand = onDemand / float64(len(days))
+		dailyNewlyCovered = newlyCovered / float64(len(days))
+	}
+	res.OnDemandMonthlyCost = dailyOnDemand * daysPerMonth * onDemandHourly
+	res.ProjectedMonthlyCost = (dailyOnDemand-dailyNewlyCovered)*daysPerMonth*onDemandHourly + float64(quantity)*o.EffectiveHourlyPrice*hoursPerMonth
+	res.MonthlySavings = res.OnDemandMonthlyCost - res.ProjectedMonthlyCost
+	res.TermSavings = res.MonthlySavings * float64(termYears(o.Term)*12)
+	monthlyCashSavings := dailyNewlyCovered*daysPerMonth*onDemandHourly - float64(quantity)*o.HourlyPrice*hoursPerMonth
+	if upfront := float64(quantity) * o.UpfrontPrice; upfront == 0 && monthlyCashSavings >= 0 {
+		breakEven := 0.0
+		res.BreakEvenMonths = &breakEven
+	} else if breakEven := upfront / monthlyCashSavings; monthlyCashSavings > 0 && breakEven <= float64(termYears(o.Term)*12) {
+		res.BreakEvenMonths = &breakEven
+	}
+	return res
+}
+
+// recommend builds the recommendation for a UsageKey, or returns false if no
+// instance ran on demand during the whole period or no purchase would lower
+// its cost.
+func recommend(key UsageKey, days []dailyUsage, p pricing) (Recommendation, bool) {
+	if len(days) == 0 {
+		return Recommendation{}, false
+	}
+	minimum := math.Inf(1)
+	var total float64
+	for _, day := range days {
+		minimum = math.Min(minimum, day.onDemand/24)
+		total += day.onDemand / 24
+	}
+	res := Recommendation{
+		UsageKey:            key,
+		Quantity:            int(math.Floor(minimum)),
+		AverageOnDemand:     total / float64(len(days)),
+		MinimumOnDemand:     minimum,
+		OnDemandHourlyPrice: p.onDemandHourly,
+		Currency:            p.currency,
+		Options:             []PurchaseOption{},
+	}
+	if res.Quantity <= 0 {
+		return res, false
+	}
+	for _, o := range p.offerings {
+		offerings := []Offering{o}
+		if key.Service == ServiceEc2 && o.OfferingClass == offeringClassStandard {
+			offerings = append(offerings, savingsPlanOffering(o))
+		}
+		for _, offering := range offerings {
+			option := PurchaseOption{
+				Offering:   offering,
+				Quantity:   res.Quantity,
+				Projection: project(days, p.onDemandHourly, offering, res.Quantity),
+			}
+			if option.MonthlySavings <= 0 {
+				continue
+			} else if offering.Type == OfferingTypeSavingsPlan {
+				option.HourlyCommitment = float64(res.Quantity) * offering.EffectiveHourlyPrice
+			}
+			res.Options = append(res.Options, option)
+		}
+	}
+	sort.SliceStable(res.Options, func(i, j int) bool {
+		return res.Options[i].TermSavings > res.Options[j].TermSavings
+	})
+	return res, len(res.Options) > 0
+}
+
+// simulate projects a simulated purchase over the usage of its UsageKey.
+func simulate(purchase WhatIfPurchase, days []dailyUsage, p pricing) (WhatIfResult, bool) {
+	offering, ok := findOffering(p, purchase)
+	if !ok {
+		return WhatIfResult{}, false
+	}
+	return WhatIfResult{
+		Purchase:   purchase,
+		Offering:   offering,
+		Currency:   p.currency,
+		Projection: project(days, p.onDemandHourly, offering, purchase.Quantity),
+	}, true
+}
+
+// getPricing gets the pricing of the instances of a UsageKey from the
+// product pricing tables. It returns false if they cannot be reserved.
+func getPricing(tx *sql.Tx, key UsageKey) (pricing, bool, error) {
+	var prices []price
+	switch key.Service {
+	case ServiceEc2:
+		ec2Prices, err := models.AwsProductPricingEc2PricesByProduct(tx, key.InstanceType, key.Region, key.Platform, key.Deployment)
+		if err != nil {
+			return pricing{}, false, err
+		}
+		for _, p := range ec2Prices {
+			prices = append(prices, price{p.Sku, p.TermType, p.LeaseContractLength, p.PurchaseOption, p.OfferingClass, p.HourlyPrice, p.UpfrontPrice, p.Currency})
+		}
+	case ServiceRds:
+		rdsPrices, err := models.AwsProductPricingRdsPricesByProduct(tx, key.InstanceType, key.Region, key.Platform, key.Deployment)
+		if err != nil {
+			return pricing{}, false, err
+		}
+		for _, p := range rdsPrices {
+			prices = append(prices, price{p.Sku, p.TermType, p.LeaseContractLength, p.PurchaseOption, p.OfferingClass, p.HourlyPrice, p.UpfrontPrice, p.Currency})
+		}
+	}
+	p, ok := pricingFromPrices(prices)
+	return p, ok, nil
+}
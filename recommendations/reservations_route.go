@@ -0,0 +1,264 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package recommendations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+
+	"github.com/trackit/jsonlog"
+	"github.com/trackit/trackit-server/aws/s3"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/es"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+// defaultLookbackDays is the number of days of usage analyzed when no period
+// is given. The period ends before the previous day, which may not be fully
+// billed yet.
+const defaultLookbackDays = 30
+
+// whatIfRequestBody is the expected request body for the what-if simulator.
+type whatIfRequestBody struct {
+	Purchases []WhatIfPurchase `json:"purchases" req:"nonzero"`
+}
+
+// reservationsQueryArgs allows to get the optional queryArgs params
+var reservationsQueryArgs = []routes.QueryArg{
+	routes.AwsAccountsOptionalQueryArg,
+	routes.QueryArg{
+		Name:        routes.DateBeginQueryArg.Name,
+		Description: "The beginning date of the analyzed usage, defaults to 30 days before the end date.",
+		Type:        routes.QueryArgDate{},
+		Optional:    true,
+	},
+	routes.QueryArg{
+		Name:        routes.DateEndQueryArg.Name,
+		Description: "The end date of the analyzed usage, defaults to two days ago.",
+		Type:        routes.QueryArgDate{},
+		Optional:    true,
+	},
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getReservationRecommendations).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs(reservationsQueryArgs),
+			routes.Documentation{
+				Summary:     "get reserved instance and savings plan purchase recommendations",
+				Description: "Responds with the reserved instances and savings plans which would lower the cost of the EC2 and RDS instances used on demand, with their break-even and expected savings",
+			},
+		),
+		http.MethodPost: routes.H(simulateReservationPurchases).With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{whatIfRequestBody{[]WhatIfPurchase{{
+				UsageKey: UsageKey{
+					Service:      ServiceEc2,
+					Region:       "us-east-1",
+					InstanceType: "m5.large",
+					Platform:     "Linux",
+					Deployment:   "Shared",
+				},
+				Type:          OfferingTypeReservation,
+				Term:          "1yr",
+				PaymentOption: "No Upfront",
+				OfferingClass: offeringClassStandard,
+				Quantity:      2,
+			}}}},
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs(reservationsQueryArgs),
+			routes.Documentation{
+				Summary:     "simulate reserved instance and savings plan purchases",
+				Description: "Responds with the projected coverage, utilization and savings of the purchases in the request body over the usage of the analyzed period",
+			},
+		),
+	}.H().Register("/recommendations/reservations")
+}
+
+// makeElasticSearchRequest prepares and run the request to retrieve the
+// usage of instances. As in the other usage routes, a missing index is not
+// an error for the user: the returned status code is then http.StatusOK with
+// a nil result.
+func makeElasticSearchRequest(ctx context.Context, parsedParams esQueryParams) (*elastic.SearchResult, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(ctx)
+	index := strings.Join(parsedParams.indexList, ",")
+	res, err := getElasticSearchUsageParams(parsedParams, es.Client, index).Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			l.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
+				"index": index,
+				"error": err.Error(),
+			})
+			return nil, http.StatusOK, err
+		}
+		l.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
+		return nil, http.StatusInternalServerError, errors.New("failed to get instance usage")
+	}
+	return res, http.StatusOK, nil
+}
+
+// getUsageHistory retrieves the daily usage of the instances of the accounts
+// of a user.
+func getUsageHistory(ctx context.Context, a routes.Arguments) (esQueryParams, usageHistory, int, error) {
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	parsedParams := parseQueryParams(a, time.Now())
+	if !parsedParams.dateBegin.Before(parsedParams.dateEnd) {
+		return parsedParams, nil, http.StatusBadRequest, errors.New("the beginning date must be before the end date")
+	}
+	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
+	if err != nil {
+		return parsedParams, nil, returnCode, err
+	}
+	parsedParams.accountList = accountsAndIndexes.Accounts
+	parsedParams.indexList = accountsAndIndexes.Indexes
+	days := dayCount(parsedParams.dateBegin, parsedParams.dateEnd)
+	sr, returnCode, err := makeElasticSearchRequest(ctx, parsedParams)
+	if err != nil {
+		if returnCode == http.StatusOK {
+			return parsedParams, usageHistory{}, returnCode, nil
+		}
+		return parsedParams, nil, returnCode, err
+	}
+	history, err := parseUsage(sr, parsedParams.dateBegin, days)
+	if err != nil {
+		l := jsonlog.LoggerFromContextOrDefault(ctx)
+		l.Error("Failed to parse instance usage.", err.Error())
+		return parsedParams, nil, http.StatusInternalServerError, errors.New("failed to parse instance usage")
+	}
+	return parsedParams, history, http.StatusOK, nil
+}
+
+// parseQueryParams builds the esQueryParams from the arguments of a request,
+// applying the default period.
+func parseQueryParams(a routes.Arguments, now time.Time) esQueryParams {
+	parsedParams := esQueryParams{
+		accountList: []string{},
+	}
+	if a[reservationsQueryArgs[0]] != nil {
+		parsedParams.accountList = a[reservationsQueryArgs[0]].([]string)
+	}
+	if end, ok := a[reservationsQueryArgs[2]].(time.Time); ok {
+		parsedParams.dateEnd = end.Add(24*time.Hour - time.Second)
+	} else {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		parsedParams.dateEnd = today.AddDate(0, 0, -1).Add(-time.Second)
+	}
+	if begin, ok := a[reservationsQueryArgs[1]].(time.Time); ok {
+		parsedParams.dateBegin = begin
+	} else {
+		parsedParams.dateBegin = parsedParams.dateEnd.Add(time.Second).AddDate(0, 0, -defaultLookbackDays)
+	}
+	return parsedParams
+}
+
+func getReservationRecommendations(request *http.Request, a routes.Arguments) (int, interface{}) {
+	tx := a[db.Transaction].(*sql.Tx)
+	l := jsonlog.LoggerFromContextOrDefault(request.Context())
+	_, history, returnCode, err := getUsageHistory(request.Context(), a)
+	if err != nil {
+		return returnCode, err
+	}
+	res := []Recommendation{}
+	for key, days := range history {
+		p, ok, err := getPricing(tx, key)
+		if err != nil {
+			l.Error("Failed to get instance prices.", err.Error())
+			return http.StatusInternalServerError, errors.New("failed to get instance prices")
+		} else if !ok {
+			continue
+		}
+		if recommendation, ok := recommend(key, days, p); ok {
+			res = append(res, recommendation)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Options[0].TermSavings > res[j].Options[0].TermSavings
+	})
+	return http.StatusOK, res
+}
+
+// validatePurchase checks a simulated purchase is complete.
+func validatePurchase(purchase WhatIfPurchase) error {
+	switch {
+	case purchase.Service != ServiceEc2 && purchase.Service != ServiceRds:
+		return fmt.Errorf("invalid service %q", purchase.Service)
+	case purchase.Type != "" && purchase.Type != OfferingTypeReservation && purchase.Type != OfferingTypeSavingsPlan:
+		return fmt.Errorf("invalid type %q", purchase.Type)
+	case purchase.Type == OfferingTypeSavingsPlan && purchase.Service != ServiceEc2:
+		return errors.New("savings plans only apply to EC2 instances")
+	case purchase.Region == "" || purchase.InstanceType == "" || purchase.Platform == "" || purchase.Deployment == "":
+		return errors.New("region, instance type, platform and deployment are required")
+	case purchase.Term == "" || purchase.PaymentOption == "":
+		return errors.New("term and payment option are required")
+	case purchase.Quantity <= 0:
+		return errors.New("quantity must be positive")
+	}
+	return nil
+}
+
+func simulateReservationPurchases(request *http.Request, a routes.Arguments) (int, interface{}) {
+	var body whatIfRequestBody
+	routes.MustRequestBody(a, &body)
+	if len(body.Purchases) == 0 {
+		return http.StatusBadRequest, errors.New("no purchase to simulate")
+	}
+	for _, purchase := range body.Purchases {
+		if err := validatePurchase(purchase); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	tx := a[db.Transaction].(*sql.Tx)
+	l := jsonlog.LoggerFromContextOrDefault(request.Context())
+	parsedParams, history, returnCode, err := getUsageHistory(request.Context(), a)
+	if err != nil {
+		return returnCode, err
+	}
+	res := make([]WhatIfResult, 0, len(body.Purchases))
+	for _, purchase := range body.Purchases {
+		if purchase.Type == "" {
+			purchase.Type = OfferingTypeReservation
+		}
+		p, ok, err := getPricing(tx, purchase.UsageKey)
+		if err != nil {
+			l.Error("Failed to get instance prices.", err.Error())
+			return http.StatusInternalServerError, errors.New("failed to get instance prices")
+		} else if !ok {
+			return http.StatusBadRequest, fmt.Errorf("no reserved offering for %s %s in %s", purchase.Platform, purchase.InstanceType, purchase.Region)
+		}
+		days, ok := history[purchase.UsageKey]
+		if !ok {
+			days = make([]dailyUsage, dayCount(parsedParams.dateBegin, parsedParams.dateEnd))
+		}
+		result, ok := simulate(purchase, days, p)
+		if !ok {
+			return http.StatusBadRequest, fmt.Errorf("no %s %s %s offering for %s %s in %s", purchase.Term, purchase.PaymentOption, purchase.Type, purchase.Platform, purchase.InstanceType, purchase.Region)
+		}
+		res = append(res, result)
+	}
+	return http.StatusOK, res
+}
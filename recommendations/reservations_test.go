@@ -0,0 +1,201 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package recommendations
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func floatEquals(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+var testKey = UsageKey{ServiceEc2, "us-east-1", "m5.large", "Linux", "Shared"}
+
+var testPrices = []price{
+	{"SKU1", "OnDemand", "", "", "", 0.096, 0, "USD"},
+	{"SKU1", "Reserved", "1yr", "No Upfront", "standard", 0.06, 0, "USD"},
+	{"SKU1", "Reserved", "1yr", "All Upfront", "standard", 0, 438, "USD"},
+	{"SKU1", "Reserved", "3yr", "Partial Upfront", "convertible", 0.025, 788.4, "USD"},
+	{"SKU2", "OnDemand", "", "", "", 0.2, 0, "USD"},
+	{"SKU2", "Reserved", "1yr", "No Upfront", "standard", 0.1, 0, "USD"},
+	{"SKU3", "OnDemand", "", "", "", 0.05, 0, "USD"},
+}
+
+func TestUsageKeyOf(t *testing.T) {
+	for _, c := range []struct {
+		productCode, operation, usageType string
+		expected                          UsageKey
+		ok                                bool
+	}{
+		{"AmazonEC2", "RunInstances", "USE1-BoxUsage:m5.large", testKey, true},
+		{"AmazonEC2", "RunInstances:0002", "USE1-DedicatedUsage:m5.large", UsageKey{ServiceEc2, "us-east-1", "m5.large", "Windows", "Dedicated"}, true},
+		{"AmazonEC2", "RunInstances:0006", "USE1-BoxUsage:m5.large", UsageKey{}, false},
+		{"AmazonEC2", "RunInstances:SV001", "USE1-SpotUsage:m5.large", UsageKey{}, false},
+		{"AmazonRDS", "CreateDBInstance:0014", "USE1-Multi-AZUsage:m5.large", UsageKey{ServiceRds, "us-east-1", "m5.large", "PostgreSQL", "Multi-AZ"}, true},
+		{"AmazonRDS", "CreateDBInstance:0002", "USE1-InstanceUsage:m5.large", UsageKey{ServiceRds, "us-east-1", "m5.large", "MySQL", "Single-AZ"}, true},
+		{"AmazonES", "ESDomain", "USE1-ESInstance:m5.large", UsageKey{}, false},
+	} {
+		key, ok := usageKeyOf(c.productCode, "us-east-1", "m5.large", c.operation, c.usageType)
+		if ok != c.ok || (ok && key != c.expected) {
+			t.Errorf("Key of %s %s should be %#v (%v), is %#v (%v) instead.", c.operation, c.usageType, c.expected, c.ok, key, ok)
+		}
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	begin := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int) int64 { return begin.AddDate(0, 0, d).Unix() * 1000 }
+	var sr elastic.SearchResult
+	raw, _ := json.Marshal(map[string]interface{}{"products": map[string]interface{}{"buckets": []interface{}{
+		map[string]interface{}{"key": "AmazonEC2", "regions": map[string]interface{}{"buckets": []interface{}{
+			map[string]interface{}{"key": "us-east-1", "instanceTypes": map[string]interface{}{"buckets": []interface{}{
+				map[string]interface{}{"key": "m5.large", "operations": map[string]interface{}{"buckets": []interface{}{
+					map[string]interface{}{"key": "RunInstances", "usageTypes": map[string]interface{}{"buckets": []interface{}{
+						map[string]interface{}{"key": "USE1-BoxUsage:m5.large", "types": map[string]interface{}{"buckets": []interface{}{
+							map[string]interface{}{"key": "Usage", "days": map[string]interface{}{"buckets": []interface{}{
+								map[string]interface{}{"key": day(0), "usage": map[string]interface{}{"value": 48}},
+								map[string]interface{}{"key": day(1), "usage": map[string]interface{}{"value": 72}},
+								map[string]interface{}{"key": day(5), "usage": map[string]interface{}{"value": 24}},
+							}}},
+							map[string]interface{}{"key": "DiscountedUsage", "days": map[string]interface{}{"buckets": []interface{}{
+								map[string]interface{}{"key": day(1), "usage": map[string]interface{}{"value": 24}},
+							}}},
+						}}},
+						map[string]interface{}{"key": "USE1-SpotUsage:m5.large", "types": map[string]interface{}{"buckets": []interface{}{}}},
+					}}},
+				}}},
+			}}},
+		}}},
+	}}})
+	json.Unmarshal(raw, &sr.Aggregations)
+	history, err := parseUsage(&sr, begin, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("History should have 1 key, has %d instead.", len(history))
+	}
+	days := history[testKey]
+	expected := []dailyUsage{{48, 0}, {72, 24}}
+	if len(days) != len(expected) {
+		t.Fatalf("History should have %d days, has %d instead.", len(expected), len(days))
+	}
+	for i := range expected {
+		if days[i] != expected[i] {
+			t.Errorf("Day %d should be %#v, is %#v instead.", i, expected[i], days[i])
+		}
+	}
+}
+
+func TestPricingFromPrices(t *testing.T) {
+	p, ok := pricingFromPrices(testPrices)
+	if !ok {
+		t.Fatal("Pricing should be found.")
+	}
+	if p.onDemandHourly != 0.096 || p.currency != "USD" {
+		t.Errorf("The cheapest reservable product should be picked, got %#v.", p)
+	}
+	if len(p.offerings) != 3 {
+		t.Fatalf("Pricing should have 3 offerings, has %d instead.", len(p.offerings))
+	}
+	if o := p.offerings[0]; o.PaymentOption != "All Upfront" || !floatEquals(o.EffectiveHourlyPrice, 0.05) {
+		t.Errorf("All upfront offerings should be amortized, got %#v.", o)
+	}
+	if o := p.offerings[1]; o.Term != "3yr" || !floatEquals(o.EffectiveHourlyPrice, 0.055) {
+		t.Errorf("Partial upfront offerings should be amortized over their term, got %#v.", o)
+	}
+	if _, ok := pricingFromPrices(testPrices[6:]); ok {
+		t.Error("Pricing should ignore products without reserved prices.")
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	p, _ := pricingFromPrices(testPrices)
+	days := []dailyUsage{{72, 0}, {48, 24}, {60, 0}}
+	r, ok := recommend(testKey, days, p)
+	if !ok {
+		t.Fatal("A recommendation should be made.")
+	}
+	if r.Quantity != 2 || !floatEquals(r.MinimumOnDemand, 2) || !floatEquals(r.AverageOnDemand, 2.5) {
+		t.Errorf("Two instances should be recommended, got %#v.", r)
+	}
+	if len(r.Options) != 5 {
+		t.Fatalf("Recommendation should have 5 options, has %d instead.", len(r.Options))
+	}
+	best := r.Options[0]
+	if best.Term != "3yr" || best.Type != OfferingTypeReservation {
+		t.Errorf("The 3 years reservation should save the most, got %#v.", best)
+	}
+	for _, o := range r.Options {
+		if o.Type == OfferingTypeSavingsPlan && (o.OfferingClass != "" || !floatEquals(o.HourlyCommitment, 2*o.EffectiveHourlyPrice)) {
+			t.Errorf("Savings plans should commit to the reserved rate of the instances, got %#v.", o)
+		}
+		if o.ProjectedUtilization != 100 {
+			t.Errorf("Recommended purchases should be fully used, got %#v.", o)
+		}
+	}
+	noUpfront := r.Options[len(r.Options)-1]
+	if noUpfront.PaymentOption != "No Upfront" || !floatEquals(noUpfront.MonthlySavings, 2*0.036*hoursPerMonth) || *noUpfront.BreakEvenMonths != 0 {
+		t.Errorf("The no upfront reservation should save 0.036 per instance hour from the start, got %#v.", noUpfront)
+	}
+	if _, ok := recommend(testKey, []dailyUsage{{72, 0}, {12, 0}}, p); ok {
+		t.Error("No recommendation should be made when less than one instance always runs on demand.")
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	p, _ := pricingFromPrices(testPrices)
+	days := []dailyUsage{{48, 0}, {24, 24}}
+	purchase := WhatIfPurchase{UsageKey: testKey, Type: OfferingTypeReservation, Term: "1yr", PaymentOption: "All Upfront", Quantity: 2}
+	res, ok := simulate(purchase, days, p)
+	if !ok {
+		t.Fatal("The offering should be found.")
+	}
+	if res.Offering.OfferingClass != "standard" || res.Currency != "USD" {
+		t.Errorf("The standard offering should be used, got %#v.", res)
+	}
+	if !floatEquals(res.CurrentCoverage, 25) || !floatEquals(res.ProjectedCoverage, 100) || !floatEquals(res.ProjectedUtilization, 75) {
+		t.Errorf("Coverage should go from 25%% to 100%% with a 75%% utilization, got %#v.", res.Projection)
+	}
+	onDemand := 36 * daysPerMonth * 0.096
+	projected := 2 * 0.05 * hoursPerMonth
+	if !floatEquals(res.OnDemandMonthlyCost, onDemand) || !floatEquals(res.ProjectedMonthlyCost, projected) {
+		t.Errorf("Monthly costs should be %f and %f, got %#v.", onDemand, projected, res.Projection)
+	}
+	if breakEven := 2 * 438 / onDemand; res.BreakEvenMonths == nil || !floatEquals(*res.BreakEvenMonths, breakEven) {
+		t.Errorf("Break-even should be %f months, got %v.", breakEven, res.BreakEvenMonths)
+	}
+	purchase.Quantity = 10
+	if res, _ := simulate(purchase, days, p); res.BreakEvenMonths != nil || res.MonthlySavings >= 0 {
+		t.Errorf("Buying too many instances should not break even within the term, got %#v.", res.Projection)
+	}
+	purchase.PaymentOption = "Partial Upfront"
+	if _, ok := simulate(purchase, days, p); ok {
+		t.Error("Simulating an unknown offering should fail.")
+	}
+}
+
+func TestDayCount(t *testing.T) {
+	begin := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	if n := dayCount(begin, begin.AddDate(0, 0, 30).Add(-time.Second)); n != 30 {
+		t.Errorf("Day count should be 30, is %d instead.", n)
+	}
+}
@@ -0,0 +1,200 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package recommendations
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const (
+	// ServiceEc2 is the service of EC2 instances.
+	ServiceEc2 = "ec2"
+	// ServiceRds is the service of RDS instances.
+	ServiceRds = "rds"
+)
+
+// ec2Platforms maps the operations of EC2 line items to the operating
+// systems of the offer file. Instances with pre-installed software are not
+// listed as their reservations are not interchangeable.
+var ec2Platforms = map[string]string{
+	"RunInstances":      "Linux",
+	"RunInstances:0002": "Windows",
+	"RunInstances:0010": "RHEL",
+	"RunInstances:000g": "SUSE",
+}
+
+// rdsPlatforms maps the operations of RDS line items to the database engines
+// of the offer file. Licensed engines are not listed as their reservations
+// depend on the license model.
+var rdsPlatforms = map[string]string{
+	"CreateDBInstance:0002": "MySQL",
+	"CreateDBInstance:0014": "PostgreSQL",
+	"CreateDBInstance:0016": "Aurora MySQL",
+	"CreateDBInstance:0018": "MariaDB",
+	"CreateDBInstance:0021": "Aurora PostgreSQL",
+}
+
+// UsageKey identifies the instances a reservation applies to. Platform is the
+// operating system for EC2 and the database engine for RDS, Deployment is the
+// tenancy for EC2 and the deployment option for RDS.
+type UsageKey struct {
+	Service      string `json:"service"`
+	Region       string `json:"region"`
+	InstanceType string `json:"instanceType"`
+	Platform     string `json:"platform"`
+	Deployment   string `json:"deployment"`
+}
+
+// dailyUsage holds the instance hours of a day, used on demand or covered by
+// a reservation or a savings plan.
+type dailyUsage struct {
+	onDemand float64
+	covered  float64
+}
+
+// usageHistory holds the daily usage of each UsageKey over a period.
+type usageHistory map[UsageKey][]dailyUsage
+
+type esUsage struct {
+	Buckets []struct {
+		Key     string `json:"key"`
+		Regions struct {
+			Buckets []struct {
+				Key           string `json:"key"`
+				InstanceTypes struct {
+					Buckets []struct {
+						Key        string `json:"key"`
+						Operations struct {
+							Buckets []struct {
+								Key        string `json:"key"`
+								UsageTypes struct {
+									Buckets []struct {
+										Key   string `json:"key"`
+										Types struct {
+											Buckets []struct {
+												Key  string `json:"key"`
+												Days struct {
+													Buckets []struct {
+														Key   float64 `json:"key"`
+														Usage struct {
+															Value float64 `json:"value"`
+														} `json:"usage"`
+													} `json:"buckets"`
+												} `json:"days"`
+											} `json:"buckets"`
+										} `json:"types"`
+									} `json:"buckets"`
+								} `json:"usageTypes"`
+							} `json:"buckets"`
+						} `json:"operations"`
+					} `json:"buckets"`
+				} `json:"instanceTypes"`
+			} `json:"buckets"`
+		} `json:"regions"`
+	} `json:"buckets"`
+}
+
+// usageKeyOf builds the UsageKey of line items. It returns false for usage
+// reservations cannot be recommended for, such as spot instances or
+// licensed software.
+func usageKeyOf(productCode, region, instanceType, operation, usageType string) (UsageKey, bool) {
+	key := UsageKey{
+		Region:       region,
+		InstanceType: instanceType,
+	}
+	var ok bool
+	switch productCode {
+	case productCodeEc2:
+		key.Service = ServiceEc2
+		if key.Platform, ok = ec2Platforms[operation]; !ok {
+			return key, false
+		} else if strings.Contains(usageType, "BoxUsage") {
+			key.Deployment = "Shared"
+		} else if strings.Contains(usageType, "DedicatedUsage") {
+			key.Deployment = "Dedicated"
+		} else {
+			return key, false
+		}
+	case productCodeRds:
+		key.Service = ServiceRds
+		if key.Platform, ok = rdsPlatforms[operation]; !ok {
+			return key, false
+		} else if strings.Contains(usageType, "Multi-AZUsage") {
+			key.Deployment = "Multi-AZ"
+		} else if strings.Contains(usageType, "InstanceUsage") {
+			key.Deployment = "Single-AZ"
+		} else {
+			return key, false
+		}
+	default:
+		return key, false
+	}
+	return key, region != "" && instanceType != ""
+}
+
+// dayCount returns the number of days of a period, counting partial days.
+func dayCount(begin, end time.Time) int {
+	return int(math.Ceil(end.Sub(begin).Hours() / 24))
+}
+
+// parseUsage builds the usage history of the period starting at begin and
+// lasting a number of days from the result of the usage query.
+func parseUsage(sr *elastic.SearchResult, begin time.Time, days int) (usageHistory, error) {
+	var products esUsage
+	raw, ok := sr.Aggregations["products"]
+	if !ok || raw == nil {
+		return nil, errors.New("missing aggregation products")
+	} else if err := json.Unmarshal(*raw, &products); err != nil {
+		return nil, err
+	}
+	history := make(usageHistory)
+	for _, product := range products.Buckets {
+		for _, region := range product.Regions.Buckets {
+			for _, instanceType := range region.InstanceTypes.Buckets {
+				for _, operation := range instanceType.Operations.Buckets {
+					for _, usageType := range operation.UsageTypes.Buckets {
+						key, ok := usageKeyOf(product.Key, region.Key, instanceType.Key, operation.Key, usageType.Key)
+						if !ok {
+							continue
+						}
+						if history[key] == nil {
+							history[key] = make([]dailyUsage, days)
+						}
+						for _, lineItemType := range usageType.Types.Buckets {
+							for _, day := range lineItemType.Days.Buckets {
+								date := time.Unix(0, int64(day.Key)*int64(time.Millisecond))
+								i := int(date.Sub(begin).Hours() / 24)
+								if i < 0 || i >= days {
+									continue
+								} else if lineItemType.Key == lineItemTypeUsage {
+									history[key][i].onDemand += day.Usage.Value
+								} else {
+									history[key][i].covered += day.Usage.Value
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return history, nil
+}
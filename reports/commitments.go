@@ -0,0 +1,97 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws"
+	"github.com/trackit/trackit-server/aws/usageReports/history"
+	"github.com/trackit/trackit-server/costs/commitments"
+	"github.com/trackit/trackit-server/users"
+)
+
+// expiringCommitmentsWindow is how far ahead commitments are reported as
+// expiring.
+const expiringCommitmentsWindow = 60 * 24 * time.Hour
+
+var expiringCommitmentsFormat = [][]cell{{
+	newCell("ARN").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Type").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Account").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Expiration Date").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Days Left").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Utilization (Percentage)").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Unused Cost").addStyle(textCenter, textBold, backgroundGrey),
+	newCell("Currency").addStyle(textCenter, textBold, backgroundGrey),
+}}
+
+func formatExpiringCommitment(commitment commitments.Commitment, now time.Time) []cell {
+	return []cell{
+		newCell(commitment.Arn),
+		newCell(commitment.Type),
+		newCell(commitment.Account),
+		newCell(commitment.EndDate.Format("2006-01-02")),
+		newCell(int(commitment.EndDate.Sub(now).Hours() / 24)),
+		newCell(commitment.Utilization),
+		newCell(commitment.UnusedCost),
+		newCell(commitment.Currency),
+	}
+}
+
+func getExpiringCommitments(ctx context.Context, aa aws.AwsAccount, tx *sql.Tx) (data [][]cell, err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+
+	data = make([][]cell, 0)
+	for _, headerRow := range expiringCommitmentsFormat {
+		data = append(data, headerRow)
+	}
+
+	dateBegin, dateEnd := history.GetHistoryDate()
+
+	identity, err := aa.GetAwsAccountIdentity()
+	if err != nil {
+		return
+	}
+
+	user, err := users.GetUserWithId(tx, aa.UserId)
+	if err != nil {
+		return
+	}
+
+	parameters := commitments.QueryParams{
+		AccountList: []string{identity},
+		DateBegin:   dateBegin,
+		DateEnd:     dateEnd,
+	}
+
+	logger.Debug("Getting expiring commitments for account", map[string]interface{}{
+		"account": aa,
+	})
+	_, report, err := commitments.GetUtilizationData(ctx, parameters, user, tx)
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, commitment := range commitments.ExpiringCommitments(report.Commitments, now, expiringCommitmentsWindow) {
+		data = append(data, formatExpiringCommitment(commitment, now))
+	}
+	return
+}
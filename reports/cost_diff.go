@@ -72,6 +72,17 @@ func formatCostDiff(data []diff.PricePoint) (values costDiffProduct) {
 	return
 }
 
+// getCostDiffCurrency returns the currency the costs of a cost diff are
+// displayed in, formatted to be appended to a header.
+func getCostDiffCurrency(report map[string][]diff.PricePoint) string {
+	for _, pricePoints := range report {
+		for _, pricePoint := range pricePoints {
+			return " (" + pricePoint.Currency + ")"
+		}
+	}
+	return ""
+}
+
 func getCostDiff(ctx context.Context, aa aws.AwsAccount, tx *sql.Tx) (data [][]cell, err error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Getting Cost Differentiator Report for account", map[string]interface{}{
@@ -93,9 +104,10 @@ func getCostDiff(ctx context.Context, aa aws.AwsAccount, tx *sql.Tx) (data [][]c
 		rawData[product] = formatCostDiff(data)
 	}
 
+	currency := getCostDiffCurrency(report)
 	dates := getDates(rawData)
 	for _, date := range dates {
-		header = append(header, newCell(date + " - Cost").addStyle(textCenter, textBold, backgroundGrey))
+		header = append(header, newCell(date + " - Cost" + currency).addStyle(textCenter, textBold, backgroundGrey))
 		if len(header) > 2 {
 			header = append(header, newCell(date + " - Variation").addStyle(textCenter, textBold, backgroundGrey),)
 		}
@@ -49,6 +49,11 @@ var modules = []module{
 		Function:  getCostDiff,
 		ErrorName: "CostDifferentiatorError",
 	},
+	{
+		Name:      "Expiring Commitments",
+		Function:  getExpiringCommitments,
+		ErrorName: "expiringCommitmentsError",
+	},
 }
 
 func GenerateReport(ctx context.Context, aa aws.AwsAccount) (errs map[string]error) {
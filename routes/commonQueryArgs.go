@@ -66,6 +66,25 @@ var (
 		Description: "The ID for a bill repository.",
 	}
 
+	// BudgetIdQueryArg allows to get the budget ID in the URL Parameters
+	// with routes.QueryArgs. This budget ID will be an int stored
+	// in the routes.Arguments map with itself for key.
+	BudgetIdQueryArg = QueryArg{
+		Name:        "budget-id",
+		Type:        QueryArgInt{},
+		Description: "The ID for a budget.",
+	}
+
+	// NotificationEndpointIdQueryArg allows to get the notification
+	// endpoint ID in the URL Parameters with routes.QueryArgs. This
+	// endpoint ID will be an int stored in the routes.Arguments map with
+	// itself for key.
+	NotificationEndpointIdQueryArg = QueryArg{
+		Name:        "notification-endpoint-id",
+		Type:        QueryArgInt{},
+		Description: "The ID for a notification endpoint.",
+	}
+
 	// DateQueryArg allows to get the iso8601 date in the URL
 	// Parameters with routes.QueryArgs. This date will be a
 	// time.Time stored in the routes.Arguments map with itself for key.
@@ -124,4 +143,23 @@ var (
 		Type:        QueryArgInt{},
 		Description: "The DB ID of the sharing",
 	}
+
+	// CostTypeQueryArg allows to get the cost type in the URL Parameters
+	// with routes.QueryArgs. This cost type will be a string stored in the
+	// routes.Arguments map with itself for key.
+	CostTypeQueryArg = QueryArg{
+		Name:        "cost-type",
+		Type:        QueryArgString{},
+		Description: "The cost to report: unblended (default), blended, amortized or net",
+		Optional:    true,
+	}
+
+	// ApiKeyIdQueryArg allows to get the DB id of an API key in the URL
+	// Parameters with routes.QueryArgs. This API key ID will be an int
+	// stored in the routes.Arguments map with itself for key.
+	ApiKeyIdQueryArg = QueryArg{
+		Name:        "api-key-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of an API key.",
+	}
 )
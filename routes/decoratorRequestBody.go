@@ -81,6 +81,7 @@ func (rb RequestBody) getDocumentation(hd HandlerDocumentation) HandlerDocumenta
 	if hd.Components == nil {
 		hd.Components = make(map[string]HandlerDocumentation)
 	}
+	hd.requestBodyExample = rb.Example
 	hd.Components["input:body:example"] = HandlerDocumentation{
 		HandlerDocumentationBody: HandlerDocumentationBody{
 			Summary:     "input body example",
@@ -0,0 +1,62 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/trackit/trackit-server/metrics"
+)
+
+var (
+	requestsTotal = metrics.NewCounter(
+		"trackit_http_requests_total",
+		"Number of HTTP requests handled, by route pattern, method and status code.",
+		"pattern", "method", "status",
+	)
+	requestDuration = metrics.NewHistogram(
+		"trackit_http_request_duration_seconds",
+		"Time taken to handle HTTP requests, by route pattern, method and status code.",
+		metrics.DefaultBuckets,
+		"pattern", "method", "status",
+	)
+)
+
+// RouteMetrics is a decorator which records the number of requests to a route
+// and their latency, by status code. The route is identified by the pattern
+// it is registered with rather than by its URL, to keep the number of series
+// bounded.
+type RouteMetrics struct {
+	Pattern string
+}
+
+func (d RouteMetrics) Decorate(h Handler) Handler {
+	h.Func = d.getFunc(h.Func)
+	return h
+}
+
+// getFunc returns a decorated handler function for RouteMetrics.
+func (d RouteMetrics) getFunc(hf HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, a Arguments) (int, interface{}) {
+		start := time.Now()
+		status, response := hf(w, r, a)
+		statusText := strconv.Itoa(status)
+		requestsTotal.Inc(d.Pattern, r.Method, statusText)
+		requestDuration.Observe(time.Since(start).Seconds(), d.Pattern, r.Method, statusText)
+		return status, response
+	}
+}
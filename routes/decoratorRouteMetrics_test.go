@@ -0,0 +1,46 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/trackit/trackit-server/metrics"
+)
+
+func TestDecoratorRouteMetrics(t *testing.T) {
+	h := H(getFoo).With(
+		RouteMetrics{"/metrics-test"},
+	)
+	for i := 0; i < 3; i++ {
+		request := httptest.NewRequest(http.MethodGet, "/metrics-test?i=1", nil)
+		response := httptest.NewRecorder()
+		h.Func(response, request, Arguments{})
+	}
+	var b bytes.Buffer
+	metrics.DefaultRegistry.WriteText(&b)
+	for _, line := range []string{
+		`trackit_http_requests_total{pattern="/metrics-test",method="GET",status="200"} 3`,
+		`trackit_http_request_duration_seconds_count{pattern="/metrics-test",method="GET",status="200"} 3`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("Metrics should contain %q.", line)
+		}
+	}
+}
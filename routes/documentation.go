@@ -34,6 +34,9 @@ type HandlerDocumentationBody struct {
 type HandlerDocumentation struct {
 	HandlerDocumentationBody
 	Components map[string]HandlerDocumentation `json:"components,omitempty"`
+	// requestBodyExample is the example of the request body, if any, from
+	// which the OpenAPI document reflects the body's schema.
+	requestBodyExample interface{}
 }
 
 const (
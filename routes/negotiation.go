@@ -0,0 +1,122 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mediaRange is a media range from an Accept header, with its quality
+// factor.
+type mediaRange struct {
+	typ     string
+	subtype string
+	params  int
+	q       float64
+}
+
+// specificity ranks media ranges as defined in RFC 7231 section 5.3.2: a
+// range with parameters is more specific than a type/subtype range, which is
+// more specific than a type/* range, which is more specific than */*.
+func (mr mediaRange) specificity() int {
+	if mr.typ == "*" {
+		return 0
+	} else if mr.subtype == "*" {
+		return 1
+	} else if mr.params == 0 {
+		return 2
+	}
+	return 3
+}
+
+// matches tells whether a media type without parameters is in the range.
+func (mr mediaRange) matches(typ, subtype string) bool {
+	return (mr.typ == "*" || mr.typ == typ) && (mr.subtype == "*" || mr.subtype == subtype)
+}
+
+// parseAccept parses the values of Accept headers. Malformed media ranges
+// are ignored.
+func parseAccept(headers []string) []mediaRange {
+	ranges := make([]mediaRange, 0, 4)
+	for _, h := range headers {
+		for _, raw := range strings.Split(h, ",") {
+			if mr, ok := parseMediaRange(raw); ok {
+				ranges = append(ranges, mr)
+			}
+		}
+	}
+	return ranges
+}
+
+func parseMediaRange(raw string) (mediaRange, bool) {
+	parts := strings.Split(raw, ";")
+	mt := strings.ToLower(strings.TrimSpace(parts[0]))
+	if mt == "*" {
+		mt = "*/*"
+	}
+	types := strings.Split(mt, "/")
+	if len(types) != 2 || types[0] == "" || types[1] == "" || (types[0] == "*" && types[1] != "*") {
+		return mediaRange{}, false
+	}
+	mr := mediaRange{typ: types[0], subtype: types[1], q: 1}
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) != 2 {
+			return mediaRange{}, false
+		} else if strings.ToLower(kv[0]) != "q" {
+			mr.params++
+		} else if q, err := strconv.ParseFloat(kv[1], 64); err != nil || q < 0 || q > 1 {
+			return mediaRange{}, false
+		} else {
+			// Parameters after the quality factor are accept
+			// extensions, which do not select media types.
+			mr.q = q
+			break
+		}
+	}
+	return mr, true
+}
+
+// quality returns the quality factor the ranges give a media type, taken from
+// the most specific range matching it. It is zero if none match.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	types := strings.SplitN(mediaType, "/", 2)
+	q, specificity := 0.0, -1
+	for _, mr := range ranges {
+		if s := mr.specificity(); mr.matches(types[0], types[1]) && s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+	return q
+}
+
+// negotiate selects the media type to respond with among offers, in order of
+// preference, given the request's Accept headers. The first offer is used
+// when no media range was specified. False is returned if no offer is
+// acceptable.
+func negotiate(accept []string, offers []string) (string, bool) {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return offers[0], true
+	}
+	best, bestQ := "", 0.0
+	for _, o := range offers {
+		if q := quality(ranges, o); q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best, best != ""
+}
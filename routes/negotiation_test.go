@@ -0,0 +1,52 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept     []string
+		expected   string
+		acceptable bool
+	}{
+		{nil, mediaTypeJson, true},
+		{[]string{""}, mediaTypeJson, true},
+		{[]string{"*/*"}, mediaTypeJson, true},
+		{[]string{"text/csv"}, mediaTypeCsv, true},
+		{[]string{"TEXT/CSV"}, mediaTypeCsv, true},
+		{[]string{"text/*"}, mediaTypeCsv, true},
+		{[]string{"text/html, text/csv;q=0.5"}, mediaTypeCsv, true},
+		{[]string{"application/json;q=0.5, text/csv"}, mediaTypeCsv, true},
+		{[]string{"application/json;q=0.5", "text/csv;q=0.8"}, mediaTypeCsv, true},
+		{[]string{"*/*;q=0.5, application/json;q=0.1"}, mediaTypeCsv, true},
+		{[]string{"*/*, application/json;q=0"}, mediaTypeCsv, true},
+		{[]string{"application/*;q=0.9, application/json;q=0.1"}, mediaTypeXlsx, true},
+		{[]string{"application/vnd.ms-excel"}, mediaTypeExcel, true},
+		{[]string{"text/csv;charset=utf-8;q=1;ext=1"}, mediaTypeCsv, true},
+		{[]string{"text/csv;q=2"}, mediaTypeJson, true},
+		{[]string{"text/html"}, "", false},
+		{[]string{"text/csv;q=0"}, "", false},
+		{[]string{"*/*;q=0"}, "", false},
+	}
+	for _, tt := range tests {
+		mediaType, acceptable := negotiate(tt.accept, responseMediaTypes)
+		if mediaType != tt.expected || acceptable != tt.acceptable {
+			t.Errorf("Accept %q should negotiate (%q, %v), negotiates (%q, %v) instead.", tt.accept, tt.expected, tt.acceptable, mediaType, acceptable)
+		}
+	}
+}
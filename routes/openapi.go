@@ -0,0 +1,400 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/trackit/trackit-server/util/req"
+)
+
+const (
+	openAPIVersion     = "3.0.3"
+	openAPITitle       = "TrackIt API"
+	openAPIDescription = "Get the api's documentation as an OpenAPI 3 " +
+		"document. Like the structured documentation, it is generated " +
+		"from the definition of the route handlers."
+	openAPISummary = "get the api's openapi document"
+)
+
+// SecurityScheme is an OpenAPI security scheme object. Decorators which
+// require credentials describe them with a SecurityScheme registered with
+// RegisterSecurityScheme.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type namedSecurityScheme struct {
+	name   string
+	scheme SecurityScheme
+}
+
+// securitySchemes maps documentation tags to the security scheme a handler
+// bearing that tag requires.
+var securitySchemes = make(map[string]namedSecurityScheme)
+
+// RegisterSecurityScheme declares that handlers whose documentation has the
+// tag require the security scheme s, which will be named name in the OpenAPI
+// document.
+func RegisterSecurityScheme(tag, name string, s SecurityScheme) {
+	securitySchemes[tag] = namedSecurityScheme{name, s}
+}
+
+type (
+	openAPIDocument struct {
+		OpenAPI    string                     `json:"openapi"`
+		Info       openAPIInfo                `json:"info"`
+		Paths      map[string]openAPIPathItem `json:"paths"`
+		Components openAPIComponents          `json:"components"`
+	}
+
+	openAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	openAPIComponents struct {
+		SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	openAPIPathItem map[string]interface{}
+
+	openAPIOperation struct {
+		OperationId string                     `json:"operationId"`
+		Summary     string                     `json:"summary,omitempty"`
+		Description string                     `json:"description,omitempty"`
+		Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]openAPIResponse `json:"responses"`
+		Security    []map[string][]string      `json:"security,omitempty"`
+	}
+
+	openAPIParameter struct {
+		Name        string        `json:"name"`
+		In          string        `json:"in"`
+		Description string        `json:"description,omitempty"`
+		Required    bool          `json:"required"`
+		Style       string        `json:"style,omitempty"`
+		Explode     *bool         `json:"explode,omitempty"`
+		Schema      openAPISchema `json:"schema"`
+	}
+
+	openAPIRequestBody struct {
+		Required bool                        `json:"required"`
+		Content  map[string]openAPIMediaType `json:"content"`
+	}
+
+	openAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]openAPIMediaType `json:"content,omitempty"`
+	}
+
+	openAPIMediaType struct {
+		Schema  *openAPISchema `json:"schema,omitempty"`
+		Example interface{}    `json:"example,omitempty"`
+	}
+
+	openAPISchema struct {
+		Type                 string                   `json:"type,omitempty"`
+		Format               string                   `json:"format,omitempty"`
+		Minimum              *int                     `json:"minimum,omitempty"`
+		Nullable             bool                     `json:"nullable,omitempty"`
+		Items                *openAPISchema           `json:"items,omitempty"`
+		Properties           map[string]openAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *openAPISchema           `json:"additionalProperties,omitempty"`
+		Required             []string                 `json:"required,omitempty"`
+	}
+)
+
+var (
+	zero        = 0
+	noExplode   = false
+	timeType    = reflect.TypeOf(time.Time{})
+	errorSchema = openAPISchema{
+		Type:       "object",
+		Properties: map[string]openAPISchema{"error": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+)
+
+// queryArgSchemas maps the FormatName of the QueryParsers to the schema of
+// the parameters they parse.
+var queryArgSchemas = map[string]openAPISchema{
+	QueryArgBool{}.FormatName():        {Type: "boolean"},
+	QueryArgInt{}.FormatName():         {Type: "integer", Format: "int64"},
+	QueryArgUint{}.FormatName():        {Type: "integer", Format: "int64", Minimum: &zero},
+	QueryArgString{}.FormatName():      {Type: "string"},
+	QueryArgIntSlice{}.FormatName():    {Type: "array", Items: &openAPISchema{Type: "integer", Format: "int64"}},
+	QueryArgUintSlice{}.FormatName():   {Type: "array", Items: &openAPISchema{Type: "integer", Format: "int64", Minimum: &zero}},
+	QueryArgStringSlice{}.FormatName(): {Type: "array", Items: &openAPISchema{Type: "string"}},
+	QueryArgDate{}.FormatName():        {Type: "string", Format: "date"},
+}
+
+// OpenAPIHandler returns a Handler which responds to http.MethodGet requests
+// with an OpenAPI 3 document describing all registered routes. The version
+// is reported as the version of the API.
+func OpenAPIHandler(version string) Handler {
+	return MethodMuxer{
+		http.MethodGet: H(func(_ *http.Request, _ Arguments) (int, interface{}) {
+			return http.StatusOK, buildOpenAPIDocument(version)
+		}).With(Documentation{
+			Summary:     openAPISummary,
+			Description: openAPIDescription,
+		}),
+	}.H()
+}
+
+// buildOpenAPIDocument builds the OpenAPI document from RegisteredHandlers.
+func buildOpenAPIDocument(version string) openAPIDocument {
+	doc := openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{openAPITitle, version},
+		Paths:   make(map[string]openAPIPathItem),
+	}
+	for _, rh := range RegisteredHandlers {
+		item := make(openAPIPathItem)
+		if rh.Documentation.Summary != "" {
+			item["summary"] = rh.Documentation.Summary
+		}
+		if rh.Documentation.Description != "" {
+			item["description"] = rh.Documentation.Description
+		}
+		for m := range rh.methods {
+			hd := rh.Documentation.Components["method:"+m]
+			op := buildOpenAPIOperation(rh.Pattern, m, rh.Documentation, hd)
+			for _, s := range op.Security {
+				for name := range s {
+					if doc.Components.SecuritySchemes == nil {
+						doc.Components.SecuritySchemes = make(map[string]SecurityScheme)
+					}
+					doc.Components.SecuritySchemes[name] = securitySchemeByName(name)
+				}
+			}
+			item[strings.ToLower(m)] = op
+		}
+		doc.Paths[rh.Pattern] = item
+	}
+	return doc
+}
+
+// buildOpenAPIOperation builds the operation for a method of a route. The
+// documentation of the route itself is merged with that of the method, since
+// decorators can be applied on either.
+func buildOpenAPIOperation(pattern, method string, route, hd HandlerDocumentation) openAPIOperation {
+	op := openAPIOperation{
+		OperationId: operationId(pattern, method),
+		Summary:     hd.Summary,
+		Description: hd.Description,
+		Responses: map[string]openAPIResponse{
+			"200":     successResponse(),
+			"406":     {"no acceptable media type", map[string]openAPIMediaType{mediaTypeJson: {Schema: &errorSchema}}},
+			"default": {"error", map[string]openAPIMediaType{mediaTypeJson: {Schema: &errorSchema}}},
+		},
+	}
+	tags := mergeTags(route.Tags, hd.Tags)
+	op.Parameters = append(queryParameters(tags[TagRequiredQueryArg], true), queryParameters(tags[TagOptionalQueryArg], false)...)
+	if example := hd.requestBodyExample; example != nil && !bodyIsIgnoredForMethod(method) {
+		op.RequestBody = requestBody(example, tags[TagRequiredContentType])
+	}
+	for _, tag := range sortedTagKeys(tags) {
+		if s, ok := securitySchemes[tag]; ok {
+			op.Security = append(op.Security, map[string][]string{s.name: {}})
+		}
+	}
+	if len(op.Security) > 0 {
+		op.Responses["401"] = openAPIResponse{"missing or invalid credentials", map[string]openAPIMediaType{mediaTypeJson: {Schema: &errorSchema}}}
+	}
+	return op
+}
+
+func mergeTags(a, b Tags) Tags {
+	tags := make(Tags)
+	for k, v := range a {
+		tags[k] = append(tags[k], v...)
+	}
+	for k, v := range b {
+		tags[k] = append(tags[k], v...)
+	}
+	return tags
+}
+
+func sortedTagKeys(tags Tags) []string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func securitySchemeByName(name string) SecurityScheme {
+	for _, s := range securitySchemes {
+		if s.name == name {
+			return s.scheme
+		}
+	}
+	return SecurityScheme{}
+}
+
+// operationId builds a unique operation ID from a method and a pattern, e.g.
+// getCostsAnomalies for GET /costs/anomalies.
+func operationId(pattern, method string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(pattern, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+func successResponse() openAPIResponse {
+	content := make(map[string]openAPIMediaType)
+	for _, mt := range responseMediaTypes {
+		content[mt] = openAPIMediaType{}
+	}
+	return openAPIResponse{"success", content}
+}
+
+// queryParameters builds parameters from the query arg documentation tags,
+// which are formatted as name:format:description.
+func queryParameters(args []string, required bool) []openAPIParameter {
+	params := make([]openAPIParameter, 0, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, ":", 3)
+		p := openAPIParameter{
+			Name:     parts[0],
+			In:       "query",
+			Required: required,
+			Schema:   openAPISchema{Type: "string"},
+		}
+		if len(parts) > 1 {
+			if s, ok := queryArgSchemas[parts[1]]; ok {
+				p.Schema = s
+			}
+		}
+		if len(parts) > 2 {
+			p.Description = parts[2]
+		}
+		if p.Schema.Type == "array" {
+			p.Style = "form"
+			p.Explode = &noExplode
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+func requestBody(example interface{}, contentTypes []string) *openAPIRequestBody {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{mediaTypeJson}
+	}
+	schema := schemaOf(reflect.TypeOf(example), make(map[reflect.Type]bool))
+	rb := &openAPIRequestBody{
+		Required: true,
+		Content:  make(map[string]openAPIMediaType),
+	}
+	for _, ct := range contentTypes {
+		rb.Content[ct] = openAPIMediaType{&schema, example}
+	}
+	return rb
+}
+
+// schemaOf reflects over a type to build the schema of its JSON encoding.
+// Fields tagged as nonzero for the validator are required. Recursive types
+// are cut short with an empty schema.
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) openAPISchema {
+	if t == timeType {
+		return openAPISchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := schemaOf(t.Elem(), seen)
+		s.Nullable = s.Type != ""
+		return s
+	case reflect.Bool:
+		return openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return openAPISchema{Type: "integer", Format: intFormat(t)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return openAPISchema{Type: "integer", Format: intFormat(t), Minimum: &zero}
+	case reflect.Float32:
+		return openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return openAPISchema{Type: "string", Format: "byte"}
+		}
+		items := schemaOf(t.Elem(), seen)
+		return openAPISchema{Type: "array", Items: &items}
+	case reflect.Map:
+		values := schemaOf(t.Elem(), seen)
+		return openAPISchema{Type: "object", AdditionalProperties: &values}
+	case reflect.Struct:
+		if seen[t] {
+			return openAPISchema{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		s := openAPISchema{Type: "object", Properties: make(map[string]openAPISchema)}
+		addStructFields(&s, t, seen)
+		return s
+	default:
+		return openAPISchema{}
+	}
+}
+
+// addStructFields adds the JSON-encoded fields of a struct type to a schema.
+// Fields of embedded structs without a JSON name are promoted.
+func addStructFields(s *openAPISchema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || (fld.PkgPath != "" && !fld.Anonymous) {
+			continue
+		} else if fld.Anonymous && name == "" && fld.Type.Kind() == reflect.Struct {
+			addStructFields(s, fld.Type, seen)
+			continue
+		} else if name == "" {
+			name = fld.Name
+		}
+		s.Properties[name] = schemaOf(fld.Type, seen)
+		for _, tag := range strings.Split(fld.Tag.Get(req.StructTagName), ",") {
+			if tag == req.StructTagNonZero {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+}
+
+func intFormat(t reflect.Type) string {
+	if t.Bits() <= 32 {
+		return "int32"
+	}
+	return "int64"
+}
@@ -0,0 +1,206 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testOpenAPIAuthTag = "require:test"
+
+type openAPITestBody struct {
+	Name     string            `json:"name" req:"nonzero"`
+	Count    uint              `json:"count,omitempty"`
+	Ratio    float64           `json:"ratio"`
+	Date     time.Time         `json:"date"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Limit    *int              `json:"limit"`
+	Parent   *openAPITestBody  `json:"parent"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+type testOpenAPIAuth struct{}
+
+func (testOpenAPIAuth) Decorate(h Handler) Handler {
+	h.Documentation = Documentation{Tags: Tags{testOpenAPIAuthTag: {"authenticated"}}}.Decorate(h).Documentation
+	return h
+}
+
+func echoHandler(_ *http.Request, _ Arguments) (int, interface{}) {
+	return http.StatusOK, nil
+}
+
+func getOpenAPITestDocument(t *testing.T) map[string]interface{} {
+	RegisterSecurityScheme(testOpenAPIAuthTag, "testToken", SecurityScheme{Type: "apiKey", In: "header", Name: "Authorization"})
+	MethodMuxer{
+		http.MethodGet: H(echoHandler).With(
+			testOpenAPIAuth{},
+			QueryArgs{
+				QueryArg{Name: "ids", Type: QueryArgUintSlice{}, Description: "ids: comma separated"},
+				QueryArg{Name: "since", Type: QueryArgDate{}, Optional: true},
+			},
+			Documentation{Summary: "get things"},
+		),
+		http.MethodPost: H(echoHandler).With(
+			RequestContentType{"application/json"},
+			RequestBody{openAPITestBody{Name: "thing"}},
+		),
+	}.H().Register("/things/all")
+	OpenAPIHandler("test-build").Register("/openapi.json")
+	defer resetRegisteredHandlers()
+	defer delete(securitySchemes, testOpenAPIAuthTag)
+	status, response := OpenAPIHandler("test-build").Func(nil, httptest.NewRequest(http.MethodGet, "/openapi.json", nil), nil)
+	if status != http.StatusOK {
+		t.Fatalf("Status code should be %d, is %d instead.", http.StatusOK, status)
+	}
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(bytes, &doc); err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	return doc
+}
+
+// lookup follows a path of keys and indices in a decoded JSON document.
+func lookup(v interface{}, path ...interface{}) interface{} {
+	for _, p := range path {
+		switch k := p.(type) {
+		case string:
+			m, _ := v.(map[string]interface{})
+			v = m[k]
+		case int:
+			s, _ := v.([]interface{})
+			if k >= len(s) {
+				return nil
+			}
+			v = s[k]
+		}
+	}
+	return v
+}
+
+func TestOpenAPIPaths(t *testing.T) {
+	doc := getOpenAPITestDocument(t)
+	tests := []struct {
+		path     []interface{}
+		expected interface{}
+	}{
+		{[]interface{}{"openapi"}, openAPIVersion},
+		{[]interface{}{"info", "version"}, "test-build"},
+		{[]interface{}{"paths", "/openapi.json", "get", "summary"}, openAPISummary},
+		{[]interface{}{"paths", "/things/all", "get", "operationId"}, "getThingsAll"},
+		{[]interface{}{"paths", "/things/all", "get", "summary"}, "get things"},
+		{[]interface{}{"paths", "/things/all", "post", "operationId"}, "postThingsAll"},
+		{[]interface{}{"paths", "/things/all", "put"}, nil},
+	}
+	for _, tt := range tests {
+		if actual := lookup(doc, tt.path...); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%v should be %v, is %v instead.", tt.path, tt.expected, actual)
+		}
+	}
+}
+
+func TestOpenAPIQueryParameters(t *testing.T) {
+	doc := getOpenAPITestDocument(t)
+	params := lookup(doc, "paths", "/things/all", "get", "parameters")
+	expected := []interface{}{
+		map[string]interface{}{
+			"name":        "ids",
+			"in":          "query",
+			"description": "ids: comma separated",
+			"required":    true,
+			"style":       "form",
+			"explode":     false,
+			"schema": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0.0},
+			},
+		},
+		map[string]interface{}{
+			"name":     "since",
+			"in":       "query",
+			"required": false,
+			"schema":   map[string]interface{}{"type": "string", "format": "date"},
+		},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Parameters should be %v, are %v instead.", expected, params)
+	}
+}
+
+func TestOpenAPIRequestBody(t *testing.T) {
+	doc := getOpenAPITestDocument(t)
+	body := lookup(doc, "paths", "/things/all", "post", "requestBody", "content", "application/json")
+	schema := lookup(body, "schema")
+	tests := []struct {
+		path     []interface{}
+		expected interface{}
+	}{
+		{[]interface{}{"example", "name"}, "thing"},
+		{[]interface{}{"schema", "required"}, []interface{}{"name"}},
+		{[]interface{}{"schema", "properties", "name", "type"}, "string"},
+		{[]interface{}{"schema", "properties", "count", "minimum"}, 0.0},
+		{[]interface{}{"schema", "properties", "ratio", "format"}, "double"},
+		{[]interface{}{"schema", "properties", "date", "format"}, "date-time"},
+		{[]interface{}{"schema", "properties", "tags", "items", "type"}, "string"},
+		{[]interface{}{"schema", "properties", "labels", "additionalProperties", "type"}, "string"},
+		{[]interface{}{"schema", "properties", "limit", "nullable"}, true},
+		{[]interface{}{"schema", "properties", "limit", "type"}, "integer"},
+		{[]interface{}{"schema", "properties", "parent"}, map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		if actual := lookup(body, tt.path...); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%v should be %v, is %v instead.", tt.path, tt.expected, actual)
+		}
+	}
+	if properties, _ := lookup(schema, "properties").(map[string]interface{}); len(properties) != 8 {
+		t.Errorf("Schema should have 8 properties, has %d instead.", len(properties))
+	}
+	if rb := lookup(doc, "paths", "/things/all", "get", "requestBody"); rb != nil {
+		t.Errorf("GET should not have a request body, has %v instead.", rb)
+	}
+}
+
+func TestOpenAPISecurityAndResponses(t *testing.T) {
+	doc := getOpenAPITestDocument(t)
+	get := lookup(doc, "paths", "/things/all", "get")
+	if security := lookup(get, "security", 0, "testToken"); !reflect.DeepEqual(security, []interface{}{}) {
+		t.Errorf("GET should require testToken, security is %v instead.", lookup(get, "security"))
+	}
+	if lookup(get, "responses", "401") == nil {
+		t.Errorf("GET should document a 401 response.")
+	}
+	if security := lookup(doc, "paths", "/things/all", "post", "security"); security != nil {
+		t.Errorf("POST should not require security, requires %v instead.", security)
+	}
+	if scheme := lookup(doc, "components", "securitySchemes", "testToken", "type"); scheme != "apiKey" {
+		t.Errorf("Security scheme testToken should be of type apiKey, is %v instead.", scheme)
+	}
+	for _, mt := range responseMediaTypes {
+		if lookup(get, "responses", "200", "content", mt) == nil {
+			t.Errorf("Success response should have media type %s.", mt)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+)
+
+const (
+	mediaTypeJson  = "application/json"
+	mediaTypeCsv   = "text/csv"
+	mediaTypeXlsx  = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mediaTypeExcel = "application/vnd.ms-excel"
+	exportFileName = "trackit"
+	xlsxSheetName  = "trackit"
+)
+
+// responseMediaTypes lists the media types a Handler can respond with, in
+// order of preference.
+var responseMediaTypes = []string{
+	mediaTypeJson,
+	mediaTypeCsv,
+	mediaTypeXlsx,
+	mediaTypeExcel,
+}
+
+// responseEncoder writes a handler's output to the response.
+type responseEncoder func(w http.ResponseWriter, r *http.Request, mediaType string, status int, output interface{})
+
+var responseEncoders = map[string]responseEncoder{
+	mediaTypeJson:  encodeJson,
+	mediaTypeCsv:   encodeCsv,
+	mediaTypeXlsx:  encodeXlsx,
+	mediaTypeExcel: encodeXlsx,
+}
+
+// csvGenerator is an interface for any type that can generate a CSV file
+// content. Other types are flattened by reflection.
+type csvGenerator interface {
+	ToCSVable() [][]string
+}
+
+// xlsGenerator is an interface for any type that can generate an xls file
+// content. Other types are flattened by reflection.
+type xlsGenerator interface {
+	GetFileContent() []byte
+	GetFileName() string
+}
+
+func encodeJson(w http.ResponseWriter, _ *http.Request, _ string, status int, output interface{}) {
+	w.Header().Set("Content-Type", mediaTypeJson+"; charset=utf-8")
+	w.WriteHeader(status)
+	e := json.NewEncoder(w)
+	if config.PrettyJsonResponses {
+		e.SetIndent("", "\t")
+	}
+	e.Encode(output)
+}
+
+func encodeCsv(w http.ResponseWriter, r *http.Request, _ string, status int, output interface{}) {
+	rows, err := exportRows(output)
+	if err != nil {
+		encodeExportError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", mediaTypeCsv+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", exportFileName))
+	w.WriteHeader(status)
+	csvWriter := csv.NewWriter(w)
+	csvWriter.WriteAll(rows)
+}
+
+func encodeXlsx(w http.ResponseWriter, r *http.Request, mediaType string, status int, output interface{}) {
+	if outputGen, ok := output.(xlsGenerator); ok {
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", outputGen.GetFileName()))
+		w.WriteHeader(status)
+		w.Write(outputGen.GetFileContent())
+		return
+	}
+	var buf bytes.Buffer
+	if rows, err := exportRows(output); err != nil {
+		encodeExportError(w, r, err)
+	} else if err := writeXlsx(&buf, rows); err != nil {
+		encodeExportError(w, r, err)
+	} else {
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", exportFileName))
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+	}
+}
+
+// exportRows returns the rows of a handler's output, header first.
+func exportRows(output interface{}) ([][]string, error) {
+	if outputGen, ok := output.(csvGenerator); ok {
+		return outputGen.ToCSVable(), nil
+	} else if t, err := tabulate(output); err != nil {
+		return nil, err
+	} else {
+		return t.strings(), nil
+	}
+}
+
+// writeXlsx writes rows in a single sheet spreadsheet. Cells which parse as
+// numbers are stored as such.
+func writeXlsx(buf *bytes.Buffer, rows [][]string) error {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet(xlsxSheetName)
+	if err != nil {
+		return err
+	}
+	for i, r := range rows {
+		row := sheet.AddRow()
+		for _, v := range r {
+			cell := row.AddCell()
+			if f, err := strconv.ParseFloat(v, 64); i > 0 && err == nil {
+				cell.SetFloat(f)
+			} else {
+				cell.SetString(v)
+			}
+		}
+	}
+	return file.Write(buf)
+}
+
+func encodeExportError(w http.ResponseWriter, r *http.Request, err error) {
+	jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to export response.", err.Error())
+	encodeJson(w, r, mediaTypeJson, http.StatusInternalServerError, errorBody{"failed to export response"})
+}
+
+// encodeNotAcceptable responds with http.StatusNotAcceptable and the list of
+// the available media types.
+func encodeNotAcceptable(w http.ResponseWriter, r *http.Request) {
+	err := errors.New("none of the available media types is acceptable: " + strings.Join(responseMediaTypes, ", "))
+	encodeJson(w, r, mediaTypeJson, http.StatusNotAcceptable, errorBody{err.Error()})
+}
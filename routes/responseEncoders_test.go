@@ -0,0 +1,115 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+type exportTestLine struct {
+	Name string  `json:"name"`
+	Cost float64 `json:"cost"`
+}
+
+var exportTestLines = []exportTestLine{{"a", 1.5}, {"b", 2}}
+
+func getExportLines(_ *http.Request, _ Arguments) (int, interface{}) {
+	return http.StatusOK, exportTestLines
+}
+
+func serveWithAccept(h Handler, accept string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	return response
+}
+
+func TestServeCsv(t *testing.T) {
+	response := serveWithAccept(H(getExportLines), "text/csv")
+	if response.Code != http.StatusOK {
+		t.Errorf("Response status should be %d, is %d instead.", http.StatusOK, response.Code)
+	}
+	if ct := response.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content type should be text/csv, is '%s' instead.", ct)
+	}
+	expected := [][]string{{"name", "cost"}, {"a", "1.5"}, {"b", "2"}}
+	if rows, err := csv.NewReader(response.Body).ReadAll(); err != nil {
+		t.Errorf("Failed to parse body as CSV: '%s'.", err.Error())
+	} else if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("CSV should be %q, is %q instead.", expected, rows)
+	}
+}
+
+func TestServeXlsx(t *testing.T) {
+	response := serveWithAccept(H(getExportLines), mediaTypeXlsx)
+	if response.Code != http.StatusOK {
+		t.Errorf("Response status should be %d, is %d instead.", http.StatusOK, response.Code)
+	}
+	if ct := response.Header().Get("Content-Type"); ct != mediaTypeXlsx {
+		t.Errorf("Content type should be %s, is '%s' instead.", mediaTypeXlsx, ct)
+	}
+	file, err := xlsx.OpenBinary(response.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to parse body as XLSX: '%s'.", err.Error())
+	}
+	sheet := file.Sheets[0]
+	if v := sheet.Cell(0, 1).String(); v != "cost" {
+		t.Errorf("Header cell should be 'cost', is '%s' instead.", v)
+	}
+	if v, err := sheet.Cell(1, 1).Float(); err != nil || v != 1.5 {
+		t.Errorf("Cost cell should be the number 1.5, is '%s' instead.", sheet.Cell(1, 1).Value)
+	}
+}
+
+func TestServeNotAcceptable(t *testing.T) {
+	var handlerRun bool
+	h := H(func(_ *http.Request, _ Arguments) (int, interface{}) { handlerRun = true; return http.StatusOK, nil })
+	response := serveWithAccept(h, "text/html")
+	if response.Code != http.StatusNotAcceptable {
+		t.Errorf("Response status should be %d, is %d instead.", http.StatusNotAcceptable, response.Code)
+	}
+	if handlerRun {
+		t.Error("Handler should not have run, has.")
+	}
+	var body errorBody
+	if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil || body.Error == "" {
+		t.Errorf("Body should be a JSON error, is '%s' instead.", response.Body.String())
+	}
+}
+
+func TestServeErrorAsJson(t *testing.T) {
+	h := H(func(_ *http.Request, _ Arguments) (int, interface{}) {
+		return http.StatusBadRequest, errors.New("bad foo")
+	}).With(ErrorBody{})
+	response := serveWithAccept(h, "text/csv")
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("Response status should be %d, is %d instead.", http.StatusBadRequest, response.Code)
+	}
+	var body errorBody
+	if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil || body.Error != "bad foo" {
+		t.Errorf("Body should be a JSON error, is '%s' instead.", response.Body.String())
+	}
+}
@@ -15,13 +15,7 @@
 package routes
 
 import (
-	"encoding/csv"
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"strings"
-
-	"github.com/trackit/trackit-server/config"
 )
 
 // RegisteredHandlers is the list of all route handlers that were registered.
@@ -54,67 +48,27 @@ type Decorator interface {
 	Decorate(Handler) Handler
 }
 
-// csvGenerator is an interface for any type that can generate a CSV file content
-type csvGenerator interface {
-	ToCSVable() [][]string
-}
-
-// xlsGenerator is an interface for any type that can generate an xls file content
-type xlsGenerator interface {
-	GetFileContent() []byte
-	GetFileName() string
-}
-
 func resetRegisteredHandlers() {
 	RegisteredHandlers = RegisteredHandlers[:0]
 }
 
-// BUG(zanchi-r): We do not support content type negociation
-// Only a single type without quality factor should be specified in the Accept header
-// Example : "Accept: text/csv"
-// If an unsupported type is specified an empty body will be returned
+// ServeHTTP runs the handler and encodes its output in the media type
+// negotiated from the request's Accept header, as specified by RFC 7231. The
+// request is answered with http.StatusNotAcceptable if no media type is
+// acceptable. Error responses are always encoded as JSON.
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	mediaType, ok := negotiate(r.Header["Accept"], responseMediaTypes)
+	if !ok {
+		encodeNotAcceptable(w, r)
+		return
+	}
 	arguments := make(Arguments)
 	status, output := h.Func(w, r, arguments)
-	acceptType := "*/*"
-	if len(r.Header["Accept"]) > 0 {
-		acceptType = r.Header["Accept"][0]
-	}
-	// If no specific accept type is specified we use application/json by default
-	if strings.HasPrefix(acceptType, "*/*") {
-		acceptType = "application/json"
-	}
-	w.Header()["Content-Type"] = []string{fmt.Sprintf("%s; charset=utf-8", acceptType)}
-	switch acceptType {
-	case "application/json":
-		w.WriteHeader(status)
-		e := json.NewEncoder(w)
-		if config.PrettyJsonResponses {
-			e.SetIndent("", "\t")
-		}
-		e.Encode(output)
-	case "text/csv":
-		w.Header().Set("Content-Disposition", "attachment; filename=trackit.csv")
-		w.WriteHeader(status)
-		if outputGen, ok := output.(csvGenerator); ok {
-			csvWriter := csv.NewWriter(w)
-			csvWriter.WriteAll(outputGen.ToCSVable())
-		} else {
-			// TODO: if the data do not implement the csvGenerator interface, try to generate it by reflection
-		}
-	case "application/vnd.ms-excel":
-		if outputGen, ok := output.(xlsGenerator); ok {
-			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", outputGen.GetFileName()))
-			w.WriteHeader(status)
-			w.Write(outputGen.GetFileContent())
-		} else {
-			if status == http.StatusOK {
-				w.WriteHeader(http.StatusNotImplemented)
-			} else {
-				w.WriteHeader(status)
-			}
-		}
+	if status >= http.StatusBadRequest {
+		mediaType = mediaTypeJson
 	}
+	responseEncoders[mediaType](w, r, mediaType, status, output)
 }
 
 func (h Handler) With(ds ...Decorator) Handler {
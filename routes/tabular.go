@@ -0,0 +1,232 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+const (
+	jsonScalar = iota
+	jsonArray
+	jsonObject
+	// valueColumn is the column of scalars which are not the field of an
+	// object.
+	valueColumn = "value"
+)
+
+// jsonNode is a decoded JSON value. Unlike an interface{}, it keeps the order
+// of the keys of objects, so that columns follow the order of struct fields.
+type jsonNode struct {
+	kind   int
+	value  tableCell
+	keys   []string
+	values []jsonNode
+}
+
+// tableCell is a cell of a table. Numbers are kept apart so that spreadsheets
+// can store them as such.
+type tableCell struct {
+	value  string
+	number bool
+}
+
+// tableRecord is a row of a table, with its cells in the order of their
+// columns.
+type tableRecord struct {
+	columns []string
+	cells   []tableCell
+}
+
+func (tr tableRecord) with(column string, cell tableCell) tableRecord {
+	return tableRecord{
+		columns: append(append([]string{}, tr.columns...), column),
+		cells:   append(append([]tableCell{}, tr.cells...), cell),
+	}
+}
+
+// table is a flattened response, ready to be encoded as CSV or XLSX.
+type table struct {
+	header []string
+	rows   [][]tableCell
+}
+
+// tabulate flattens any value which can be marshaled to JSON into a table.
+//
+// Scalars are rows with a single value column. Arrays are the concatenation
+// of the rows of their elements. Objects are records: their scalar fields are
+// columns, nested objects are flattened into dotted columns and nested arrays
+// are unnested, the record being repeated for each of their rows. An object
+// with a single key whose value is an object, like the maps built by
+// SimplifiedCostsDocument.ToJsonable, is a grouping instead: the key names a
+// column whose values are the keys of the inner object.
+func tabulate(v interface{}) (table, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return table{}, err
+	}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	root, err := decodeJsonNode(d)
+	if err != nil {
+		return table{}, err
+	}
+	return buildTable(flatten(root)), nil
+}
+
+func decodeJsonNode(d *json.Decoder) (jsonNode, error) {
+	t, err := d.Token()
+	if err != nil {
+		return jsonNode{}, err
+	}
+	switch v := t.(type) {
+	case json.Delim:
+		n := jsonNode{kind: jsonArray}
+		if v == '{' {
+			n.kind = jsonObject
+		}
+		for d.More() {
+			if n.kind == jsonObject {
+				if k, err := d.Token(); err != nil {
+					return n, err
+				} else {
+					n.keys = append(n.keys, k.(string))
+				}
+			}
+			if child, err := decodeJsonNode(d); err != nil {
+				return n, err
+			} else {
+				n.values = append(n.values, child)
+			}
+		}
+		_, err := d.Token()
+		return n, err
+	case json.Number:
+		return jsonNode{value: tableCell{v.String(), true}}, nil
+	case string:
+		return jsonNode{value: tableCell{value: v}}, nil
+	case bool:
+		return jsonNode{value: tableCell{value: strconv.FormatBool(v)}}, nil
+	case nil:
+		return jsonNode{}, nil
+	default:
+		return jsonNode{}, errors.New("unexpected json token")
+	}
+}
+
+func (n jsonNode) isGrouping() bool {
+	return n.kind == jsonObject && len(n.keys) == 1 && n.values[0].kind == jsonObject && len(n.values[0].keys) > 0
+}
+
+// flatten builds the rows of a node, as described for tabulate.
+func flatten(n jsonNode) []tableRecord {
+	switch {
+	case n.kind == jsonScalar:
+		return []tableRecord{tableRecord{}.with(valueColumn, n.value)}
+	case n.kind == jsonArray:
+		var records []tableRecord
+		for _, v := range n.values {
+			records = append(records, flatten(v)...)
+		}
+		return records
+	case n.isGrouping():
+		var records []tableRecord
+		group, inner := n.keys[0], n.values[0]
+		for i, k := range inner.keys {
+			for _, r := range flatten(inner.values[i]) {
+				records = append(records, tableRecord{
+					columns: append([]string{group}, r.columns...),
+					cells:   append([]tableCell{{value: k}}, r.cells...),
+				})
+			}
+		}
+		return records
+	default:
+		records := []tableRecord{{}}
+		for i, k := range n.keys {
+			if v := n.values[i]; v.kind == jsonScalar {
+				for j := range records {
+					records[j] = records[j].with(k, v.value)
+				}
+			} else if sub := flatten(v); len(sub) > 0 {
+				records = unnest(records, sub, k)
+			}
+		}
+		return records
+	}
+}
+
+// unnest repeats each record for each row of a field, whose columns are
+// prefixed with the field's name.
+func unnest(records, sub []tableRecord, field string) []tableRecord {
+	out := make([]tableRecord, 0, len(records)*len(sub))
+	for _, r := range records {
+		for _, s := range sub {
+			o := r
+			for i, c := range s.columns {
+				if c == valueColumn {
+					o = o.with(field, s.cells[i])
+				} else {
+					o = o.with(field+"."+c, s.cells[i])
+				}
+			}
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// buildTable lays records out in a table whose columns are in order of first
+// appearance. Records without cells are dropped.
+func buildTable(records []tableRecord) table {
+	var t table
+	index := make(map[string]int)
+	for _, r := range records {
+		for _, c := range r.columns {
+			if _, ok := index[c]; !ok {
+				index[c] = len(t.header)
+				t.header = append(t.header, c)
+			}
+		}
+	}
+	for _, r := range records {
+		if len(r.cells) == 0 {
+			continue
+		}
+		row := make([]tableCell, len(t.header))
+		for i, c := range r.columns {
+			row[index[c]] = r.cells[i]
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
+
+// strings returns the table as rows of strings, its header first.
+func (t table) strings() [][]string {
+	out := make([][]string, 0, len(t.rows)+1)
+	out = append(out, t.header)
+	for _, row := range t.rows {
+		s := make([]string, len(row))
+		for i, c := range row {
+			s[i] = c.value
+		}
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tabularTestLine struct {
+	Name  string    `json:"name"`
+	Cost  float64   `json:"cost"`
+	Date  time.Time `json:"date"`
+	Owner *string   `json:"owner"`
+	Usage struct {
+		Hours int `json:"hours"`
+	} `json:"usage"`
+	Tags   []string `json:"tags,omitempty"`
+	hidden string
+}
+
+func TestTabulate(t *testing.T) {
+	date := time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)
+	owner := "alice"
+	lines := []tabularTestLine{
+		{Name: "a", Cost: 1.5, Date: date, Owner: &owner},
+		{Name: "b", Cost: 2, Date: date, Tags: []string{"x", "y"}},
+	}
+	lines[0].Usage.Hours = 3
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected [][]string
+	}{
+		{"scalar", "foo", [][]string{{"value"}, {"foo"}}},
+		{"nil", nil, [][]string{{"value"}, {""}}},
+		{"empty map", map[string]interface{}{}, [][]string{nil}},
+		{"scalars", []int{1, 2}, [][]string{{"value"}, {"1"}, {"2"}}},
+		{"structs", lines, [][]string{
+			{"name", "cost", "date", "owner", "usage.hours", "tags"},
+			{"a", "1.5", "2018-01-02T00:00:00Z", "alice", "3", ""},
+			{"b", "2", "2018-01-02T00:00:00Z", "", "0", "x"},
+			{"b", "2", "2018-01-02T00:00:00Z", "", "0", "y"},
+		}},
+		{"costs", map[string]interface{}{
+			"account": map[string]interface{}{
+				"123": map[string]interface{}{
+					"product": map[string]interface{}{"AmazonEC2": 12.5, "AmazonS3": 0.5},
+				},
+				"456": map[string]interface{}{
+					"product": map[string]interface{}{"AmazonEC2": 1.0},
+				},
+			},
+		}, [][]string{
+			{"account", "product", "value"},
+			{"123", "AmazonEC2", "12.5"},
+			{"123", "AmazonS3", "0.5"},
+			{"456", "AmazonEC2", "1"},
+		}},
+		{"nested costs", struct {
+			Currency string                 `json:"currency"`
+			Costs    map[string]interface{} `json:"costs"`
+		}{"USD", map[string]interface{}{"day": map[string]interface{}{"2018-01-01": 3.0, "2018-01-02": 4.0}}}, [][]string{
+			{"currency", "costs.day", "costs"},
+			{"USD", "2018-01-01", "3"},
+			{"USD", "2018-01-02", "4"},
+		}},
+	}
+	for _, tt := range tests {
+		table, err := tabulate(tt.input)
+		if err != nil {
+			t.Errorf("%s: error should be nil, is '%s' instead.", tt.name, err.Error())
+		} else if actual := table.strings(); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%s: table should be %q, is %q instead.", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestTabulateUnmarshalable(t *testing.T) {
+	if _, err := tabulate(make(chan int)); err == nil {
+		t.Errorf("Error should not be nil.")
+	}
+}
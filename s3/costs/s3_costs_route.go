@@ -39,6 +39,7 @@ type esQueryParams struct {
 	dateEnd     time.Time
 	accountList []string
 	indexList   []string
+	costField   string
 }
 
 // esFilter represents an elasticsearch filter
@@ -72,7 +73,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getS3CostData).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "get the s3 costs data",
 				Description: "Responds with cost data based on the queryparams passed to it",
@@ -80,6 +81,7 @@ func init() {
 			routes.QueryArgs{routes.AwsAccountsOptionalQueryArg},
 			routes.QueryArgs{routes.DateBeginQueryArg},
 			routes.QueryArgs{routes.DateEndQueryArg},
+			routes.QueryArgs{routes.CostTypeQueryArg},
 		),
 	}.H().Register("/s3/costs")
 }
@@ -108,6 +110,7 @@ func makeElasticSearchRequest(ctx context.Context, parsedParams esQueryParams,
 		parsedParams.dateBegin,
 		parsedParams.dateEnd,
 		esFilters,
+		parsedParams.costField,
 		es.Client,
 		index,
 	)
@@ -145,6 +148,10 @@ func getS3CostData(request *http.Request, a routes.Arguments) (int, interface{})
 	}
 	var err error
 	var returnCode int
+	costType, _ := a[routes.CostTypeQueryArg].(string)
+	if parsedParams.costField, err = s3.CostTypeField(costType); err != nil {
+		return http.StatusBadRequest, err
+	}
 	tx := a[db.Transaction].(*sql.Tx)
 	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
 	if err != nil {
@@ -0,0 +1,138 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/leader"
+	"github.com/trackit/trackit-server/periodic"
+	"github.com/trackit/trackit-server/routes"
+	"github.com/trackit/trackit-server/users"
+)
+
+// periodicsLeaderResponseBody is the response body of the
+// getPeriodicsLeader request handler.
+type periodicsLeaderResponseBody struct {
+	leader.Lease
+	// Active is whether the lease of the leader has not expired.
+	Active bool `json:"active"`
+	// BackendId is the ID of the backend which answered the request.
+	BackendId string `json:"backendId"`
+}
+
+// periodicTasksResponseBody is the response body of the getPeriodicTasks
+// request handler.
+type periodicTasksResponseBody struct {
+	Ticking bool                  `json:"ticking"`
+	Tasks   []periodic.TaskStatus `json:"tasks"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getPeriodicTasks).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.Documentation{
+				Summary:     "list the periodic tasks",
+				Description: "Responds with the periodic tasks registered on this backend, their schedule, their next run and their last runs.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/periodic/tasks")
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getPeriodicsLeader).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.Documentation{
+				Summary:     "get the periodic tasks leader",
+				Description: "Responds with the backend elected to run the periodic tasks and when its lease expires.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/periodic/leader")
+}
+
+// getPeriodicTasks is a route handler which lists the periodic tasks and
+// their state.
+func getPeriodicTasks(r *http.Request, a routes.Arguments) (int, interface{}) {
+	return http.StatusOK, periodicTasksResponseBody{sched.Ticking(), sched.Status()}
+}
+
+// getPeriodicsLeader is a route handler which responds with the backend
+// elected to run the periodic tasks.
+func getPeriodicsLeader(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	lease, err := leader.Current(tx, periodicsElection)
+	if err != nil {
+		l.Error("Failed to get periodic tasks leader.", err.Error())
+		return http.StatusInternalServerError, errors.New("failed to get periodic tasks leader")
+	}
+	return http.StatusOK, periodicsLeaderResponseBody{
+		Lease:     lease,
+		Active:    lease.Holder != "" && lease.Expires.After(time.Now()),
+		BackendId: backendId,
+	}
+}
+
+// dbHistory stores the runs of periodic tasks in the database.
+type dbHistory struct {
+	db *sql.DB
+}
+
+// Record stores a run of a periodic task.
+func (h dbHistory) Record(r periodic.Run) error {
+	const sqlstr = `INSERT INTO periodic_task_run(
+		task,
+		backend_id,
+		started,
+		duration_ms,
+		error
+	) VALUES (?, ?, ?, ?, ?)`
+	_, err := h.db.Exec(sqlstr, r.Task, backendId, r.Started, int64(r.Duration/time.Millisecond), r.Error)
+	return err
+}
+
+// Last returns the last successful and failed runs of a periodic task.
+func (h dbHistory) Last(task string) (lastSuccess, lastError *periodic.Run, err error) {
+	const sqlstr = `SELECT started, duration_ms, error FROM periodic_task_run
+	WHERE task=? AND (error = "") = ?
+	ORDER BY started DESC LIMIT 1`
+	if lastSuccess, err = h.last(sqlstr, task, true); err == nil {
+		lastError, err = h.last(sqlstr, task, false)
+	}
+	return
+}
+
+// last returns the last run of a periodic task matching a query, or nil.
+func (h dbHistory) last(sqlstr string, task string, success bool) (*periodic.Run, error) {
+	var durationMs int64
+	r := periodic.Run{Task: task}
+	err := h.db.QueryRow(sqlstr, task, success).Scan(&r.Started, &durationMs, &r.Error)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	r.Duration = time.Duration(durationMs) * time.Millisecond
+	return &r, nil
+}
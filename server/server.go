@@ -19,22 +19,34 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"regexp"
+	"syscall"
 	"time"
 
 	"github.com/satori/go.uuid"
 	"github.com/trackit/jsonlog"
 
 	_ "github.com/trackit/trackit-server/aws"
+	_ "github.com/trackit/trackit-server/aws/product"
 	_ "github.com/trackit/trackit-server/aws/routes"
 	_ "github.com/trackit/trackit-server/aws/s3"
+	_ "github.com/trackit/trackit-server/budgets"
 	"github.com/trackit/trackit-server/config"
 	_ "github.com/trackit/trackit-server/costs"
 	_ "github.com/trackit/trackit-server/costs/anomalies"
+	_ "github.com/trackit/trackit-server/costs/commitments"
 	_ "github.com/trackit/trackit-server/costs/diff"
+	_ "github.com/trackit/trackit-server/costs/forecast"
 	_ "github.com/trackit/trackit-server/costs/tags"
+	_ "github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/leader"
+	"github.com/trackit/trackit-server/metrics"
+	_ "github.com/trackit/trackit-server/notifications"
 	"github.com/trackit/trackit-server/periodic"
 	_ "github.com/trackit/trackit-server/plugins"
+	_ "github.com/trackit/trackit-server/recommendations"
 	_ "github.com/trackit/trackit-server/reports"
 	"github.com/trackit/trackit-server/routes"
 	_ "github.com/trackit/trackit-server/s3/costs"
@@ -62,6 +74,11 @@ var tasks = map[string]func(context.Context) error{
 	"check-user-entitlement":  taskCheckEntitlement,
 	"generate-spreadsheet":    taskSpreadsheet,
 	"update-aws-identity":     taskUpdateAwsIdentity,
+	"check-budgets":           taskCheckBudgets,
+	"load-currency-rates":     taskLoadCurrencyRates,
+	"ingest-pricing":          taskIngestPricing,
+	"worker":                  taskWorker,
+	"enqueue":                 taskEnqueue,
 }
 
 // dockerHostnameRe matches the value of the HOSTNAME environment variable when
@@ -69,8 +86,10 @@ var tasks = map[string]func(context.Context) error{
 var dockerHostnameRe = regexp.MustCompile(`[0-9a-z]{12}`)
 
 func main() {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	logger := jsonlog.DefaultLogger
+	go cancelOnSignal(cancel)
 	logger.Info("Started.", struct {
 		BackendId string `json:"backendId"`
 	}{backendId})
@@ -88,23 +107,115 @@ func main() {
 	}
 }
 
+// cancelOnSignal cancels the context of the task when the process is asked to
+// stop, so that it can end gracefully. A second signal exits at once.
+func cancelOnSignal(cancel context.CancelFunc) {
+	logger := jsonlog.DefaultLogger
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+	logger.Info("Received signal, stopping.", sig.String())
+	cancel()
+	sig = <-signals
+	logger.Warning("Received second signal, exiting.", sig.String())
+	os.Exit(1)
+}
+
 var sched periodic.Scheduler
 
 func schedulePeriodicTasks() {
-	sched.Register(taskIngestDue, 10*time.Minute, "ingest-due-updates")
-	sched.Start()
+	sched.History = dbHistory{db.Db}
+	sched.RegisterSchedule(taskIngestDue, periodic.MustParseCron("*/10 * * * *"), "ingest-due-updates", periodic.Options{
+		Jitter:  time.Minute,
+		Timeout: time.Hour,
+	})
+	sched.RegisterSchedule(taskCheckBudgets, periodic.MustParseCron("@hourly"), "check-budgets", periodic.Options{
+		Jitter:  5 * time.Minute,
+		Timeout: 30 * time.Minute,
+	})
+	sched.RegisterSchedule(taskIngestAllPricing, periodic.MustParseCron("@daily"), "ingest-pricing", periodic.Options{
+		Jitter:  30 * time.Minute,
+		Timeout: 2 * time.Hour,
+	})
+}
+
+// periodicsElection is the name of the election of the backend running the
+// periodic tasks.
+const periodicsElection = "periodics"
+
+// electPeriodicsLeader takes part in the election of the backend running the
+// periodic tasks, starting the scheduler while this backend is the leader.
+func electPeriodicsLeader(ctx context.Context) {
+	elector := leader.NewElector(db.Db, periodicsElection, backendId, config.LeaderLease, sched.Start, sched.Stop)
+	elector.Run(ctx)
 }
 
 func taskServer(ctx context.Context) error {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	initializeHandlers()
+	if config.CurrencyRatesFile != "" {
+		taskLoadCurrencyRates(ctx)
+	}
+	elected := make(chan struct{})
 	if config.Periodics {
 		schedulePeriodicTasks()
-		logger.Info("Scheduled periodic tasks.", nil)
+		go func() {
+			electPeriodicsLeader(ctx)
+			close(elected)
+		}()
+		logger.Info("Scheduled periodic tasks, they run once this backend is elected leader.", nil)
+	} else {
+		close(elected)
+	}
+	notified := make(chan struct{})
+	if config.NotificationsWorker {
+		go func() {
+			runNotificationsWorker(ctx)
+			close(notified)
+		}()
+		logger.Info("Started notifications worker.", nil)
+	} else {
+		close(notified)
+	}
+	server := &http.Server{Addr: config.HttpAddress}
+	served := make(chan error, 1)
+	go func() {
+		logger.Info(fmt.Sprintf("Listening on %s.", config.HttpAddress), nil)
+		served <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-served:
+		logger.Error("Server stopped.", err.Error())
+		return err
+	case <-ctx.Done():
+	}
+	return shutdownServer(server, elected, notified)
+}
+
+// shutdownServer drains the HTTP server and shuts the periodic tasks
+// scheduler down, cancelling the running tasks, once the leader lease was
+// released and the notifications being sent were. It waits at most
+// config.ShutdownTimeout.
+func shutdownServer(server *http.Server, elected, notified <-chan struct{}) error {
+	logger := jsonlog.DefaultLogger
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	logger.Info("Shutting down.", nil)
+	err := server.Shutdown(ctx)
+	if err != nil {
+		logger.Error("Failed to drain HTTP requests.", err.Error())
+	}
+	for _, done := range []<-chan struct{}{elected, notified} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	if sErr := sched.Shutdown(ctx); sErr != nil {
+		logger.Error("Failed to wait for periodic tasks.", sErr.Error())
+		err = sErr
 	}
-	logger.Info(fmt.Sprintf("Listening on %s.", config.HttpAddress), nil)
-	err := http.ListenAndServe(config.HttpAddress, nil)
-	logger.Error("Server stopped.", err.Error())
+	logger.Info("Server stopped.", nil)
 	return err
 }
 
@@ -124,16 +235,18 @@ func initializeHandlers() {
 	}
 	logger := jsonlog.DefaultLogger
 	routes.DocumentationHandler().Register("/docs")
+	routes.OpenAPIHandler(buildNumber).Register("/openapi.json")
 	for _, rh := range routes.RegisteredHandlers {
 		applyDecoratorsAndHandle(rh.Pattern, rh.Handler, globalDecorators)
 		logger.Info(fmt.Sprintf("Registered route %s.", rh.Pattern), nil)
 	}
+	http.Handle("/metrics", metrics.Handler(config.MetricsToken))
 }
 
 // applyDecoratorsAndHandle applies a list of decorators to a handler and
-// registers it.
+// registers it. Metrics are recorded for each route.
 func applyDecoratorsAndHandle(p string, h routes.Handler, ds []routes.Decorator) {
-	h = h.With(ds...)
+	h = h.With(ds...).With(routes.RouteMetrics{p})
 	http.Handle(p, h)
 }
 
@@ -17,32 +17,19 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"flag"
-	"strconv"
 
 	"github.com/trackit/jsonlog"
 
 	"github.com/trackit/trackit-server/anomaliesDetection"
 	"github.com/trackit/trackit-server/aws"
 	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/jobs"
 )
 
 // taskAnomaliesDetection processes an AwsAccount to email
 // the user if anomalies are detected.
 func taskAnomaliesDetection(ctx context.Context) error {
-	args := flag.Args()
-	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	logger.Debug("Running task 'anomalies-detection'.", map[string]interface{}{
-		"args": args,
-	})
-	if len(args) != 1 {
-		return errors.New("taskAnomaliesDetection requires an integer argument")
-	} else if aaId, err := strconv.Atoi(args[0]); err != nil {
-		return err
-	} else {
-		return processAnomaliesForAccount(ctx, aaId)
-	}
+	return runJobForAccountArg(ctx, jobs.AnomaliesDetection)
 }
 
 func processAnomaliesForAccount(ctx context.Context, aaId int) (err error) {
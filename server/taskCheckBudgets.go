@@ -0,0 +1,49 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/budgets"
+	"github.com/trackit/trackit-server/db"
+)
+
+// taskCheckBudgets computes the spend of every budget and emails alerts for
+// the thresholds reached.
+func taskCheckBudgets(ctx context.Context) (err error) {
+	var tx *sql.Tx
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug("Running task 'check-budgets'.", nil)
+	defer func() {
+		if tx != nil {
+			if err != nil {
+				tx.Rollback()
+			} else {
+				tx.Commit()
+			}
+		}
+	}()
+	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
+	} else if err = budgets.CheckBudgets(ctx, tx); err != nil {
+	}
+	if err != nil {
+		logger.Error("Failed to check budgets.", err.Error())
+	}
+	return
+}
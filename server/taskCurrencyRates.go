@@ -0,0 +1,55 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/currencies"
+	"github.com/trackit/trackit-server/db"
+)
+
+// taskLoadCurrencyRates stores the currency conversion rates of the file
+// given with the currency-rates-file flag.
+func taskLoadCurrencyRates(ctx context.Context) (err error) {
+	var tx *sql.Tx
+	var rates currencies.Rates
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug("Running task 'load-currency-rates'.", map[string]interface{}{
+		"file": config.CurrencyRatesFile,
+	})
+	defer func() {
+		if tx != nil {
+			if err != nil {
+				tx.Rollback()
+			} else {
+				tx.Commit()
+			}
+		}
+	}()
+	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
+	} else if rates, err = currencies.LoadRatesFile(tx, config.CurrencyRatesFile); err != nil {
+	}
+	if err != nil {
+		logger.Error("Failed to load currency rates.", err.Error())
+	} else {
+		logger.Info("Loaded currency rates.", rates)
+	}
+	return
+}
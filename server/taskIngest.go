@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"errors"
 	"flag"
+	"fmt"
 	"math/rand"
 	"strconv"
 	"time"
@@ -28,6 +29,8 @@ import (
 	"github.com/trackit/trackit-server/aws"
 	"github.com/trackit/trackit-server/aws/s3"
 	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/jobs"
+	"github.com/trackit/trackit-server/notifications"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 )
 
@@ -80,6 +83,14 @@ func ingestBillingDataForBillRepository(ctx context.Context, aaId, brId int) (er
 		br.Error = ""
 		err = updateBillRepositoryForNextUpdate(ctx, tx, br, latestManifest)
 	}
+	if ctx.Err() != nil {
+		logger.Warning("Billing data ingestion cancelled.", map[string]interface{}{
+			"awsAccountId":     aaId,
+			"billRepositoryId": brId,
+		})
+		updateCompletion(ctx, aaId, brId, db.Db, updateId, jobs.ErrCancelled)
+		return jobs.ErrCancelled
+	}
 	if err != nil {
 		logger.Error("Failed to ingest billing data.", map[string]interface{}{
 			"awsAccountId":     aaId,
@@ -88,10 +99,52 @@ func ingestBillingDataForBillRepository(ctx context.Context, aaId, brId int) (er
 		})
 	}
 	updateCompletion(ctx, aaId, brId, db.Db, updateId, err)
+	if err != nil && aa.Id != 0 {
+		notifyIngestionFailure(ctx, aa, brId, updateId, err)
+	}
 	updateSubAccounts(ctx, aa)
 	return
 }
 
+// notifyIngestionFailure notifies an AWS account that the billing data of
+// one of its bill repositories could not be ingested. It uses its own
+// transaction as the one of the ingestion is rolled back.
+func notifyIngestionFailure(ctx context.Context, aa aws.AwsAccount, brId int, updateId int64, ingestionErr error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	var tx *sql.Tx
+	var err error
+	defer func() {
+		if tx != nil {
+			if err != nil {
+				tx.Rollback()
+			} else {
+				tx.Commit()
+			}
+		}
+	}()
+	event := notifications.Event{
+		Type:    notifications.EventIngestionFailure,
+		Key:     fmt.Sprintf("ingestion-failure:%d:%d", brId, updateId),
+		Subject: fmt.Sprintf("Failed to import the billing data of \"%s\"", aa.Pretty),
+		Message: fmt.Sprintf("Hi, the billing data of the AWS account \"%s\" could not be imported: %s. "+
+			"You can connect to your account to check its bill repositories: https://re.trackit.io/",
+			aa.Pretty, ingestionErr.Error()),
+		Data: map[string]interface{}{
+			"billRepositoryId": brId,
+			"error":            ingestionErr.Error(),
+		},
+	}
+	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
+		logger.Error("Failed to get DB Tx", err.Error())
+	} else if err = notifications.Notify(ctx, tx, aa, event); err != nil {
+		logger.Warning("Failed to notify ingestion failure.", map[string]interface{}{
+			"awsAccountId":     aa.Id,
+			"billRepositoryId": brId,
+			"error":            err.Error(),
+		})
+	}
+}
+
 func updateSubAccounts(ctx context.Context, aa aws.AwsAccount) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	var tx *sql.Tx
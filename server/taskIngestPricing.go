@@ -0,0 +1,88 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws/product"
+	"github.com/trackit/trackit-server/db"
+)
+
+// taskIngestPricing downloads the offer files of the services given as
+// arguments, or of all of them, and stores their products and prices.
+func taskIngestPricing(ctx context.Context) error {
+	args := flag.Args()
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug("Running task 'ingest-pricing'.", map[string]interface{}{
+		"args": args,
+	})
+	return ingestPricingServices(ctx, args)
+}
+
+// taskIngestAllPricing downloads the offer files of all the services and
+// stores their products and prices. It is run periodically.
+func taskIngestAllPricing(ctx context.Context) error {
+	return ingestPricingServices(ctx, nil)
+}
+
+// ingestPricingServices imports the offer files of the services named in
+// names, or of all of them if names is empty.
+func ingestPricingServices(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		for name := range product.Services {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		service, ok := product.Services[name]
+		if !ok {
+			return fmt.Errorf("unknown pricing service %q", name)
+		} else if err := ingestPricing(ctx, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestPricing imports the offer file of a service in its own transaction.
+func ingestPricing(ctx context.Context, service product.Service) (err error) {
+	var tx *sql.Tx
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	defer func() {
+		if tx != nil {
+			if err != nil {
+				tx.Rollback()
+			} else {
+				tx.Commit()
+			}
+		}
+	}()
+	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
+	} else if err = product.ImportPricing(ctx, service, tx); err != nil {
+	}
+	if err != nil {
+		logger.Error("Failed to ingest pricing.", map[string]interface{}{
+			"service": service.Name,
+			"error":   err.Error(),
+		})
+	}
+	return
+}
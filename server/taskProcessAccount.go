@@ -17,9 +17,6 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"flag"
-	"strconv"
 	"time"
 
 	"github.com/trackit/jsonlog"
@@ -29,29 +26,19 @@ import (
 	"github.com/trackit/trackit-server/aws/usageReports/history"
 	"github.com/trackit/trackit-server/aws/usageReports/rds"
 	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/jobs"
 )
 
 // taskProcessAccount processes an AwsAccount to retrieve data from the AWS api.
 func taskProcessAccount(ctx context.Context) error {
-	args := flag.Args()
-	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	logger.Debug("Running task 'process-account'.", map[string]interface{}{
-		"args": args,
-	})
-	if len(args) != 1 {
-		return errors.New("taskProcessAccount requires an integer argument")
-	} else if aaId, err := strconv.Atoi(args[0]); err != nil {
-		return err
-	} else {
-		return ingestDataForAccount(ctx, aaId)
-	}
+	return runJobForAccountArg(ctx, jobs.ProcessAccount)
 }
 
-// ingestDataForAccount ingests the AWS api data for an AwsAccount.
-func ingestDataForAccount(ctx context.Context, aaId int) (err error) {
+// ingestDataForAccount ingests the AWS api data for an AwsAccount as part of
+// the job updateId.
+func ingestDataForAccount(ctx context.Context, aaId int, updateId int64) (err error) {
 	var tx *sql.Tx
 	var aa aws.AwsAccount
-	var updateId int64
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	defer func() {
 		if tx != nil {
@@ -64,16 +51,14 @@ func ingestDataForAccount(ctx context.Context, aaId int) (err error) {
 	}()
 	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
 	} else if aa, err = aws.GetAwsAccountWithId(aaId, tx); err != nil {
-	} else if updateId, err = registerAccountProcessing(db.Db, aa); err != nil {
 	} else {
 		ec2Err := processAccountEC2(ctx, aa)
 		rdsErr := processAccountRDS(ctx, aa)
 		esErr := processAccountES(ctx, aa)
 		historyCreated, historyErr := processAccountHistory(ctx, aa)
-		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, nil, rdsErr, ec2Err, esErr, historyErr, historyCreated)
+		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, rdsErr, ec2Err, esErr, historyErr, historyCreated)
 	}
 	if err != nil {
-		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, err, nil, nil, nil, nil, false)
 		logger.Error("Failed to process account data.", map[string]interface{}{
 			"awsAccountId": aaId,
 			"error":        err.Error(),
@@ -82,21 +67,9 @@ func ingestDataForAccount(ctx context.Context, aaId int) (err error) {
 	return
 }
 
-func registerAccountProcessing(db *sql.DB, aa aws.AwsAccount) (int64, error) {
-	const sqlstr = `INSERT INTO aws_account_update_job(
-		aws_account_id,
-		worker_id
-	) VALUES (?, ?)`
-	res, err := db.Exec(sqlstr, aa.Id, backendId)
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
-}
-
-func updateAccountProcessingCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, jobErr, rdsErr error, ec2Err error, esErr error, historyErr error, historyCreated bool) {
+func updateAccountProcessingCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, rdsErr error, ec2Err error, esErr error, historyErr error, historyCreated bool) {
 	updateNextUpdateAccount(db, aaId)
-	rErr := registerAccountProcessingCompletion(db, updateId, jobErr, rdsErr, ec2Err, esErr, historyErr, historyCreated)
+	rErr := registerAccountProcessingCompletion(db, updateId, rdsErr, ec2Err, esErr, historyErr, historyCreated)
 	if rErr != nil {
 		logger := jsonlog.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to register account processing completion.", map[string]interface{}{
@@ -122,17 +95,15 @@ func updateNextUpdateAccount(db *sql.DB, aaId int) error {
 	return err
 }
 
-func registerAccountProcessingCompletion(db *sql.DB, updateId int64, jobErr, rdsErr error, ec2Err error, esErr error, historyErr error, historyCreated bool) error {
+func registerAccountProcessingCompletion(db *sql.DB, updateId int64, rdsErr error, ec2Err error, esErr error, historyErr error, historyCreated bool) error {
 	const sqlstr = `UPDATE aws_account_update_job SET
-		completed=?,
-		jobError=?,
 		rdsError=?,
 		ec2Error=?,
 		esError=?,
 		historyError=?,
 		monthly_reports_generated=?
 	WHERE id=?`
-	_, err := db.Exec(sqlstr, time.Now(), errToStr(jobErr), errToStr(rdsErr), errToStr(ec2Err), errToStr(esErr), errToStr(historyErr), historyCreated, updateId)
+	_, err := db.Exec(sqlstr, errToStr(rdsErr), errToStr(ec2Err), errToStr(esErr), errToStr(historyErr), historyCreated, updateId)
 	return err
 }
 
@@ -17,43 +17,30 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"flag"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/trackit/jsonlog"
 	"github.com/trackit/trackit-server/aws"
 	"github.com/trackit/trackit-server/db"
 	"github.com/trackit/trackit-server/es"
+	"github.com/trackit/trackit-server/jobs"
+	"github.com/trackit/trackit-server/metrics"
 	core "github.com/trackit/trackit-server/plugins/account/core"
 	"github.com/trackit/trackit-server/users"
 )
 
 // taskProcessAccountPlugins is the entry point for account plugins processing
 func taskProcessAccountPlugins(ctx context.Context) error {
-	args := flag.Args()
-	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	logger.Debug("Running task 'process-account-plugin'.", map[string]interface{}{
-		"args": args,
-	})
-	if len(args) != 1 {
-		return errors.New("taskProcessAccountPlugins requires an integer argument")
-	} else if aaId, err := strconv.Atoi(args[0]); err != nil {
-		return err
-	} else {
-		return preparePluginsProcessingForAccount(ctx, aaId)
-	}
+	return runJobForAccountArg(ctx, jobs.ProcessAccountPlugins)
 }
 
 // preparePluginsProcessingForAccount retrieves all the informations needed to
-// run the plugins for a given account
-func preparePluginsProcessingForAccount(ctx context.Context, aaId int) (err error) {
+// run the plugins for a given account as part of the job updateId
+func preparePluginsProcessingForAccount(ctx context.Context, aaId int, updateId int64) (err error) {
 	var tx *sql.Tx
 	var aa aws.AwsAccount
 	var user users.User
-	var updateId int64
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	defer func() {
 		if tx != nil {
@@ -67,13 +54,11 @@ func preparePluginsProcessingForAccount(ctx context.Context, aaId int) (err erro
 	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
 	} else if aa, err = aws.GetAwsAccountWithId(aaId, tx); err != nil {
 	} else if user, err = users.GetUserWithId(tx, aa.UserId); err != nil {
-	} else if updateId, err = registerAccountPluginsProcessing(db.Db, aa); err != nil {
 	} else {
 		runPluginsForAccount(ctx, user, aa)
-		updateAccountPluginsCompletion(ctx, aaId, db.Db, updateId, nil)
+		updateAccountPluginsCompletion(ctx, aaId, db.Db, updateId)
 	}
 	if err != nil {
-		updateAccountPluginsCompletion(ctx, aaId, db.Db, updateId, err)
 		logger.Error("Failed to process account plugins.", map[string]interface{}{
 			"awsAccountId": aaId,
 			"error":        err.Error(),
@@ -123,29 +108,34 @@ func runPluginsForAccount(ctx context.Context, user users.User, aa aws.AwsAccoun
 			pluginResultES.Passed = res.Passed
 		}
 		core.IngestPluginResult(ctx, aa, pluginResultES)
+		recordPluginRun(pluginResultES)
 	}
 }
 
-func registerAccountPluginsProcessing(db *sql.DB, aa aws.AwsAccount) (int64, error) {
-	const sqlstr = `INSERT INTO aws_account_plugins_job(
-	aws_account_id,
-	worker_id
-	) VALUES (?, ?)`
-	res, err := db.Exec(sqlstr, aa.Id, backendId)
-	if err != nil {
-		return 0, err
+// pluginRuns counts the runs of account plugins by outcome.
+var pluginRuns = metrics.NewCounter(
+	"trackit_plugin_runs_total",
+	"Number of account plugin runs, by plugin and outcome: the status of the result, or error.",
+	"plugin", "outcome",
+)
+
+// recordPluginRun records the outcome of a plugin run in the metrics.
+func recordPluginRun(res core.PluginResultES) {
+	outcome := res.Status
+	if res.Error != "" {
+		outcome = "error"
+	} else if outcome == "" {
+		outcome = "unknown"
 	}
-	return res.LastInsertId()
+	pluginRuns.Inc(res.PluginName, outcome)
 }
 
-func updateAccountPluginsCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, jobErr error) {
-	updateNextUpdateAccountPlugins(db, aaId)
-	rErr := registerAccountPluginsCompletion(db, updateId, jobErr)
-	if rErr != nil {
+func updateAccountPluginsCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64) {
+	if err := updateNextUpdateAccountPlugins(db, aaId); err != nil {
 		logger := jsonlog.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to register account plugins completion.", map[string]interface{}{
 			"awsAccountId": aaId,
-			"error":        rErr.Error(),
+			"error":        err.Error(),
 			"updateId":     updateId,
 		})
 	}
@@ -158,16 +148,3 @@ func updateNextUpdateAccountPlugins(db *sql.DB, aaId int) error {
 	_, err := db.Exec(sqlstr, time.Now().AddDate(0, 0, 1), aaId)
 	return err
 }
-
-func registerAccountPluginsCompletion(db *sql.DB, updateId int64, jobErr error) error {
-	const sqlstr = `UPDATE aws_account_plugins_job SET
-	completed=?,
-	jobError=?
-	WHERE id=?`
-	jobError := ""
-	if jobErr != nil {
-		jobError = jobErr.Error()
-	}
-	_, err := db.Exec(sqlstr, time.Now(), jobError, updateId)
-	return err
-}
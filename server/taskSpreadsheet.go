@@ -16,9 +16,6 @@ package main
 
 import (
 	"context"
-	"errors"
-	"flag"
-	"strconv"
 	"time"
 
 	"database/sql"
@@ -27,30 +24,19 @@ import (
 	"github.com/trackit/trackit-server/aws"
 	"github.com/trackit/trackit-server/aws/usageReports/history"
 	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/jobs"
 	"github.com/trackit/trackit-server/models"
 	"github.com/trackit/trackit-server/reports"
 )
 
 // taskSpreadsheet generates Spreadsheet with reports for a given AwsAccount.
 func taskSpreadsheet(ctx context.Context) error {
-	args := flag.Args()
-	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	logger.Debug("Running task 'Spreadsheet'.", map[string]interface{}{
-		"args": args,
-	})
-	if len(args) != 1 {
-		return errors.New("taskSpreadsheet requires an integer argument")
-	} else if aaId, err := strconv.Atoi(args[0]); err != nil {
-		return err
-	} else {
-		return generateReport(ctx, aaId)
-	}
+	return runJobForAccountArg(ctx, jobs.GenerateSpreadsheet)
 }
 
-func generateReport(ctx context.Context, aaId int) (err error) {
+func generateReport(ctx context.Context, aaId int, updateId int64) (err error) {
 	var tx *sql.Tx
 	var aa aws.AwsAccount
-	var updateId int64
 	var generation bool
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	defer func() {
@@ -65,17 +51,15 @@ func generateReport(ctx context.Context, aaId int) (err error) {
 	if tx, err = db.Db.BeginTx(ctx, nil); err != nil {
 	} else if aa, err = aws.GetAwsAccountWithId(aaId, tx); err != nil {
 	} else if generation, err = checkReportGeneration(ctx, db.Db, aa); err != nil || !generation {
-	} else if updateId, err = registerAccountReportGeneration(db.Db, aa); err != nil {
 	} else {
 		errs := reports.GenerateReport(ctx, aa)
-		updateAccountReportGenerationCompletion(ctx, aaId, db.Db, updateId, nil, errs)
+		updateAccountReportGenerationCompletion(ctx, aaId, db.Db, updateId, errs)
 	}
 	if err != nil {
 		logger.Error("Error while generating spreadsheet report.", map[string]interface{}{
 			"awsAccountId": aaId,
 			"error":        err.Error(),
 		})
-		updateAccountReportGenerationCompletion(ctx, aaId, db.Db, updateId, err, nil)
 	}
 	return
 }
@@ -142,20 +126,8 @@ func checkReportGeneration(ctx context.Context, db *sql.DB, aa aws.AwsAccount) (
 	}
 }
 
-func registerAccountReportGeneration(db *sql.DB, aa aws.AwsAccount) (int64, error) {
-	dbReportGeneration := models.AwsAccountReportsJob{
-		AwsAccountID: aa.Id,
-		WorkerID:     backendId,
-	}
-	err := dbReportGeneration.Insert(db)
-	if err != nil {
-		return 0, err
-	}
-	return int64(dbReportGeneration.ID), err
-}
-
-func updateAccountReportGenerationCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, jobErr error, errs map[string]error) {
-	rErr := registerAccountReportGenerationCompletion(db, aaId, updateId, jobErr, errs)
+func updateAccountReportGenerationCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, errs map[string]error) {
+	rErr := registerAccountReportGenerationCompletion(db, aaId, updateId, errs)
 	if rErr != nil {
 		logger := jsonlog.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to register account processing completion.", map[string]interface{}{
@@ -166,14 +138,12 @@ func updateAccountReportGenerationCompletion(ctx context.Context, aaId int, db *
 	}
 }
 
-func registerAccountReportGenerationCompletion(db *sql.DB, aaId int, updateId int64, jobErr error, errs map[string]error) error {
+func registerAccountReportGenerationCompletion(db *sql.DB, aaId int, updateId int64, errs map[string]error) error {
 	dbAccountReports, err := models.AwsAccountReportsJobByID(db, int(updateId))
 	if err != nil {
 		return err
 	}
 	date := time.Now()
-	dbAccountReports.Completed = date
-	dbAccountReports.Joberror = errToStr(jobErr)
 	dbAccountReports.Spreadsheeterror = errToStr(errs["speadsheetError"])
 	dbAccountReports.Costdifferror = errToStr(errs["costDiffError"])
 	dbAccountReports.Ec2usagereporterror = errToStr(errs["ec2UsageReportError"])
@@ -0,0 +1,142 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/jobs"
+	"github.com/trackit/trackit-server/notifications"
+)
+
+// jobHandlers are the handlers for the jobs of each queue.
+var jobHandlers = map[jobs.Queue]jobs.Handler{
+	jobs.ProcessAccount: func(ctx context.Context, job jobs.Job) error {
+		return ingestDataForAccount(ctx, job.AwsAccountId, job.Id)
+	},
+	jobs.ProcessAccountPlugins: func(ctx context.Context, job jobs.Job) error {
+		return preparePluginsProcessingForAccount(ctx, job.AwsAccountId, job.Id)
+	},
+	jobs.AnomaliesDetection: func(ctx context.Context, job jobs.Job) error {
+		return processAnomaliesForAccount(ctx, job.AwsAccountId)
+	},
+	jobs.GenerateSpreadsheet: func(ctx context.Context, job jobs.Job) error {
+		return generateReport(ctx, job.AwsAccountId, job.Id)
+	},
+	jobs.SendNotifications: func(ctx context.Context, job jobs.Job) error {
+		return notifications.SendPendingDeliveries(ctx, db.Db, job.AwsAccountId, job.Attempts >= job.MaxAttempts)
+	},
+}
+
+// taskWorker continuously claims and runs the jobs of all the queues.
+func taskWorker(ctx context.Context) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Running task 'worker'.", map[string]interface{}{
+		"workerId":    backendId,
+		"concurrency": config.WorkerConcurrency,
+		"lease":       config.JobLease.String(),
+	})
+	worker := jobs.NewWorker(db.Db, backendId, config.WorkerConcurrency, config.JobLease, jobHandlers)
+	return worker.Run(ctx)
+}
+
+// runNotificationsWorker sends the queued notifications until the context is
+// cancelled, so that they are sent even where no 'worker' task runs.
+func runNotificationsWorker(ctx context.Context) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	handlers := map[jobs.Queue]jobs.Handler{
+		jobs.SendNotifications: jobHandlers[jobs.SendNotifications],
+	}
+	worker := jobs.NewWorker(db.Db, backendId, config.WorkerConcurrency, config.JobLease, handlers)
+	if err := worker.Run(ctx); err != nil {
+		logger.Error("Notifications worker stopped.", err.Error())
+	}
+}
+
+// taskEnqueue adds jobs to a queue for one or more AWS accounts.
+func taskEnqueue(ctx context.Context) error {
+	args := flag.Args()
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug("Running task 'enqueue'.", map[string]interface{}{
+		"args": args,
+	})
+	if len(args) < 2 {
+		return errors.New("taskEnqueue requires a queue name and integer arguments")
+	}
+	q, ok := jobs.QueueByName(args[0])
+	if !ok {
+		return fmt.Errorf("unknown queue %q", args[0])
+	}
+	for _, arg := range args[1:] {
+		aaId, err := strconv.Atoi(arg)
+		if err != nil {
+			return err
+		}
+		id, err := jobs.Enqueue(db.Db, q, aaId, config.JobMaxAttempts, time.Now())
+		if err != nil {
+			logger.Error("Failed to enqueue job.", map[string]interface{}{
+				"queue":        q.Name,
+				"awsAccountId": aaId,
+				"error":        err.Error(),
+			})
+			return err
+		}
+		logger.Info("Enqueued job.", map[string]interface{}{
+			"queue":        q.Name,
+			"awsAccountId": aaId,
+			"jobId":        id,
+		})
+	}
+	return nil
+}
+
+// runJobForAccountArg runs a job for the AWS account given as the only
+// argument of a task. The job is enqueued and claimed at once so that it is
+// recorded like the jobs run by workers, which retry it if it fails.
+func runJobForAccountArg(ctx context.Context, q jobs.Queue) error {
+	args := flag.Args()
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug(fmt.Sprintf("Running task '%s'.", q.Name), map[string]interface{}{
+		"args": args,
+	})
+	if len(args) != 1 {
+		return fmt.Errorf("task %s requires an integer argument", q.Name)
+	}
+	aaId, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	id, err := jobs.Enqueue(db.Db, q, aaId, config.JobMaxAttempts, time.Now())
+	if err != nil {
+		return err
+	}
+	job, ok, err := jobs.ClaimId(db.Db, q, id, backendId, config.JobLease, time.Now())
+	if err != nil {
+		return err
+	} else if !ok {
+		return jobs.ErrLeaseLost
+	}
+	worker := jobs.NewWorker(db.Db, backendId, 1, config.JobLease, jobHandlers)
+	return worker.Execute(ctx, job)
+}
@@ -65,7 +65,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getEc2Instances).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(ec2QueryArgs),
 			routes.Documentation{
 				Summary:     "get the list of EC2 instances",
@@ -76,7 +76,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getEc2UnusedInstances).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(ec2UnusedQueryArgs),
 			routes.Documentation{
 				Summary:     "get the list of the most unused EC2 instances of a month",
@@ -84,6 +84,17 @@ func init() {
 			},
 		),
 	}.H().Register("/ec2/unused")
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getEc2Rightsizing).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
+			routes.QueryArgs(ec2QueryArgs),
+			routes.Documentation{
+				Summary:     "get rightsizing recommendations for EC2 instances",
+				Description: "Responds with a cheaper instance type for each EC2 instance whose CPU utilization allows it, with at least as much memory and priced for the same operating system and tenancy, with the estimated monthly saving. Prices come from the EC2 pricing imported by the 'ingest-pricing' task.",
+			},
+		),
+	}.H().Register("/ec2/rightsizing")
 }
 
 // getEc2Instances returns the list of EC2 reports based on the query params, in JSON format.
@@ -127,3 +138,23 @@ func getEc2UnusedInstances(request *http.Request, a routes.Arguments) (int, inte
 		return returnCode, report
 	}
 }
+
+// getEc2Rightsizing returns the rightsizing recommendations for the EC2
+// instances of the report based on the query params, in JSON format.
+func getEc2Rightsizing(request *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	parsedParams := Ec2QueryParams{
+		AccountList: []string{},
+		Date:        a[routes.DateQueryArg].(time.Time),
+	}
+	if a[routes.AwsAccountsOptionalQueryArg] != nil {
+		parsedParams.AccountList = a[routes.AwsAccountsOptionalQueryArg].([]string)
+	}
+	returnCode, report, err := GetEc2RightsizingData(request.Context(), parsedParams, user, tx)
+	if err != nil {
+		return returnCode, err
+	} else {
+		return returnCode, report
+	}
+}
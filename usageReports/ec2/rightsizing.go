@@ -0,0 +1,261 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package ec2
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws/usageReports/ec2"
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/models"
+	"github.com/trackit/trackit-server/users"
+)
+
+const (
+	// hoursPerMonth is the average number of hours in a month, used to
+	// estimate monthly savings from hourly prices.
+	hoursPerMonth = 730
+)
+
+type (
+	// RightsizingThresholds are the highest CPU percentages expected on a
+	// suggested instance type, and the lowest ratio of its memory to the
+	// memory of the current type.
+	RightsizingThresholds struct {
+		MaxPeakCpu     float64
+		MaxAverageCpu  float64
+		MinMemoryRatio float64
+	}
+
+	// InstanceTypeSummary describes an instance type and its on-demand price.
+	InstanceTypeSummary struct {
+		Type        string  `json:"type"`
+		Vcpu        int     `json:"vcpu"`
+		Memory      string  `json:"memory"`
+		HourlyPrice float64 `json:"hourlyPrice"`
+	}
+
+	// RightsizingRecommendation suggests a cheaper instance type for an
+	// instance, with the CPU utilization expected on it.
+	RightsizingRecommendation struct {
+		Account       string              `json:"account"`
+		Id            string              `json:"id"`
+		Region        string              `json:"region"`
+		Tags          map[string]string   `json:"tags"`
+		Cpu           ec2.Cpu             `json:"cpu"`
+		ProjectedCpu  ec2.Cpu             `json:"projectedCpu"`
+		Current       InstanceTypeSummary `json:"current"`
+		Suggested     InstanceTypeSummary `json:"suggested"`
+		SameFamily    bool                `json:"sameFamily"`
+		Currency      string              `json:"currency"`
+		MonthlySaving float64             `json:"monthlySaving"`
+	}
+)
+
+// defaultRightsizingThresholds returns the thresholds set in the
+// configuration.
+func defaultRightsizingThresholds() RightsizingThresholds {
+	return RightsizingThresholds{
+		MaxPeakCpu:     config.RightsizingMaxPeakCpu,
+		MaxAverageCpu:  config.RightsizingMaxAverageCpu,
+		MinMemoryRatio: config.RightsizingMinMemoryRatio,
+	}
+}
+
+// instanceFamily returns the family of an instance type, such as "m5" for
+// "m5.large".
+func instanceFamily(instanceType string) string {
+	return strings.SplitN(instanceType, ".", 2)[0]
+}
+
+// isBurstable tells whether an instance type earns CPU credits, in which case
+// utilization cannot be projected from another type.
+func isBurstable(instanceType string) bool {
+	return strings.HasPrefix(instanceType, "t")
+}
+
+// regionFromAvailabilityZone returns the region code of an availability
+// zone, such as "us-east-1" for "us-east-1a".
+func regionFromAvailabilityZone(availabilityZone string) string {
+	if n := len(availabilityZone); n > 0 && availabilityZone[n-1] >= 'a' && availabilityZone[n-1] <= 'z' {
+		return availabilityZone[:n-1]
+	}
+	return availabilityZone
+}
+
+// instanceOperatingSystem returns the operating system of the prices of an
+// instance given its platform, which is only set for Windows instances. Linux
+// distributions with license fees cannot be told apart and get Linux prices.
+func instanceOperatingSystem(platform string) string {
+	if strings.EqualFold(platform, "windows") {
+		return "Windows"
+	}
+	return "Linux"
+}
+
+// instanceTenancy returns the tenancy of the prices of an instance given its
+// purchasing option, which is its tenancy unless it is shared.
+func instanceTenancy(purchasing string) string {
+	switch purchasing {
+	case "dedicated":
+		return "Dedicated"
+	case "host":
+		return "Host"
+	default:
+		return "Shared"
+	}
+}
+
+// projectCpu projects the CPU utilization of an instance on a type with a
+// different vCPU count.
+func projectCpu(cpu ec2.Cpu, currentVcpu, vcpu int) ec2.Cpu {
+	ratio := float64(currentVcpu) / float64(vcpu)
+	return ec2.Cpu{
+		Average: cpu.Average * ratio,
+		Peak:    cpu.Peak * ratio,
+	}
+}
+
+// suggestInstanceType returns the cheapest current generation instance type
+// on which the CPU utilization of an instance stays under the thresholds and
+// with enough memory, preferring the same family on equal prices. It returns false if no type is
+// cheaper than the current one.
+func suggestInstanceType(cpu ec2.Cpu, current models.AwsProductPricingEc2InstanceTypePrice, options []models.AwsProductPricingEc2InstanceTypePrice, thresholds RightsizingThresholds) (models.AwsProductPricingEc2InstanceTypePrice, bool) {
+	var best models.AwsProductPricingEc2InstanceTypePrice
+	found := false
+	family := instanceFamily(current.InstanceType)
+	for _, option := range options {
+		if !option.CurrentGeneration || option.Vcpu <= 0 || option.HourlyPrice >= current.HourlyPrice {
+			continue
+		} else if option.MemoryGib < current.MemoryGib*thresholds.MinMemoryRatio {
+			continue
+		} else if isBurstable(option.InstanceType) && !isBurstable(current.InstanceType) {
+			continue
+		}
+		projected := projectCpu(cpu, current.Vcpu, option.Vcpu)
+		if projected.Peak > thresholds.MaxPeakCpu || projected.Average > thresholds.MaxAverageCpu {
+			continue
+		}
+		if !found || option.HourlyPrice < best.HourlyPrice ||
+			(option.HourlyPrice == best.HourlyPrice && instanceFamily(option.InstanceType) == family) {
+			best = option
+			found = true
+		}
+	}
+	return best, found
+}
+
+// recommendRightsizing builds the recommendation for an instance, given the
+// instance types available in its region for its operating system and
+// tenancy. It returns false if the instance has no statistics or no cheaper
+// type fits it.
+func recommendRightsizing(instance InstanceReport, options map[string]models.AwsProductPricingEc2InstanceTypePrice, thresholds RightsizingThresholds) (RightsizingRecommendation, bool) {
+	cpu := instance.Instance.Stats.Cpu
+	if cpu.Average == -1 || cpu.Peak == -1 {
+		return RightsizingRecommendation{}, false
+	}
+	current, ok := options[instance.Instance.Type]
+	if !ok || current.Vcpu <= 0 || current.MemoryGib <= 0 {
+		return RightsizingRecommendation{}, false
+	}
+	list := make([]models.AwsProductPricingEc2InstanceTypePrice, 0, len(options))
+	for _, option := range options {
+		list = append(list, option)
+	}
+	suggested, ok := suggestInstanceType(cpu, current, list, thresholds)
+	if !ok {
+		return RightsizingRecommendation{}, false
+	}
+	return RightsizingRecommendation{
+		Account:       instance.Account,
+		Id:            instance.Instance.Id,
+		Region:        instance.Instance.Region,
+		Tags:          instance.Instance.Tags,
+		Cpu:           cpu,
+		ProjectedCpu:  projectCpu(cpu, current.Vcpu, suggested.Vcpu),
+		Current:       instanceTypeSummary(current),
+		Suggested:     instanceTypeSummary(suggested),
+		SameFamily:    instanceFamily(current.InstanceType) == instanceFamily(suggested.InstanceType),
+		Currency:      current.Currency,
+		MonthlySaving: (current.HourlyPrice - suggested.HourlyPrice) * hoursPerMonth,
+	}, true
+}
+
+func instanceTypeSummary(price models.AwsProductPricingEc2InstanceTypePrice) InstanceTypeSummary {
+	return InstanceTypeSummary{
+		Type:        price.InstanceType,
+		Vcpu:        price.Vcpu,
+		Memory:      price.Memory,
+		HourlyPrice: price.HourlyPrice,
+	}
+}
+
+// getInstanceTypePrices returns the instance types of a region for an
+// operating system and a tenancy by name.
+func getInstanceTypePrices(tx *sql.Tx, regionCode, operatingSystem, tenancy string) (map[string]models.AwsProductPricingEc2InstanceTypePrice, error) {
+	prices, err := models.AwsProductPricingEc2InstanceTypePricesByRegionCode(tx, regionCode, operatingSystem, tenancy)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]models.AwsProductPricingEc2InstanceTypePrice, len(prices))
+	for _, price := range prices {
+		res[price.InstanceType] = price
+	}
+	return res, nil
+}
+
+// GetEc2RightsizingData gets EC2 reports and suggests a cheaper instance
+// type for the instances whose CPU utilization allows it, sorted by monthly
+// saving.
+func GetEc2RightsizingData(ctx context.Context, params Ec2QueryParams, user users.User, tx *sql.Tx) (int, []RightsizingRecommendation, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	returnCode, instances, err := GetEc2Data(ctx, params, user, tx)
+	if err != nil {
+		return returnCode, nil, err
+	}
+	thresholds := defaultRightsizingThresholds()
+	type pricesKey struct{ region, operatingSystem, tenancy string }
+	prices := make(map[pricesKey]map[string]models.AwsProductPricingEc2InstanceTypePrice)
+	recommendations := make([]RightsizingRecommendation, 0)
+	for _, instance := range instances {
+		key := pricesKey{
+			region:          regionFromAvailabilityZone(instance.Instance.Region),
+			operatingSystem: instanceOperatingSystem(instance.Instance.Platform),
+			tenancy:         instanceTenancy(instance.Instance.Purchasing),
+		}
+		options, ok := prices[key]
+		if !ok {
+			if options, err = getInstanceTypePrices(tx, key.region, key.operatingSystem, key.tenancy); err != nil {
+				logger.Error("Failed to get EC2 instance type prices.", err.Error())
+				return http.StatusInternalServerError, nil, errors.New("Error while getting EC2 prices.")
+			}
+			prices[key] = options
+		}
+		if recommendation, ok := recommendRightsizing(instance, options, thresholds); ok {
+			recommendations = append(recommendations, recommendation)
+		}
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].MonthlySaving > recommendations[j].MonthlySaving
+	})
+	return returnCode, recommendations, nil
+}
@@ -0,0 +1,106 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package ec2
+
+import (
+	"testing"
+
+	"github.com/trackit/trackit-server/aws/usageReports/ec2"
+	"github.com/trackit/trackit-server/models"
+)
+
+var testThresholds = RightsizingThresholds{MaxPeakCpu: 80, MaxAverageCpu: 40, MinMemoryRatio: 1}
+
+var testInstanceTypes = map[string]models.AwsProductPricingEc2InstanceTypePrice{
+	"m5.xlarge": {InstanceType: "m5.xlarge", Vcpu: 4, Memory: "16 GiB", MemoryGib: 16, CurrentGeneration: true, HourlyPrice: 0.192, Currency: "USD"},
+	"r5.large":  {InstanceType: "r5.large", Vcpu: 2, Memory: "16 GiB", MemoryGib: 16, CurrentGeneration: true, HourlyPrice: 0.126, Currency: "USD"},
+	"m5.large":  {InstanceType: "m5.large", Vcpu: 2, Memory: "8 GiB", MemoryGib: 8, CurrentGeneration: true, HourlyPrice: 0.096, Currency: "USD"},
+	"c5.large":  {InstanceType: "c5.large", Vcpu: 2, Memory: "4 GiB", MemoryGib: 4, CurrentGeneration: true, HourlyPrice: 0.085, Currency: "USD"},
+	"m4.large":  {InstanceType: "m4.large", Vcpu: 2, Memory: "8 GiB", MemoryGib: 8, CurrentGeneration: false, HourlyPrice: 0.05, Currency: "USD"},
+	"t3.large":  {InstanceType: "t3.large", Vcpu: 2, Memory: "8 GiB", MemoryGib: 8, CurrentGeneration: true, HourlyPrice: 0.0832, Currency: "USD"},
+}
+
+func testInstance(instanceType string, cpu ec2.Cpu) InstanceReport {
+	var instance InstanceReport
+	instance.Instance.Id = "i-0123456789abcdef0"
+	instance.Instance.Region = "us-east-1a"
+	instance.Instance.Type = instanceType
+	instance.Instance.Stats.Cpu = cpu
+	return instance
+}
+
+func TestRightsizingSuggestsCheapestFittingType(t *testing.T) {
+	recommendation, ok := recommendRightsizing(testInstance("m5.xlarge", ec2.Cpu{Average: 10, Peak: 30}), testInstanceTypes, testThresholds)
+	if !ok {
+		t.Fatalf("A recommendation was expected.")
+	}
+	if recommendation.Suggested.Type != "r5.large" {
+		t.Errorf("Suggested type should be r5.large, is %s.", recommendation.Suggested.Type)
+	}
+	if recommendation.SameFamily {
+		t.Errorf("r5.large is not in the family of m5.xlarge.")
+	}
+	if recommendation.ProjectedCpu.Peak != 60 || recommendation.ProjectedCpu.Average != 20 {
+		t.Errorf("Unexpected projected CPU %#v.", recommendation.ProjectedCpu)
+	}
+	if saving := (0.192 - 0.126) * hoursPerMonth; recommendation.MonthlySaving != saving {
+		t.Errorf("Monthly saving should be %f, is %f.", saving, recommendation.MonthlySaving)
+	}
+}
+
+func TestRightsizingRespectsMemoryRatio(t *testing.T) {
+	thresholds := testThresholds
+	thresholds.MinMemoryRatio = 0.5
+	recommendation, ok := recommendRightsizing(testInstance("m5.xlarge", ec2.Cpu{Average: 10, Peak: 30}), testInstanceTypes, thresholds)
+	if !ok {
+		t.Fatalf("A recommendation was expected.")
+	}
+	if recommendation.Suggested.Type != "m5.large" {
+		t.Errorf("Suggested type should be m5.large, is %s.", recommendation.Suggested.Type)
+	}
+}
+
+func TestRightsizingPricesOfInstance(t *testing.T) {
+	for platform, expected := range map[string]string{"": "Linux", "windows": "Windows"} {
+		if os := instanceOperatingSystem(platform); os != expected {
+			t.Errorf("Operating system of platform %q should be %q, is %q.", platform, expected, os)
+		}
+	}
+	for purchasing, expected := range map[string]string{"on demand": "Shared", "spot": "Shared", "dedicated": "Dedicated", "host": "Host"} {
+		if tenancy := instanceTenancy(purchasing); tenancy != expected {
+			t.Errorf("Tenancy of purchasing option %q should be %q, is %q.", purchasing, expected, tenancy)
+		}
+	}
+}
+
+func TestRightsizingRespectsThresholds(t *testing.T) {
+	if recommendation, ok := recommendRightsizing(testInstance("m5.xlarge", ec2.Cpu{Average: 10, Peak: 50}), testInstanceTypes, testThresholds); ok {
+		t.Errorf("No type should fit a peak of 50%% on half the vCPUs, got %s.", recommendation.Suggested.Type)
+	}
+}
+
+func TestRightsizingWithoutStats(t *testing.T) {
+	if _, ok := recommendRightsizing(testInstance("m5.xlarge", ec2.Cpu{Average: -1, Peak: -1}), testInstanceTypes, testThresholds); ok {
+		t.Errorf("Instances without statistics should not be rightsized.")
+	}
+}
+
+func TestRegionFromAvailabilityZone(t *testing.T) {
+	for zone, expected := range map[string]string{"us-east-1a": "us-east-1", "eu-west-3": "eu-west-3", "": ""} {
+		if region := regionFromAvailabilityZone(zone); region != expected {
+			t.Errorf("Region of %q should be %q, is %q.", zone, expected, region)
+		}
+	}
+}
@@ -65,7 +65,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getESDomains).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(esQueryArgs),
 			routes.Documentation{
 				Summary:     "get the latest ES report",
@@ -76,7 +76,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getESUnusedDomains).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(esUnusedQueryArgs),
 			routes.Documentation{
 				Summary:     "get the list of the most unused ES domains of a month",
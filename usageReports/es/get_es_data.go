@@ -117,13 +117,13 @@ func GetEsData(ctx context.Context, parsedParams EsQueryParams, user users.User,
 	if err != nil {
 		return returnCode, nil, err
 	} else if monthlyDomains != nil && len(monthlyDomains) > 0 {
-		return returnCode, monthlyDomains, nil
+		return returnCode, addListPrices(ctx, tx, monthlyDomains), nil
 	}
 	returnCode, dailyDomains, err := GetEsDailyDomains(ctx, parsedParams, user, tx)
 	if err != nil {
 		return returnCode, nil, err
 	}
-	return returnCode, dailyDomains, nil
+	return returnCode, addListPrices(ctx, tx, dailyDomains), nil
 }
 
 // GetEsUnusedData gets ES reports and parse them based on query params to have an array of unused domains
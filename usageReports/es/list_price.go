@@ -0,0 +1,47 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package es
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws/product"
+)
+
+// ListPrice is the on-demand list price of all the instances of a domain, to
+// compare with its actual cost.
+type ListPrice struct {
+	Hourly   float64 `json:"hourly"`
+	Currency string  `json:"currency"`
+}
+
+// addListPrices sets the list price of the domains whose instance price is
+// known. Failing to get a price is not fatal: the domain is left without one.
+func addListPrices(ctx context.Context, tx *sql.Tx, domains []DomainReport) []DomainReport {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	for i, domain := range domains {
+		base := domain.Domain.DomainBase
+		price, err := product.EsOnDemandPrice(tx, base.InstanceType, base.Region)
+		if err != nil {
+			logger.Error("Failed to get ES list price.", err.Error())
+		} else if price != nil {
+			domains[i].Domain.ListPrice = &ListPrice{price.HourlyPrice * float64(base.InstanceCount), price.Currency}
+		}
+	}
+	return domains
+}
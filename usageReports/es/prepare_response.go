@@ -91,9 +91,10 @@ type (
 	// Domain represents all the informations of an ES domain.
 	Domain struct {
 		es.DomainBase
-		Tags  map[string]string  `json:"tags"`
-		Costs map[string]float64 `json:"costs"`
-		Stats es.Stats           `json:"stats"`
+		Tags      map[string]string  `json:"tags"`
+		Costs     map[string]float64 `json:"costs"`
+		Stats     es.Stats           `json:"stats"`
+		ListPrice *ListPrice         `json:"listPrice,omitempty"`
 	}
 )
 
@@ -117,13 +117,13 @@ func GetRdsData(ctx context.Context, parsedParams RdsQueryParams, user users.Use
 	if err != nil {
 		return returnCode, nil, err
 	} else if monthlyInstances != nil && len(monthlyInstances) > 0 {
-		return returnCode, monthlyInstances, nil
+		return returnCode, addListPrices(ctx, tx, monthlyInstances), nil
 	}
 	returnCode, dailyInstances, err := GetRdsDailyInstances(ctx, parsedParams, user, tx)
 	if err != nil {
 		return returnCode, nil, err
 	}
-	return returnCode, dailyInstances, nil
+	return returnCode, addListPrices(ctx, tx, dailyInstances), nil
 }
 
 // GetRdsUnusedData gets RDS reports and parse them based on query params to have an array of unused instances
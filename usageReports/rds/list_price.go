@@ -0,0 +1,64 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package rds
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/aws/product"
+)
+
+// ListPrice is the on-demand list price of an instance, to compare with its
+// actual cost.
+type ListPrice struct {
+	Hourly   float64 `json:"hourly"`
+	Currency string  `json:"currency"`
+}
+
+// regionFromAvailabilityZone returns the region code of an availability
+// zone, such as "us-east-1" for "us-east-1a".
+func regionFromAvailabilityZone(availabilityZone string) string {
+	if len(availabilityZone) == 0 {
+		return ""
+	}
+	return availabilityZone[:len(availabilityZone)-1]
+}
+
+// addListPrices sets the list price of the instances whose price is known.
+// Failing to get a price is not fatal: the instance is left without one.
+func addListPrices(ctx context.Context, tx *sql.Tx, instances []InstanceReport) []InstanceReport {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	cache := make(map[string]*ListPrice)
+	for i, instance := range instances {
+		base := instance.Instance.InstanceBase
+		region := regionFromAvailabilityZone(base.AvailabilityZone)
+		key := base.DBInstanceClass + "/" + region + "/" + base.Engine + "/" + product.RdsDeploymentOption(base.MultiAZ)
+		listPrice, ok := cache[key]
+		if !ok {
+			price, err := product.RdsOnDemandPrice(tx, base.DBInstanceClass, region, base.Engine, base.MultiAZ)
+			if err != nil {
+				logger.Error("Failed to get RDS list price.", err.Error())
+			} else if price != nil {
+				listPrice = &ListPrice{price.HourlyPrice, price.Currency}
+			}
+			cache[key] = listPrice
+		}
+		instances[i].Instance.ListPrice = listPrice
+	}
+	return instances
+}
@@ -92,9 +92,10 @@ type (
 	// Instance contains the information of an RDS instance
 	Instance struct {
 		rds.InstanceBase
-		Tags  map[string]string  `json:"tags"`
-		Costs map[string]float64 `json:"costs"`
-		Stats rds.Stats          `json:"stats"`
+		Tags      map[string]string  `json:"tags"`
+		Costs     map[string]float64 `json:"costs"`
+		Stats     rds.Stats          `json:"stats"`
+		ListPrice *ListPrice         `json:"listPrice,omitempty"`
 	}
 )
 
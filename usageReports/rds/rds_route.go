@@ -65,7 +65,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getRdsReport).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(rdsQueryArgs),
 			routes.Documentation{
 				Summary:     "get a RDS report of a month",
@@ -76,7 +76,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getRdsUnusedInstances).With(
 			db.RequestTransaction{Db: db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs(rdsUnusedQueryArgs),
 			routes.Documentation{
 				Summary:     "get the list of the most unused RDS instances of a month",
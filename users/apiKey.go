@@ -0,0 +1,303 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/models"
+)
+
+const (
+	// apiKeyPrefix starts every API key, which tells them apart from JWT
+	// tokens and lets secret scanners recognize them.
+	apiKeyPrefix = "trackit_"
+	// apiKeyEntropy is the count of random bytes in an API key.
+	apiKeyEntropy = 32
+	// apiKeyDisplayLength is the length of the beginning of a key which is
+	// stored in clear to help its owner recognize it.
+	apiKeyDisplayLength = len(apiKeyPrefix) + 6
+)
+
+var (
+	ErrInvalidApiKey       = errors.New("invalid, expired or revoked api key")
+	ErrApiKeyReadOnly      = errors.New("this api key is read-only")
+	ErrApiKeyNotFound      = errors.New("api key not found")
+	ErrApiKeyAwsAccount    = errors.New("aws account not found")
+	ErrApiKeyExpired       = errors.New("expiry date is in the past")
+	ErrApiKeyForbidden     = errors.New("this action is unavailable to api keys")
+	ErrFailedCreatingKey   = errors.New("failed to create api key")
+	ErrFailedRetrievingKey = errors.New("failed to retrieve api keys")
+	ErrFailedRevokingKey   = errors.New("failed to revoke api key")
+)
+
+// ApiKeyScope restricts what a user authenticated with an API key can do. A
+// read-only key can only be used for safe methods. A key with AWS accounts can
+// only access those accounts.
+type ApiKeyScope struct {
+	ApiKeyId    int
+	ReadOnly    bool
+	AwsAccounts []int
+}
+
+// ApiKey is an API key as shown to its owner. The key itself is only known
+// when it is created: only its hash is stored.
+type ApiKey struct {
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	ReadOnly    bool       `json:"readOnly"`
+	AwsAccounts []int      `json:"awsAccounts"`
+	Created     time.Time  `json:"created"`
+	Expires     *time.Time `json:"expires"`
+	LastUsed    *time.Time `json:"lastUsed"`
+	Revoked     *time.Time `json:"revoked"`
+}
+
+// CanAccessAwsAccount tells whether the user may access an AWS account given
+// the scope of the API key it authenticated with, if any. It does not check
+// the account belongs to the user.
+func (u User) CanAccessAwsAccount(aaid int) bool {
+	if u.ApiKeyScope == nil || len(u.ApiKeyScope.AwsAccounts) == 0 {
+		return true
+	}
+	for _, id := range u.ApiKeyScope.AwsAccounts {
+		if id == aaid {
+			return true
+		}
+	}
+	return false
+}
+
+// isApiKey tells whether a token from an Authorization header is an API key.
+func isApiKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// hashApiKey hashes an API key. Keys are random enough that a fast hash does
+// not make them easier to guess, and lets them be looked up by hash.
+func hashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// generateApiKey generates a new random API key.
+func generateApiKey() (string, error) {
+	var random [apiKeyEntropy]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random[:]), nil
+}
+
+func nullTimePtr(t mysql.NullTime) *time.Time {
+	if t.Valid {
+		return &t.Time
+	}
+	return nil
+}
+
+func apiKeyFromDbApiKey(dbApiKey models.APIKey, awsAccounts []int) ApiKey {
+	return ApiKey{
+		Id:          dbApiKey.ID,
+		Name:        dbApiKey.Name,
+		Prefix:      dbApiKey.Prefix,
+		ReadOnly:    dbApiKey.ReadOnly,
+		AwsAccounts: awsAccounts,
+		Created:     dbApiKey.Created,
+		Expires:     nullTimePtr(dbApiKey.Expires),
+		LastUsed:    nullTimePtr(dbApiKey.LastUsed),
+		Revoked:     nullTimePtr(dbApiKey.Revoked),
+	}
+}
+
+func getApiKeyAwsAccounts(db models.XODB, apiKeyId int) ([]int, error) {
+	dbAwsAccounts, err := models.APIKeyAwsAccountsByAPIKeyID(db, apiKeyId)
+	if err != nil {
+		return nil, err
+	}
+	awsAccounts := make([]int, len(dbAwsAccounts))
+	for i := range dbAwsAccounts {
+		awsAccounts[i] = dbAwsAccounts[i].AwsAccountID
+	}
+	return awsAccounts, nil
+}
+
+// userHasAwsAccount tells whether an AWS account belongs to or is shared
+// with a user.
+func userHasAwsAccount(tx *sql.Tx, user User, aaid int) (bool, error) {
+	if dbAwsAccount, err := models.AwsAccountByID(tx, aaid); err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else if dbAwsAccount.UserID == user.Id {
+		return true, nil
+	}
+	dbSharedAccounts, err := models.SharedAccountsByUserID(tx, user.Id)
+	if err != nil {
+		return false, err
+	}
+	for _, sa := range dbSharedAccounts {
+		if sa.AccountID == aaid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateApiKey creates an API key for a user. The key is returned along with
+// its description, and cannot be retrieved later on.
+func CreateApiKey(ctx context.Context, tx *sql.Tx, user User, name string, readOnly bool, awsAccounts []int, expires *time.Time) (ApiKey, string, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if expires != nil && !expires.After(time.Now()) {
+		return ApiKey{}, "", ErrApiKeyExpired
+	}
+	for _, aaid := range awsAccounts {
+		if ok, err := userHasAwsAccount(tx, user, aaid); err != nil {
+			logger.Error("Failed to check AWS account for api key.", err.Error())
+			return ApiKey{}, "", ErrFailedCreatingKey
+		} else if !ok {
+			return ApiKey{}, "", ErrApiKeyAwsAccount
+		}
+	}
+	key, err := generateApiKey()
+	if err != nil {
+		logger.Error("Failed to generate api key.", err.Error())
+		return ApiKey{}, "", ErrFailedCreatingKey
+	}
+	dbApiKey := models.APIKey{
+		UserID:   user.Id,
+		Name:     name,
+		Prefix:   key[:apiKeyDisplayLength],
+		Hash:     hashApiKey(key),
+		ReadOnly: readOnly,
+		Created:  time.Now().UTC(),
+	}
+	if expires != nil {
+		dbApiKey.Expires = mysql.NullTime{Time: expires.UTC(), Valid: true}
+	}
+	if err := dbApiKey.Insert(tx); err != nil {
+		logger.Error("Failed to insert api key.", err.Error())
+		return ApiKey{}, "", ErrFailedCreatingKey
+	}
+	for _, aaid := range awsAccounts {
+		dbApiKeyAwsAccount := models.APIKeyAwsAccount{APIKeyID: dbApiKey.ID, AwsAccountID: aaid}
+		if err := dbApiKeyAwsAccount.Insert(tx); err != nil {
+			logger.Error("Failed to insert api key AWS account.", err.Error())
+			return ApiKey{}, "", ErrFailedCreatingKey
+		}
+	}
+	return apiKeyFromDbApiKey(dbApiKey, awsAccounts), key, nil
+}
+
+// GetApiKeysByUser returns the API keys of a user, including revoked ones.
+func GetApiKeysByUser(ctx context.Context, db models.XODB, user User) ([]ApiKey, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbApiKeys, err := models.APIKeysByUserID(db, user.Id)
+	if err != nil {
+		logger.Error("Failed to get api keys.", err.Error())
+		return nil, ErrFailedRetrievingKey
+	}
+	res := make([]ApiKey, len(dbApiKeys))
+	for i := range dbApiKeys {
+		awsAccounts, err := getApiKeyAwsAccounts(db, dbApiKeys[i].ID)
+		if err != nil {
+			logger.Error("Failed to get api key AWS accounts.", err.Error())
+			return nil, ErrFailedRetrievingKey
+		}
+		res[i] = apiKeyFromDbApiKey(*dbApiKeys[i], awsAccounts)
+	}
+	return res, nil
+}
+
+// RevokeApiKey revokes one of a user's API keys. Revoked keys are kept so
+// that their owner can still see when they were last used.
+func RevokeApiKey(ctx context.Context, db models.XODB, user User, id int) (ApiKey, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbApiKey, err := models.APIKeyByID(db, id)
+	if err == sql.ErrNoRows || (err == nil && dbApiKey.UserID != user.Id) {
+		return ApiKey{}, ErrApiKeyNotFound
+	} else if err != nil {
+		logger.Error("Failed to get api key.", err.Error())
+		return ApiKey{}, ErrFailedRetrievingKey
+	}
+	awsAccounts, err := getApiKeyAwsAccounts(db, dbApiKey.ID)
+	if err != nil {
+		logger.Error("Failed to get api key AWS accounts.", err.Error())
+		return ApiKey{}, ErrFailedRetrievingKey
+	}
+	if !dbApiKey.Revoked.Valid {
+		dbApiKey.Revoked = mysql.NullTime{Time: time.Now().UTC(), Valid: true}
+		if err := dbApiKey.Update(db); err != nil {
+			logger.Error("Failed to revoke api key.", err.Error())
+			return ApiKey{}, ErrFailedRevokingKey
+		}
+	}
+	return apiKeyFromDbApiKey(*dbApiKey, awsAccounts), nil
+}
+
+// testApiKey checks whether an API key is valid and retrieves the owning User
+// if it is, with the key's scope. The time the key was last used is updated.
+func testApiKey(tx *sql.Tx, key string) (User, error) {
+	var user User
+	dbApiKey, err := models.APIKeyByHash(tx, hashApiKey(key))
+	if err == sql.ErrNoRows {
+		return user, ErrInvalidApiKey
+	} else if err != nil {
+		return user, err
+	} else if dbApiKey.Revoked.Valid || (dbApiKey.Expires.Valid && !time.Now().Before(dbApiKey.Expires.Time)) {
+		return user, ErrInvalidApiKey
+	}
+	awsAccounts, err := getApiKeyAwsAccounts(tx, dbApiKey.ID)
+	if err != nil {
+		return user, err
+	}
+	if user, err = GetUserWithId(tx, dbApiKey.UserID); err != nil {
+		return user, err
+	} else if !user.AwsCustomerEntitlement {
+		return user, ErrMarketplaceInvalidToken
+	} else if err = models.TouchAPIKey(tx, dbApiKey.ID); err != nil {
+		return user, err
+	}
+	user.ApiKeyScope = &ApiKeyScope{
+		ApiKeyId:    dbApiKey.ID,
+		ReadOnly:    dbApiKey.ReadOnly,
+		AwsAccounts: awsAccounts,
+	}
+	return user, nil
+}
+
+// isSafeMethod tells whether an HTTP method only reads data, and can thus be
+// used with a read-only API key.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
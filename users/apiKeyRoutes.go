@@ -0,0 +1,118 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+)
+
+// createApiKeyRequestBody is the expected request body for the createApiKey
+// route handler.
+type createApiKeyRequestBody struct {
+	Name        string     `json:"name"        req:"nonzero"`
+	ReadOnly    bool       `json:"readOnly"`
+	AwsAccounts []int      `json:"awsAccounts"`
+	Expires     *time.Time `json:"expires"`
+}
+
+// createApiKeyResponseBody is the response body of the createApiKey route
+// handler. It is the only time the key is given.
+type createApiKeyResponseBody struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getApiKeys).With(
+			RequireAuthenticatedUser{ViewerCannot, ApiKeyCannot},
+			routes.Documentation{
+				Summary:     "list the user's api keys",
+				Description: "Lists the API keys of the user, including revoked ones. The keys themselves are not returned.",
+			},
+		),
+		http.MethodPost: routes.H(createApiKey).With(
+			routes.RequestContentType{"application/json"},
+			RequireAuthenticatedUser{ViewerCannot, ApiKeyCannot},
+			routes.RequestBody{createApiKeyRequestBody{"automation", true, []int{1}, nil}},
+			routes.Documentation{
+				Summary:     "create an api key",
+				Description: "Creates an API key which can be used in place of a token in the Authorization header. A read-only key can only be used to get data. A key with AWS accounts can only access those accounts. The key is returned only once.",
+			},
+		),
+		http.MethodDelete: routes.H(revokeApiKey).With(
+			RequireAuthenticatedUser{ViewerCannot, ApiKeyCannot},
+			routes.QueryArgs{routes.ApiKeyIdQueryArg},
+			routes.Documentation{
+				Summary:     "revoke an api key",
+				Description: "Revokes an API key, which cannot be used anymore.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary: "manage the user's api keys",
+		},
+	).Register("/user/apikeys")
+}
+
+// getApiKeys is a route handler which returns the user's API keys.
+func getApiKeys(r *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[AuthenticatedUser].(User)
+	tx := a[db.Transaction].(*sql.Tx)
+	if apiKeys, err := GetApiKeysByUser(r.Context(), tx, user); err != nil {
+		return http.StatusInternalServerError, err
+	} else {
+		return http.StatusOK, apiKeys
+	}
+}
+
+// createApiKey is a route handler which creates an API key for the user.
+func createApiKey(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body createApiKeyRequestBody
+	routes.MustRequestBody(a, &body)
+	user := a[AuthenticatedUser].(User)
+	tx := a[db.Transaction].(*sql.Tx)
+	apiKey, key, err := CreateApiKey(r.Context(), tx, user, body.Name, body.ReadOnly, body.AwsAccounts, body.Expires)
+	switch err {
+	case nil:
+		return http.StatusOK, createApiKeyResponseBody{apiKey, key}
+	case ErrApiKeyExpired, ErrApiKeyAwsAccount:
+		return http.StatusBadRequest, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
+
+// revokeApiKey is a route handler which revokes one of the user's API keys.
+func revokeApiKey(r *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[AuthenticatedUser].(User)
+	tx := a[db.Transaction].(*sql.Tx)
+	id := a[routes.ApiKeyIdQueryArg].(int)
+	apiKey, err := RevokeApiKey(r.Context(), tx, user, id)
+	switch err {
+	case nil:
+		return http.StatusOK, apiKey
+	case ErrApiKeyNotFound:
+		return http.StatusNotFound, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
@@ -0,0 +1,120 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/trackit/trackit-server/routes"
+)
+
+func TestGenerateApiKey(t *testing.T) {
+	key, err := generateApiKey()
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	if !isApiKey(key) {
+		t.Errorf("Key '%s' should be recognized as an api key.", key)
+	}
+	if l := len(key); l != len(apiKeyPrefix)+43 {
+		t.Errorf("Key length should be %d, is %d instead.", len(apiKeyPrefix)+43, l)
+	}
+	if other, _ := generateApiKey(); other == key {
+		t.Errorf("Keys should be random, got '%s' twice.", key)
+	}
+}
+
+func TestIsApiKey(t *testing.T) {
+	if isApiKey("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.signature") {
+		t.Errorf("JWT token should not be recognized as an api key.")
+	}
+}
+
+func TestHashApiKey(t *testing.T) {
+	const key = "trackit_foo"
+	hash := hashApiKey(key)
+	if len(hash) != 64 || strings.Trim(hash, "0123456789abcdef") != "" {
+		t.Errorf("Hash should be 64 hexadecimal characters, is '%s' instead.", hash)
+	}
+	if hash != hashApiKey(key) {
+		t.Errorf("Hash should be deterministic.")
+	}
+	if hash == hashApiKey(key+"bar") {
+		t.Errorf("Different keys should have different hashes.")
+	}
+}
+
+func TestCanAccessAwsAccount(t *testing.T) {
+	tests := []struct {
+		scope    *ApiKeyScope
+		aaid     int
+		expected bool
+	}{
+		{nil, 1, true},
+		{&ApiKeyScope{}, 1, true},
+		{&ApiKeyScope{AwsAccounts: []int{1, 2}}, 2, true},
+		{&ApiKeyScope{AwsAccounts: []int{1, 2}}, 3, false},
+	}
+	for _, tt := range tests {
+		user := User{Id: 1, ApiKeyScope: tt.scope}
+		if actual := user.CanAccessAwsAccount(tt.aaid); actual != tt.expected {
+			t.Errorf("Access to AWS account %d with scope %+v should be %v, is %v instead.", tt.aaid, tt.scope, tt.expected, actual)
+		}
+	}
+}
+
+func TestIsSafeMethod(t *testing.T) {
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		if !isSafeMethod(m) {
+			t.Errorf("Method %s should be safe.", m)
+		}
+	}
+	for _, m := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if isSafeMethod(m) {
+			t.Errorf("Method %s should not be safe.", m)
+		}
+	}
+}
+
+func TestApiKeyHandling(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request, a routes.Arguments) (int, interface{}) {
+		return http.StatusOK, nil
+	}
+	tests := []struct {
+		handling apiKeyHandling
+		scope    *ApiKeyScope
+		method   string
+		expected int
+	}{
+		{ApiKeyCannot, nil, http.MethodPatch, http.StatusOK},
+		{ApiKeyCannot, &ApiKeyScope{}, http.MethodGet, http.StatusForbidden},
+		{ApiKeyCannot, &ApiKeyScope{}, http.MethodPatch, http.StatusForbidden},
+		{ApiKeyAllowed, &ApiKeyScope{}, http.MethodPatch, http.StatusOK},
+		{ApiKeyAllowed, &ApiKeyScope{ReadOnly: true}, http.MethodGet, http.StatusOK},
+		{ApiKeyAllowed, &ApiKeyScope{ReadOnly: true}, http.MethodPatch, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		d := RequireAuthenticatedUser{ViewerAsSelf, tt.handling}
+		user := User{Id: 1, ApiKeyScope: tt.scope}
+		r := httptest.NewRequest(tt.method, "/", nil)
+		status, _ := d.handleWithAuthenticatedUser(user, nil, handler, httptest.NewRecorder(), r, routes.Arguments{})
+		if status != tt.expected {
+			t.Errorf("%s with scope %+v and handling %d should respond %d, responded %d instead.", tt.method, tt.scope, tt.handling, tt.expected, status)
+		}
+	}
+}
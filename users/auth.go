@@ -15,23 +15,33 @@
 package users
 
 import (
+	"crypto/ecdsa"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/trackit/jsonlog"
 	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	bCryptCost = 12
+	// signingKeyExtension is the extension of the files signing keys are
+	// read from. The rest of the file name is the key's ID.
+	signingKeyExtension = ".pem"
 )
 
 var (
 	jwtIssuer                  = config.AuthIssuer
-	jwtSecret                  = []byte(config.AuthSecret)
+	signingKeys                = mustLoadSigningKeys()
 	ErrInvalidClaims           = errors.New("claims are invalid")
 	ErrCannotReadToken         = errors.New("failed to read token")
 	ErrMissingToken            = errors.New("missing or duplicate token")
@@ -50,35 +60,143 @@ func passwordMatchesHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
+// signingKey is a key JWT tokens are signed or verified with. Keys of which
+// only the public part is known cannot sign tokens.
+type signingKey struct {
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// signingKeySet is the set of keys JWT tokens are verified with, by key ID,
+// along with the ID of the key new tokens are signed with. Keys are rotated by
+// adding a new key, signing with it and removing the previous one once the
+// tokens it signed have expired.
+type signingKeySet struct {
+	keys    map[string]signingKey
+	current string
+}
+
+// mustLoadSigningKeys loads the signing keys from the configuration, and
+// exits if they cannot be loaded.
+func mustLoadSigningKeys() signingKeySet {
+	ks, err := loadSigningKeys(config.AuthKeys, config.AuthSigningKey, config.AuthSecret)
+	if err != nil {
+		jsonlog.DefaultLogger.Error("Failed to load JWT signing keys.", err.Error())
+		os.Exit(1)
+	}
+	return ks
+}
+
+// loadSigningKeys loads the signing keys from a directory. If there is none,
+// the secret is used to sign tokens with HS256 and no key ID.
+func loadSigningKeys(dir, current, secret string) (signingKeySet, error) {
+	if dir == "" {
+		return signingKeySet{
+			keys: map[string]signingKey{"": {jwt.SigningMethodHS256, []byte(secret), []byte(secret)}},
+		}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+signingKeyExtension))
+	if err != nil {
+		return signingKeySet{}, err
+	}
+	ks := signingKeySet{keys: make(map[string]signingKey), current: current}
+	for _, f := range files {
+		kid := strings.TrimSuffix(filepath.Base(f), signingKeyExtension)
+		if data, err := ioutil.ReadFile(f); err != nil {
+			return ks, err
+		} else if key, err := parseSigningKey(data); err != nil {
+			return ks, fmt.Errorf("key %s: %s", kid, err.Error())
+		} else {
+			ks.keys[kid] = key
+		}
+	}
+	if ks.current == "" && len(ks.keys) == 1 {
+		for kid := range ks.keys {
+			ks.current = kid
+		}
+	}
+	if key, ok := ks.keys[ks.current]; !ok {
+		return ks, fmt.Errorf("signing key '%s' not found in %s", ks.current, dir)
+	} else if key.sign == nil {
+		return ks, fmt.Errorf("signing key '%s' is a public key", ks.current)
+	}
+	return ks, nil
+}
+
+// parseSigningKey parses a PEM encoded RSA or ECDSA key, either private or
+// public.
+func parseSigningKey(data []byte) (signingKey, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return signingKey{jwt.SigningMethodRS256, key, &key.PublicKey}, nil
+	} else if key, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+		return ecdsaSigningKey(key, &key.PublicKey)
+	} else if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return signingKey{jwt.SigningMethodRS256, nil, key}, nil
+	} else if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return ecdsaSigningKey(nil, key)
+	}
+	return signingKey{}, errors.New("not a PEM encoded RSA or ECDSA key")
+}
+
+func ecdsaSigningKey(private *ecdsa.PrivateKey, public *ecdsa.PublicKey) (signingKey, error) {
+	var sign interface{}
+	if private != nil {
+		sign = private
+	}
+	switch public.Curve.Params().BitSize {
+	case 256:
+		return signingKey{jwt.SigningMethodES256, sign, public}, nil
+	case 384:
+		return signingKey{jwt.SigningMethodES384, sign, public}, nil
+	case 521:
+		return signingKey{jwt.SigningMethodES512, sign, public}, nil
+	default:
+		return signingKey{}, errors.New("unsupported elliptic curve")
+	}
+}
+
 // jwtClaims represents the JWT claims used by this software, as a structure.
 type jwtClaims struct {
 	Issuer    string `json:"iss"`
 	NotBefore int64  `json:"nbf"`
 	Expires   int64  `json:"exp"`
 	Subject   int    `json:"sub"`
+	Session   int    `json:"sid"`
 	User      User   `json:"usr"`
 	jwt.StandardClaims
 }
 
-// generateToken generates a valid JWT token for a given user.
-func generateToken(user User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+// generateToken generates a valid JWT access token for a given user's
+// session. It is signed with the current signing key, whose ID is set in the
+// token's header.
+func generateToken(user User, sessionId int, now time.Time) (string, error) {
+	key := signingKeys.keys[signingKeys.current]
+	token := jwt.NewWithClaims(key.method, jwtClaims{
 		Issuer:    jwtIssuer,
-		NotBefore: time.Now().Add(-1 * time.Hour).Unix(),
-		Expires:   time.Now().Add(60 * 24 * time.Hour).Unix(),
+		NotBefore: now.Add(-1 * time.Hour).Unix(),
+		Expires:   now.Add(config.AuthAccessTokenTtl).Unix(),
 		Subject:   user.Id,
+		Session:   sessionId,
 		User:      user,
 	})
-	return token.SignedString([]byte(jwtSecret))
+	if signingKeys.current != "" {
+		token.Header["kid"] = signingKeys.current
+	}
+	return token.SignedString(key.sign)
 }
 
 // getTokenSigningKey is used by jwt-go to check whether a token is acceptable
-// before verifying it.
+// before verifying it. The key is chosen from the token's key ID, and must
+// be of the method the token was signed with.
 func getTokenSigningKey(token *jwt.Token) (interface{}, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := signingKeys.keys[kid]; !ok {
+		return nil, fmt.Errorf("Unknown key ID: %v.", token.Header["kid"])
+	} else if token.Method.Alg() != key.method.Alg() {
 		return nil, fmt.Errorf("Unexpected signing method: %v.", token.Header["alg"])
 	} else {
-		return jwtSecret, nil
+		return key.verify, nil
 	}
 }
 
@@ -86,7 +204,19 @@ func getTokenSigningKey(token *jwt.Token) (interface{}, error) {
 // valid.
 func areClaimsValid(claims jwtClaims) bool {
 	now := time.Now().Unix()
-	return claims.Issuer == jwtIssuer && claims.NotBefore <= now && now < claims.Expires
+	return claims.Issuer == jwtIssuer && claims.NotBefore <= now && now < claims.Expires && claims.Session != 0
+}
+
+// isSessionValid checks whether the session of a JWT token was neither
+// revoked nor expired.
+func isSessionValid(tx *sql.Tx, claims jwtClaims) (bool, error) {
+	session, err := models.UserSessionByID(tx, claims.Session)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return session.UserID == claims.Subject && !session.Revoked.Valid && time.Now().Before(session.Expires), nil
 }
 
 // testToken checks whether a JWT token is valid and retrieves the owning User
@@ -96,14 +226,19 @@ func testToken(tx *sql.Tx, tokenString string) (User, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwtClaims{}, getTokenSigningKey)
 	if err == nil {
 		if claims, ok := token.Claims.(*jwtClaims); ok && token.Valid {
-			if areClaimsValid(*claims) {
+			if !areClaimsValid(*claims) {
+				err = ErrInvalidClaims
+			} else if valid, sessionErr := isSessionValid(tx, *claims); sessionErr != nil {
+				err = sessionErr
+			} else if !valid {
+				err = ErrInvalidClaims
+			} else {
 				userId := claims.Subject
 				user, err = GetUserWithId(tx, userId)
+				user.SessionId = claims.Session
 				if !user.AwsCustomerEntitlement {
 					err = ErrMarketplaceInvalidToken
 				}
-			} else {
-				err = ErrInvalidClaims
 			}
 		} else {
 			err = ErrCannotReadToken
@@ -0,0 +1,221 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// writeKeys writes PEM encoded keys to a temporary directory, by key ID.
+func writeKeys(t *testing.T, keys map[string]*pem.Block) string {
+	dir, err := ioutil.TempDir("", "trackit-keys")
+	if err != nil {
+		t.Fatalf("Failed to create key directory: %s", err.Error())
+	}
+	for kid, block := range keys {
+		if err := ioutil.WriteFile(filepath.Join(dir, kid+signingKeyExtension), pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatalf("Failed to write key: %s", err.Error())
+		}
+	}
+	return dir
+}
+
+func rsaKeyBlocks(t *testing.T) (*pem.Block, *pem.Block) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %s", err.Error())
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal RSA public key: %s", err.Error())
+	}
+	return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		&pem.Block{Type: "PUBLIC KEY", Bytes: public}
+}
+
+func ecKeyBlock(t *testing.T, curve elliptic.Curve) *pem.Block {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %s", err.Error())
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal ECDSA key: %s", err.Error())
+	}
+	return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+}
+
+// withSigningKeys runs a test with a given set of signing keys.
+func withSigningKeys(ks signingKeySet, f func()) {
+	previous := signingKeys
+	signingKeys = ks
+	defer func() { signingKeys = previous }()
+	f()
+}
+
+func parseTestToken(token string) (*jwtClaims, error) {
+	claims := &jwtClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, getTokenSigningKey)
+	return claims, err
+}
+
+func TestLoadSigningKeysSecret(t *testing.T) {
+	ks, err := loadSigningKeys("", "", "secret")
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	if key := ks.keys[ks.current]; key.method != jwt.SigningMethodHS256 {
+		t.Errorf("Method should be HS256, is %s instead.", key.method.Alg())
+	}
+	withSigningKeys(ks, func() {
+		token, err := generateToken(User{Id: 1}, 2, time.Now())
+		if err != nil {
+			t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+		}
+		if claims, err := parseTestToken(token); err != nil {
+			t.Errorf("Error should be nil, is '%s' instead.", err.Error())
+		} else if claims.Subject != 1 || claims.Session != 2 {
+			t.Errorf("Claims should be for user 1 and session 2, are for %d and %d instead.", claims.Subject, claims.Session)
+		}
+	})
+}
+
+func TestLoadSigningKeys(t *testing.T) {
+	private, public := rsaKeyBlocks(t)
+	dir := writeKeys(t, map[string]*pem.Block{
+		"new":    ecKeyBlock(t, elliptic.P384()),
+		"old":    private,
+		"remote": public,
+	})
+	defer os.RemoveAll(dir)
+	ks, err := loadSigningKeys(dir, "new", "")
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	expected := map[string]jwt.SigningMethod{
+		"new":    jwt.SigningMethodES384,
+		"old":    jwt.SigningMethodRS256,
+		"remote": jwt.SigningMethodRS256,
+	}
+	for kid, method := range expected {
+		if key, ok := ks.keys[kid]; !ok {
+			t.Errorf("Key '%s' should be loaded.", kid)
+		} else if key.method != method {
+			t.Errorf("Key '%s' method should be %s, is %s instead.", kid, method.Alg(), key.method.Alg())
+		}
+	}
+	if ks.keys["remote"].sign != nil {
+		t.Errorf("Public key should not be able to sign.")
+	}
+	if _, err := loadSigningKeys(dir, "remote", ""); err == nil {
+		t.Errorf("Signing with a public key should fail.")
+	}
+	if _, err := loadSigningKeys(dir, "missing", ""); err == nil {
+		t.Errorf("Signing with a missing key should fail.")
+	}
+	if _, err := loadSigningKeys(dir, "", ""); err == nil {
+		t.Errorf("Signing key should be required when there are several keys.")
+	}
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	private, _ := rsaKeyBlocks(t)
+	dir := writeKeys(t, map[string]*pem.Block{
+		"old": private,
+		"new": ecKeyBlock(t, elliptic.P256()),
+	})
+	defer os.RemoveAll(dir)
+	oldKeys, err := loadSigningKeys(dir, "old", "")
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	newKeys, err := loadSigningKeys(dir, "new", "")
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	var token string
+	withSigningKeys(oldKeys, func() {
+		token, err = generateToken(User{Id: 1}, 1, time.Now())
+	})
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	withSigningKeys(newKeys, func() {
+		if _, err := parseTestToken(token); err != nil {
+			t.Errorf("Token signed with the previous key should be valid, got '%s'.", err.Error())
+		}
+		delete(newKeys.keys, "old")
+		if _, err := parseTestToken(token); err == nil {
+			t.Errorf("Token signed with a removed key should be invalid.")
+		}
+	})
+}
+
+func TestRejectsSigningMethodMismatch(t *testing.T) {
+	private, public := rsaKeyBlocks(t)
+	dir := writeKeys(t, map[string]*pem.Block{"rsa": private})
+	defer os.RemoveAll(dir)
+	ks, err := loadSigningKeys(dir, "", "")
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{Subject: 1})
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString(pem.EncodeToMemory(public))
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	withSigningKeys(ks, func() {
+		if _, err := parseTestToken(forged); err == nil {
+			t.Errorf("Token signed with HS256 should be rejected for an RSA key.")
+		}
+	})
+}
+
+func TestAreClaimsValid(t *testing.T) {
+	now := time.Now()
+	valid := jwtClaims{
+		Issuer:    jwtIssuer,
+		NotBefore: now.Add(-time.Minute).Unix(),
+		Expires:   now.Add(time.Minute).Unix(),
+		Subject:   1,
+		Session:   1,
+	}
+	if !areClaimsValid(valid) {
+		t.Errorf("Claims should be valid.")
+	}
+	expired := valid
+	expired.Expires = now.Add(-time.Second).Unix()
+	if areClaimsValid(expired) {
+		t.Errorf("Expired claims should be invalid.")
+	}
+	sessionless := valid
+	sessionless.Session = 0
+	if areClaimsValid(sessionless) {
+		t.Errorf("Claims without a session should be invalid.")
+	}
+}
@@ -55,7 +55,7 @@ func init() {
 			},
 		),
 		http.MethodPatch: routes.H(patchUser).With(
-			RequireAuthenticatedUser{ViewerAsSelf},
+			RequireAuthenticatedUser{ViewerAsSelf, ApiKeyCannot},
 			routes.RequestContentType{"application/json"},
 			routes.RequestBody{createUserRequestBody{"example@example.com", "pa55w0rd", "marketplacetoken"}},
 			routes.Documentation{
@@ -64,7 +64,7 @@ func init() {
 			},
 		),
 		http.MethodGet: routes.H(me).With(
-			RequireAuthenticatedUser{ViewerAsSelf},
+			RequireAuthenticatedUser{ViewerAsSelf, ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "get the current user",
 				Description: "Responds with the currently authenticated user's data.",
@@ -80,7 +80,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodPost: routes.H(createViewerUser).With(
 			routes.RequestContentType{"application/json"},
-			RequireAuthenticatedUser{ViewerCannot},
+			RequireAuthenticatedUser{ViewerCannot, ApiKeyCannot},
 			routes.RequestBody{createViewerUserRequestBody{"example@example.com"}},
 			routes.Documentation{
 				Summary:     "register a new viewer user",
@@ -88,7 +88,7 @@ func init() {
 			},
 		),
 		http.MethodGet: routes.H(getViewerUsers).With(
-			RequireAuthenticatedUser{ViewerAsParent},
+			RequireAuthenticatedUser{ViewerAsParent, ApiKeyCannot},
 			routes.Documentation{
 				Summary:     "list viewer users",
 				Description: "Lists the viewer users registered for the current account.",
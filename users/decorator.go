@@ -27,10 +27,12 @@ import (
 
 type RequireAuthenticatedUser struct {
 	ViewerHandling viewerHandling
+	ApiKeyHandling apiKeyHandling
 }
 
 type authenticatedUserArgumentKey uint
 type viewerHandling uint
+type apiKeyHandling uint
 
 const (
 	AuthenticatedUser            = authenticatedUserArgumentKey(iota)
@@ -43,6 +45,22 @@ const (
 	ViewerAsParent
 )
 
+// API keys are refused unless a route opts in with ApiKeyAllowed. Routes
+// which manage credentials or users must never do so.
+const (
+	ApiKeyCannot = apiKeyHandling(iota)
+	ApiKeyAllowed
+)
+
+func init() {
+	routes.RegisterSecurityScheme(TagRequireUserAuthentication, "userToken", routes.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "Authorization",
+		Description: "The token returned by /user/login or an API key created with /user/apikeys, sent as is.",
+	})
+}
+
 func (d RequireAuthenticatedUser) Decorate(h routes.Handler) routes.Handler {
 	h.Func = d.getFunc(h.Func)
 	h.Documentation = d.getDocumentation(h.Documentation)
@@ -56,13 +74,12 @@ func (d RequireAuthenticatedUser) getFunc(hf routes.HandlerFunc) routes.HandlerF
 		tx := a[db.Transaction].(*sql.Tx)
 		if auth != nil && len(auth) == 1 {
 			tokenString := auth[0]
-			if user, err := testToken(tx, tokenString); err == nil {
+			if user, err := authenticate(tx, tokenString); err == nil {
 				return d.handleWithAuthenticatedUser(user, tx, hf, w, r, a)
-			} else if err != ErrCannotReadToken && err != ErrInvalidClaims && err != ErrMarketplaceInvalidToken{
+			} else if err != ErrCannotReadToken && err != ErrInvalidClaims && err != ErrMarketplaceInvalidToken && err != ErrInvalidApiKey {
 				logger.Error("Abnormal authentication failure.", map[string]interface{}{
 					"error": err.Error(),
 					"user":  user.Email,
-					"token": tokenString,
 				})
 				return http.StatusInternalServerError, ErrFailedToValidateToken
 			} else {
@@ -74,16 +91,33 @@ func (d RequireAuthenticatedUser) getFunc(hf routes.HandlerFunc) routes.HandlerF
 	}
 }
 
+// authenticate authenticates a user with either an API key or a JWT token.
+func authenticate(tx *sql.Tx, tokenString string) (User, error) {
+	if isApiKey(tokenString) {
+		return testApiKey(tx, tokenString)
+	}
+	return testToken(tx, tokenString)
+}
+
 func (d RequireAuthenticatedUser) handleWithAuthenticatedUser(user User, tx *sql.Tx, hf routes.HandlerFunc, w http.ResponseWriter, r *http.Request, a routes.Arguments) (int, interface{}) {
+	if user.ApiKeyScope != nil {
+		if d.ApiKeyHandling != ApiKeyAllowed {
+			return http.StatusForbidden, ErrApiKeyForbidden
+		} else if user.ApiKeyScope.ReadOnly && !isSafeMethod(r.Method) {
+			return http.StatusForbidden, ErrApiKeyReadOnly
+		}
+	}
 	switch d.ViewerHandling {
 	case ViewerAsParent:
 		if user.ParentId != nil {
 			var err error
+			scope := user.ApiKeyScope
 			user, err = GetUserParent(r.Context(), tx, user)
 			if err != nil {
 				jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to get viewer user parent.", err.Error())
 				return http.StatusInternalServerError, errors.New("Failed to get viewer user parent.")
 			}
+			user.ApiKeyScope = scope
 		}
 	case ViewerCannot:
 		if user.ParentId != nil {
@@ -32,8 +32,8 @@ type loginRequestBody struct {
 
 // loginResponseBody is the response body in case LogIn succeeds.
 type loginResponseBody struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User User `json:"user"`
+	Session
 }
 
 func init() {
@@ -44,7 +44,7 @@ func init() {
 			db.RequestTransaction{db.Db},
 			routes.Documentation{
 				Summary:     "log in as a user",
-				Description: "Logs a user in based on an e-mail/password couple and returns a JWT token and the user's data.",
+				Description: "Logs a user in based on an e-mail/password couple and returns a short-lived JWT token, a refresh token and the user's data.",
 			},
 		),
 	}.H().Register("/user/login")
@@ -69,7 +69,7 @@ func logInWithValidBody(request *http.Request, body loginRequestBody, tx *sql.Tx
 			logger.Warning("AWS entitlement failure.", user)
 			return 403, errors.New("Please check your AWS marketplace subscription.")
 		} else {
-			return logAuthenticatedUserIn(request, user)
+			return logAuthenticatedUserIn(request, tx, user)
 		}
 	} else {
 		logger.Warning("Authentication failure.", struct {
@@ -79,20 +79,19 @@ func logInWithValidBody(request *http.Request, body loginRequestBody, tx *sql.Tx
 	}
 }
 
-// logAuthenticatedUserIn generates a token for a user that's already been
+// logAuthenticatedUserIn opens a session for a user that's already been
 // authenticated.
-func logAuthenticatedUserIn(request *http.Request, user User) (int, interface{}) {
+func logAuthenticatedUserIn(request *http.Request, tx *sql.Tx, user User) (int, interface{}) {
 	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
-	token, err := generateToken(user)
+	session, err := CreateSession(request.Context(), tx, user)
 	if err == nil {
 		logger.Info("User logged in.", user)
 		return 200, loginResponseBody{
-			User:  user,
-			Token: token,
+			User:    user,
+			Session: session,
 		}
 	} else {
-		logger.Error("Failed to generate token.", err.Error())
-		return 500, errors.New("Failed to generate token.")
+		return 500, err
 	}
 }
 
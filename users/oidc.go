@@ -0,0 +1,778 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/models"
+)
+
+const (
+	// oidcLoginTtl is how long a user has to log in with the OpenID Connect
+	// provider once the login started.
+	oidcLoginTtl = 10 * time.Minute
+	// oidcClockSkew is the clock difference tolerated with the OpenID
+	// Connect provider when checking ID tokens.
+	oidcClockSkew = time.Minute
+	// oidcKeysMinRefresh is the minimum delay between two retrievals of the
+	// provider's keys, which are retrieved again when a token is signed
+	// with an unknown key.
+	oidcKeysMinRefresh = time.Minute
+	// oidcHttpTimeout is the timeout of requests to the provider.
+	oidcHttpTimeout = 10 * time.Second
+)
+
+var (
+	ErrOidcDisabled         = errors.New("single sign-on is not configured")
+	ErrOidcInvalidState     = errors.New("invalid or expired login state")
+	ErrOidcExchangeFailed   = errors.New("failed to exchange the authorization code")
+	ErrOidcInvalidToken     = errors.New("invalid id token")
+	ErrOidcProvider         = errors.New("failed to reach the identity provider")
+	ErrOidcEmailNotVerified = errors.New("email address is not verified by the identity provider")
+	ErrOidcDomainNotAllowed = errors.New("email domain is not allowed to log in")
+	ErrOidcNoRole           = errors.New("user is not a member of any allowed group")
+	ErrOidcRoleMismatch     = errors.New("user's role does not match their groups")
+	ErrOidcFailedLogin      = errors.New("failed to log in with single sign-on")
+)
+
+// oidc is the OpenID Connect provider users can log in with, or nil if
+// single sign-on is disabled.
+var oidc = mustLoadOidcProvider()
+
+// oidcRole is the role given to users logging in with single sign-on.
+type oidcRole int
+
+const (
+	oidcRoleNone oidcRole = iota
+	oidcRoleOwner
+	oidcRoleViewer
+)
+
+// oidcProvider is an OpenID Connect provider users log in with using the
+// authorization code flow with PKCE.
+type oidcProvider struct {
+	issuer         string
+	clientId       string
+	clientSecret   string
+	redirectUrl    string
+	scopes         string
+	allowedDomains []string
+	groupsClaim    string
+	ownerGroups    []string
+	viewerGroups   []string
+	viewerParent   string
+	client         *http.Client
+
+	mutex       sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// oidcDiscovery is the part of the provider's configuration document used to
+// log users in.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// jsonWebKey is a public key of the provider, as found in its key set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// oidcAudience is the audience of an ID token, which is either a string or
+// an array of strings.
+type oidcAudience []string
+
+// oidcClaims are the claims of an ID token used to log a user in.
+type oidcClaims struct {
+	Issuer          string       `json:"iss"`
+	Subject         string       `json:"sub"`
+	Audience        oidcAudience `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	Expires         int64        `json:"exp"`
+	IssuedAt        int64        `json:"iat"`
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   *bool        `json:"email_verified"`
+	raw             map[string]json.RawMessage
+}
+
+// splitList splits a comma-separated configuration list, ignoring empty
+// items.
+func splitList(list string) []string {
+	var res []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// mustLoadOidcProvider builds the OpenID Connect provider from the
+// configuration, and exits if the configuration is inconsistent.
+func mustLoadOidcProvider() *oidcProvider {
+	if config.OidcIssuer == "" {
+		return nil
+	}
+	p := &oidcProvider{
+		issuer:         strings.TrimSuffix(config.OidcIssuer, "/"),
+		clientId:       config.OidcClientId,
+		clientSecret:   config.OidcClientSecret,
+		redirectUrl:    config.OidcRedirectUrl,
+		scopes:         config.OidcScopes,
+		allowedDomains: splitList(config.OidcAllowedDomains),
+		groupsClaim:    config.OidcGroupsClaim,
+		ownerGroups:    splitList(config.OidcOwnerGroups),
+		viewerGroups:   splitList(config.OidcViewerGroups),
+		viewerParent:   config.OidcViewerParent,
+		client:         &http.Client{Timeout: oidcHttpTimeout},
+	}
+	var err error
+	if p.clientId == "" || p.redirectUrl == "" {
+		err = errors.New("client ID and redirect URL are required")
+	} else if len(p.viewerGroups) > 0 && p.viewerParent == "" {
+		err = errors.New("viewer groups require a viewer parent")
+	}
+	if err != nil {
+		jsonlog.DefaultLogger.Error("Invalid OpenID Connect configuration.", err.Error())
+		os.Exit(1)
+	}
+	return p
+}
+
+// UnmarshalJSON fulfills json.Unmarshaler.
+func (a *oidcAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = oidcAudience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = oidcAudience(multiple)
+	return nil
+}
+
+// contains tells whether a client is part of the audience.
+func (a oidcAudience) contains(clientId string) bool {
+	for _, aud := range a {
+		if aud == clientId {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON fulfills json.Unmarshaler. All claims are kept so that the
+// groups claim can be configured.
+func (c *oidcClaims) UnmarshalJSON(data []byte) error {
+	type plainOidcClaims oidcClaims
+	if err := json.Unmarshal(data, (*plainOidcClaims)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.raw)
+}
+
+// Valid fulfills jwt.Claims. Claims are checked by oidcProvider.checkClaims
+// since they depend on the login.
+func (c oidcClaims) Valid() error {
+	return nil
+}
+
+// groups returns the groups listed in a claim, which is either a string or an
+// array of strings.
+func (c oidcClaims) groups(claim string) []string {
+	var groups oidcAudience
+	if raw, ok := c.raw[claim]; !ok || json.Unmarshal(raw, &groups) != nil {
+		return nil
+	}
+	return []string(groups)
+}
+
+// randomString generates a random URL safe string.
+func randomString() (string, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random[:]), nil
+}
+
+// pkceChallenge computes the S256 PKCE challenge of a code verifier.
+func pkceChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// getJson retrieves a JSON document from the provider.
+func (p *oidcProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", url, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// getDiscovery retrieves the configuration of the provider, which is only
+// retrieved once.
+func (p *oidcProvider) getDiscovery(ctx context.Context) (oidcDiscovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.discovery != nil {
+		return *p.discovery, nil
+	}
+	var discovery oidcDiscovery
+	if err := p.getJson(ctx, p.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return discovery, err
+	} else if discovery.Issuer != p.issuer {
+		return discovery, fmt.Errorf("discovered issuer '%s' does not match '%s'", discovery.Issuer, p.issuer)
+	}
+	p.discovery = &discovery
+	return discovery, nil
+}
+
+// publicKey decodes a key from the provider's key set.
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		return new(big.Int).SetBytes(b), err
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+	}
+}
+
+// getKey returns a signing key of the provider by ID. The provider's keys
+// are retrieved again when the key is unknown, since the provider may have
+// rotated its keys. A token without key ID can only be verified if the
+// provider has a single key.
+func (p *oidcProvider) getKey(ctx context.Context, kid string) (interface{}, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	} else if time.Since(p.keysFetched) < oidcKeysMinRefresh {
+		return nil, fmt.Errorf("unknown key '%s'", kid)
+	}
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJson(ctx, discovery.JwksUri, &keySet); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]interface{})
+	p.keysFetched = time.Now()
+	for _, k := range keySet.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		} else if key, err := k.publicKey(); err == nil {
+			p.keys[k.Kid] = key
+		}
+	}
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key '%s'", kid)
+}
+
+func (p *oidcProvider) findKey(kid string) interface{} {
+	if key, ok := p.keys[kid]; ok {
+		return key
+	} else if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// keyMatchesMethod tells whether a token signed with a method can be
+// verified with a key. It prevents tokens signed with an unexpected method,
+// such as HMAC with the public key as secret, from being accepted.
+func keyMatchesMethod(key interface{}, method jwt.SigningMethod) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		k, ok := key.(*ecdsa.PublicKey)
+		return ok && k.Curve.Params().BitSize == m.CurveBits
+	default:
+		return false
+	}
+}
+
+// authorizationUrl builds the URL a user logs in at.
+func (p *oidcProvider) authorizationUrl(discovery oidcDiscovery, state, nonce, verifier string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientId},
+		"redirect_uri":          {p.redirectUrl},
+		"scope":                 {p.scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// exchangeCode exchanges an authorization code for an ID token, proving with
+// the PKCE code verifier the login was started by this server.
+func (p *oidcProvider) exchangeCode(ctx context.Context, code, verifier string) (string, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		logger.Error("Failed to retrieve OpenID Connect configuration.", err.Error())
+		return "", ErrOidcProvider
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectUrl},
+		"client_id":     {p.clientId},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		logger.Error("Failed to build token request.", err.Error())
+		return "", ErrOidcProvider
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientId), url.QueryEscape(p.clientSecret))
+	}
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		logger.Error("Failed to request token.", err.Error())
+		return "", ErrOidcProvider
+	}
+	defer res.Body.Close()
+	var body struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil || res.StatusCode >= 500 {
+		logger.Error("Invalid token response.", map[string]interface{}{"status": res.StatusCode})
+		return "", ErrOidcProvider
+	} else if res.StatusCode != http.StatusOK || body.IdToken == "" {
+		logger.Warning("Authorization code exchange refused.", map[string]interface{}{
+			"status":      res.StatusCode,
+			"error":       body.Error,
+			"description": body.ErrorDescription,
+		})
+		return "", ErrOidcExchangeFailed
+	}
+	return body.IdToken, nil
+}
+
+// verifyIdToken verifies the signature and claims of an ID token issued for a
+// login.
+func (p *oidcProvider) verifyIdToken(ctx context.Context, idToken, nonce string, now time.Time) (oidcClaims, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	var claims oidcClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.getKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		} else if !keyMatchesMethod(key, token.Method) {
+			return nil, fmt.Errorf("unexpected signing method '%v'", token.Header["alg"])
+		}
+		return key, nil
+	})
+	if err == nil {
+		err = p.checkClaims(claims, nonce, now)
+	}
+	if err != nil {
+		logger.Warning("Invalid ID token.", err.Error())
+		return claims, ErrOidcInvalidToken
+	}
+	return claims, nil
+}
+
+// checkClaims checks an ID token was issued by the provider for this client
+// and this login, and is currently valid.
+func (p *oidcProvider) checkClaims(claims oidcClaims, nonce string, now time.Time) error {
+	if claims.Issuer != p.issuer {
+		return fmt.Errorf("unexpected issuer '%s'", claims.Issuer)
+	} else if !claims.Audience.contains(p.clientId) {
+		return errors.New("token was not issued for this client")
+	} else if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientId {
+		return errors.New("token was not issued to this client")
+	} else if now.Add(-oidcClockSkew).Unix() >= claims.Expires {
+		return errors.New("token is expired")
+	} else if now.Add(oidcClockSkew).Unix() < claims.IssuedAt {
+		return errors.New("token is issued in the future")
+	} else if claims.Nonce != nonce {
+		return errors.New("nonce does not match")
+	} else if claims.Subject == "" {
+		return errors.New("missing subject")
+	}
+	return nil
+}
+
+// isDomainAllowed tells whether users with an email address can log in.
+func (p *oidcProvider) isDomainAllowed(email string) bool {
+	if len(p.allowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	for _, domain := range p.allowedDomains {
+		if strings.EqualFold(email[at+1:], domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapsGroups tells whether the role of users depends on their groups.
+func (p *oidcProvider) mapsGroups() bool {
+	return len(p.ownerGroups) > 0 || len(p.viewerGroups) > 0
+}
+
+// role returns the role of a user given its groups. Without group mapping,
+// all users are owners.
+func (p *oidcProvider) role(groups []string) oidcRole {
+	if !p.mapsGroups() {
+		return oidcRoleOwner
+	}
+	isMember := func(allowed []string) bool {
+		for _, group := range groups {
+			for _, a := range allowed {
+				if group == a {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if isMember(p.ownerGroups) {
+		return oidcRoleOwner
+	} else if isMember(p.viewerGroups) {
+		return oidcRoleViewer
+	}
+	return oidcRoleNone
+}
+
+// oidcStateBinding returns the value binding a login's state to the browser
+// which started the login. It is kept in a cookie so that a user cannot be
+// made to complete a login started by someone else.
+func oidcStateBinding(state string) string {
+	return hashApiKey(state)
+}
+
+// isOidcStateBound tells whether a state is bound to the browser the binding
+// was sent by.
+func isOidcStateBound(state, binding string) bool {
+	return binding != "" && subtle.ConstantTimeCompare([]byte(oidcStateBinding(state)), []byte(binding)) == 1
+}
+
+// StartOidcLogin starts logging a user in with the OpenID Connect provider.
+// It returns the URL the user logs in at, which redirects the user to the
+// configured redirect URL with a code and a state to complete the login, and
+// the binding of the state to keep in the user's browser.
+func StartOidcLogin(ctx context.Context, tx *sql.Tx) (string, string, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if oidc == nil {
+		return "", "", ErrOidcDisabled
+	}
+	discovery, err := oidc.getDiscovery(ctx)
+	if err != nil {
+		logger.Error("Failed to retrieve OpenID Connect configuration.", err.Error())
+		return "", "", ErrOidcProvider
+	}
+	state, err := randomString()
+	if err != nil {
+		logger.Error("Failed to generate state.", err.Error())
+		return "", "", ErrOidcFailedLogin
+	}
+	nonce, err := randomString()
+	if err != nil {
+		logger.Error("Failed to generate nonce.", err.Error())
+		return "", "", ErrOidcFailedLogin
+	}
+	verifier, err := randomString()
+	if err != nil {
+		logger.Error("Failed to generate code verifier.", err.Error())
+		return "", "", ErrOidcFailedLogin
+	}
+	now := time.Now()
+	if err := models.DeleteExpiredOidcLogins(tx, now); err != nil {
+		logger.Error("Failed to delete expired OpenID Connect logins.", err.Error())
+		return "", "", ErrOidcFailedLogin
+	}
+	login := models.OidcLogin{
+		StateHash:    hashApiKey(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		Created:      now,
+		Expires:      now.Add(oidcLoginTtl),
+	}
+	if err := login.Insert(tx); err != nil {
+		logger.Error("Failed to insert OpenID Connect login.", err.Error())
+		return "", "", ErrOidcFailedLogin
+	}
+	return oidc.authorizationUrl(discovery, state, nonce, verifier), oidcStateBinding(state), nil
+}
+
+// CompleteOidcLogin completes the login of a user with the code and state the
+// OpenID Connect provider redirected the user with, and the binding of the
+// state found in the user's browser. Users are created the first time they
+// log in, and users with the same email address are linked to their identity
+// if the provider verified it. The boolean is true if the user was created.
+func CompleteOidcLogin(ctx context.Context, tx *sql.Tx, code, state, binding string) (User, bool, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if oidc == nil {
+		return User{}, false, ErrOidcDisabled
+	} else if !isOidcStateBound(state, binding) {
+		logger.Warning("OpenID Connect state is not bound to the browser.", nil)
+		return User{}, false, ErrOidcInvalidState
+	}
+	login, err := models.OidcLoginByStateHash(tx, hashApiKey(state))
+	if err == sql.ErrNoRows {
+		return User{}, false, ErrOidcInvalidState
+	} else if err != nil {
+		logger.Error("Failed to retrieve OpenID Connect login.", err.Error())
+		return User{}, false, ErrOidcFailedLogin
+	}
+	// The login is deleted outside of the request's transaction, which is
+	// rolled back if the login fails, so that its state cannot be replayed.
+	if deleted, err := models.DeleteOidcLoginByStateHash(db.Db, login.StateHash); err != nil {
+		logger.Error("Failed to delete OpenID Connect login.", err.Error())
+		return User{}, false, ErrOidcFailedLogin
+	} else if !deleted {
+		return User{}, false, ErrOidcInvalidState
+	}
+	now := time.Now()
+	if !now.Before(login.Expires) {
+		return User{}, false, ErrOidcInvalidState
+	}
+	idToken, err := oidc.exchangeCode(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return User{}, false, err
+	}
+	claims, err := oidc.verifyIdToken(ctx, idToken, login.Nonce, now)
+	if err != nil {
+		return User{}, false, err
+	} else if claims.Email == "" || (claims.EmailVerified != nil && !*claims.EmailVerified) {
+		return User{}, false, ErrOidcEmailNotVerified
+	} else if !oidc.isDomainAllowed(claims.Email) {
+		return User{}, false, ErrOidcDomainNotAllowed
+	}
+	role := oidc.role(claims.groups(oidc.groupsClaim))
+	if role == oidcRoleNone {
+		return User{}, false, ErrOidcNoRole
+	}
+	return oidc.provisionUser(ctx, tx, claims, role)
+}
+
+// provisionUser returns the user an identity of the provider belongs to. If
+// there is none, the identity is linked to the user with the same email
+// address, who is created with the given role if needed. Existing users must
+// have the role their groups map to.
+func (p *oidcProvider) provisionUser(ctx context.Context, tx *sql.Tx, claims oidcClaims, role oidcRole) (User, bool, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	identity, err := models.UserOidcIdentityByIssuerSubject(tx, p.issuer, claims.Subject)
+	if err == nil {
+		user, err := GetUserWithId(tx, identity.UserID)
+		if err != nil {
+			logger.Error("Failed to retrieve OpenID Connect user.", err.Error())
+			return user, false, ErrOidcFailedLogin
+		}
+		return user, false, p.checkRole(ctx, tx, user, role)
+	} else if err != sql.ErrNoRows {
+		logger.Error("Failed to retrieve OpenID Connect identity.", err.Error())
+		return User{}, false, ErrOidcFailedLogin
+	}
+	created := false
+	dbUser, err := models.UserByEmail(tx, claims.Email)
+	if err == sql.ErrNoRows {
+		dbUser, err = p.createUser(tx, claims.Email, role)
+		created = true
+	} else if err == nil && (claims.EmailVerified == nil || !*claims.EmailVerified) {
+		return User{}, false, ErrOidcEmailNotVerified
+	} else if err == nil {
+		if err := p.checkRole(ctx, tx, UserFromDbUser(*dbUser), role); err != nil {
+			return User{}, false, err
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to provision OpenID Connect user.", err.Error())
+		return User{}, false, ErrOidcFailedLogin
+	}
+	identity = &models.UserOidcIdentity{
+		UserID:  dbUser.ID,
+		Issuer:  p.issuer,
+		Subject: claims.Subject,
+		Created: time.Now(),
+	}
+	if err := identity.Insert(tx); err != nil {
+		logger.Error("Failed to insert OpenID Connect identity.", err.Error())
+		return User{}, false, ErrOidcFailedLogin
+	}
+	user := UserFromDbUser(*dbUser)
+	logger.Info("OpenID Connect identity linked.", map[string]interface{}{
+		"user":    user,
+		"created": created,
+	})
+	return user, created, nil
+}
+
+// checkRole checks a user has the role their groups map to. Without group
+// mapping, users keep the role they have.
+func (p *oidcProvider) checkRole(ctx context.Context, tx *sql.Tx, user User, role oidcRole) error {
+	if !p.mapsGroups() {
+		return nil
+	}
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	viewerParentId := 0
+	if user.ParentId != nil {
+		parent, err := models.UserByEmail(tx, p.viewerParent)
+		if err != nil && err != sql.ErrNoRows {
+			logger.Error("Failed to retrieve OpenID Connect viewer parent.", err.Error())
+			return ErrOidcFailedLogin
+		} else if err == nil {
+			viewerParentId = parent.ID
+		}
+	}
+	if current := userRole(user, viewerParentId); current != role {
+		logger.Warning("OpenID Connect user's role does not match their groups.", map[string]interface{}{
+			"user":         user,
+			"role":         current,
+			"expectedRole": role,
+		})
+		return ErrOidcRoleMismatch
+	}
+	return nil
+}
+
+// userRole returns the role of a user: owners have no parent and viewers are
+// attached to the viewer parent, whose ID is viewerParentId. Viewers of other
+// users have no role.
+func userRole(user User, viewerParentId int) oidcRole {
+	if user.ParentId == nil {
+		return oidcRoleOwner
+	} else if *user.ParentId == viewerParentId {
+		return oidcRoleViewer
+	}
+	return oidcRoleNone
+}
+
+// createUser creates a user logging in with single sign-on for the first
+// time. Such users have no password. Viewers are attached to the configured
+// viewer parent.
+func (p *oidcProvider) createUser(tx *sql.Tx, email string, role oidcRole) (*models.User, error) {
+	dbUser := models.User{
+		Email:                  email,
+		AwsCustomerEntitlement: true,
+		DisplayCurrency:        DefaultDisplayCurrency,
+	}
+	if role == oidcRoleViewer {
+		parent, err := models.UserByEmail(tx, p.viewerParent)
+		if err != nil {
+			return nil, err
+		}
+		dbUser.ParentUserID = sql.NullInt64{int64(parent.ID), true}
+		dbUser.DisplayCurrency = parent.DisplayCurrency
+	}
+	return &dbUser, dbUser.Insert(tx)
+}
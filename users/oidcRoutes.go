@@ -0,0 +1,144 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+)
+
+// oidcStateCookie is the name of the cookie binding the state of a login to
+// the browser which started it.
+const oidcStateCookie = "oidc_state"
+
+// oidcLoginResponseBody is the response body of the oidcLogIn route handler.
+type oidcLoginResponseBody struct {
+	Url string `json:"url"`
+}
+
+// oidcCallbackRequestBody is the expected request body for the oidcCallback
+// route handler. It holds the query parameters the OpenID Connect provider
+// redirected the user with.
+type oidcCallbackRequestBody struct {
+	Code  string `json:"code"  req:"nonzero"`
+	State string `json:"state" req:"nonzero"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodPost: routes.Handler{Func: oidcLogIn}.With(
+			db.RequestTransaction{db.Db},
+			routes.Documentation{
+				Summary:     "start logging in with single sign-on",
+				Description: "Returns the URL of the OpenID Connect provider the user logs in at, and sets a cookie binding the login to the browser. The provider then redirects the user to the configured redirect URL with a code and a state, to be sent to /user/oidc/callback along with the cookie.",
+			},
+		),
+	}.H().Register("/user/oidc/login")
+	routes.MethodMuxer{
+		http.MethodPost: routes.Handler{Func: oidcCallback}.With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{oidcCallbackRequestBody{"code", "state"}},
+			db.RequestTransaction{db.Db},
+			routes.Documentation{
+				Summary:     "complete logging in with single sign-on",
+				Description: "Logs a user in with the code and state the OpenID Connect provider redirected the user with, creating the user on first login. An existing user is only linked if the provider verified their email address, and users whose role no longer matches their groups are refused. Returns the same tokens as /user/login.",
+			},
+		),
+	}.H().Register("/user/oidc/callback")
+}
+
+// setOidcStateCookie sets the cookie binding the state of a login to the
+// browser. An empty binding clears the cookie. SameSite is appended by hand
+// since http.Cookie only supports it from Go 1.11.
+func setOidcStateCookie(w http.ResponseWriter, binding string) {
+	maxAge := int(oidcLoginTtl / time.Second)
+	if binding == "" {
+		maxAge = -1
+	}
+	cookie := http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    binding,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(config.OidcRedirectUrl, "https://"),
+		HttpOnly: true,
+	}
+	w.Header().Add("Set-Cookie", cookie.String()+"; SameSite=Lax")
+}
+
+// oidcLogIn is a route handler which starts logging a user in with the
+// OpenID Connect provider.
+func oidcLogIn(w http.ResponseWriter, request *http.Request, a routes.Arguments) (int, interface{}) {
+	tx := a[db.Transaction].(*sql.Tx)
+	url, binding, err := StartOidcLogin(request.Context(), tx)
+	switch err {
+	case nil:
+		setOidcStateCookie(w, binding)
+		return http.StatusOK, oidcLoginResponseBody{url}
+	case ErrOidcDisabled:
+		return http.StatusNotFound, err
+	case ErrOidcProvider:
+		return http.StatusBadGateway, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
+
+// oidcCallback is a route handler which completes logging a user in with
+// the OpenID Connect provider.
+func oidcCallback(w http.ResponseWriter, request *http.Request, a routes.Arguments) (int, interface{}) {
+	var body oidcCallbackRequestBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	var binding string
+	if cookie, err := request.Cookie(oidcStateCookie); err == nil {
+		binding = cookie.Value
+	}
+	setOidcStateCookie(w, "")
+	user, created, err := CompleteOidcLogin(request.Context(), tx, body.Code, body.State, binding)
+	switch err {
+	case nil:
+	case ErrOidcDisabled:
+		return http.StatusNotFound, err
+	case ErrOidcInvalidState, ErrOidcExchangeFailed, ErrOidcInvalidToken:
+		return http.StatusUnauthorized, err
+	case ErrOidcEmailNotVerified, ErrOidcDomainNotAllowed, ErrOidcNoRole, ErrOidcRoleMismatch:
+		logger.Warning("Single sign-on refused.", err.Error())
+		return http.StatusForbidden, err
+	case ErrOidcProvider:
+		return http.StatusBadGateway, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+	if !user.AwsCustomerEntitlement {
+		logger.Warning("AWS entitlement failure.", user)
+		return http.StatusForbidden, errors.New("Please check your AWS marketplace subscription.")
+	}
+	if created && user.ParentId == nil && config.DefaultRole != "" && config.DefaultRoleName != "" &&
+		config.DefaultRoleExternal != "" && config.DefaultRoleBucket != "" {
+		addDefaultRole(request, user, tx)
+	}
+	return logAuthenticatedUserIn(request, tx, user)
+}
@@ -0,0 +1,268 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/trackit/trackit-server/users/oidctest"
+)
+
+const (
+	testOidcClientId     = "trackit"
+	testOidcClientSecret = "secret"
+	testOidcVerifier     = "verifier-verifier-verifier-verifier-verifier"
+)
+
+func newTestOidcProvider(t *testing.T) (*oidcProvider, *oidctest.Provider) {
+	mock, err := oidctest.NewProvider(testOidcClientId, testOidcClientSecret)
+	if err != nil {
+		t.Fatalf("Failed to start mock provider: %s", err.Error())
+	}
+	return &oidcProvider{
+		issuer:       mock.URL,
+		clientId:     testOidcClientId,
+		clientSecret: testOidcClientSecret,
+		redirectUrl:  "https://trackit.example.com/sso",
+		scopes:       "openid email",
+		groupsClaim:  "groups",
+		client:       http.DefaultClient,
+	}, mock
+}
+
+// logInWithTestOidcProvider logs in at the mock provider and returns the ID
+// token obtained for the code it redirected with.
+func logInWithTestOidcProvider(t *testing.T, p *oidcProvider, mock *oidctest.Provider) string {
+	ctx := context.Background()
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		t.Fatalf("Failed to retrieve discovery document: %s", err.Error())
+	}
+	code, state, err := mock.Authorize(p.authorizationUrl(discovery, "state", "nonce", testOidcVerifier))
+	if err != nil {
+		t.Fatalf("Failed to authorize: %s", err.Error())
+	} else if state != "state" {
+		t.Errorf("State should be 'state', is '%s' instead.", state)
+	}
+	idToken, err := p.exchangeCode(ctx, code, testOidcVerifier)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %s", err.Error())
+	}
+	return idToken
+}
+
+func TestOidcAuthorizationCodeFlow(t *testing.T) {
+	p, mock := newTestOidcProvider(t)
+	defer mock.Close()
+	mock.Claims["groups"] = []string{"finance", "engineering"}
+	idToken := logInWithTestOidcProvider(t, p, mock)
+	claims, err := p.verifyIdToken(context.Background(), idToken, "nonce", time.Now())
+	if err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	}
+	if claims.Subject != "user" || claims.Email != "user@example.com" {
+		t.Errorf("Claims should be for user@example.com, are %v instead.", claims)
+	}
+	if groups := claims.groups("groups"); len(groups) != 2 || groups[1] != "engineering" {
+		t.Errorf("Groups should be [finance engineering], are %v instead.", groups)
+	}
+}
+
+func TestOidcExchangeRequiresVerifier(t *testing.T) {
+	p, mock := newTestOidcProvider(t)
+	defer mock.Close()
+	ctx := context.Background()
+	discovery, _ := p.getDiscovery(ctx)
+	code, _, err := mock.Authorize(p.authorizationUrl(discovery, "state", "nonce", testOidcVerifier))
+	if err != nil {
+		t.Fatalf("Failed to authorize: %s", err.Error())
+	}
+	if _, err := p.exchangeCode(ctx, code, testOidcVerifier+"x"); err != ErrOidcExchangeFailed {
+		t.Errorf("Error should be '%v' for a wrong verifier, is '%v' instead.", ErrOidcExchangeFailed, err)
+	}
+	if _, err := p.exchangeCode(ctx, code, testOidcVerifier); err != ErrOidcExchangeFailed {
+		t.Errorf("Error should be '%v' for a used code, is '%v' instead.", ErrOidcExchangeFailed, err)
+	}
+}
+
+func TestOidcRejectsInvalidClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		nonce  string
+	}{
+		{"other issuer", map[string]interface{}{"iss": "https://idp.example.com"}, "nonce"},
+		{"other audience", map[string]interface{}{"aud": "other"}, "nonce"},
+		{"other authorized party", map[string]interface{}{"aud": []string{testOidcClientId, "other"}, "azp": "other"}, "nonce"},
+		{"expired", map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}, "nonce"},
+		{"issued in the future", map[string]interface{}{"iat": time.Now().Add(time.Hour).Unix()}, "nonce"},
+		{"other nonce", nil, "other"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, mock := newTestOidcProvider(t)
+			defer mock.Close()
+			for k, v := range test.claims {
+				mock.Claims[k] = v
+			}
+			idToken := logInWithTestOidcProvider(t, p, mock)
+			if _, err := p.verifyIdToken(context.Background(), idToken, test.nonce, time.Now()); err != ErrOidcInvalidToken {
+				t.Errorf("Error should be '%v', is '%v' instead.", ErrOidcInvalidToken, err)
+			}
+		})
+	}
+}
+
+func TestOidcRejectsForgedTokens(t *testing.T) {
+	p, mock := newTestOidcProvider(t)
+	defer mock.Close()
+	claims := jwt.MapClaims{
+		"iss":   mock.URL,
+		"aud":   testOidcClientId,
+		"sub":   "user",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": "nonce",
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err.Error())
+	}
+	public, _ := x509.MarshalPKIXPublicKey(&mock.Key.PublicKey)
+	publicPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+	}{
+		{"other key", jwt.SigningMethodRS256, other},
+		{"public key as HMAC secret", jwt.SigningMethodHS256, publicPem},
+	}
+	for _, test := range tests {
+		token := jwt.NewWithClaims(test.method, claims)
+		token.Header["kid"] = oidctest.KeyId
+		idToken, err := token.SignedString(test.key)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %s", err.Error())
+		}
+		if _, err := p.verifyIdToken(context.Background(), idToken, "nonce", time.Now()); err != ErrOidcInvalidToken {
+			t.Errorf("%s: error should be '%v', is '%v' instead.", test.name, ErrOidcInvalidToken, err)
+		}
+	}
+}
+
+func TestOidcAudience(t *testing.T) {
+	var claims oidcClaims
+	if err := json.Unmarshal([]byte(`{"aud":"trackit","groups":"admins"}`), &claims); err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	} else if !claims.Audience.contains("trackit") {
+		t.Errorf("Audience should contain 'trackit', is %v instead.", claims.Audience)
+	} else if groups := claims.groups("groups"); len(groups) != 1 || groups[0] != "admins" {
+		t.Errorf("Groups should be [admins], are %v instead.", groups)
+	}
+	if err := json.Unmarshal([]byte(`{"aud":["other","trackit"]}`), &claims); err != nil {
+		t.Fatalf("Error should be nil, is '%s' instead.", err.Error())
+	} else if !claims.Audience.contains("trackit") || claims.Audience.contains("none") {
+		t.Errorf("Audience should be [other trackit], is %v instead.", claims.Audience)
+	}
+}
+
+func TestOidcIsDomainAllowed(t *testing.T) {
+	p := oidcProvider{}
+	if !p.isDomainAllowed("user@anywhere.com") {
+		t.Errorf("All domains should be allowed without allowed domains.")
+	}
+	p.allowedDomains = []string{"example.com"}
+	tests := map[string]bool{
+		"user@example.com":         true,
+		"user@EXAMPLE.com":         true,
+		"user@sub.example.com":     false,
+		"user@example.com.evil.io": false,
+		"example.com":              false,
+	}
+	for email, expected := range tests {
+		if allowed := p.isDomainAllowed(email); allowed != expected {
+			t.Errorf("%s: allowed should be %t, is %t instead.", email, expected, allowed)
+		}
+	}
+}
+
+func TestOidcRole(t *testing.T) {
+	p := oidcProvider{}
+	if role := p.role(nil); role != oidcRoleOwner {
+		t.Errorf("Users should be owners without group mapping, are %d instead.", role)
+	}
+	p.ownerGroups = []string{"finops"}
+	p.viewerGroups = []string{"engineering", "finops-readers"}
+	tests := []struct {
+		groups []string
+		role   oidcRole
+	}{
+		{[]string{"engineering", "finops"}, oidcRoleOwner},
+		{[]string{"finops-readers"}, oidcRoleViewer},
+		{[]string{"sales"}, oidcRoleNone},
+		{nil, oidcRoleNone},
+	}
+	for _, test := range tests {
+		if role := p.role(test.groups); role != test.role {
+			t.Errorf("%v: role should be %d, is %d instead.", test.groups, test.role, role)
+		}
+	}
+}
+
+func TestOidcUserRole(t *testing.T) {
+	viewerParentId, otherParentId := 1, 2
+	tests := []struct {
+		parentId *int
+		role     oidcRole
+	}{
+		{nil, oidcRoleOwner},
+		{&viewerParentId, oidcRoleViewer},
+		{&otherParentId, oidcRoleNone},
+	}
+	for _, test := range tests {
+		if role := userRole(User{ParentId: test.parentId}, viewerParentId); role != test.role {
+			t.Errorf("%v: role should be %d, is %d instead.", test.parentId, test.role, role)
+		}
+	}
+}
+
+func TestOidcStateBinding(t *testing.T) {
+	binding := oidcStateBinding("state")
+	tests := []struct {
+		state   string
+		binding string
+		bound   bool
+	}{
+		{"state", binding, true},
+		{"other", binding, false},
+		{"state", "", false},
+		{"state", "state", false},
+	}
+	for _, test := range tests {
+		if bound := isOidcStateBound(test.state, test.binding); bound != test.bound {
+			t.Errorf("%s, %s: bound should be %t, is %t instead.", test.state, test.binding, test.bound, bound)
+		}
+	}
+}
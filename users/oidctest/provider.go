@@ -0,0 +1,243 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package oidctest provides a local OpenID Connect provider to test single
+// sign-on against.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// KeyId is the ID of the key the provider signs ID tokens with.
+const KeyId = "oidctest"
+
+// Provider is an OpenID Connect provider supporting the authorization code
+// flow with PKCE. Users logging in at its authorization endpoint are
+// immediately redirected with a code, for which the token endpoint issues an
+// ID token with the provider's Claims.
+type Provider struct {
+	*httptest.Server
+	ClientId     string
+	ClientSecret string
+	// Claims are the claims of the ID tokens issued, in addition to those
+	// identifying the provider, the client and the login. They can
+	// override them.
+	Claims map[string]interface{}
+	// Key is the key ID tokens are signed with.
+	Key *rsa.PrivateKey
+
+	mutex sync.Mutex
+	codes map[string]authorization
+}
+
+// authorization is a login waiting for its code to be exchanged.
+type authorization struct {
+	redirectUri   string
+	codeChallenge string
+	nonce         string
+	claims        map[string]interface{}
+}
+
+// NewProvider starts a provider for a client. It must be closed once done.
+func NewProvider(clientId, clientSecret string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		Claims: map[string]interface{}{
+			"sub":            "user",
+			"email":          "user@example.com",
+			"email_verified": true,
+		},
+		Key:   key,
+		codes: make(map[string]authorization),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.serveDiscovery)
+	mux.HandleFunc("/jwks", p.serveKeys)
+	mux.HandleFunc("/authorize", p.serveAuthorize)
+	mux.HandleFunc("/token", p.serveToken)
+	p.Server = httptest.NewServer(mux)
+	return p, nil
+}
+
+// Authorize logs in at an authorization URL, and returns the code and state
+// the user is redirected with.
+func (p *Provider) Authorize(authorizationUrl string) (code, state string, err error) {
+	client := http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(authorizationUrl)
+	if err != nil {
+		return "", "", err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		return "", "", errors.New("authorization refused: " + res.Status)
+	}
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJson(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func (p *Provider) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *Provider) serveKeys(w http.ResponseWriter, r *http.Request) {
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": KeyId,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   encode(p.Key.N),
+			"e":   encode(big.NewInt(int64(p.Key.E))),
+		}},
+	})
+}
+
+func (p *Provider) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectUri, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid redirect_uri")
+		return
+	} else if query.Get("client_id") != p.ClientId {
+		writeError(w, http.StatusBadRequest, "unauthorized_client", "unknown client_id")
+		return
+	} else if query.Get("response_type") != "code" {
+		writeError(w, http.StatusBadRequest, "unsupported_response_type", "only code is supported")
+		return
+	} else if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "S256 code challenge required")
+		return
+	}
+	var random [16]byte
+	rand.Read(random[:])
+	code := base64.RawURLEncoding.EncodeToString(random[:])
+	claims := make(map[string]interface{})
+	p.mutex.Lock()
+	for k, v := range p.Claims {
+		claims[k] = v
+	}
+	p.codes[code] = authorization{query.Get("redirect_uri"), query.Get("code_challenge"), query.Get("nonce"), claims}
+	p.mutex.Unlock()
+	redirectQuery := redirectUri.Query()
+	redirectQuery.Set("code", code)
+	redirectQuery.Set("state", query.Get("state"))
+	redirectUri.RawQuery = redirectQuery.Encode()
+	http.Redirect(w, r, redirectUri.String(), http.StatusFound)
+}
+
+func (p *Provider) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "expected a form POST")
+		return
+	}
+	clientId, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientId, _ = url.QueryUnescape(clientId)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientId, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientId != p.ClientId || clientSecret != p.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+		return
+	} else if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+	code := r.PostForm.Get("code")
+	p.mutex.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mutex.Unlock()
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || auth.redirectUri != r.PostForm.Get("redirect_uri") {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "invalid code")
+		return
+	} else if base64.RawURLEncoding.EncodeToString(challenge[:]) != auth.codeChallenge {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "invalid code_verifier")
+		return
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.URL,
+		"aud":   p.ClientId,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyId
+	idToken, err := token.SignedString(p.Key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"access_token": code,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
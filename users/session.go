@@ -0,0 +1,173 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit-server/config"
+	"github.com/trackit/trackit-server/models"
+)
+
+// refreshTokenEntropy is the count of random bytes in a refresh token.
+const refreshTokenEntropy = 32
+
+var (
+	ErrInvalidRefreshToken   = errors.New("invalid, expired or revoked refresh token")
+	ErrRefreshTokenReused    = errors.New("refresh token was already used, session revoked")
+	ErrNoSession             = errors.New("not authenticated with a session")
+	ErrFailedCreatingSession = errors.New("failed to create session")
+	ErrFailedRefreshing      = errors.New("failed to refresh session")
+	ErrFailedRevokingSession = errors.New("failed to revoke session")
+)
+
+// Session is a set of tokens a user authenticates with. The access token is
+// short-lived and is renewed, along with the refresh token, using the refresh
+// token. A refresh token can only be used once.
+type Session struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	Expires      time.Time `json:"expires"`
+}
+
+// generateRefreshToken generates a new random refresh token.
+func generateRefreshToken() (string, error) {
+	var random [refreshTokenEntropy]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random[:]), nil
+}
+
+// CreateSession opens a new session for a user.
+func CreateSession(ctx context.Context, tx *sql.Tx, user User) (Session, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	now := time.Now()
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		logger.Error("Failed to generate refresh token.", err.Error())
+		return Session{}, ErrFailedCreatingSession
+	}
+	dbSession := models.UserSession{
+		UserID:      user.Id,
+		RefreshHash: hashApiKey(refreshToken),
+		Created:     now,
+		Expires:     now.Add(config.AuthRefreshTokenTtl),
+	}
+	if err := dbSession.Insert(tx); err != nil {
+		logger.Error("Failed to insert session.", err.Error())
+		return Session{}, ErrFailedCreatingSession
+	}
+	token, err := generateToken(user, dbSession.ID, now)
+	if err != nil {
+		logger.Error("Failed to generate token.", err.Error())
+		return Session{}, ErrFailedCreatingSession
+	}
+	return Session{token, refreshToken, now.Add(config.AuthAccessTokenTtl)}, nil
+}
+
+// RefreshSession renews the tokens of a session using its refresh token. The
+// refresh token is replaced. Using any of the replaced refresh tokens of a
+// session again means it was stolen: the session is then revoked, and the
+// transaction must be committed for the revocation to be kept.
+func RefreshSession(ctx context.Context, tx *sql.Tx, refreshToken string) (User, Session, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	now := time.Now()
+	hash := hashApiKey(refreshToken)
+	dbSession, err := models.UserSessionByRefreshHash(tx, hash)
+	if err == sql.ErrNoRows {
+		return User{}, Session{}, checkRefreshTokenReuse(ctx, tx, hash)
+	} else if err != nil {
+		logger.Error("Failed to retrieve session.", err.Error())
+		return User{}, Session{}, ErrFailedRefreshing
+	} else if dbSession.Revoked.Valid || !now.Before(dbSession.Expires) {
+		return User{}, Session{}, ErrInvalidRefreshToken
+	}
+	user, err := GetUserWithId(tx, dbSession.UserID)
+	if err != nil {
+		logger.Error("Failed to retrieve session user.", err.Error())
+		return User{}, Session{}, ErrFailedRefreshing
+	} else if !user.AwsCustomerEntitlement {
+		return user, Session{}, ErrMarketplaceInvalidToken
+	}
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		logger.Error("Failed to generate refresh token.", err.Error())
+		return user, Session{}, ErrFailedRefreshing
+	}
+	rotated, err := models.RotateUserSessionRefreshHash(tx, dbSession.ID, hash, hashApiKey(newRefreshToken), now.Add(config.AuthRefreshTokenTtl))
+	if err != nil {
+		logger.Error("Failed to rotate refresh token.", err.Error())
+		return user, Session{}, ErrFailedRefreshing
+	} else if !rotated {
+		return user, Session{}, ErrInvalidRefreshToken
+	}
+	token, err := generateToken(user, dbSession.ID, now)
+	if err != nil {
+		logger.Error("Failed to generate token.", err.Error())
+		return user, Session{}, ErrFailedRefreshing
+	}
+	return user, Session{token, newRefreshToken, now.Add(config.AuthAccessTokenTtl)}, nil
+}
+
+// checkRefreshTokenReuse revokes the session a refresh token was replaced in,
+// if any.
+func checkRefreshTokenReuse(ctx context.Context, tx *sql.Tx, hash string) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	rotated, err := models.UserSessionRotatedRefreshHashByRefreshHash(tx, hash)
+	if err == sql.ErrNoRows {
+		return ErrInvalidRefreshToken
+	} else if err != nil {
+		logger.Error("Failed to retrieve rotated refresh hash.", err.Error())
+		return ErrFailedRefreshing
+	}
+	logger.Warning("Refresh token reused, revoking session.", map[string]interface{}{
+		"sessionId": rotated.SessionID,
+	})
+	if err := models.RevokeUserSession(tx, rotated.SessionID); err != nil {
+		logger.Error("Failed to revoke session.", err.Error())
+		return ErrFailedRefreshing
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeSession revokes the session a user is authenticated with.
+func RevokeSession(ctx context.Context, tx *sql.Tx, user User) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if user.SessionId == 0 {
+		return ErrNoSession
+	} else if err := models.RevokeUserSession(tx, user.SessionId); err != nil {
+		logger.Error("Failed to revoke session.", err.Error())
+		return ErrFailedRevokingSession
+	}
+	return nil
+}
+
+// RevokeAllSessions revokes all the sessions of a user.
+func RevokeAllSessions(ctx context.Context, tx *sql.Tx, user User) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if err := models.RevokeUserSessions(tx, user.Id); err != nil {
+		logger.Error("Failed to revoke sessions.", err.Error())
+		return ErrFailedRevokingSession
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+//   Copyright 2018 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/trackit/trackit-server/db"
+	"github.com/trackit/trackit-server/routes"
+)
+
+// refreshRequestBody is the expected request body for the refresh route
+// handler.
+type refreshRequestBody struct {
+	RefreshToken string `json:"refreshToken" req:"nonzero"`
+}
+
+// refreshResponseBody is the response body in case refresh succeeds.
+type refreshResponseBody struct {
+	User User `json:"user"`
+	Session
+}
+
+// reusedRefreshTokenBody is the response body in case a refresh token is
+// reused. It is not an error so that the revocation of the session is
+// committed.
+type reusedRefreshTokenBody struct {
+	Error string `json:"error"`
+}
+
+// logoutAllQueryArg allows to ask for all the sessions of a user to be
+// revoked instead of the current one.
+var logoutAllQueryArg = routes.QueryArg{
+	Name:        "all",
+	Type:        routes.QueryArgBool{},
+	Description: "Whether to log out all the sessions of the user.",
+	Optional:    true,
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(refresh).With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{refreshRequestBody{"refresh-token"}},
+			db.RequestTransaction{db.Db},
+			routes.Documentation{
+				Summary:     "refresh a session",
+				Description: "Returns a new token and a new refresh token in exchange for a refresh token, which cannot be used again. Using it again revokes the session.",
+			},
+		),
+	}.H().Register("/user/token/refresh")
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(logOut).With(
+			db.RequestTransaction{db.Db},
+			RequireAuthenticatedUser{ViewerAsSelf, ApiKeyCannot},
+			routes.QueryArgs{logoutAllQueryArg},
+			routes.Documentation{
+				Summary:     "log out",
+				Description: "Revokes the session the user is authenticated with, or all of the user's sessions if 'all' is set.",
+			},
+		),
+	}.H().Register("/user/logout")
+}
+
+// refresh is a route handler which renews the tokens of a session.
+func refresh(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body refreshRequestBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user, session, err := RefreshSession(r.Context(), tx, body.RefreshToken)
+	switch err {
+	case nil:
+		return http.StatusOK, refreshResponseBody{user, session}
+	case ErrRefreshTokenReused:
+		return http.StatusUnauthorized, reusedRefreshTokenBody{err.Error()}
+	case ErrInvalidRefreshToken, ErrMarketplaceInvalidToken:
+		return http.StatusUnauthorized, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
+
+// logOut is a route handler which revokes the user's current session or all
+// their sessions.
+func logOut(r *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[AuthenticatedUser].(User)
+	tx := a[db.Transaction].(*sql.Tx)
+	var err error
+	if all, _ := a[logoutAllQueryArg].(bool); all {
+		err = RevokeAllSessions(r.Context(), tx, user)
+	} else {
+		err = RevokeSession(r.Context(), tx, user)
+	}
+	switch err {
+	case nil:
+		return http.StatusOK, nil
+	case ErrNoSession:
+		return http.StatusBadRequest, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
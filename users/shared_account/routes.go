@@ -22,7 +22,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(listSharedUsers).With(
 			db.RequestTransaction{db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "List shared users",
 				Description: "Return a list of user who have an access to an AWS account on Trackit",
@@ -33,7 +33,7 @@ func init() {
 		),
 		http.MethodPost: routes.H(inviteUser).With(
 			db.RequestTransaction{db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.RequestContentType{"application/json"},
 			routes.Documentation{
 				Summary:     "Creates an invite",
@@ -45,7 +45,7 @@ func init() {
 		),
 		http.MethodPatch: routes.H(updateSharedUsers).With(
 			db.RequestTransaction{db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.QueryArgs{
 				routes.ShareIdQueryArg,
 			},
@@ -57,7 +57,7 @@ func init() {
 		),
 		http.MethodDelete: routes.H(deleteSharedUsers).With(
 			db.RequestTransaction{db.Db},
-			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			users.RequireAuthenticatedUser{users.ViewerAsParent, users.ApiKeyAllowed},
 			routes.Documentation{
 				Summary:     "Delete shared users",
 				Description: "Delete shared users associated with a specific AWS account",
@@ -22,6 +22,9 @@ const (
 // permission level to perform an action on a shared account
 func safetyCheckByAccountId(ctx context.Context, tx *sql.Tx, AccountId int, user users.User) (bool, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if !user.CanAccessAwsAccount(AccountId) {
+		return false, nil
+	}
 	dbAwsAccount, err := models.AwsAccountByID(tx, AccountId)
 	if err == sql.ErrNoRows {
 		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This AWS Account does not exist"})
@@ -64,6 +67,9 @@ func checkLevel(PermissionLevelToCheck int, currentUserPermissionLevel int) (boo
 // to the permissionLevel of the viewer account.
 func safetyCheckByAccountIdAndPermissionLevel(ctx context.Context, tx *sql.Tx, AccountId int, body InviteUserRequest, user users.User) (bool, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if !user.CanAccessAwsAccount(AccountId) {
+		return false, nil
+	}
 	dbAwsAccount, err := models.AwsAccountByID(tx, AccountId)
 	if err == sql.ErrNoRows {
 		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This AWS Account does not exist"})
@@ -100,6 +106,9 @@ func safetyCheckByShareId(ctx context.Context, tx *sql.Tx, shareId int, user use
 		logger.Error("Error while retrieving Shared Accounts" , err)
 		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
 	}
+	if !user.CanAccessAwsAccount(dbShareAccount.AccountID) {
+		return false, nil
+	}
 	dbAwsAccount, err := models.AwsAccountByID(tx, dbShareAccount.AccountID)
 	if dbAwsAccount.UserID == user.Id {
 		return true, nil
@@ -128,6 +137,9 @@ func safetyCheckByShareIdAndPermissionLevel(ctx context.Context, tx *sql.Tx, sha
 		logger.Error("Error while retrieving Shared Accounts from DB" , err)
 		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
 	}
+	if !user.CanAccessAwsAccount(dbShareAccount.AccountID) {
+		return false, nil
+	}
 	dbAwsAccount, err := models.AwsAccountByID(tx, dbShareAccount.AccountID)
 	if dbAwsAccount.UserID == user.Id {
 		return true, nil
@@ -32,6 +32,10 @@ var (
 	ErrFailedCreating = errors.New("Failed to create user")
 )
 
+// DefaultDisplayCurrency is the currency costs are displayed in for users
+// who did not choose one.
+const DefaultDisplayCurrency = "USD"
+
 // User is a user of the platform. It is different from models.User which is
 // the database representation of a User.
 type User struct {
@@ -40,6 +44,13 @@ type User struct {
 	NextExternal            string `json:"-"`
 	ParentId                *int   `json:"parentId,omitempty"`
 	AwsCustomerEntitlement	bool   `json:aws_customer_entitlement`
+	DisplayCurrency         string `json:"displayCurrency"`
+	// ApiKeyScope is the scope of the API key the user authenticated
+	// with, if any.
+	ApiKeyScope *ApiKeyScope `json:"-"`
+	// SessionId is the ID of the session the user authenticated with, if
+	// any.
+	SessionId int `json:"-"`
 }
 
 // CreateUserWithPassword creates a user with an email and a password. A nil
@@ -50,6 +61,7 @@ func CreateUserWithPassword(ctx context.Context, db models.XODB, email string, p
 		Email: email,
 		AwsCustomerIdentifier: customerIdentifier,
 		AwsCustomerEntitlement: true,
+		DisplayCurrency: DefaultDisplayCurrency,
 	}
 	auth, err := getPasswordHash(password)
 	if err != nil {
@@ -72,6 +84,7 @@ func CreateUserWithParent(ctx context.Context, db models.XODB, email string, par
 		Email:        email,
 		ParentUserID: sql.NullInt64{int64(parent.Id), true},
 		AwsCustomerEntitlement: true,
+		DisplayCurrency: parent.DisplayCurrency,
 	}
 	var user User
 	var passRandom [12]byte
@@ -121,8 +134,8 @@ func GetUserParent(ctx context.Context, db models.XODB, user User) (parent User,
 	return
 }
 
-// UpdateUserWithPassword updates a user with an email and a password. A nil
-// error indicates a success.
+// UpdateUserWithPassword updates a user with an email and a password, and
+// revokes all their sessions and API keys. A nil error indicates a success.
 func UpdateUserWithPassword(ctx context.Context, tx *sql.Tx, dbUser *models.User, email string, password string) (User, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	dbUser.Email = email
@@ -134,6 +147,8 @@ func UpdateUserWithPassword(ctx context.Context, tx *sql.Tx, dbUser *models.User
 		err = dbUser.Update(tx)
 		if err != nil {
 			logger.Error("Failed to update user.", err.Error())
+		} else if err = revokeCredentials(tx, dbUser.ID); err != nil {
+			logger.Error("Failed to revoke user credentials.", err.Error())
 		}
 	}
 	return UserFromDbUser(*dbUser), err
@@ -159,7 +174,8 @@ func (u User) Delete() error {
 	return ErrNotImplemented
 }
 
-// UpdatePassword updates a user's password. A nil error indicates a success.
+// UpdatePassword updates a user's password and revokes all their sessions and
+// API keys. A nil error indicates a success.
 func (u User) UpdatePassword(db models.XODB, password string) error {
 	dbUser, err := models.UserByID(db, u.Id)
 	if err == nil {
@@ -168,12 +184,24 @@ func (u User) UpdatePassword(db models.XODB, password string) error {
 			return err
 		}
 		dbUser.Auth = auth
-		return dbUser.Update(db)
+		if err := dbUser.Update(db); err != nil {
+			return err
+		}
+		return revokeCredentials(db, u.Id)
 	} else {
 		return err
 	}
 }
 
+// revokeCredentials revokes all the sessions and API keys of a user, whose
+// password changed.
+func revokeCredentials(db models.XODB, userId int) error {
+	if err := models.RevokeUserSessions(db, userId); err != nil {
+		return err
+	}
+	return models.RevokeUserAPIKeys(db, userId)
+}
+
 // PasswordMatches tests whether a password matches a user's stored hash. A nil
 // error indicates a match.
 func (u User) PasswordMatches(password string) error {
@@ -233,6 +261,10 @@ func UserFromDbUser(dbUser models.User) User {
 		Id:                     dbUser.ID,
 		Email:                  dbUser.Email,
 		AwsCustomerEntitlement: dbUser.AwsCustomerEntitlement,
+		DisplayCurrency:        dbUser.DisplayCurrency,
+	}
+	if u.DisplayCurrency == "" {
+		u.DisplayCurrency = DefaultDisplayCurrency
 	}
 	if dbUser.NextExternal.Valid {
 		u.NextExternal = dbUser.NextExternal.String
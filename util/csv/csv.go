@@ -82,6 +82,16 @@ func (d *Decoder) ReadRecord(v interface{}) error {
 	}
 }
 
+// DecodeRecord stores a record which was already split into fields, such as
+// a row read from another file format, the same way ReadRecord would.
+func (d *Decoder) DecodeRecord(record []string, v interface{}) error {
+	if rt, err := getRecordType(v); err != nil {
+		return err
+	} else {
+		return d.storeRecord(rt, v, record)
+	}
+}
+
 func (d *Decoder) storeRecord(rt recordType, vi interface{}, record []string) error {
 	v := reflect.ValueOf(vi)
 	if v.Type().Kind() == reflect.Ptr {
@@ -157,3 +157,15 @@ newline
 		}
 	}
 }
+
+func TestDecodeRecord(t *testing.T) {
+	var dn TaggedNames
+	d := NewDecoder(nil)
+	d.SetHeader([]string{"Foo", "Baz", "Bar"})
+	if err := d.DecodeRecord([]string{"foo val", "baz val", "bar val"}, &dn); err != nil {
+		t.Errorf("DecodeRecord should succeed. Failed with %s.", err.Error())
+	}
+	if e := (TaggedNames{"foo val", "bar val", "baz val"}); e != dn {
+		t.Errorf("Record structure should be %#v, is %#v instead.", e, dn)
+	}
+}